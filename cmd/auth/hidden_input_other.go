@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package auth
+
+import (
+	"bufio"
+	"os"
+)
+
+// readHiddenLine reads one line; echo cannot be disabled on this platform.
+func readHiddenLine(file *os.File) (string, error) {
+	return bufio.NewReader(file).ReadString('\n')
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package auth
+
+import (
+	"bufio"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// readHiddenLine reads one line from a terminal with echo disabled.
+func readHiddenLine(file *os.File) (string, error) {
+	fd := int(file.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return bufio.NewReader(file).ReadString('\n')
+	}
+	hidden := *saved
+	hidden.Lflag &^= unix.ECHO
+	hidden.Lflag |= unix.ICANON | unix.ISIG
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &hidden); err != nil {
+		return "", err
+	}
+	defer func() { _ = unix.IoctlSetTermios(fd, ioctlSetTermios, saved) }()
+	return bufio.NewReader(file).ReadString('\n')
+}
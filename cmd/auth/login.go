@@ -91,9 +91,10 @@ var AuthCmd = &cobra.Command{
 	Short:   "Login through a local browser page",
 	Long: `Start a local browser-based Telegram login flow.
 
-QR login is the only supported sign-in method. The page is printed to stderr
-as a one-time localhost URL, then the command waits for completion and emits
-JSON on stdout.`,
+The page signs in with a QR code. It is printed to stderr as a one-time
+localhost URL, then the command waits for completion and emits JSON on
+stdout. On hosts without a browser use "auth phone" to sign in with a
+phone number, login code and 2FA password instead.`,
 	Args: cobra.NoArgs,
 	Run:  runAuthWeb,
 }
@@ -104,6 +105,7 @@ func AddAuthCommand(rootCmd *cobra.Command) {
 
 	addAuthBaseFlags(AuthCmd)
 	addWebAuthFlags(AuthCmd)
+	addPhoneAuthCommand(AuthCmd)
 }
 
 func addAuthBaseFlags(cmd *cobra.Command) {
@@ -145,7 +147,7 @@ func finishAuth(cmd *cobra.Command, runtime authRuntimeConfig, state *authflow.S
 		if socketPath == "" {
 			socketPath, _ = cmd.Root().PersistentFlags().GetString("socket")
 		}
-		serverReloaded = reloadServerSession(socketPath, sessionData, selection)
+		serverReloaded = reloadServerSession(socketPath, commandStringFlag(cmd, "profile"), sessionData, selection)
 		if !serverReloaded && !selection.Persistent {
 			if err := config.SavePendingSession(sessionData); err != nil {
 				return nil, fmt.Errorf("save session for daemon startup: %w", err)
@@ -220,7 +222,9 @@ func failJSON(message string) {
 	cliutil.Exit(1)
 }
 
-func reloadServerSession(socketPath string, sessionData []byte, selection sessionstore.Selection) bool {
+// reloadServerSession asks the daemon to reload the account for route, the
+// --profile value; an empty route reloads the primary account.
+func reloadServerSession(socketPath, route string, sessionData []byte, selection sessionstore.Selection) bool {
 	if len(sessionData) == 0 {
 		fmt.Fprintln(os.Stderr, "warning: no in-memory session data to reload")
 		return false
@@ -228,7 +232,7 @@ func reloadServerSession(socketPath string, sessionData []byte, selection sessio
 	if socketPath == "" {
 		socketPath = paths.DefaultSocketPath
 	}
-	client := ipc.NewClient(socketPath)
+	client := ipc.NewClient(socketPath).WithProfile(route)
 	if _, err := client.Call("status", nil); err != nil {
 		return false
 	}
@@ -36,7 +36,9 @@ func runLogout(cmd *cobra.Command, _ []string) {
 		cliutil.Exit(1)
 		return
 	}
-	if _, err := ipc.NewClient(socketPath).CallWithOptions("logout", nil, ipc.CallOptions{Confirm: true}); err != nil {
+	profile, _ := cmd.Root().PersistentFlags().GetString("profile")
+	client := ipc.NewClient(socketPath).WithProfile(profile)
+	if _, err := client.CallWithOptions("logout", nil, ipc.CallOptions{Confirm: true}); err != nil {
 		fmt.Printf("Logout failed: %s\n", err.Message)
 		cliutil.Exit(1)
 		return
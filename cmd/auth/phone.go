@@ -0,0 +1,373 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/authflow"
+	"agent-telegram/internal/cliutil"
+	"agent-telegram/internal/config"
+)
+
+// Environment variables read by the phone flow when selected as a source.
+const (
+	envAuthCode     = "AGENT_TELEGRAM_AUTH_CODE"
+	envAuthPassword = "AGENT_TELEGRAM_2FA_PASSWORD"
+)
+
+const (
+	phoneNextCode     = "code"
+	phoneNextPassword = "password"
+
+	inputFilePoll = time.Second
+)
+
+var (
+	authPhone        string
+	authPhoneState   string
+	authCodeFrom     string
+	authPasswordFrom string
+)
+
+var newPhoneBackend = func(cfg *config.Config) authflow.PhoneBackend {
+	return authflow.NewTelegramBackend(cfg, silentLogger())
+}
+
+// PhoneCmd signs in with a phone number, login code and optional 2FA password.
+var PhoneCmd = &cobra.Command{
+	Use:   "phone",
+	Short: "Login with phone number, code and 2FA password without a browser",
+	Long: `Sign in without a browser by phone number, login code and, when the
+account has one, its two-step verification password.
+
+Each step prints a JSON state on stdout with "next" set to "code",
+"password" or "done". Progress is kept in the auth state directory, so a
+flow can stop after any step and be resumed later with --state.
+
+Input sources for --code-from and --password-from:
+  stdin       read one line from standard input
+  env[:NAME]  read an environment variable
+              (default AGENT_TELEGRAM_AUTH_CODE / AGENT_TELEGRAM_2FA_PASSWORD)
+  file:PATH   wait for PATH to exist, then read it
+  prompt      ask on the terminal; passwords are not echoed
+
+Without --code-from the command prompts on a terminal and otherwise stops
+after sending the code. Without --password-from it uses
+AGENT_TELEGRAM_2FA_PASSWORD when set, then a terminal prompt.
+
+Examples:
+  agent-telegram auth phone --phone +15551234567 --code-from stdin
+  agent-telegram auth phone --phone +15551234567
+  AGENT_TELEGRAM_AUTH_CODE=12345 agent-telegram auth phone --state <id> --code-from env`,
+	Args: cobra.NoArgs,
+	Run:  runAuthPhone,
+}
+
+func addPhoneAuthCommand(authCmd *cobra.Command) {
+	authCmd.AddCommand(PhoneCmd)
+
+	addAuthBaseFlags(PhoneCmd)
+	PhoneCmd.Flags().BoolVar(&authReload, "reload-server", true, "Reload running IPC server after successful login")
+	PhoneCmd.Flags().StringVar(&authPhone, "phone", os.Getenv("AGENT_TELEGRAM_PHONE"), "Phone number in international format")
+	PhoneCmd.Flags().StringVar(&authPhoneState, "state", "", "Resume the flow saved under this state ID")
+	PhoneCmd.Flags().StringVar(&authCodeFrom, "code-from", "", "Login code source: stdin, env[:NAME], file:PATH or prompt")
+	PhoneCmd.Flags().StringVar(&authPasswordFrom, "password-from", "",
+		"2FA password source: stdin, env[:NAME], file:PATH or prompt")
+}
+
+type phoneAuthOptions struct {
+	Phone        string
+	StateID      string
+	CodeFrom     string
+	PasswordFrom string
+}
+
+// phoneAuth walks one phone sign-in from sending the code to saving the
+// session. Intermediate states are emitted before it blocks on input.
+type phoneAuth struct {
+	cmd         *cobra.Command
+	runtime     authRuntimeConfig
+	opts        phoneAuthOptions
+	store       *authflow.StateStore
+	backend     authflow.PhoneBackend
+	state       *authflow.State
+	stdin       *bufio.Reader
+	stderr      io.Writer
+	interactive bool
+	emit        func(any)
+}
+
+// phoneAuthError is a failed step that can be retried with the saved state.
+type phoneAuthError struct {
+	state *authflow.State
+	next  string
+	err   error
+}
+
+func (e *phoneAuthError) Error() string { return e.err.Error() }
+func (e *phoneAuthError) Unwrap() error { return e.err }
+
+func runAuthPhone(cmd *cobra.Command, _ []string) {
+	_ = godotenv.Load()
+
+	runtime := authRuntimeFromGlobals()
+	flow := &phoneAuth{
+		cmd:     cmd,
+		runtime: runtime,
+		opts: phoneAuthOptions{
+			Phone:        authPhone,
+			StateID:      authPhoneState,
+			CodeFrom:     authCodeFrom,
+			PasswordFrom: authPasswordFrom,
+		},
+		store:       runtime.stateStore(),
+		stdin:       bufio.NewReader(os.Stdin),
+		stderr:      cmd.ErrOrStderr(),
+		interactive: fileIsTerminal(os.Stdin),
+		emit:        writeJSON,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), runtime.StateTTL)
+	defer cancel()
+
+	body, err := flow.run(ctx)
+	if err != nil {
+		var stepErr *phoneAuthError
+		if errors.As(err, &stepErr) {
+			failed := phoneStateBody(stepErr.state, stepErr.next)
+			failed["ok"] = false
+			failed["error"] = stepErr.Error()
+			writeJSON(failed)
+			cliutil.Exit(1)
+		}
+		failJSON(err.Error())
+	}
+	writeJSON(body)
+}
+
+func (a *phoneAuth) run(ctx context.Context) (map[string]any, error) {
+	if err := a.begin(ctx); err != nil {
+		return nil, err
+	}
+	if !a.state.Requires2FA {
+		code, ok, err := a.readInput(a.opts.CodeFrom, envAuthCode, phoneNextCode, "Login code: ", false)
+		if err != nil || !ok {
+			return a.stopped(phoneNextCode), err
+		}
+		if err := a.signIn(ctx, code); err != nil {
+			return nil, err
+		}
+	}
+	if a.state.Requires2FA {
+		source := a.opts.PasswordFrom
+		if source == "" && os.Getenv(envAuthPassword) != "" {
+			source = "env"
+		}
+		prompt := "2FA password: "
+		if a.state.TwoFactorHint != "" {
+			prompt = fmt.Sprintf("2FA password (hint: %s): ", a.state.TwoFactorHint)
+		}
+		password, ok, err := a.readInput(source, envAuthPassword, phoneNextPassword, prompt, true)
+		if err != nil || !ok {
+			return a.stopped(phoneNextPassword), err
+		}
+		if err := a.checkPassword(ctx, password); err != nil {
+			return nil, err
+		}
+	}
+	return finishAuth(a.cmd, a.runtime, a.state)
+}
+
+// begin resumes a saved flow or sends a new login code.
+func (a *phoneAuth) begin(ctx context.Context) error {
+	if a.opts.StateID != "" {
+		return a.resume(ctx)
+	}
+	phone := normalizePhone(a.opts.Phone)
+	if phone == "" {
+		return fmt.Errorf("--phone or --state is required")
+	}
+	cfg, err := a.runtime.authConfig()
+	if err != nil {
+		return err
+	}
+	a.backend = newPhoneBackend(cfg)
+	sent, err := a.backend.SendCode(ctx, phone)
+	if err != nil {
+		return err
+	}
+	sessionData, err := a.backend.ExportSession(ctx)
+	if err != nil {
+		return fmt.Errorf("export auth session: %w", err)
+	}
+	a.state, err = a.store.Create(phone, sent.PhoneCodeHash, cfg.AppID, cfg.AppHash, sessionData, a.runtime.StateTTL)
+	return err
+}
+
+func (a *phoneAuth) resume(ctx context.Context) error {
+	state, err := a.store.Load(a.opts.StateID)
+	if err != nil {
+		return err
+	}
+	if state.Phone == "" || state.PhoneCodeHash == "" {
+		return fmt.Errorf("auth state %s is not a phone login", state.ID)
+	}
+	sessionData, err := state.SessionData()
+	if err != nil {
+		return err
+	}
+	a.backend = newPhoneBackend(config.LoadFromArgs(state.AppID, state.AppHash, "", ""))
+	if err := a.backend.ImportSession(ctx, sessionData); err != nil {
+		return fmt.Errorf("import auth session: %w", err)
+	}
+	a.state = state
+	return nil
+}
+
+func (a *phoneAuth) signIn(ctx context.Context, code string) error {
+	result, err := a.backend.SignIn(ctx, a.state.Phone, code, a.state.PhoneCodeHash)
+	if err != nil {
+		return &phoneAuthError{state: a.state, next: phoneNextCode, err: err}
+	}
+	switch {
+	case result.Requires2FA:
+		a.state.Requires2FA = true
+		a.state.TwoFactorHint = result.TwoFactorHint
+	case !result.Success:
+		message := firstNonEmpty(result.AuthError, "sign in failed")
+		return &phoneAuthError{state: a.state, next: phoneNextCode, err: errors.New(message)}
+	}
+	return a.saveSession(ctx)
+}
+
+func (a *phoneAuth) checkPassword(ctx context.Context, password string) error {
+	result, err := a.backend.SignInWith2FA(ctx, a.state.Phone, password)
+	if err == nil && (result == nil || !result.Success) {
+		err = errors.New("2FA authentication failed")
+	}
+	if err != nil {
+		return &phoneAuthError{state: a.state, next: phoneNextPassword, err: err}
+	}
+	return a.saveSession(ctx)
+}
+
+func (a *phoneAuth) saveSession(ctx context.Context) error {
+	sessionData, err := a.backend.ExportSession(ctx)
+	if err != nil {
+		return fmt.Errorf("export auth session: %w", err)
+	}
+	a.state.SetSessionData(sessionData)
+	return a.store.Save(a.state)
+}
+
+// stopped reports the state to resume from when no input source is set.
+func (a *phoneAuth) stopped(next string) map[string]any {
+	if a.state == nil {
+		return nil
+	}
+	return phoneStateBody(a.state, next)
+}
+
+// readInput resolves a code or password from source. It returns ok=false
+// when no source applies, so the flow stops and can be resumed later.
+func (a *phoneAuth) readInput(source, defaultEnv, next, prompt string, secret bool) (string, bool, error) {
+	if source == "" {
+		if !a.interactive {
+			return "", false, nil
+		}
+		source = "prompt"
+	}
+	kind, arg, _ := strings.Cut(source, ":")
+	var (
+		value string
+		err   error
+	)
+	switch kind {
+	case "env":
+		name := firstNonEmpty(arg, defaultEnv)
+		value = os.Getenv(name)
+		if strings.TrimSpace(value) == "" {
+			return "", false, &phoneAuthError{state: a.state, next: next, err: fmt.Errorf("%s is not set", name)}
+		}
+	case "stdin":
+		a.emit(phoneStateBody(a.state, next))
+		value, err = a.stdin.ReadString('\n')
+	case "file":
+		if arg == "" {
+			return "", false, fmt.Errorf("file source needs a path, e.g. file:/tmp/code")
+		}
+		a.emit(phoneStateBody(a.state, next))
+		value, err = waitForInputFile(arg, a.state.ExpiresAt)
+	case "prompt":
+		a.emit(phoneStateBody(a.state, next))
+		_, _ = fmt.Fprint(a.stderr, prompt)
+		if secret && a.interactive {
+			value, err = readHiddenLine(os.Stdin)
+			_, _ = fmt.Fprintln(a.stderr)
+		} else {
+			value, err = a.stdin.ReadString('\n')
+		}
+	default:
+		return "", false, fmt.Errorf("unknown input source %q (use stdin, env, file:PATH or prompt)", source)
+	}
+	if err != nil && !(errors.Is(err, io.EOF) && strings.TrimSpace(value) != "") {
+		return "", false, &phoneAuthError{state: a.state, next: next, err: fmt.Errorf("read %s: %w", next, err)}
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", false, &phoneAuthError{state: a.state, next: next, err: fmt.Errorf("empty %s", next)}
+	}
+	return value, true, nil
+}
+
+// waitForInputFile polls path until it has content or deadline passes, so a
+// separate CI step can drop the code in once it arrives.
+func waitForInputFile(path string, deadline time.Time) (string, error) {
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.TrimSpace(string(data)) != "" {
+			return string(data), nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for %s", path)
+		}
+		time.Sleep(inputFilePoll)
+	}
+}
+
+func phoneStateBody(state *authflow.State, next string) map[string]any {
+	body := map[string]any{
+		"ok":        true,
+		"next":      next,
+		"stateId":   state.ID,
+		"phone":     maskPhone(state.Phone),
+		"expiresAt": state.ExpiresAt.Format(time.RFC3339),
+	}
+	if state.Requires2FA {
+		body["requires2FA"] = true
+		if state.TwoFactorHint != "" {
+			body["twoFactorHint"] = state.TwoFactorHint
+		}
+	}
+	return body
+}
+
+func normalizePhone(phone string) string {
+	return strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(trimAllSpace(phone))
+}
+
+func fileIsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
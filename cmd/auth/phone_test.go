@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/authflow"
+	"agent-telegram/internal/config"
+	"agent-telegram/internal/types"
+)
+
+type fakePhoneBackend struct {
+	code     string
+	password string
+	session  []byte
+	imported []byte
+}
+
+func (f *fakePhoneBackend) ImportSession(_ context.Context, data []byte) error {
+	f.imported = append([]byte(nil), data...)
+	f.session = append([]byte(nil), data...)
+	return nil
+}
+
+func (f *fakePhoneBackend) SendCode(_ context.Context, _ string) (*types.SendCodeResult, error) {
+	f.session = []byte("after-send")
+	return &types.SendCodeResult{PhoneCodeHash: "code-hash", Timeout: 60}, nil
+}
+
+func (f *fakePhoneBackend) SignIn(_ context.Context, _, code, codeHash string) (*types.SignInResult, error) {
+	if codeHash != "code-hash" || code != f.code {
+		return &types.SignInResult{AuthError: "PHONE_CODE_INVALID"}, nil
+	}
+	f.session = []byte("after-code")
+	if f.password != "" {
+		return &types.SignInResult{Requires2FA: true, TwoFactorHint: "pet"}, nil
+	}
+	return &types.SignInResult{Success: true}, nil
+}
+
+func (f *fakePhoneBackend) SignInWith2FA(_ context.Context, _, password string) (*types.SignInResult, error) {
+	if password != f.password {
+		return nil, errors.New("PASSWORD_HASH_INVALID")
+	}
+	f.session = []byte("after-password")
+	return &types.SignInResult{Success: true}, nil
+}
+
+func (f *fakePhoneBackend) ExportSession(_ context.Context) ([]byte, error) {
+	return append([]byte(nil), f.session...), nil
+}
+
+func newTestPhoneAuth(t *testing.T, backend *fakePhoneBackend, opts phoneAuthOptions, stdin string) (*phoneAuth, *[]any) {
+	t.Helper()
+	oldBackend := newPhoneBackend
+	t.Cleanup(func() { newPhoneBackend = oldBackend })
+	newPhoneBackend = func(*config.Config) authflow.PhoneBackend { return backend }
+
+	var emitted []any
+	runtime := authRuntimeFromGlobals()
+	return &phoneAuth{
+		cmd:     &cobra.Command{},
+		runtime: runtime,
+		opts:    opts,
+		store:   runtime.stateStore(),
+		stdin:   bufio.NewReader(strings.NewReader(stdin)),
+		stderr:  io.Discard,
+		emit:    func(v any) { emitted = append(emitted, v) },
+	}, &emitted
+}
+
+func TestPhoneAuthCodeAndPasswordFromInputs(t *testing.T) {
+	resetAuthGlobals(t, t.TempDir())
+	t.Setenv(envAuthPassword, "secret")
+	backend := &fakePhoneBackend{code: "12345", password: "secret"}
+	flow, emitted := newTestPhoneAuth(t, backend, phoneAuthOptions{
+		Phone:    "+1 555 123-4567",
+		CodeFrom: "stdin",
+	}, "12345\n")
+
+	body, err := flow.run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["next"] != "done" || body["phone"] != "***4567" {
+		t.Fatalf("final body = %+v", body)
+	}
+	if len(*emitted) != 1 {
+		t.Fatalf("emitted %d states, want the code state only", len(*emitted))
+	}
+	state, _ := (*emitted)[0].(map[string]any)
+	if state["next"] != phoneNextCode || state["stateId"] == "" {
+		t.Fatalf("code state = %+v", state)
+	}
+	if _, err := flow.store.Load(flow.state.ID); err == nil {
+		t.Fatal("auth state should be deleted after finishing")
+	}
+}
+
+func TestPhoneAuthStopsAndResumes(t *testing.T) {
+	resetAuthGlobals(t, t.TempDir())
+	t.Setenv(envAuthPassword, "")
+	backend := &fakePhoneBackend{code: "12345", password: "secret"}
+	flow, _ := newTestPhoneAuth(t, backend, phoneAuthOptions{Phone: "+15551234567"}, "")
+
+	body, err := flow.run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateID, _ := body["stateId"].(string)
+	if body["next"] != phoneNextCode || stateID == "" {
+		t.Fatalf("first step = %+v", body)
+	}
+
+	t.Setenv("CODE_FOR_TEST", "00000")
+	flow, _ = newTestPhoneAuth(t, backend, phoneAuthOptions{StateID: stateID, CodeFrom: "env:CODE_FOR_TEST"}, "")
+	_, err = flow.run(context.Background())
+	var stepErr *phoneAuthError
+	if !errors.As(err, &stepErr) || stepErr.next != phoneNextCode {
+		t.Fatalf("wrong code error = %v", err)
+	}
+	if string(backend.imported) != "after-send" {
+		t.Fatalf("imported session = %q", backend.imported)
+	}
+
+	t.Setenv("CODE_FOR_TEST", "12345")
+	flow, _ = newTestPhoneAuth(t, backend, phoneAuthOptions{StateID: stateID, CodeFrom: "env:CODE_FOR_TEST"}, "")
+	body, err = flow.run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["next"] != phoneNextPassword || body["requires2FA"] != true || body["twoFactorHint"] != "pet" {
+		t.Fatalf("password step = %+v", body)
+	}
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	flow, _ = newTestPhoneAuth(t, backend, phoneAuthOptions{StateID: stateID, PasswordFrom: "file:" + passwordFile}, "")
+	body, err = flow.run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["next"] != "done" {
+		t.Fatalf("final body = %+v", body)
+	}
+	if string(backend.imported) != "after-code" {
+		t.Fatalf("password step imported session = %q", backend.imported)
+	}
+}
+
+func TestPhoneAuthRejectsUnknownSource(t *testing.T) {
+	resetAuthGlobals(t, t.TempDir())
+	flow, _ := newTestPhoneAuth(t, &fakePhoneBackend{}, phoneAuthOptions{Phone: "+15551234567", CodeFrom: "carrier-pigeon"}, "")
+	if _, err := flow.run(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown input source") {
+		t.Fatalf("err = %v", err)
+	}
+}
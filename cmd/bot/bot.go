@@ -161,8 +161,7 @@ func runPress(_ *cobra.Command, args []string) {
 	requirePeer(runner, pressTo.Peer())
 	messageID := runner.MustParseInt64(messageIDArg)
 	source := getMessage(runner, pressTo.Peer(), messageID)
-	snapshot := newMessageSnapshot(source)
-	threadID := snapshot.ThreadID
+	threadID := cliutil.ExtractInt64(source, "threadId")
 	pressParams := map[string]any{
 		"peer":      pressTo.Peer(),
 		"messageId": messageID,
@@ -172,13 +171,18 @@ func runPress(_ *cobra.Command, args []string) {
 	} else {
 		pressParams["buttonIndex"] = runner.MustParseInt(buttonIndexArg)
 	}
+	var offset int64
+	var epoch string
+	if pressWait {
+		offset, epoch = send.UpdateCursor(runner)
+	}
 	action := runner.Call("press_inline_button", pressParams)
 
 	var message map[string]any
 	var waitMeta map[string]any
 	var event string
 	if pressWait {
-		outcome := waitForBotEvent(runner, pressTo.Peer(), threadID, messageID, snapshot, pressTimeout)
+		outcome := waitForBotEvent(runner, pressTo.Peer(), threadID, messageID, offset, epoch, pressTimeout)
 		if !outcome.Completed {
 			send.FailReplyTimeout(runner, pressTo.Peer(), action, send.WaitOutcome{
 				ThreadID: threadID, AfterMessageID: messageID, Polls: outcome.Polls, Timeout: pressTimeout,
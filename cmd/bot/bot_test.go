@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/cliutil"
 )
 
 func TestAddBotCommandScopesStepTextAlias(t *testing.T) {
@@ -129,76 +131,55 @@ func TestResolvePressArgs(t *testing.T) {
 	}
 }
 
-func TestSnapshotChanged(t *testing.T) {
-	base := map[string]any{
-		"id": float64(123), "threadId": float64(77), "editDate": float64(10), "text": "before",
-		"buttons": []any{map[string]any{"text": "Edit", "index": float64(0)}},
-	}
-	snapshot := newMessageSnapshot(base)
-	if snapshotChanged(snapshot, base) {
-		t.Fatal("unchanged message reported as edited")
-	}
-	for _, change := range []map[string]any{
-		{"id": float64(123), "threadId": float64(77), "editDate": float64(11), "text": "before", "buttons": base["buttons"]},
-		{"id": float64(123), "threadId": float64(77), "editDate": float64(10), "text": "after", "buttons": base["buttons"]},
-		{"id": float64(123), "threadId": float64(77), "editDate": float64(10), "text": "before", "buttons": []any{map[string]any{"text": "Demo"}}},
-	} {
-		if !snapshotChanged(snapshot, change) {
-			t.Fatalf("change not detected: %#v", change)
-		}
-	}
-}
-
-type editedMessagePoller struct {
-	message map[string]any
+// eventPoller answers wait_for_message with one event and get_message with
+// the full message.
+type eventPoller struct {
+	event string
+	waits *[]map[string]any
 }
 
-type newMessagePoller struct{}
-
-func (newMessagePoller) CallInternal(method string, params any) any {
-	if method != "get_messages" {
-		return map[string]any{}
-	}
+func (p eventPoller) CallInternal(method string, params any) any {
 	request := params.(map[string]any)
-	if request["threadId"] != int64(77) {
-		panic("threadId missing from bot poll")
-	}
-	return map[string]any{"messages": []any{
-		map[string]any{"id": float64(124), "out": false, "threadId": float64(77), "text": "new"},
-	}}
-}
-
-func (p editedMessagePoller) CallInternal(method string, _ any) any {
-	if method == "get_messages" {
-		return map[string]any{"messages": []any{}}
+	switch method {
+	case "wait_for_message":
+		*p.waits = append(*p.waits, request)
+		id := float64(124)
+		if p.event == "message_edited" {
+			id = 123
+		}
+		return map[string]any{"messages": []any{map[string]any{
+			"event":   p.event,
+			"message": map[string]any{"id": id, "thread_id": float64(77)},
+		}}}
+	case "get_message":
+		return map[string]any{"message": map[string]any{
+			"id": float64(request["messageId"].(int64)), "threadId": float64(77), "text": p.event,
+		}}
+	default:
+		panic("unexpected method: " + method)
 	}
-	return map[string]any{"message": p.message}
 }
 
 func TestWaitForBotEventCompletesOnEdit(t *testing.T) {
-	oldNow, oldSleep := botWaitNow, botWaitSleep
-	t.Cleanup(func() { botWaitNow, botWaitSleep = oldNow, oldSleep })
-	now := time.Unix(1, 0)
-	botWaitNow = func() time.Time { return now }
-	botWaitSleep = func(d time.Duration) { now = now.Add(d) }
-
-	before := newMessageSnapshot(map[string]any{"id": float64(123), "threadId": float64(77), "text": "before"})
-	edited := map[string]any{"id": float64(123), "threadId": float64(77), "editDate": float64(20), "text": "after"}
-	outcome := waitForBotEvent(editedMessagePoller{message: edited}, "@bot", 77, 123, before, time.Second)
-	if !outcome.Completed || outcome.Event != "message_edited" || outcome.Message["text"] != "after" {
+	var waits []map[string]any
+	outcome := waitForBotEvent(eventPoller{event: "message_edited", waits: &waits}, "@bot", 77, 123, 40, "e1", time.Second)
+	if !outcome.Completed || outcome.Event != "message_edited" || outcome.Message["text"] != "message_edited" {
 		t.Fatalf("outcome = %+v", outcome)
 	}
+	request := waits[0]
+	if request["editOf"] != int64(123) || request["afterId"] != int64(123) || request["threadId"] != int64(77) ||
+		request["offset"] != int64(40) || request["epoch"] != "e1" {
+		t.Fatalf("wait params = %#v", request)
+	}
 }
 
 func TestWaitForBotEventCompletesOnNewMessage(t *testing.T) {
-	oldNow, oldSleep := botWaitNow, botWaitSleep
-	t.Cleanup(func() { botWaitNow, botWaitSleep = oldNow, oldSleep })
-	now := time.Unix(1, 0)
-	botWaitNow = func() time.Time { return now }
-	botWaitSleep = func(d time.Duration) { now = now.Add(d) }
-
-	outcome := waitForBotEvent(newMessagePoller{}, "@bot", 77, 123, messageSnapshot{ID: 123}, time.Second)
-	if !outcome.Completed || outcome.Event != "new_message" || outcome.Message["text"] != "new" {
+	var waits []map[string]any
+	outcome := waitForBotEvent(eventPoller{event: "new_message", waits: &waits}, "@bot", 77, 123, 0, "", time.Second)
+	if !outcome.Completed || outcome.Event != "new_message" || cliutil.ExtractInt64(outcome.Message, "id") != 124 {
 		t.Fatalf("outcome = %+v", outcome)
 	}
+	if _, ok := waits[0]["offset"]; ok {
+		t.Fatalf("wait without a cursor should not send one: %#v", waits[0])
+	}
 }
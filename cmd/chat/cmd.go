@@ -28,6 +28,7 @@ func AddChatCommand(rootCmd *cobra.Command) {
 	ChatCmd.AddCommand(SlowModeCmd)
 	AddPermissionsCommand(ChatCmd)
 	AddKeyboardCommand(ChatCmd)
+	AddExportCommand(ChatCmd)
 
 	// Add the parent chat command to root
 	rootCmd.AddCommand(ChatCmd)
@@ -0,0 +1,138 @@
+// Package chat provides commands for managing chats.
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/chatexport"
+	"agent-telegram/internal/cliutil"
+)
+
+var (
+	exportTo         cliutil.Recipient
+	exportOutput     string
+	exportCheckpoint string
+	exportTranscript string
+	exportMedia      bool
+	exportSince      string
+	exportUntil      string
+	exportMinID      int64
+	exportMaxID      int64
+	exportNoTakeout  bool
+)
+
+// ExportCmd represents the chat export command.
+var ExportCmd = &cobra.Command{
+	Use:   "export [peer]",
+	Short: "Export the full history of a chat to JSONL",
+	Long: `Export the full history of a chat, oldest message first, to
+messages.jsonl in the output directory, one message object per line with the
+fields "msg list" returns. Service messages are not exported.
+
+The export runs in a Telegram takeout session, which has relaxed flood
+limits. The first takeout of an account may fail with TAKEOUT_INIT_DELAY
+until the request is confirmed in another Telegram app; use --no-takeout to
+export with normal limits instead.
+
+Progress is saved to a checkpoint after every page. Run the same command
+again to resume an interrupted export, or to append messages sent since a
+completed one.
+
+--transcript html writes a Telegram-Desktop-like messages.html;
+--transcript markdown writes messages.md. --media downloads photos and
+documents into media/<message_id>/.
+
+--since and --until take YYYY-MM-DD (local time, inclusive), RFC 3339 or
+Unix seconds; --min-id and --max-id are inclusive message ID bounds.
+
+Examples:
+  agent-telegram chat export @support -o ./archive/support
+  agent-telegram chat export @support --transcript html --media
+  agent-telegram chat export -1001234567890 --since 2024-01-01 --until 2024-03-31`,
+	Args: cobra.MaximumNArgs(1),
+}
+
+// AddExportCommand adds the export command to the parent command.
+func AddExportCommand(parentCmd *cobra.Command) {
+	parentCmd.AddCommand(ExportCmd)
+	cliutil.MarkFirstArgPeer(ExportCmd)
+
+	ExportCmd.Flags().VarP(&exportTo, "to", "t", "Chat to export (@username, username, or ID)")
+	ExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output directory (default export-<peer>)")
+	ExportCmd.Flags().StringVar(&exportCheckpoint, "checkpoint", "", "Checkpoint file (default <output>/checkpoint.json)")
+	ExportCmd.Flags().StringVar(&exportTranscript, "transcript", "", "Also write a transcript: html or markdown")
+	ExportCmd.Flags().BoolVar(&exportMedia, "media", false, "Download photos and documents into <output>/media")
+	ExportCmd.Flags().StringVar(&exportSince, "since", "", "Only messages sent on or after this date")
+	ExportCmd.Flags().StringVar(&exportUntil, "until", "", "Only messages sent on or before this date")
+	ExportCmd.Flags().Int64Var(&exportMinID, "min-id", 0, "Only messages with this ID or higher")
+	ExportCmd.Flags().Int64Var(&exportMaxID, "max-id", 0, "Only messages with this ID or lower")
+	ExportCmd.Flags().BoolVar(&exportNoTakeout, "no-takeout", false, "Export without a takeout session")
+
+	ExportCmd.Run = func(cmd *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		if len(args) > 0 {
+			_ = exportTo.Set(args[0])
+		}
+		peer := exportTo.Peer()
+		if peer == "" {
+			runner.Fatal("peer is required (positional or --to)")
+			return
+		}
+		since, err := chatexport.ParseDate(exportSince, false, time.Local)
+		if err != nil {
+			runner.Fatal("--since: " + err.Error())
+			return
+		}
+		until, err := chatexport.ParseDate(exportUntil, true, time.Local)
+		if err != nil {
+			runner.Fatal("--until: " + err.Error())
+			return
+		}
+		if exportMinID < 0 || exportMaxID < 0 || (exportMaxID > 0 && exportMinID > exportMaxID) {
+			runner.Fatal("--min-id and --max-id must be >= 0 and ordered")
+			return
+		}
+		if until > 0 && since > until {
+			runner.Fatal("--since must not be after --until")
+			return
+		}
+		output := exportOutput
+		if output == "" {
+			output = "export-" + exportDirName(peer)
+		}
+
+		result, err := chatexport.Run(runner, chatexport.Options{
+			Peer:       peer,
+			Dir:        output,
+			Checkpoint: exportCheckpoint,
+			Transcript: exportTranscript,
+			Media:      exportMedia,
+			Filter:     chatexport.Filter{MinID: exportMinID, MaxID: exportMaxID, Since: since, Until: until},
+			Takeout:    !exportNoTakeout,
+			Progress: func(r chatexport.Result) {
+				runner.Logf("Exported %d messages (last %d)\n", r.Exported, r.LastMessageID)
+			},
+		})
+		if err != nil {
+			runner.Fatal(fmt.Sprintf("export failed: %v (run again to resume)", err))
+			return
+		}
+		runner.PrintResult(result, nil)
+	}
+}
+
+// exportDirName turns a peer into a directory name component.
+func exportDirName(peer string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimPrefix(peer, "@"))
+}
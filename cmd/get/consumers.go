@@ -0,0 +1,187 @@
+// Package get provides commands for retrieving information.
+package get
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/cliutil"
+)
+
+var (
+	consumeName           string
+	consumeLimit          int
+	consumeTo             cliutil.Recipient
+	consumeTypes          []string
+	consumeDirection      string
+	consumeRedeliverAfter time.Duration
+
+	ackName string
+	ackUpTo int64
+)
+
+// ConsumeCmd represents the updates consume command.
+var ConsumeCmd = &cobra.Command{
+	Use:   "consume",
+	Short: "Get unacknowledged updates for a named consumer",
+	Long: `Return updates that the named consumer has not acknowledged yet.
+
+The daemon tracks each consumer's committed offset, so several agents sharing
+one daemon each keep their own progress. Returned updates are reserved for the
+consumer until they are acknowledged with "updates ack" or --redeliver-after
+passes, after which they are returned again. A new consumer starts at the
+newest stored update and keeps the --to, --type and --direction filter of its
+first call: updates the filter excludes are acknowledged automatically, so
+consuming with another filter fails until the consumer is deleted.
+
+Offsets survive daemon restarts; reservations do not, so unacknowledged
+updates are returned again after a restart.
+
+Examples:
+  agent-telegram updates consume --consumer triage
+  agent-telegram updates consume --consumer triage --type new_message --direction in --limit 20
+  agent-telegram updates consume --consumer alerts --to @ops --redeliver-after 5m`,
+	Args: cobra.NoArgs,
+}
+
+// AckCmd represents the updates ack command.
+var AckCmd = &cobra.Command{
+	Use:   "ack <update_id>...",
+	Short: "Acknowledge updates for a named consumer",
+	Long: `Acknowledge updates returned by "updates consume". The consumer's committed
+offset advances over every contiguous acknowledged update. Use --up-to to
+acknowledge everything up to and including an update ID.
+
+Examples:
+  agent-telegram updates ack --consumer triage 41 42
+  agent-telegram updates ack --consumer triage 41,42,45
+  agent-telegram updates ack --consumer triage --up-to 45`,
+}
+
+// ConsumersCmd represents the updates consumers command.
+var ConsumersCmd = &cobra.Command{
+	Use:   "consumers",
+	Short: "List named update consumers and their offsets",
+	Long: `List named update consumers with their committed offset, out-of-order
+acknowledgements and deliveries awaiting acknowledgement.
+
+Example:
+  agent-telegram updates consumers`,
+	Args: cobra.NoArgs,
+}
+
+// ConsumerDeleteCmd represents the updates consumers delete command.
+var ConsumerDeleteCmd = &cobra.Command{
+	Use:   "delete <consumer>",
+	Short: "Forget a named update consumer",
+	Long: `Forget a consumer and its offset. Consuming again under the same name
+starts at the newest stored update.
+
+Example:
+  agent-telegram updates consumers delete triage`,
+	Args: cobra.ExactArgs(1),
+}
+
+// addConsumerCommands adds the consume, ack and consumers subcommands.
+func addConsumerCommands(parentCmd *cobra.Command) {
+	parentCmd.AddCommand(ConsumeCmd, AckCmd, ConsumersCmd)
+	ConsumersCmd.AddCommand(ConsumerDeleteCmd)
+
+	ConsumeCmd.Flags().StringVar(&consumeName, "consumer", "", "Consumer name (letters, digits, '_', '.', '-')")
+	ConsumeCmd.Flags().IntVarP(&consumeLimit, "limit", "l", cliutil.DefaultLimitSmall, "Number of updates (max 100)")
+	ConsumeCmd.Flags().VarP(&consumeTo, "to", "t", "Only deliver updates for this chat (@username or chat ID)")
+	ConsumeCmd.Flags().StringSliceVar(&consumeTypes, "type", nil, "Only deliver these update types (comma-separated)")
+	ConsumeCmd.Flags().StringVar(&consumeDirection, "direction", "", "Only deliver incoming (in) or outgoing (out) messages")
+	ConsumeCmd.Flags().DurationVar(&consumeRedeliverAfter, "redeliver-after", 0,
+		"Return unacknowledged updates again after this long (default 1m)")
+	_ = ConsumeCmd.MarkFlagRequired("consumer")
+	ConsumeCmd.Run = func(*cobra.Command, []string) {
+		runner := cliutil.NewRunnerFromCmd(ConsumeCmd, true) // Always JSON
+		params, err := consumeParams()
+		if err != nil {
+			runner.Fatal(err.Error())
+		}
+		result := runner.CallWithParams("consume_updates", params)
+		runner.PrintResult(result, nil)
+	}
+
+	AckCmd.Flags().StringVar(&ackName, "consumer", "", "Consumer name")
+	AckCmd.Flags().Int64Var(&ackUpTo, "up-to", 0, "Acknowledge every update up to and including this ID")
+	_ = AckCmd.MarkFlagRequired("consumer")
+	AckCmd.Run = func(_ *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(AckCmd, true) // Always JSON
+		ids, err := parseUpdateIDs(args)
+		if err != nil {
+			runner.Fatal(err.Error())
+		}
+		if len(ids) == 0 && ackUpTo == 0 {
+			runner.Fatal("pass update IDs or --up-to")
+		}
+		params := map[string]any{"consumer": ackName}
+		if len(ids) > 0 {
+			params["ids"] = ids
+		}
+		if ackUpTo > 0 {
+			params["upTo"] = ackUpTo
+		}
+		result := runner.CallWithParams("ack_updates", params)
+		runner.PrintResult(result, nil)
+	}
+
+	ConsumersCmd.Run = func(*cobra.Command, []string) {
+		runner := cliutil.NewRunnerFromCmd(ConsumersCmd, true) // Always JSON
+		result := runner.Call("list_consumers", nil)
+		runner.PrintResult(result, nil)
+	}
+
+	ConsumerDeleteCmd.Run = func(_ *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(ConsumerDeleteCmd, true) // Always JSON
+		result := runner.CallWithParams("delete_consumer", map[string]any{"consumer": args[0]})
+		runner.PrintResult(result, nil)
+	}
+}
+
+// consumeParams builds consume_updates params from the consume flags.
+func consumeParams() (map[string]any, error) {
+	params := map[string]any{"consumer": consumeName, "limit": consumeLimit}
+	if peer := consumeTo.Peer(); peer != "" {
+		params["peer"] = peer
+	}
+	if len(consumeTypes) > 0 {
+		params["types"] = consumeTypes
+	}
+	if consumeDirection != "" {
+		params["direction"] = consumeDirection
+	}
+	if consumeRedeliverAfter != 0 {
+		if consumeRedeliverAfter < time.Second {
+			return nil, fmt.Errorf("--redeliver-after must be at least 1s")
+		}
+		params["redeliverAfter"] = int(consumeRedeliverAfter / time.Second)
+	}
+	return params, nil
+}
+
+// parseUpdateIDs parses update IDs given as separate or comma-separated
+// arguments.
+func parseUpdateIDs(args []string) ([]int64, error) {
+	var ids []int64
+	for _, arg := range args {
+		for part := range strings.SplitSeq(arg, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(part, 10, 64)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("invalid update ID %q", part)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
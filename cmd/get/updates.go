@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"agent-telegram/internal/cliutil"
+	"agent-telegram/telegram/types"
 )
 
 var (
@@ -38,8 +39,13 @@ var UpdatesCmd = &cobra.Command{
 	Short:   "Get Telegram updates",
 	Long: `Retrieve Telegram updates from the bounded update store without removing them.
 
-Use --follow to continuously poll with a cursor (JSON Lines output).
-Use --type to filter by update type (e.g., new_message, edit_message).`,
+Use --follow to stream updates as JSON Lines. The daemon pushes updates over
+the socket as they arrive; older daemons are polled every --interval seconds.
+Use --type to filter by update type (e.g., new_message, edit_message, read_outbox,
+participant). See "updateTypes" in the manifest for every type and its fields.
+
+Use "updates consume --consumer <name>" instead of --offset/--epoch to let the
+daemon track progress per consumer, and "updates ack" to acknowledge updates.`,
 }
 
 // AddUpdatesCommand adds the updates command to the root command.
@@ -48,11 +54,12 @@ func AddUpdatesCommand(rootCmd *cobra.Command) {
 
 	UpdatesCmd.Flags().IntVarP(&GetUpdatesLimit, "limit", "l", cliutil.DefaultLimitSmall, "Number of updates (max 100)")
 	UpdatesCmd.Flags().VarP(&GetUpdatesTo, "to", "t", "Recipient (@username, username, or chat ID) to filter updates")
-	UpdatesCmd.Flags().BoolVarP(&GetUpdatesFollow, "follow", "f", false, "Continuously poll for updates (JSON Lines)")
+	UpdatesCmd.Flags().BoolVarP(&GetUpdatesFollow, "follow", "f", false, "Stream updates continuously (JSON Lines)")
 	UpdatesCmd.Flags().StringVar(&GetUpdatesType, "type", "", "Filter by update type (e.g., new_message, edit_message)")
-	UpdatesCmd.Flags().IntVar(&GetUpdatesInterval, "interval", 2, "Polling interval in seconds (with --follow)")
+	UpdatesCmd.Flags().IntVar(&GetUpdatesInterval, "interval", 2, "Polling interval in seconds (with --follow, older daemons only)")
 	UpdatesCmd.Flags().Int64Var(&GetUpdatesOffset, "offset", 0, "Return updates after this update ID")
 	UpdatesCmd.Flags().StringVar(&GetUpdatesEpoch, "epoch", "", "Daemon epoch returned by an earlier updates call")
+	addConsumerCommands(UpdatesCmd)
 	UpdatesCmd.Run = func(*cobra.Command, []string) {
 		pag := cliutil.NewPagination(GetUpdatesLimit, 0, cliutil.PaginationConfig{
 			MaxLimit: cliutil.MaxLimitStandard,
@@ -93,8 +100,12 @@ func updateCursor(params map[string]any, result any) {
 	}
 }
 
-// runFollowMode continuously polls for updates and outputs JSON Lines to stdout.
+// runFollowMode streams updates as JSON Lines to stdout, falling back to
+// polling when the daemon does not support subscribe_updates.
 func runFollowMode(runner *cliutil.Runner, params map[string]any) {
+	if followSubscription(runner, params) {
+		return
+	}
 	interval := time.Duration(GetUpdatesInterval) * time.Second
 	if interval < time.Second {
 		interval = time.Second
@@ -134,13 +145,73 @@ func runFollowMode(runner *cliutil.Runner, params map[string]any) {
 				}
 			}
 			//nolint:errchkjson // JSON Lines output to stdout
-			_ = encoder.Encode(update)
+			_ = encoder.Encode(runner.RenderText(update))
 		}
 
 		time.Sleep(interval)
 	}
 }
 
+// followSubscription streams updates pushed by subscribe_updates. It returns
+// false when the daemon does not support subscriptions.
+func followSubscription(runner *cliutil.Runner, params map[string]any) bool {
+	subParams := map[string]any{}
+	for _, key := range []string{"peer", "username", "offset", "epoch"} {
+		if value, ok := params[key]; ok {
+			subParams[key] = value
+		}
+	}
+	if GetUpdatesType != "" {
+		subParams["types"] = []string{GetUpdatesType}
+	}
+	sub, ok := runner.Subscribe("subscribe_updates", subParams)
+	if !ok {
+		return false
+	}
+	defer func() { _ = sub.Close() }()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	interrupted := make(chan struct{})
+	go func() {
+		<-sigCh
+		close(interrupted)
+		_ = sub.Close()
+	}()
+
+	encoder := json.NewEncoder(os.Stdout)
+	for {
+		notification, err := sub.Next()
+		if err != nil {
+			select {
+			case <-interrupted:
+				return true
+			default:
+			}
+			runner.Fatal(fmt.Sprintf("update subscription closed: %v", err))
+			return true
+		}
+		if notification.Method != types.UpdatesNotificationMethod {
+			continue
+		}
+		var batch types.UpdatesNotification
+		if err := json.Unmarshal(notification.Params, &batch); err != nil {
+			continue
+		}
+		if batch.Gap {
+			_ = encoder.Encode(map[string]any{
+				"type":       "cursor_gap",
+				"epoch":      batch.Epoch,
+				"nextOffset": batch.NextOffset,
+			})
+		}
+		for _, update := range batch.Updates {
+			//nolint:errchkjson // JSON Lines output to stdout
+			_ = encoder.Encode(runner.RenderText(update))
+		}
+	}
+}
+
 // extractUpdates extracts update items from the result.
 func extractUpdates(result any) []map[string]any {
 	// Result might be a slice or a map with "updates" key
@@ -176,6 +247,22 @@ func FormatUpdateType(updateType string) string {
 		return "Edited Message"
 	case "star_gift":
 		return "Star Gift"
+	case "delete":
+		return "Deleted Message"
+	case "read_outbox":
+		return "Read Receipt"
+	case "typing":
+		return "Typing"
+	case "user_status":
+		return "User Status"
+	case "reaction":
+		return "Reaction"
+	case "participant":
+		return "Member Change"
+	case "pin":
+		return "Pinned Messages"
+	case "service":
+		return "Service Message"
 	default:
 		return fmt.Sprintf("Update (%s)", updateType)
 	}
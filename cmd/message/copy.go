@@ -0,0 +1,86 @@
+// Package message provides commands for managing messages.
+package message
+
+import (
+	"github.com/spf13/cobra"
+
+	"agent-telegram/cmd/send"
+	"agent-telegram/internal/cliutil"
+)
+
+var (
+	copyFrom, copyTo cliutil.Recipient
+	copyCaption      string
+	copyDropCaption  bool
+	copyParseMode    string
+	copyThreadID     int64
+	copyReplyTo      int64
+	copySchedule     string
+	copyOptions      send.SendOptionFlags
+)
+
+// CopyCmd represents the copy command.
+var CopyCmd = &cobra.Command{
+	Use:   "copy <message_id>",
+	Short: "Re-send a message as your own, without a forward header",
+	Long: `Copy a message's text and media into another chat as a new message.
+
+Unlike forward, the copy is sent under your identity and can carry a new
+caption. Photos, videos, files, locations, venues, contacts and dice are
+copied; polls and other media must be forwarded instead.
+
+Examples:
+  agent-telegram msg copy --from @source --to @mirror 120
+  agent-telegram msg copy --from @source --to @mirror 120 --caption "Curated: *launch*" --parse-mode markdown
+  agent-telegram msg copy --from @source --to @mirror 121 --drop-caption --silent`,
+	Args: cobra.ExactArgs(1),
+}
+
+// AddCopyCommand adds the copy command to the parent command.
+func AddCopyCommand(parentCmd *cobra.Command) {
+	parentCmd.AddCommand(CopyCmd)
+
+	CopyCmd.Flags().VarP(&copyFrom, "from", "f", "Source peer (@username, username, or chat ID)")
+	CopyCmd.Flags().VarP(&copyTo, "to", "t", "Destination peer (@username, username, or chat ID)")
+	CopyCmd.Flags().StringVar(&copyCaption, "caption", "", "Replace the text or caption")
+	CopyCmd.Flags().BoolVar(&copyDropCaption, "drop-caption", false, "Copy media without its caption")
+	CopyCmd.Flags().StringVar(&copyParseMode, "parse-mode", "", "Caption formatting: markdown, html or none")
+	CopyCmd.Flags().Int64Var(&copyThreadID, "thread-id", 0, "Destination forum topic root message ID")
+	CopyCmd.Flags().Int64Var(&copyReplyTo, "reply-to", 0, "Reply to message ID in the destination")
+	CopyCmd.Flags().StringVar(&copySchedule, "schedule-date", "",
+		"Schedule instead of sending now (RFC 3339, Unix seconds, or +30m/+2h/+1d)")
+	copyOptions.Register(CopyCmd)
+	_ = CopyCmd.MarkFlagRequired("from")
+	_ = CopyCmd.MarkFlagRequired("to")
+
+	CopyCmd.Run = func(_ *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(CopyCmd, true)
+		params := map[string]any{
+			"fromPeer":  copyFrom.Peer(),
+			"toPeer":    copyTo.Peer(),
+			"messageId": runner.MustParseInt64(args[0]),
+		}
+		if copyCaption != "" {
+			params["caption"] = copyCaption
+		}
+		if copyDropCaption {
+			params["dropCaption"] = true
+		}
+		if copyParseMode != "" {
+			params["parseMode"] = copyParseMode
+		}
+		if copyThreadID != 0 {
+			params["threadId"] = copyThreadID
+		}
+		if copyReplyTo != 0 {
+			params["replyTo"] = copyReplyTo
+		}
+		if copySchedule != "" {
+			params["scheduleDate"] = copySchedule
+		}
+		copyOptions.AddToParams(params)
+
+		result := runner.CallWithParams("copy_message", params)
+		runner.PrintResult(result, nil)
+	}
+}
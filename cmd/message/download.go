@@ -0,0 +1,86 @@
+package message
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/cliutil"
+)
+
+var (
+	downloadTo        cliutil.Recipient
+	downloadOutput    string
+	downloadThreads   int
+	downloadOverwrite bool
+)
+
+// DownloadCmd represents the msg download command.
+var DownloadCmd = &cobra.Command{
+	Use:   "download <message_id>",
+	Short: "Download the photo or document attached to a message",
+	Long: `Download the photo or document attached to a message.
+
+Photos are saved in their largest size; documents keep their original file
+name when --output is a directory. Chunks are fetched in parallel and written
+to <path>.part, so an interrupted or timed-out download resumes where it
+stopped when the command is run again. For large files raise
+AGENT_TELEGRAM_RPC_TIMEOUT (e.g. 10m).
+
+Without --output the file is saved to ~/.agent-telegram/downloads/.
+
+Examples:
+  agent-telegram msg download 12345 --to @channel
+  agent-telegram msg download 12345 -t @username -o ./report.pdf
+  agent-telegram msg download 12345 -t @channel -o ./media/ --threads 8`,
+	Args: cobra.ExactArgs(1),
+}
+
+// AddDownloadCommand adds the download command to the parent command.
+func AddDownloadCommand(parentCmd *cobra.Command) {
+	parentCmd.AddCommand(DownloadCmd)
+
+	DownloadCmd.Flags().VarP(&downloadTo, "to", "t", "Chat/channel containing the message (required)")
+	DownloadCmd.Flags().StringVarP(&downloadOutput, "output", "o", "", "Destination file or directory")
+	DownloadCmd.Flags().IntVar(&downloadThreads, "threads", 0, "Parallel chunk requests (default 4, max 8)")
+	DownloadCmd.Flags().BoolVar(&downloadOverwrite, "overwrite", false, "Replace an existing destination file")
+	_ = DownloadCmd.MarkFlagRequired("to")
+
+	DownloadCmd.Run = func(_ *cobra.Command, args []string) {
+		msgID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: invalid message ID")
+			os.Exit(1)
+		}
+
+		runner := cliutil.NewRunnerFromCmd(DownloadCmd, true)
+		params := map[string]any{
+			"messageId": msgID,
+		}
+		downloadTo.AddToParams(params)
+		if downloadOutput != "" {
+			// The daemon has its own working directory; send an absolute path.
+			output, err := filepath.Abs(downloadOutput)
+			if err != nil {
+				runner.Fatal(fmt.Sprintf("invalid output path: %v", err))
+			}
+			if strings.HasSuffix(downloadOutput, string(filepath.Separator)) {
+				output += string(filepath.Separator)
+			}
+			params["output"] = output
+		}
+		if downloadThreads > 0 {
+			params["threads"] = downloadThreads
+		}
+		if downloadOverwrite {
+			params["overwrite"] = true
+		}
+
+		result := runner.CallWithParams("download_media", params)
+		runner.PrintResult(result, nil)
+	}
+}
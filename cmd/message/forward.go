@@ -5,16 +5,35 @@ import (
 	"github.com/spf13/cobra"
 
 	"agent-telegram/internal/cliutil"
+	"agent-telegram/telegram/types"
+)
+
+var (
+	forwardFrom, forwardTo cliutil.Recipient
+	forwardDropAuthor      bool
+	forwardDropCaptions    bool
+	forwardSilent          bool
+	forwardThreadID        int64
+	forwardReplyTo         int64
 )
 
 // ForwardCmd represents the forward command.
 var ForwardCmd = &cobra.Command{
-	Use:   "forward <message_id>",
-	Short: "Forward a Telegram message to another user or chat",
-	Long: `Forward a message from one peer to another.
+	Use:   "forward <message_id|id1,id2,...|first-last>",
+	Short: "Forward Telegram messages to another user or chat",
+	Long: `Forward one or more messages from one peer to another.
 
 Use --from @username, --from username, or --from <chat_id> to specify the source.
-Use --to @username, --to username, or --to <chat_id> to specify the destination.`,
+Use --to @username, --to username, or --to <chat_id> to specify the destination.
+Pass several IDs as a comma-separated list or range (up to 100 messages).
+
+Use --drop-author to forward without the "Forwarded from" header, and
+--drop-captions to also strip media captions.
+
+Examples:
+  agent-telegram msg forward --from @news --to @mirror 120
+  agent-telegram msg forward --from @news --to @mirror 120-125,130 --drop-author
+  agent-telegram msg forward --from @news --to @forum 120 --thread-id 7 --silent`,
 	Args: cobra.ExactArgs(1),
 }
 
@@ -22,20 +41,51 @@ Use --to @username, --to username, or --to <chat_id> to specify the destination.
 func AddForwardCommand(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(ForwardCmd)
 
-	var fromPeer, toPeer cliutil.Recipient
-	ForwardCmd.Flags().VarP(&fromPeer, "from", "f", "Source peer (@username, username, or chat ID)")
-	ForwardCmd.Flags().VarP(&toPeer, "to", "t", "Destination peer (@username, username, or chat ID)")
+	ForwardCmd.Flags().VarP(&forwardFrom, "from", "f", "Source peer (@username, username, or chat ID)")
+	ForwardCmd.Flags().VarP(&forwardTo, "to", "t", "Destination peer (@username, username, or chat ID)")
+	ForwardCmd.Flags().BoolVar(&forwardDropAuthor, "drop-author", false, "Hide the original sender (no forward header)")
+	ForwardCmd.Flags().BoolVar(&forwardDropCaptions, "drop-captions", false, "Strip media captions (with --drop-author)")
+	ForwardCmd.Flags().BoolVar(&forwardSilent, "silent", false, "Forward without a notification")
+	ForwardCmd.Flags().Int64Var(&forwardThreadID, "thread-id", 0, "Destination forum topic root message ID")
+	ForwardCmd.Flags().Int64Var(&forwardReplyTo, "reply-to", 0, "Reply to message ID in the destination")
 	_ = ForwardCmd.MarkFlagRequired("from")
 	_ = ForwardCmd.MarkFlagRequired("to")
 
 	ForwardCmd.Run = func(_ *cobra.Command, args []string) {
 		runner := cliutil.NewRunnerFromCmd(ForwardCmd, true) // Always JSON
+		ids, err := types.ParseMessageIDs(args[0])
+		if err != nil {
+			runner.Fatal(err.Error())
+		}
 		params := map[string]any{
-			"messageId": runner.MustParseInt64(args[0]),
+			"fromPeer": forwardFrom.Peer(),
+			"toPeer":   forwardTo.Peer(),
+		}
+		if len(ids) == 1 {
+			params["messageId"] = ids[0]
+		} else {
+			params["messageIds"] = ids
 		}
-		params["fromPeer"] = fromPeer.Peer()
-		params["toPeer"] = toPeer.Peer()
+		addForwardFlags(params)
 		result := runner.CallWithParams("forward_message", params)
 		runner.PrintResult(result, nil)
 	}
 }
+
+func addForwardFlags(params map[string]any) {
+	if forwardDropAuthor {
+		params["dropAuthor"] = true
+	}
+	if forwardDropCaptions {
+		params["dropMediaCaptions"] = true
+	}
+	if forwardSilent {
+		params["silent"] = true
+	}
+	if forwardThreadID != 0 {
+		params["threadId"] = forwardThreadID
+	}
+	if forwardReplyTo != 0 {
+		params["replyTo"] = forwardReplyTo
+	}
+}
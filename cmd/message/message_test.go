@@ -20,7 +20,7 @@ func TestAddMsgCommandRegistersExpectedSurface(t *testing.T) {
 		t.Fatal("msg command was not registered")
 	}
 	for _, name := range []string{
-		"get", "list", "delete", "forward", "pin", "inspect-buttons",
+		"get", "list", "delete", "forward", "copy", "pin", "inspect-buttons",
 		"press-button", "reaction", "inspect-keyboard", "press-keyboard",
 		"wait", "read", "typing", "scheduled", "clear", "replies", "reply-comment",
 	} {
@@ -28,6 +28,20 @@ func TestAddMsgCommandRegistersExpectedSurface(t *testing.T) {
 			t.Fatalf("msg subcommand %q was not registered", name)
 		}
 	}
+	for _, name := range []string{"send", "delete", "edit"} {
+		if childCommand(ScheduledCmd, name) == nil {
+			t.Fatalf("msg scheduled subcommand %q was not registered", name)
+		}
+	}
+	if ScheduledEditCmd.Flags().Lookup("schedule-date") == nil {
+		t.Fatal("msg scheduled edit should expose --schedule-date")
+	}
+	if ForwardCmd.Flags().Lookup("drop-author") == nil || ForwardCmd.Flags().Lookup("thread-id") == nil {
+		t.Fatal("msg forward should expose --drop-author and --thread-id")
+	}
+	if CopyCmd.Flags().Lookup("caption") == nil || CopyCmd.Flags().Lookup("silent") == nil {
+		t.Fatal("msg copy should expose --caption and send option flags")
+	}
 	if childCommand(root, "send") == nil {
 		t.Fatal("send command should be registered as a top-level command")
 	}
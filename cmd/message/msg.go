@@ -11,7 +11,7 @@ var MsgCmd = &cobra.Command{
 	GroupID: "message",
 	Use:     "msg",
 	Short:   "Message management commands",
-	Long:    `Commands for managing Telegram messages - send, delete, forward, pin, react, and more.`,
+	Long:    `Commands for managing Telegram messages - send, delete, forward, copy, pin, react, and more.`,
 }
 
 // AddMsgCommand adds the msg command group to the root command.
@@ -20,9 +20,11 @@ func AddMsgCommand(rootCmd *cobra.Command) {
 
 	// Call AddXxxCommand functions with MsgCmd to setup flags and Run
 	AddGetCommand(MsgCmd)
+	AddDownloadCommand(MsgCmd)
 	AddListCommand(MsgCmd)
 	AddDeleteCommand(MsgCmd)
 	AddForwardCommand(MsgCmd)
+	AddCopyCommand(MsgCmd)
 	AddPinMessageCommand(MsgCmd)
 	AddInspectButtonsCommand(MsgCmd)
 	AddPressButtonCommand(MsgCmd)
@@ -39,7 +41,8 @@ func AddMsgCommand(rootCmd *cobra.Command) {
 
 	// Update Use strings for subcommands
 	DeleteCmd.Use = "delete <message_id|id1,id2,...>"
-	ForwardCmd.Use = "forward <message_id>"
+	ForwardCmd.Use = "forward <message_id|id1,id2,...|first-last>"
+	CopyCmd.Use = "copy <message_id>"
 	PinMessageCmd.Use = "pin <message_id>"
 	InspectButtonsCmd.Use = "inspect-buttons <message_id>"
 	PressButtonCmd.Use = "press-button <message_id> <button_index>"
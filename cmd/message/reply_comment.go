@@ -13,6 +13,7 @@ var (
 	replyCommentTo      cliutil.Recipient
 	replyCommentMessage int64
 	replyCommentID      int64
+	replyCommentParse   string
 )
 
 // ReplyCommentCmd represents the msg reply-comment command.
@@ -36,6 +37,7 @@ func AddReplyCommentCommand(parentCmd *cobra.Command) {
 	ReplyCommentCmd.Flags().VarP(&replyCommentTo, "to", "t", "Channel peer")
 	ReplyCommentCmd.Flags().Int64VarP(&replyCommentMessage, "message", "m", 0, "Channel post message ID (required)")
 	ReplyCommentCmd.Flags().Int64VarP(&replyCommentID, "comment", "c", 0, "Comment ID to reply to (required)")
+	ReplyCommentCmd.Flags().StringVar(&replyCommentParse, "parse-mode", "", "Text formatting: markdown, html or none")
 
 	ReplyCommentCmd.Run = func(_ *cobra.Command, args []string) {
 		var text string
@@ -80,6 +82,9 @@ func AddReplyCommentCommand(parentCmd *cobra.Command) {
 			"commentId": replyCommentID,
 			"text":      text,
 		}
+		if replyCommentParse != "" {
+			params["parseMode"] = replyCommentParse
+		}
 
 		result := runner.CallWithParams("reply_to_comment", params)
 		runner.PrintResult(result, func(r any) {
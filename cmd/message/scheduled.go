@@ -4,25 +4,57 @@ package message
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"agent-telegram/internal/cliutil"
 )
 
-var scheduledTo cliutil.Recipient
+var (
+	scheduledTo       cliutil.Recipient
+	scheduledQueueTo  cliutil.Recipient
+	scheduledEditDate string
+	scheduledEditMode string
+)
 
 // ScheduledCmd represents the scheduled command.
 var ScheduledCmd = &cobra.Command{
 	Use:   "scheduled",
-	Short: "List scheduled messages",
-	Long: `List all scheduled messages in a chat.
+	Short: "List and manage scheduled messages",
+	Long: `List all scheduled messages in a chat, or send, delete and edit them.
+
+Messages are scheduled with --schedule-date on any send command.
 
-Example:
-  agent-telegram msg scheduled --to @channel`,
+Examples:
+  agent-telegram msg scheduled --to @channel
+  agent-telegram msg scheduled send --to @channel 101,102
+  agent-telegram msg scheduled delete --to @channel 101
+  agent-telegram msg scheduled edit --to @channel 101 "New text" --schedule-date +2h`,
 	Args: cobra.NoArgs,
 }
 
+// ScheduledSendCmd represents the msg scheduled send command.
+var ScheduledSendCmd = &cobra.Command{
+	Use:   "send <id1,id2,...>",
+	Short: "Send scheduled messages now",
+	Args:  cobra.ExactArgs(1),
+}
+
+// ScheduledDeleteCmd represents the msg scheduled delete command.
+var ScheduledDeleteCmd = &cobra.Command{
+	Use:   "delete <id1,id2,...>",
+	Short: "Delete scheduled messages",
+	Args:  cobra.ExactArgs(1),
+}
+
+// ScheduledEditCmd represents the msg scheduled edit command.
+var ScheduledEditCmd = &cobra.Command{
+	Use:   "edit <id> [text]",
+	Short: "Edit the text or send time of a scheduled message",
+	Args:  cobra.RangeArgs(1, 2),
+}
+
 // AddScheduledCommand adds the scheduled command to the parent command.
 func AddScheduledCommand(parentCmd *cobra.Command) {
 	parentCmd.AddCommand(ScheduledCmd)
@@ -45,4 +77,62 @@ func AddScheduledCommand(parentCmd *cobra.Command) {
 			}
 		})
 	}
+
+	addScheduledQueueCommands(ScheduledCmd)
+}
+
+func addScheduledQueueCommands(parentCmd *cobra.Command) {
+	parentCmd.AddCommand(ScheduledSendCmd, ScheduledDeleteCmd, ScheduledEditCmd)
+	for _, cmd := range []*cobra.Command{ScheduledSendCmd, ScheduledDeleteCmd, ScheduledEditCmd} {
+		cmd.Flags().VarP(&scheduledQueueTo, "to", "t", "Chat/channel of the scheduled messages")
+		_ = cmd.MarkFlagRequired("to")
+	}
+	ScheduledEditCmd.Flags().StringVar(&scheduledEditDate, "schedule-date", "",
+		"New send time (RFC 3339, Unix seconds, or +30m/+2h/+1d)")
+	ScheduledEditCmd.Flags().StringVar(&scheduledEditMode, "parse-mode", "", "Text formatting: markdown, html or none")
+
+	ScheduledSendCmd.Run = func(_ *cobra.Command, args []string) {
+		runScheduledQueue(ScheduledSendCmd, "send_scheduled_messages", args[0], "Scheduled message(s) sent")
+	}
+	ScheduledDeleteCmd.Run = func(_ *cobra.Command, args []string) {
+		runScheduledQueue(ScheduledDeleteCmd, "delete_scheduled_messages", args[0], "Scheduled message(s) deleted")
+	}
+	ScheduledEditCmd.Run = func(_ *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(ScheduledEditCmd, true)
+		params := map[string]any{"messageId": runner.MustParseInt64(args[0])}
+		scheduledQueueTo.AddToParams(params)
+		if len(args) == 2 {
+			params["text"] = args[1]
+		}
+		if scheduledEditDate != "" {
+			params["scheduleDate"] = scheduledEditDate
+		}
+		if scheduledEditMode != "" {
+			params["parseMode"] = scheduledEditMode
+		}
+		if params["text"] == nil && scheduledEditDate == "" {
+			fmt.Fprintln(os.Stderr, "Error: new text or --schedule-date is required")
+			os.Exit(1)
+		}
+
+		result := runner.CallWithParams("edit_scheduled_message", params)
+		runner.PrintResult(result, func(any) {
+			fmt.Fprintln(os.Stderr, "Scheduled message updated")
+		})
+	}
+}
+
+func runScheduledQueue(cmd *cobra.Command, method, ids, done string) {
+	runner := cliutil.NewRunnerFromCmd(cmd, false)
+	messageIDs := make([]int64, 0)
+	for _, id := range strings.Split(ids, ",") {
+		messageIDs = append(messageIDs, runner.MustParseInt64(strings.TrimSpace(id)))
+	}
+	params := map[string]any{"messageIds": messageIDs}
+	scheduledQueueTo.AddToParams(params)
+
+	result := runner.CallWithParams(method, params)
+	runner.PrintResult(result, func(any) {
+		fmt.Fprintf(os.Stderr, "%s: %d\n", done, len(messageIDs))
+	})
 }
@@ -22,8 +22,10 @@ var WaitCmd = &cobra.Command{
 	Short: "Wait for the next incoming message",
 	Long: `Wait for the next incoming message from a peer after a known message ID.
 
-This is the high-level agentic wait primitive. Internally it polls Telegram
-messages, but polling steps are not written as separate CLI audit events.`,
+This is the high-level agentic wait primitive. The daemon watches its update
+stream with wait_for_message, so no history is polled and replies stored
+shortly before the wait started are still found. Wait calls are not written
+as separate CLI audit events.`,
 	Args: cobra.MaximumNArgs(1),
 }
 
@@ -0,0 +1,68 @@
+// Package peers provides commands for inspecting the persistent peer cache.
+package peers
+
+import (
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/cliutil"
+)
+
+// PeersCmd represents the peers command group.
+var PeersCmd = &cobra.Command{
+	GroupID: "server",
+	Use:     "peers",
+	Short:   "Inspect resolved peers",
+}
+
+// CacheCmd represents the peers cache command group.
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect or clear the persistent peer cache",
+	Long: `The server remembers users, chats and channels seen in responses and
+updates, with the access hashes needed to address them, in peers.json next to
+the instance's socket. Usernames and numeric IDs resolve from it after a
+restart without contacting Telegram. Entries Telegram rejects are dropped
+automatically.`,
+}
+
+// CacheStatsCmd represents the peers cache stats command.
+var CacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Count cached users, chats, channels and usernames",
+	Long: `Count the cached peers of the connected account.
+
+Example:
+  agent-telegram peers cache stats`,
+	Args: cobra.NoArgs,
+}
+
+// CacheClearCmd represents the peers cache clear command.
+var CacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Forget cached peers",
+	Long: `Forget the cached peers of the connected account. They are resolved
+through Telegram again on next use.
+
+Example:
+  agent-telegram peers cache clear`,
+	Args: cobra.NoArgs,
+}
+
+// AddPeersCommand adds the peers command group to the root command.
+func AddPeersCommand(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(PeersCmd)
+	PeersCmd.AddCommand(CacheCmd)
+	CacheCmd.AddCommand(CacheStatsCmd, CacheClearCmd)
+
+	CacheStatsCmd.Run = func(cmd *cobra.Command, _ []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		result := runner.Call("peer_cache_stats", nil)
+		runner.PrintResult(result, nil)
+	}
+
+	CacheClearCmd.Run = func(cmd *cobra.Command, _ []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		result := runner.Call("clear_peer_cache", nil)
+		runner.PrintResult(result, nil)
+	}
+}
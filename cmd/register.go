@@ -12,12 +12,15 @@ import (
 	"agent-telegram/cmd/gift"
 	"agent-telegram/cmd/message"
 	"agent-telegram/cmd/open"
+	"agent-telegram/cmd/peers"
 	"agent-telegram/cmd/privacy"
+	"agent-telegram/cmd/rules"
 	"agent-telegram/cmd/search"
 	"agent-telegram/cmd/send"
 	"agent-telegram/cmd/session"
 	"agent-telegram/cmd/sys"
 	"agent-telegram/cmd/user"
+	"agent-telegram/cmd/webhook"
 	"agent-telegram/internal/cliutil"
 )
 
@@ -72,6 +75,10 @@ func init() {
 	sys.AddServerCommand(RootCmd)
 	sys.AddDocsCommand(RootCmd)
 	sys.AddSkillsCommand(RootCmd)
+	sys.AddMCPCommand(RootCmd)
+	webhook.AddWebhookCommand(RootCmd)
+	rules.AddRulesCommand(RootCmd)
+	peers.AddPeersCommand(RootCmd)
 
 	// Register schema methods for commands not using helper constructors.
 	// Commands using NewSimpleCommand/NewToggleCommand/NewListCommand auto-register.
@@ -89,10 +96,16 @@ func registerSchemaMethods() {
 	r(get.MyInfoCmd, "get_me")
 	r(get.UpdatesCmd, "get_updates")
 
+	// Peers
+	r(peers.CacheStatsCmd, "peer_cache_stats")
+	r(peers.CacheClearCmd, "clear_peer_cache")
+
 	// Message
 	r(message.ListCmd, "get_messages")
+	r(message.DownloadCmd, "download_media")
 	r(message.DeleteCmd, "delete_message")
 	r(message.ForwardCmd, "forward_message")
+	r(message.CopyCmd, "copy_message")
 	r(message.PinMessageCmd, "pin_message")
 	r(message.InspectButtonsCmd, "inspect_inline_buttons")
 	r(message.PressButtonCmd, "press_inline_button")
@@ -101,6 +114,9 @@ func registerSchemaMethods() {
 	r(message.ReadCmd, "read_messages")
 	r(message.TypingCmd, "set_typing")
 	r(message.ScheduledCmd, "get_scheduled_messages")
+	r(message.ScheduledSendCmd, "send_scheduled_messages")
+	r(message.ScheduledDeleteCmd, "delete_scheduled_messages")
+	r(message.ScheduledEditCmd, "edit_scheduled_message")
 	r(message.ClearCmd, "clear_messages")
 	r(message.RepliesCmd, "get_replies")
 	r(message.ReplyCommentCmd, "reply_to_comment")
@@ -39,7 +39,9 @@ func TestTelegramHandlersHaveResultSchemas(t *testing.T) {
 
 func TestOperationMethodsHaveHandlers(t *testing.T) {
 	handlerMethods := stringSet(telegramipc.RegisteredMethods())
-	for _, method := range []string{"ping", "echo", "status", "shutdown", "logout", "reload_session"} {
+	for _, method := range []string{"ping", "echo", "status", "shutdown", "logout", "reload_session",
+		"subscribe_updates", "unsubscribe", "wait_for_message", "consume_updates", "ack_updates", "list_consumers", "delete_consumer",
+		"list_webhooks", "test_webhook", "replay_webhooks", "list_rules", "test_rules"} {
 		handlerMethods[method] = struct{}{}
 	}
 
@@ -221,13 +221,14 @@ func init() {
 	// Global flags
 	RootCmd.PersistentFlags().StringP("socket", "s", paths.DefaultSocketPath, "Path to Unix socket")
 	RootCmd.PersistentFlags().String("session-provider", os.Getenv("AGENT_TELEGRAM_SESSION_PROVIDER"), "Session provider (default: native platform provider)")
-	RootCmd.PersistentFlags().String("profile", os.Getenv("AGENT_TELEGRAM_PROFILE"), "Session profile (default: default)")
+	RootCmd.PersistentFlags().String("profile", os.Getenv("AGENT_TELEGRAM_PROFILE"), "Session profile; selects the account on a server hosting several (default: default)")
 	RootCmd.PersistentFlags().BoolP("quiet", "q", false, "Suppress status messages (data still goes to stdout)")
 	RootCmd.PersistentFlags().String("output", "", "Output format: json or ids")
 	RootCmd.PersistentFlags().StringSlice("filter", nil, "Filter results (e.g., 'stars>1000', 'type=channel')")
 	RootCmd.PersistentFlags().String("verbosity", "full", "Output detail: minimal, compact, full, raw")
 	RootCmd.PersistentFlags().Int("max-items", 0, "Maximum array items in JSON output (0 uses verbosity default)")
 	RootCmd.PersistentFlags().Int("max-text-chars", 0, "Maximum text field characters in JSON output (0 uses verbosity default)")
+	RootCmd.PersistentFlags().String("text-format", "", "Render message text from its entities: markdown, html or plain")
 	RootCmd.PersistentFlags().StringSlice("include", nil, "Include output fields (comma-separated, supports dot paths)")
 	RootCmd.PersistentFlags().StringSlice("omit", nil, "Omit output fields (comma-separated, supports dot paths)")
 	RootCmd.PersistentFlags().Bool("summary", false, "Output a compact result summary")
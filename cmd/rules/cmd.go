@@ -0,0 +1,101 @@
+// Package rules provides commands for inspecting and testing automation
+// rules.
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/cliutil"
+)
+
+// RulesCmd represents the rules command group.
+var RulesCmd = &cobra.Command{
+	GroupID: "server",
+	Use:     "rules",
+	Short:   "Inspect and test automation rules",
+	Long: `Commands for the automation rules in rules.json next to the instance's
+socket. The running server re-reads the file when it changes, matches every
+new update against it, and runs the actions of matching rules through the
+account's policy and audit journal.`,
+}
+
+// ListCmd represents the rules list command.
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List loaded rules with match and run counters",
+	Long: `List the loaded rules with their counters. If the rules file failed to
+load, the error is reported and the last valid rules stay active.
+
+Example:
+  agent-telegram rules list`,
+	Args: cobra.NoArgs,
+}
+
+// TestCmd represents the rules test command.
+var TestCmd = &cobra.Command{
+	Use:   "test <update.json>",
+	Short: "Show which rules an update would trigger",
+	Long: `Match a stored update (as printed by "updates") against the loaded rules
+and show the actions that would run, with rendered params and the policy
+decision for each. Nothing is sent and rate limits are not consumed. Use -
+to read the update from stdin.
+
+Examples:
+  agent-telegram rules test update.json
+  agent-telegram updates --limit 1 | jq '.updates[0]' | agent-telegram rules test -`,
+	Args: cobra.ExactArgs(1),
+}
+
+// AddRulesCommand adds the rules command group to the root command.
+func AddRulesCommand(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(RulesCmd)
+	RulesCmd.AddCommand(ListCmd, TestCmd)
+
+	ListCmd.Run = func(cmd *cobra.Command, _ []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		runner.SetIDKey("id")
+		result := runner.Call("list_rules", nil)
+		runner.PrintResult(result, nil)
+	}
+
+	TestCmd.Run = func(cmd *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		update, err := readUpdate(args[0])
+		if err != nil {
+			runner.Fatal(err.Error())
+			return
+		}
+		result := runner.Call("test_rules", map[string]any{"update": update})
+		runner.PrintResult(result, nil)
+	}
+}
+
+// readUpdate reads one update object from path, or stdin for "-". Numbers
+// are kept verbatim so large IDs survive the round trip.
+func readUpdate(path string) (map[string]any, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		// #nosec G304 -- the user names the file to read
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var update map[string]any
+	if err := decoder.Decode(&update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
@@ -20,6 +20,9 @@ type SendFlags struct {
 	ThreadID    int64
 	ReplyTo     int64
 	WaitReply   bool
+	ParseMode   string
+	Schedule    string
+	Options     SendOptionFlags
 	WaitTimeout time.Duration
 	cmd         *cobra.Command
 }
@@ -30,6 +33,8 @@ func (f *SendFlags) Register(command *cobra.Command) {
 	command.Flags().VarP(&f.To, "to", "t", "Recipient (@username, username, or chat ID)")
 	command.Flags().StringVar(&f.Caption, "caption", "", "Caption")
 	f.registerThreadTarget(command)
+	f.registerSchedule(command)
+	f.Options.Register(command)
 	_ = command.MarkFlagRequired("to")
 }
 
@@ -39,6 +44,8 @@ func (f *SendFlags) RegisterOptionalTo(command *cobra.Command) {
 	command.Flags().VarP(&f.To, "to", "t", "Recipient (@username, username, or chat ID)")
 	command.Flags().StringVar(&f.Caption, "caption", "", "Caption")
 	f.registerThreadTarget(command)
+	f.registerSchedule(command)
+	f.Options.Register(command)
 	command.Flags().BoolVarP(&f.WaitReply, "wait-reply", "w", false, "Wait for a reply after sending")
 	command.Flags().DurationVar(&f.WaitTimeout, "timeout", 10*time.Second, "Timeout for --wait-reply")
 }
@@ -50,6 +57,18 @@ func (f *SendFlags) RegisterWithoutCaption(command *cobra.Command) {
 	_ = command.MarkFlagRequired("to")
 }
 
+// RegisterParseMode registers --parse-mode for commands that send text.
+func (f *SendFlags) RegisterParseMode(command *cobra.Command) {
+	command.Flags().StringVar(&f.ParseMode, "parse-mode", "", "Text formatting: markdown, html or none")
+}
+
+// AddParseMode adds the text parse mode to an IPC request.
+func (f *SendFlags) AddParseMode(params map[string]any) {
+	if f.ParseMode != "" {
+		params["parseMode"] = f.ParseMode
+	}
+}
+
 // AddToParams adds flags to params map.
 func (f *SendFlags) AddToParams(params map[string]any) {
 	f.To.AddToParams(params)
@@ -57,6 +76,10 @@ func (f *SendFlags) AddToParams(params map[string]any) {
 		params["caption"] = f.Caption
 	}
 	f.AddThreadTarget(params)
+	if f.Schedule != "" {
+		params["scheduleDate"] = f.Schedule
+	}
+	f.Options.AddToParams(params)
 }
 
 func (f *SendFlags) registerThreadTarget(command *cobra.Command) {
@@ -64,6 +87,58 @@ func (f *SendFlags) registerThreadTarget(command *cobra.Command) {
 	command.Flags().Int64Var(&f.ReplyTo, "reply-to", 0, "Reply to message ID")
 }
 
+func (f *SendFlags) registerSchedule(command *cobra.Command) {
+	command.Flags().StringVar(&f.Schedule, "schedule-date", "",
+		"Schedule instead of sending now (RFC 3339, Unix seconds, or +30m/+2h/+1d)")
+}
+
+// SendOptionFlags holds the delivery option flags shared by send commands.
+type SendOptionFlags struct {
+	Silent      bool
+	NoWebpage   bool
+	InvertMedia bool
+	NoForwards  bool
+	Spoiler     bool
+	TTL         int
+	SendAs      string
+}
+
+// Register registers the delivery option flags on a cobra command.
+func (o *SendOptionFlags) Register(command *cobra.Command) {
+	command.Flags().BoolVar(&o.Silent, "silent", false, "Send without a notification")
+	command.Flags().BoolVar(&o.NoWebpage, "no-webpage", false, "Disable the link preview")
+	command.Flags().BoolVar(&o.InvertMedia, "invert-media", false, "Show the link preview or media above the text")
+	command.Flags().BoolVar(&o.NoForwards, "no-forwards", false, "Protect the message from forwarding and saving")
+	command.Flags().BoolVar(&o.Spoiler, "spoiler", false, "Hide photos and videos behind a spoiler")
+	command.Flags().IntVar(&o.TTL, "ttl", 0, "Self-destruct timer in seconds for photos and videos (1-60)")
+	command.Flags().StringVar(&o.SendAs, "send-as", "", "Post as this channel or linked chat")
+}
+
+// AddToParams adds the set delivery options to an IPC request.
+func (o *SendOptionFlags) AddToParams(params map[string]any) {
+	if o.Silent {
+		params["silent"] = true
+	}
+	if o.NoWebpage {
+		params["noWebpage"] = true
+	}
+	if o.InvertMedia {
+		params["invertMedia"] = true
+	}
+	if o.NoForwards {
+		params["noforwards"] = true
+	}
+	if o.Spoiler {
+		params["hasSpoiler"] = true
+	}
+	if o.TTL != 0 {
+		params["ttlSeconds"] = o.TTL
+	}
+	if o.SendAs != "" {
+		params["sendAs"] = o.SendAs
+	}
+}
+
 // AddThreadTarget adds non-zero topic and reply identifiers to an IPC request.
 func (f *SendFlags) AddThreadTarget(params map[string]any) {
 	if f.ThreadID != 0 {
@@ -28,6 +28,8 @@ var (
 	sendVideoNote string
 	sendSticker   string
 	sendGIF       string
+	// Album files, sent as one grouped message
+	sendAlbum []string
 	// Poll options
 	pollQuestion string
 	pollOptions  []string
@@ -55,6 +57,8 @@ By default, sends a text message. Use flags to send other types.
 Use @username, username, or <chat_id> to specify the recipient.`,
 	Example: `  agent-telegram send @user "Hello world"
   agent-telegram send --to @user "Hello world"
+  agent-telegram send @user --album before.jpg,after.jpg --caption "Site report"
+  agent-telegram send @user --parse-mode markdown "**Done:** see [the report](https://example.com)"
   agent-telegram send @user --photo image.png
   agent-telegram send @user --poll "Question?" --option "Yes" --option "No"`,
 	Args: cobra.MaximumNArgs(2),
@@ -77,6 +81,7 @@ func AddSendCommand(rootCmd *cobra.Command) {
 
 	// Register common flags with optional --to (positional peer supported)
 	sendFlags.RegisterOptionalTo(SendCmd)
+	sendFlags.RegisterParseMode(SendCmd)
 
 	// Content type flags (mutually exclusive)
 	SendCmd.Flags().StringVar(&sendFile, "file", "", "Send file (auto-detect type)")
@@ -88,6 +93,8 @@ func AddSendCommand(rootCmd *cobra.Command) {
 	SendCmd.Flags().StringVar(&sendVideoNote, "video-note", "", "Send video note (circle)")
 	SendCmd.Flags().StringVar(&sendSticker, "sticker", "", "Send sticker (WEBP)")
 	SendCmd.Flags().StringVar(&sendGIF, "gif", "", "Send GIF/animation")
+	SendCmd.Flags().StringSliceVar(&sendAlbum, "album", nil,
+		"Send up to 10 photos/videos (or files) as one album; --caption goes on the first")
 
 	// Poll flags
 	SendCmd.Flags().StringVar(&pollQuestion, "poll", "", "Create poll with question")
@@ -186,7 +193,7 @@ func buildSendParams(args []string) (string, map[string]any) {
 		params["caption"] = sendFlags.Caption
 	}
 
-	// Priority: dice > contact > poll > location > sticker > voice > video-note > gif >
+	// Priority: dice > contact > poll > location > album > sticker > voice > video-note > gif >
 	// photo > video > audio > document > file > message
 	switch {
 	case sendDice:
@@ -222,6 +229,18 @@ func buildSendParams(args []string) (string, map[string]any) {
 		params["longitude"] = longitude
 		return "send_location", params
 
+	case len(sendAlbum) > 0:
+		items := make([]map[string]any, len(sendAlbum))
+		for i, file := range sendAlbum {
+			items[i] = map[string]any{"file": file}
+		}
+		if caption, ok := params["caption"]; ok {
+			items[0]["caption"] = caption
+			delete(params, "caption")
+		}
+		params["items"] = items
+		return "send_album", params
+
 	case sendSticker != "":
 		params["file"] = sendSticker
 		return "send_sticker", params
@@ -266,14 +285,17 @@ func buildSendParams(args []string) (string, map[string]any) {
 		}
 		params["messageId"] = sendFlags.ReplyTo
 		delete(params, "replyTo")
+		sendFlags.AddParseMode(params)
 		return methodSendReply, params
 
 	case len(args) > 0:
 		params["message"] = args[0]
+		sendFlags.AddParseMode(params)
 		return methodSendMessage, params
 
 	default:
 		params["message"] = ""
+		sendFlags.AddParseMode(params)
 		return methodSendMessage, params
 	}
 }
@@ -21,7 +21,7 @@ func TestAddSendCommandRegistersExpectedSurface(t *testing.T) {
 			t.Fatalf("send subcommand %q was not registered", name)
 		}
 	}
-	for _, flag := range []string{"to", "caption", "wait-reply", "thread-id", "reply-to", "file", "photo", "video", "poll", "latitude", "contact", "dice"} {
+	for _, flag := range []string{"to", "caption", "parse-mode", "schedule-date", "silent", "no-forwards", "spoiler", "ttl", "send-as", "wait-reply", "thread-id", "reply-to", "file", "album", "photo", "video", "poll", "latitude", "contact", "dice"} {
 		if SendCmd.Flags().Lookup(flag) == nil {
 			t.Fatalf("send flag --%s was not registered", flag)
 		}
@@ -30,6 +30,12 @@ func TestAddSendCommandRegistersExpectedSurface(t *testing.T) {
 		if cmd.Flags().Lookup("thread-id") == nil || cmd.Flags().Lookup("reply-to") == nil {
 			t.Fatalf("%s should expose thread target flags", cmd.CommandPath())
 		}
+		if cmd.Flags().Lookup("schedule-date") == nil {
+			t.Fatalf("%s should expose --schedule-date", cmd.CommandPath())
+		}
+		if cmd.Flags().Lookup("silent") == nil || cmd.Flags().Lookup("send-as") == nil {
+			t.Fatalf("%s should expose send option flags", cmd.CommandPath())
+		}
 	}
 }
 
@@ -158,6 +164,43 @@ func TestBuildSendParamsDefaultMessage(t *testing.T) {
 	}
 }
 
+func TestBuildSendParamsSendOptions(t *testing.T) {
+	resetSendGlobals(t)
+	_ = sendFlags.To.Set("@peer")
+	sendFlags.Options = SendOptionFlags{Silent: true, NoForwards: true, Spoiler: true, TTL: 30, SendAs: "@channel"}
+	sendPhoto = "p.jpg"
+
+	_, params := buildSendParams(nil)
+	if params["silent"] != true || params["noforwards"] != true || params["hasSpoiler"] != true {
+		t.Fatalf("params = %#v", params)
+	}
+	if params["ttlSeconds"] != 30 || params["sendAs"] != "@channel" {
+		t.Fatalf("params = %#v", params)
+	}
+	if _, ok := params["noWebpage"]; ok {
+		t.Fatalf("unset options should be omitted: %#v", params)
+	}
+}
+
+func TestBuildSendParamsAlbumCaptionsFirstItem(t *testing.T) {
+	resetSendGlobals(t)
+	_ = sendFlags.To.Set("@peer")
+	sendFlags.Caption = "report"
+	sendAlbum = []string{"a.jpg", "b.jpg"}
+
+	method, params := buildSendParams(nil)
+	if method != "send_album" {
+		t.Fatalf("method = %q, want send_album", method)
+	}
+	items, ok := params["items"].([]map[string]any)
+	if !ok || len(items) != 2 || items[0]["caption"] != "report" || items[1]["file"] != "b.jpg" {
+		t.Fatalf("items = %#v", params["items"])
+	}
+	if _, ok := params["caption"]; ok {
+		t.Fatalf("album params should not keep a top-level caption: %#v", params)
+	}
+}
+
 func resetSendGlobals(t *testing.T) {
 	t.Helper()
 	sendFlags = SendFlags{}
@@ -170,6 +213,7 @@ func resetSendGlobals(t *testing.T) {
 	sendVideoNote = ""
 	sendSticker = ""
 	sendGIF = ""
+	sendAlbum = nil
 	pollQuestion = ""
 	pollOptions = nil
 	latitude = 0
@@ -17,6 +17,7 @@ var TextCmd = &cobra.Command{
 Examples:
   agent-telegram send text @user "Hello world"
   agent-telegram send text --to @user "Hello world"
+  agent-telegram send text @user --parse-mode html "<b>Done</b>"
   echo "Hello" | agent-telegram send text @user`,
 	Args: cobra.RangeArgs(1, 2),
 	Run: func(_ *cobra.Command, args []string) {
@@ -43,6 +44,7 @@ Examples:
 			"message": messageText,
 		}
 		textFlags.AddToParams(params)
+		textFlags.AddParseMode(params)
 
 		method := "send_message"
 		if textFlags.ReplyTo != 0 {
@@ -64,4 +66,5 @@ func addTextCommand(parentCmd *cobra.Command) {
 	parentCmd.AddCommand(TextCmd)
 	cliutil.MarkFirstArgPeer(TextCmd)
 	textFlags.RegisterOptionalTo(TextCmd)
+	textFlags.RegisterParseMode(TextCmd)
 }
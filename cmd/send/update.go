@@ -17,7 +17,8 @@ var UpdateCmd = &cobra.Command{
 Use --to @username, --to username, or --to <chat_id> to specify the recipient.
 
 Example:
-  send update --to @user 12345 "New text"`,
+  send update --to @user 12345 "New text"
+  send update --to @user 12345 --parse-mode markdown "~~Old~~ **new** text"`,
 	Args: cobra.ExactArgs(2),
 	Run: func(_ *cobra.Command, args []string) {
 		runner := sendFlags.NewRunner()
@@ -26,6 +27,7 @@ Example:
 			"text":      args[1],
 		}
 		sendFlags.To.AddToParams(params)
+		sendFlags.AddParseMode(params)
 		result := runner.CallWithParams("update_message", params)
 		runner.PrintResult(result, func(any) {
 			fmt.Fprintln(os.Stderr, "Message updated successfully!")
@@ -36,4 +38,5 @@ Example:
 func init() {
 	SendCmd.AddCommand(UpdateCmd)
 	sendFlags.RegisterWithoutCaption(UpdateCmd)
+	sendFlags.RegisterParseMode(UpdateCmd)
 }
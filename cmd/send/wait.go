@@ -9,19 +9,20 @@ import (
 	"agent-telegram/internal/cliutil"
 	"agent-telegram/internal/ipc"
 	"agent-telegram/internal/observability"
+	"agent-telegram/telegram/types"
 )
 
-const (
-	waitPollInterval = 500 * time.Millisecond
-	waitPollLimit    = 10
-)
+// waitCallTimeout bounds one wait_for_message call so it ends before the
+// client's RPC deadline. Longer waits resume from the returned cursor.
+const waitCallTimeout = 20 * time.Second
 
-var (
-	waitNow   = time.Now
-	waitSleep = time.Sleep
-)
+// gapCheckLimit is how many recent messages a wait reads from history when
+// the update stream reports a gap.
+const gapCheckLimit = 20
+
+var waitNow = time.Now
 
-// ReplyPoller is the narrow polling surface used by reply waits.
+// ReplyPoller is the narrow call surface used by reply waits.
 type ReplyPoller interface {
 	CallInternal(method string, params any) any
 }
@@ -29,6 +30,7 @@ type ReplyPoller interface {
 // WaitOutcome describes either a completed reply wait or its deadline.
 type WaitOutcome struct {
 	Reply          any
+	Event          string
 	ThreadID       int64
 	AfterMessageID int64
 	Polls          int
@@ -36,46 +38,156 @@ type WaitOutcome struct {
 	Completed      bool
 }
 
-// WaitForReply polls get_messages until a reply appears after afterMsgID, or timeout.
-// Its outcome preserves polling and deadline metadata without classifying the
+// MessageWait selects the incoming message a wait completes on.
+type MessageWait struct {
+	Peer     string
+	ThreadID int64
+	// AfterID skips messages at or before this ID.
+	AfterID int64
+	// EditOf also completes the wait on an edit of this message.
+	EditOf int64
+	// Offset and Epoch, from UpdateCursor, start the wait at a point taken
+	// before the triggering action so an early edit is not missed.
+	Offset  int64
+	Epoch   string
+	Timeout time.Duration
+}
+
+// WaitForReply waits for an incoming message after afterMsgID, or timeout.
+// Its outcome preserves call and deadline metadata without classifying the
 // timeout as a validation error.
 func WaitForReply(poller ReplyPoller, peer string, threadID, afterMsgID int64, timeout time.Duration) WaitOutcome {
-	outcome := WaitOutcome{ThreadID: threadID, AfterMessageID: afterMsgID, Timeout: timeout}
-	deadline := waitNow().Add(timeout)
-	polls := 0
+	return WaitForMessage(poller, MessageWait{Peer: peer, ThreadID: threadID, AfterID: afterMsgID, Timeout: timeout})
+}
+
+// WaitForMessage waits on the daemon's update stream with wait_for_message
+// instead of polling message history. The reply is returned as get_message
+// reports it.
+func WaitForMessage(poller ReplyPoller, wait MessageWait) WaitOutcome {
+	outcome := WaitOutcome{ThreadID: wait.ThreadID, AfterMessageID: wait.AfterID, Timeout: wait.Timeout}
+	deadline := waitNow().Add(wait.Timeout)
+	offset, epoch := wait.Offset, wait.Epoch
+	checkedHistory := false
 
-	for waitNow().Before(deadline) {
+	for {
+		remaining := deadline.Sub(waitNow())
+		if remaining <= 0 {
+			return outcome
+		}
 		params := map[string]any{
-			"username": peer,
-			"limit":    waitPollLimit,
+			"peer":    wait.Peer,
+			"timeout": waitSeconds(min(remaining, waitCallTimeout)),
 		}
-		if threadID != 0 {
-			params["threadId"] = threadID
+		if wait.ThreadID != 0 {
+			params["threadId"] = wait.ThreadID
+		}
+		if wait.AfterID != 0 {
+			params["afterId"] = wait.AfterID
+		}
+		if wait.EditOf != 0 {
+			params["editOf"] = wait.EditOf
+		}
+		// The epoch alone is a cursor: offset 0 then means the start of an
+		// epoch that had no updates yet when the cursor was taken.
+		if epoch != "" {
+			params["offset"] = offset
+			params["epoch"] = epoch
 		}
 
-		polls++
-		result := poller.CallInternal("get_messages", params)
-
-		if reply := FindReply(result, afterMsgID, threadID); reply != nil {
-			outcome.Reply = reply
-			outcome.Polls = polls
-			outcome.Completed = true
+		outcome.Polls++
+		result, ok := poller.CallInternal("wait_for_message", params).(map[string]any)
+		if !ok {
 			return outcome
 		}
+		if messages, _ := result["messages"].([]any); len(messages) > 0 {
+			if waited, ok := messages[0].(map[string]any); ok {
+				message, _ := waited["message"].(map[string]any)
+				outcome.Reply = fullMessage(poller, wait.Peer, message)
+				outcome.Event, _ = waited["event"].(string)
+				outcome.Completed = true
+				return outcome
+			}
+		}
+		// The stream may have lost the reply; history still has it.
+		if gap, _ := result["gap"].(bool); gap && wait.AfterID != 0 && !checkedHistory {
+			checkedHistory = true
+			if reply := historyReply(poller, wait); reply != nil {
+				outcome.Reply = reply
+				outcome.Event = types.WaitEventNewMessage
+				outcome.Completed = true
+				return outcome
+			}
+		}
+		offset = cliutil.ExtractInt64(result, "nextOffset")
+		epoch, _ = result["epoch"].(string)
+	}
+}
 
-		remaining := deadline.Sub(waitNow())
-		if remaining <= 0 {
-			break
+// historyReply returns the earliest incoming message after wait.AfterID
+// among the peer's recent messages, or nil.
+func historyReply(poller ReplyPoller, wait MessageWait) map[string]any {
+	params := map[string]any{"username": wait.Peer, "limit": gapCheckLimit}
+	if wait.ThreadID != 0 {
+		params["threadId"] = wait.ThreadID
+	}
+	result, ok := poller.CallInternal("get_messages", params).(map[string]any)
+	if !ok {
+		return nil
+	}
+	messages, _ := result["messages"].([]any)
+	var reply map[string]any
+	for _, item := range messages {
+		msg, ok := item.(map[string]any)
+		if !ok {
+			continue
 		}
-		if remaining < waitPollInterval {
-			waitSleep(remaining)
-		} else {
-			waitSleep(waitPollInterval)
+		if out, _ := msg["out"].(bool); out {
+			continue
+		}
+		id := cliutil.ExtractInt64(msg, "id")
+		if id <= wait.AfterID || (wait.ThreadID != 0 && cliutil.ExtractInt64(msg, "threadId") != wait.ThreadID) {
+			continue
+		}
+		if reply == nil || id < cliutil.ExtractInt64(reply, "id") {
+			reply = msg
 		}
 	}
+	return reply
+}
 
-	outcome.Polls = polls
-	return outcome
+// UpdateCursor returns the daemon's current update cursor. Take it before an
+// action whose effect a later wait should see. The offset is 0 while the
+// update store is empty; the epoch still makes it a cursor for
+// wait_for_message.
+func UpdateCursor(poller ReplyPoller) (offset int64, epoch string) {
+	result, ok := poller.CallInternal("get_updates", map[string]any{"limit": 1}).(map[string]any)
+	if !ok {
+		return 0, ""
+	}
+	epoch, _ = result["epoch"].(string)
+	return cliutil.ExtractInt64(result, "nextOffset"), epoch
+}
+
+// fullMessage replaces the update stream form of a message with the
+// get_message form that send and bot commands have always returned.
+func fullMessage(poller ReplyPoller, peer string, message map[string]any) map[string]any {
+	id := cliutil.ExtractInt64(message, "id")
+	if id == 0 {
+		return message
+	}
+	result, ok := poller.CallInternal("get_message", map[string]any{"peer": peer, "messageId": id}).(map[string]any)
+	if !ok {
+		return message
+	}
+	if full, ok := result["message"].(map[string]any); ok {
+		return full
+	}
+	return message
+}
+
+// waitSeconds rounds d up to whole seconds, the unit wait_for_message takes.
+func waitSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
 }
 
 // FailReplyTimeout reports a reply deadline without losing evidence of a
@@ -134,47 +246,6 @@ func replyTimeoutFailure(runner *cliutil.Runner, peer string, action any, outcom
 	return err, details
 }
 
-// FindReply searches messages for an incoming message in the requested thread.
-func FindReply(result any, afterMsgID, threadID int64) map[string]any {
-	r, ok := result.(map[string]any)
-	if !ok {
-		return nil
-	}
-
-	messages, ok := r["messages"].([]any)
-	if !ok {
-		return nil
-	}
-
-	for _, item := range messages {
-		msg, ok := item.(map[string]any)
-		if !ok {
-			continue
-		}
-
-		// Skip our own messages
-		if out, _ := msg["out"].(bool); out {
-			continue
-		}
-
-		// Check message ID > afterMsgID
-		msgID := cliutil.ExtractInt64(msg, "id")
-		if msgID <= afterMsgID {
-			continue
-		}
-		if threadID != 0 && cliutil.ExtractInt64(msg, "threadId") != threadID {
-			continue
-		}
-		return msg
-	}
-
-	return nil
-}
-
-func findReply(result any, afterMsgID, threadID int64) map[string]any {
-	return FindReply(result, afterMsgID, threadID)
-}
-
 // HandleWaitReply performs the wait-reply flow after a send.
 // sendResult is the result from the send call. Exits on timeout.
 func HandleWaitReply(runner *cliutil.Runner, peer string, threadID int64, sendResult any, timeout time.Duration) {
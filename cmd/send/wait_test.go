@@ -10,8 +10,11 @@ import (
 )
 
 type fakeReplyPoller struct {
-	results []any
-	calls   int
+	results   []any
+	calls     int
+	waits     []map[string]any
+	history   any
+	histories int
 }
 
 func TestReplyTimeoutFailurePreservesActionAndRecoveryCorrelation(t *testing.T) {
@@ -55,15 +58,24 @@ func TestReplyTimeoutFailureForStandaloneWaitHasNoPartialAction(t *testing.T) {
 }
 
 func (f *fakeReplyPoller) CallInternal(method string, params any) any {
-	if method != "get_messages" {
+	switch method {
+	case "wait_for_message":
+		f.waits = append(f.waits, params.(map[string]any))
+		index := f.calls
+		f.calls++
+		if index >= len(f.results) {
+			return map[string]any{"messages": []any{}, "nextOffset": float64(0), "epoch": "e1"}
+		}
+		return f.results[index]
+	case "get_messages":
+		f.histories++
+		return f.history
+	case "get_message":
+		request := params.(map[string]any)
+		return map[string]any{"message": map[string]any{"id": float64(request["messageId"].(int64)), "threadId": float64(77)}}
+	default:
 		panic("unexpected method: " + method)
 	}
-	index := f.calls
-	f.calls++
-	if index >= len(f.results) {
-		return map[string]any{"messages": []any{}}
-	}
-	return f.results[index]
 }
 
 func TestWaitOutcomeReturnsIncomingMessageAfterAction(t *testing.T) {
@@ -71,23 +83,85 @@ func TestWaitOutcomeReturnsIncomingMessageAfterAction(t *testing.T) {
 	base := time.Unix(1, 0)
 	now := base
 	waitNow = func() time.Time { return now }
-	waitSleep = func(d time.Duration) { now = now.Add(d) }
 
 	poller := &fakeReplyPoller{results: []any{
-		map[string]any{"messages": []any{
-			map[string]any{"id": float64(124), "out": true},
-		}},
-		map[string]any{"messages": []any{
-			map[string]any{"id": float64(125), "out": false},
-		}},
+		map[string]any{"messages": []any{}, "nextOffset": float64(40), "epoch": "e1"},
+		map[string]any{"messages": []any{map[string]any{
+			"event":   "new_message",
+			"message": map[string]any{"id": float64(125), "thread_id": float64(77)},
+		}}},
+	}}
+	outcome := WaitForReply(poller, "@bot", 77, 123, time.Minute)
+	if !outcome.Completed || outcome.Polls != 2 || outcome.AfterMessageID != 123 || outcome.Event != "new_message" {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+	reply, _ := outcome.Reply.(map[string]any)
+	if got := int64(reply["id"].(float64)); got != 125 || reply["threadId"] != float64(77) {
+		t.Fatalf("reply = %#v, want message 125 in get_message form", reply)
+	}
+
+	first, second := poller.waits[0], poller.waits[1]
+	if first["afterId"] != int64(123) || first["threadId"] != int64(77) || first["timeout"] != 20 {
+		t.Fatalf("first wait params = %#v", first)
+	}
+	if _, ok := first["offset"]; ok {
+		t.Fatalf("first wait should start at the daemon's head: %#v", first)
+	}
+	if second["offset"] != int64(40) || second["epoch"] != "e1" {
+		t.Fatalf("second wait should resume from the returned cursor: %#v", second)
+	}
+}
+
+func TestWaitForMessageSendsEpochCursorAtOffsetZero(t *testing.T) {
+	restoreWaitClock(t)
+	now := time.Unix(1, 0)
+	waitNow = func() time.Time { return now }
+
+	poller := &fakeReplyPoller{results: []any{
+		map[string]any{"messages": []any{map[string]any{
+			"event":   "message_edited",
+			"message": map[string]any{"id": float64(5)},
+		}}},
 	}}
-	outcome := WaitForReply(poller, "@bot", 0, 123, time.Second)
-	if !outcome.Completed || outcome.Polls != 2 || outcome.AfterMessageID != 123 || outcome.Timeout != time.Second {
+	outcome := WaitForMessage(poller, MessageWait{Peer: "@bot", EditOf: 5, Epoch: "e1", Timeout: time.Minute})
+	if !outcome.Completed {
 		t.Fatalf("outcome = %+v", outcome)
 	}
+	if first := poller.waits[0]; first["offset"] != int64(0) || first["epoch"] != "e1" {
+		t.Fatalf("an empty-store cursor must still be sent: %#v", first)
+	}
+}
+
+func TestWaitForMessageChecksHistoryOnGap(t *testing.T) {
+	restoreWaitClock(t)
+	now := time.Unix(1, 0)
+	waitNow = func() time.Time { return now }
+
+	gap := map[string]any{"messages": []any{}, "nextOffset": float64(400), "epoch": "e1", "gap": true}
+	poller := &fakeReplyPoller{
+		results: []any{gap, gap},
+		history: map[string]any{"messages": []any{
+			map[string]any{"id": float64(130), "text": "later"},
+			map[string]any{"id": float64(126), "text": "mine", "out": true},
+			map[string]any{"id": float64(125), "text": "Welcome"},
+			map[string]any{"id": float64(120), "text": "before"},
+		}},
+	}
+	outcome := WaitForReply(poller, "@bot", 0, 123, time.Minute)
 	reply, _ := outcome.Reply.(map[string]any)
-	if got := int64(reply["id"].(float64)); got != 125 {
-		t.Fatalf("reply id = %d, want 125", got)
+	if !outcome.Completed || outcome.Event != "new_message" || reply["text"] != "Welcome" {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+
+	// History is read once per wait, however many gaps follow.
+	poller = &fakeReplyPoller{results: []any{gap, gap}, history: map[string]any{"messages": []any{}}}
+	poller.results = append(poller.results, map[string]any{"messages": []any{map[string]any{
+		"event":   "new_message",
+		"message": map[string]any{"id": float64(140)},
+	}}})
+	outcome = WaitForReply(poller, "@bot", 0, 123, time.Minute)
+	if !outcome.Completed || poller.histories != 1 || outcome.Polls != 3 {
+		t.Fatalf("outcome = %+v, history checks = %d", outcome, poller.histories)
 	}
 }
 
@@ -103,7 +177,6 @@ func TestWaitOutcomeDeadlineIsDeterministic(t *testing.T) {
 		times = times[1:]
 		return value
 	}
-	waitSleep = func(time.Duration) { t.Fatal("deadline should not sleep") }
 
 	outcome := WaitForReply(&fakeReplyPoller{}, "-5424738551", 0, 123, time.Nanosecond)
 	want := WaitOutcome{AfterMessageID: 123, Timeout: time.Nanosecond, Completed: false}
@@ -112,30 +185,16 @@ func TestWaitOutcomeDeadlineIsDeterministic(t *testing.T) {
 	}
 }
 
-func TestFindReplyFiltersThread(t *testing.T) {
-	result := map[string]any{"messages": []any{
-		map[string]any{"id": float64(105), "out": true, "threadId": float64(77)},
-		map[string]any{"id": float64(99), "out": false, "threadId": float64(77)},
-		map[string]any{"id": float64(104), "out": false},
-		map[string]any{"id": float64(103), "out": false, "threadId": float64(78)},
-		map[string]any{"id": float64(102), "out": false, "threadId": float64(77)},
-	}}
-
-	got := findReply(result, 100, 77)
-	if cliutil.ExtractInt64(got, "id") != 102 {
-		t.Fatalf("thread reply = %#v", got)
-	}
-	legacy := findReply(result, 100, 0)
-	if cliutil.ExtractInt64(legacy, "id") != 104 {
-		t.Fatalf("legacy reply = %#v", legacy)
+func TestWaitSecondsRoundsUp(t *testing.T) {
+	for d, want := range map[time.Duration]int{time.Millisecond: 1, time.Second: 1, 1500 * time.Millisecond: 2, waitCallTimeout: 20} {
+		if got := waitSeconds(d); got != want {
+			t.Errorf("waitSeconds(%s) = %d, want %d", d, got, want)
+		}
 	}
 }
 
 func restoreWaitClock(t *testing.T) {
 	t.Helper()
-	oldNow, oldSleep := waitNow, waitSleep
-	t.Cleanup(func() {
-		waitNow = oldNow
-		waitSleep = oldSleep
-	})
+	oldNow := waitNow
+	t.Cleanup(func() { waitNow = oldNow })
 }
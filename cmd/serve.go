@@ -20,9 +20,16 @@ import (
 	"agent-telegram/internal/ipc"
 	"agent-telegram/internal/observability"
 	"agent-telegram/internal/paths"
+	"agent-telegram/internal/peercache"
 	"agent-telegram/internal/policy"
+	"agent-telegram/internal/rules"
 	"agent-telegram/internal/sessionstore"
 	telegramipc "agent-telegram/internal/telegram/ipc"
+	"agent-telegram/internal/updateconsumer"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/internal/updatejournal"
+	"agent-telegram/internal/updatestate"
+	"agent-telegram/internal/webhook"
 	"agent-telegram/telegram"
 )
 
@@ -33,6 +40,7 @@ var (
 	serveSocket       string
 	serveForeground   bool
 	serveLogoutOnStop bool
+	serveProfiles     []string
 )
 
 // serveCmd represents the serve command.
@@ -42,7 +50,17 @@ var serveCmd = &cobra.Command{
 	Long: `Start the IPC server with a Telegram client in the background.
 
 The server listens on a Unix socket and handles requests from other commands.
-Telegram client runs in background and stays connected.`,
+Telegram client runs in background and stays connected.
+
+With --profiles one server hosts several accounts. Requests select one with
+--profile (the "profile" field of a JSON-RPC request); requests without a
+profile go to the primary account chosen by --profile at startup. Each
+account has its own update journal, webhooks and audit journal, and uses
+~/.agent-telegram/policy.<profile>.json when present.
+
+Examples:
+  agent-telegram serve --profile support --profiles sales,ops
+  agent-telegram send @alice "hi" --profile sales`,
 	Run:     runServe,
 	GroupID: GroupIDServer,
 }
@@ -56,6 +74,7 @@ func init() {
 		"Run in foreground (default: background)")
 	serveCmd.Flags().BoolVar(&serveLogoutOnStop, "logout-on-stop", false,
 		"Logout from Telegram when the server stops")
+	serveCmd.Flags().StringSliceVar(&serveProfiles, "profiles", nil, serveProfilesUsage)
 }
 
 //nolint:funlen // Server startup logic requires sequential steps
@@ -67,6 +86,11 @@ func runServe(cmd *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 	appID, appHash := storedCfg.AppID, storedCfg.AppHash
+	profiles, err := additionalProfiles(serveProfiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	socketPath := getSocketPath()
 
@@ -112,15 +136,38 @@ func runServe(cmd *cobra.Command, _ []string) {
 	// Setup slog after daemonize (when in foreground mode or in child process)
 	setupLoggerForSocket(socketPath)
 
+	updateStore := openUpdateStore(socketPath)
+	defer func() { _ = updateStore.Close() }()
+	updateState := openUpdateState(socketPath)
+	if updateState != nil {
+		defer func() { _ = updateState.Close() }()
+	}
+	peerStore := openPeerStore(socketPath)
+	if peerStore != nil {
+		defer func() { _ = peerStore.Close() }()
+	}
+	consumers := openUpdateConsumers(socketPath)
+	defer func() { _ = consumers.Close() }()
+
+	provider := firstConfigured(sessionFlagValue(cmd, "session-provider"), storedCfg.SessionProvider)
 	tgClient := createTelegramClient(appID, appHash, telegramClientOptions{
-		Provider: firstConfigured(sessionFlagValue(cmd, "session-provider"), storedCfg.SessionProvider),
-		Profile:  firstConfigured(sessionFlagValue(cmd, "profile"), storedCfg.SessionProfile),
+		Provider:    provider,
+		Profile:     firstConfigured(sessionFlagValue(cmd, "profile"), storedCfg.SessionProfile),
+		UpdateStore: updateStore,
+		UpdateState: updateState,
+		PeerStore:   peerStore,
 	})
 	logoutOnStop := boolFromEnv(envLogoutOnStop, serveLogoutOnStop)
-	var logoutOnce sync.Once
+	var (
+		logoutOnce sync.Once
+		accounts   []*hostedAccount
+	)
 	logout := func() {
 		logoutOnce.Do(func() {
 			logoutTelegramClient(tgClient, logoutOnStop)
+			for _, account := range accounts {
+				logoutTelegramClient(account.client, logoutOnStop)
+			}
 		})
 	}
 
@@ -129,7 +176,21 @@ func runServe(cmd *cobra.Command, _ []string) {
 	startTelegramClient(ctx, tgClient)
 	go waitForTelegramReady(ctx, tgClient)
 
-	srv := createIPCServer(socketPath, tgClient, cancel)
+	policyChecker := loadPolicyChecker(tgClient)
+	feed := updatefeed.New()
+	hooks := openWebhooks(socketPath, tgClient, policyChecker)
+	publishUpdates(updateStore, feed, hooks)
+	go hooks.Run(ctx)
+	engine := openRules(socketPath, socketPath, tgClient, policyChecker)
+	go engine.Run(ctx, tgClient, feed)
+
+	srv := createIPCServer(socketPath, tgClient, policyChecker, feed, hooks, consumers, engine)
+	accounts = hostProfiles(ctx, srv, socketPath, appID, appHash, provider, tgClient, profiles)
+	defer closeAccounts(accounts)
+	for _, account := range accounts {
+		telegramipc.RegisterSubscriptionHandlers(account.scope, account.client, account.feed)
+	}
+	registerControlHandlers(srv, tgClient, accounts, cancel)
 	if err := srv.Start(ctx); err != nil {
 		slog.Error("server error", "error", err)
 		os.Exit(1)
@@ -200,6 +261,54 @@ type telegramClientOptions struct {
 	SessionData []byte
 	Provider    string
 	Profile     string
+	UpdateStore *telegram.UpdateStore
+	UpdateState *updatestate.Store
+	PeerStore   *peercache.Store
+	// Additional marks a hosted profile other than the primary one. It only
+	// uses its provider profile: TELEGRAM_SESSION and the one-time login
+	// handoff belong to the primary account.
+	Additional bool
+}
+
+// logFloodEvent logs throttling and flood waits with the run and trace IDs of
+// the delayed request, so they show up in `trace inspect`.
+func logFloodEvent(profile string) telegram.FloodObserver {
+	return func(ctx context.Context, event telegram.FloodEvent) {
+		runID, traceID := ipc.TraceFromContext(ctx)
+		slog.Info("Telegram flood control",
+			"run_id", runID,
+			"trace_id", traceID,
+			"profile", profile,
+			"action", event.Action,
+			"method", event.Method,
+			"dc", event.DC,
+			"wait_ms", event.Wait.Milliseconds(),
+			"attempt", event.Attempt,
+		)
+	}
+}
+
+// openUpdateStore opens the instance-scoped update journal so cursors and the
+// epoch survive restarts. It falls back to a volatile store on error.
+func openUpdateStore(instance string) *telegram.UpdateStore {
+	dir, err := paths.UpdatesDirForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve update journal path; updates are volatile", "error", err)
+		return telegram.NewUpdateStore(1000)
+	}
+	journal, err := updatejournal.Open(dir, updatejournal.OptionsFromEnv())
+	if err != nil {
+		slog.Warn("Failed to open update journal; updates are volatile", "error", err)
+		return telegram.NewUpdateStore(1000)
+	}
+	store, err := telegram.NewJournaledUpdateStore(1000, journal)
+	if err != nil {
+		_ = journal.Close()
+		slog.Warn("Failed to load update journal; updates are volatile", "error", err)
+		return telegram.NewUpdateStore(1000)
+	}
+	slog.Info("Using persistent update journal", "dir", dir, "epoch", journal.Epoch(), "last_id", journal.LastID())
+	return store
 }
 
 // createTelegramClient creates and configures the Telegram client.
@@ -212,7 +321,7 @@ func createTelegramClient(appID int, appHash string, opts telegramClientOptions)
 		storage = telegram.NewMemoryStorage(opts.SessionData)
 		slog.Info("Using caller-provided in-memory Telegram session")
 	}
-	if envSession := os.Getenv(envTelegramSession); envSession != "" {
+	if envSession := os.Getenv(envTelegramSession); envSession != "" && !opts.Additional {
 		envStorage, err := telegram.NewEnvStorage(envSession)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: invalid %s: %v\n", envTelegramSession, err)
@@ -226,6 +335,9 @@ func createTelegramClient(appID int, appHash string, opts telegramClientOptions)
 		if err != nil {
 			slog.Warn("Failed to open configured session provider; using memory", "error", err)
 			storage = telegram.NewMemoryStorage(nil)
+		} else if opts.Additional {
+			storage = managed
+			slog.Info("Using Telegram session for additional profile", "provider", managed.Provider(), "profile", managed.Profile())
 		} else if managed.Persistent() {
 			if pending, pendingErr := config.ConsumePendingSession(); pendingErr != nil {
 				slog.Warn("Failed to consume pending Telegram session", "error", pendingErr)
@@ -252,8 +364,69 @@ func createTelegramClient(appID int, appHash string, opts telegramClientOptions)
 		}
 	}
 	tgClient = tgClient.WithSessionStorage(storage)
+	tgClient = tgClient.WithFloodObserver(logFloodEvent(tgClient.SessionStorageStatus().Profile))
+
+	if opts.UpdateState != nil {
+		tgClient = tgClient.WithUpdateStateStorage(opts.UpdateState)
+	}
+	if opts.PeerStore != nil {
+		tgClient = tgClient.WithPeerStore(opts.PeerStore)
+	}
+	updateStore := opts.UpdateStore
+	if updateStore == nil {
+		updateStore = telegram.NewUpdateStore(1000)
+	}
+	return tgClient.WithUpdateStore(updateStore)
+}
 
-	return tgClient.WithUpdateStore(telegram.NewUpdateStore(1000))
+// openUpdateState opens the persisted pts/qts/seq state used to recover
+// updates missed while the daemon was down. It returns nil on error, leaving
+// recovery limited to reconnects within one process.
+func openUpdateState(instance string) *updatestate.Store {
+	path, err := paths.UpdateStateFilePathForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve update state path; gap recovery is in-memory", "error", err)
+		return nil
+	}
+	store, err := updatestate.Open(path)
+	if err != nil {
+		slog.Warn("Failed to open update state; gap recovery is in-memory", "error", err)
+		return nil
+	}
+	return store
+}
+
+// openPeerStore opens the persistent peer cache. It returns nil on error,
+// leaving peer resolution cached in memory only.
+func openPeerStore(instance string) *peercache.Store {
+	path, err := paths.PeerCacheFilePathForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve peer cache path; peers are cached in memory", "error", err)
+		return nil
+	}
+	store, err := peercache.Open(path)
+	if err != nil {
+		slog.Warn("Failed to open peer cache; peers are cached in memory", "error", err)
+		return nil
+	}
+	return store
+}
+
+// openUpdateConsumers opens the committed offsets of named update consumers.
+// On error it returns an in-memory store, so consumers restart from the
+// newest update after a daemon restart.
+func openUpdateConsumers(instance string) *updateconsumer.Store {
+	path, err := paths.UpdateConsumersFilePathForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve update consumers path; consumer offsets are in-memory", "error", err)
+		return updateconsumer.New()
+	}
+	store, err := updateconsumer.Open(path)
+	if err != nil {
+		slog.Warn("Failed to open update consumers; consumer offsets are in-memory", "error", err)
+		return updateconsumer.New()
+	}
+	return store
 }
 
 func sessionFlagValue(cmd *cobra.Command, name string) string {
@@ -370,23 +543,80 @@ func waitForTelegramReady(ctx context.Context, tgClient *telegram.Client) {
 	}
 }
 
-// createIPCServer creates and configures the IPC server.
+// createIPCServer creates the IPC server with the primary account's
+// handlers. Control handlers are registered once hosted profiles exist.
 func createIPCServer(
 	socketPath string,
 	tgClient *telegram.Client,
-	cancel context.CancelFunc,
+	policyChecker ipc.PolicyChecker,
+	feed *updatefeed.Feed,
+	hooks *webhook.Dispatcher,
+	consumers *updateconsumer.Store,
+	engine *rules.Engine,
 ) *ipc.SocketServer {
 	srv := ipc.NewSocketServer(socketPath)
 	ipc.RegisterPingPong(srv)
-	policyChecker := loadPolicyChecker(tgClient)
 	srv.SetPolicyChecker(policyChecker)
 	telegramipc.RegisterHandlers(srv, tgClient)
-
-	registerControlHandlers(srv, tgClient, cancel)
+	telegramipc.RegisterSubscriptionHandlers(srv, tgClient, feed)
+	telegramipc.RegisterWaitHandlers(srv, tgClient, feed)
+	telegramipc.RegisterConsumerHandlers(srv, tgClient, consumers)
+	webhook.RegisterHandlers(srv, hooks)
+	rules.RegisterHandlers(srv, engine)
 	return srv
 }
 
-func registerControlHandlers(srv ipc.MethodRegistrar, tgClient *telegram.Client, cancel context.CancelFunc) {
+// registerControlHandlers registers status, shutdown, logout and
+// reload_session. Each hosted profile gets its own status, logout and
+// reload_session; shutdown always stops the whole server.
+func registerControlHandlers(
+	srv ipc.MethodRegistrar,
+	tgClient *telegram.Client,
+	accounts []*hostedAccount,
+	cancel context.CancelFunc,
+) {
+	registerStatusHandler(srv, tgClient, tgClient, accounts)
+	for _, account := range accounts {
+		registerStatusHandler(account.scope, account.client, tgClient, accounts)
+		registerAccountLogout(account.scope, account.client)
+		registerReloadSession(account.scope, account.client)
+	}
+
+	srv.Register("shutdown", func(_ context.Context, _ json.RawMessage) (any, *ipc.ErrorObject) {
+		// Trigger graceful shutdown by canceling context
+		go func() {
+			time.Sleep(100 * time.Millisecond) // Small delay to send response first
+			cancel()
+		}()
+		return map[string]any{
+			"success": true,
+			"message": "Shutting down...",
+		}, nil
+	})
+
+	srv.Register("logout", func(_ context.Context, _ json.RawMessage) (any, *ipc.ErrorObject) {
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			logoutTelegramClient(tgClient, true)
+			cancel()
+		}()
+		return map[string]any{
+			"success": true,
+			"message": "Logging out and shutting down...",
+		}, nil
+	})
+
+	registerReloadSession(srv, tgClient)
+}
+
+// registerStatusHandler reports the routed account at the top level and every
+// hosted account under "accounts".
+func registerStatusHandler(
+	srv ipc.MethodRegistrar,
+	tgClient *telegram.Client,
+	primary *telegram.Client,
+	accounts []*hostedAccount,
+) {
 	srv.Register("status", func(parent context.Context, _ json.RawMessage) (any, *ipc.ErrorObject) {
 		// Create a short-lived context for the status check
 		ctx, statusCancel := context.WithTimeout(parent, 2*time.Second)
@@ -407,35 +637,30 @@ func registerControlHandlers(srv ipc.MethodRegistrar, tgClient *telegram.Client,
 			"username":           tgStatus.Username,
 			"first_name":         tgStatus.FirstName,
 			"user_id":            tgStatus.UserID,
+			"flood_control":      tgClient.FloodControlStatus(),
+			"accounts":           accountStatuses(ctx, primary, accounts),
 		}, nil
 	})
+}
 
-	srv.Register("shutdown", func(_ context.Context, _ json.RawMessage) (any, *ipc.ErrorObject) {
-		// Trigger graceful shutdown by canceling context
-		go func() {
-			time.Sleep(100 * time.Millisecond) // Small delay to send response first
-			cancel()
-		}()
-		return map[string]any{
-			"success": true,
-			"message": "Shutting down...",
-		}, nil
-	})
-
+// registerAccountLogout logs out a hosted profile without stopping the
+// server or the other accounts.
+func registerAccountLogout(srv ipc.MethodRegistrar, tgClient *telegram.Client) {
 	srv.Register("logout", func(_ context.Context, _ json.RawMessage) (any, *ipc.ErrorObject) {
 		go func() {
 			time.Sleep(100 * time.Millisecond)
 			logoutTelegramClient(tgClient, true)
-			cancel()
 		}()
 		return map[string]any{
 			"success": true,
-			"message": "Logging out and shutting down...",
+			"message": "Logging out profile " + tgClient.SessionStorageStatus().Profile + "...",
 		}, nil
 	})
+}
 
+func registerReloadSession(srv ipc.MethodRegistrar, tgClient *telegram.Client) {
 	srv.Register("reload_session", func(_ context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
-		slog.Info("Reload session requested via IPC")
+		slog.Info("Reload session requested via IPC", "profile", tgClient.SessionStorageStatus().Profile)
 		sessionData, err := parseReloadSessionData(params)
 		if err != nil {
 			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
@@ -449,7 +674,6 @@ func registerControlHandlers(srv ipc.MethodRegistrar, tgClient *telegram.Client,
 			"message": "Session reload initiated",
 		}, nil
 	})
-
 }
 
 func parseReloadSessionData(params json.RawMessage) ([]byte, error) {
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"net"
 	"os"
@@ -12,7 +11,10 @@ import (
 
 	"agent-telegram/internal/config"
 	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/rules"
 	telegramipc "agent-telegram/internal/telegram/ipc"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/internal/webhook"
 	"agent-telegram/telegram"
 )
 
@@ -24,6 +26,7 @@ var (
 	serveAPIFileRoots []string
 	serveAPIUnsafe    bool
 	serveAPILogout    bool
+	serveAPIProfiles  []string
 )
 
 var serveAPICmd = &cobra.Command{
@@ -32,6 +35,7 @@ var serveAPICmd = &cobra.Command{
 	Long: `Start an HTTP server that exposes all Telegram actions as REST endpoints.
 
 Each registered method is available at POST /rpc/{method} with a JSON body.
+Updates stream from GET /events (Server-Sent Events) and GET /ws (WebSocket).
 
 Examples:
   curl -X POST http://localhost:8080/rpc/send_message \
@@ -40,7 +44,12 @@ Examples:
     -d '{"peer": "username", "message": "hello"}'
 
   curl http://localhost:8080/health
-  curl http://localhost:8080/methods -H "Authorization: Bearer <secret>"`,
+  curl http://localhost:8080/methods -H "Authorization: Bearer <secret>"
+  curl -N "http://localhost:8080/events?types=new_message" -H "Authorization: Bearer <secret>"
+
+With --profiles the server hosts several accounts; select one per request
+with the X-Agent-Telegram-Profile header (or a "profile" field on POST /rpc
+JSON-RPC requests). Requests without a profile use the primary account.`,
 	GroupID: GroupIDServer,
 	Run:     runServeAPI,
 }
@@ -61,6 +70,7 @@ func init() {
 	)
 	serveAPICmd.Flags().BoolVar(&serveAPIUnsafe, "unsafe-no-auth", false, "Allow HTTP API without auth")
 	serveAPICmd.Flags().BoolVar(&serveAPILogout, "logout-on-stop", false, "Logout from Telegram when the server stops")
+	serveAPICmd.Flags().StringSliceVar(&serveAPIProfiles, "profiles", nil, serveProfilesUsage)
 }
 
 func runServeAPI(cmd *cobra.Command, _ []string) {
@@ -82,24 +92,73 @@ func runServeAPI(cmd *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 
+	profiles, err := additionalProfiles(serveAPIProfiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	address := net.JoinHostPort(serveAPIListen, strconv.Itoa(serveAPIPort))
+	// The HTTP server has no socket; key its update journal by listen address.
+	instance := "http://" + address
+	updateStore := openUpdateStore(instance)
+	defer func() { _ = updateStore.Close() }()
+	updateState := openUpdateState(instance)
+	if updateState != nil {
+		defer func() { _ = updateState.Close() }()
+	}
+	peerStore := openPeerStore(instance)
+	if peerStore != nil {
+		defer func() { _ = peerStore.Close() }()
+	}
+	consumers := openUpdateConsumers(instance)
+	defer func() { _ = consumers.Close() }()
+
+	provider := firstConfigured(sessionFlagValue(cmd, "session-provider"), storedCfg.SessionProvider)
 	tgClient := createTelegramClient(storedCfg.AppID, storedCfg.AppHash, telegramClientOptions{
-		Provider: firstConfigured(sessionFlagValue(cmd, "session-provider"), storedCfg.SessionProvider),
-		Profile:  firstConfigured(sessionFlagValue(cmd, "profile"), storedCfg.SessionProfile),
+		Provider:    provider,
+		Profile:     firstConfigured(sessionFlagValue(cmd, "profile"), storedCfg.SessionProfile),
+		UpdateStore: updateStore,
+		UpdateState: updateState,
+		PeerStore:   peerStore,
 	})
 	logoutOnStop := boolFromEnv(envLogoutOnStop, serveAPILogout)
-	var logoutOnce sync.Once
+	var (
+		logoutOnce sync.Once
+		accounts   []*hostedAccount
+	)
 	logout := func() {
 		logoutOnce.Do(func() {
 			logoutTelegramClient(tgClient, logoutOnStop)
+			for _, account := range accounts {
+				logoutTelegramClient(account.client, logoutOnStop)
+			}
 		})
 	}
 	ctx, cancel := setupContextWithShutdown(logout)
 	startTelegramClient(ctx, tgClient)
 	go waitForTelegramReady(ctx, tgClient)
 
-	address := net.JoinHostPort(serveAPIListen, strconv.Itoa(serveAPIPort))
-	srv := createHTTPAPIServer(address, secret, serveAPICORS, serveAPIFileRoots, tgClient, cancel)
-	srv.SetPolicyChecker(loadPolicyChecker(tgClient))
+	srv := createHTTPAPIServer(address, secret, serveAPICORS, serveAPIFileRoots, tgClient)
+	policyChecker := loadPolicyChecker(tgClient)
+	srv.SetPolicyChecker(policyChecker)
+	feed := updatefeed.New()
+	hooks := openWebhooks(instance, tgClient, policyChecker)
+	publishUpdates(updateStore, feed, hooks)
+	go hooks.Run(ctx)
+	engine := openRules(instance, "", tgClient, policyChecker)
+	go engine.Run(ctx, tgClient, feed)
+	webhook.RegisterHandlers(srv, hooks)
+	rules.RegisterHandlers(srv, engine)
+	telegramipc.RegisterWaitHandlers(srv, tgClient, feed)
+	telegramipc.RegisterConsumerHandlers(srv, tgClient, consumers)
+	srv.SetUpdateFeed(tgClient, feed)
+	accounts = hostProfiles(ctx, srv, instance, storedCfg.AppID, storedCfg.AppHash, provider, tgClient, profiles)
+	defer closeAccounts(accounts)
+	for _, account := range accounts {
+		account.scope.SetUpdateFeed(account.client, account.feed)
+	}
+	registerControlHandlers(srv, tgClient, accounts, cancel)
 	fmt.Fprintf(os.Stderr, "REST API on http://%s\n", address)
 
 	if err := srv.Start(ctx); err != nil {
@@ -116,14 +175,11 @@ func runServeAPI(cmd *cobra.Command, _ []string) {
 func createHTTPAPIServer(
 	address, secret, cors string,
 	fileRoots []string,
-	tgClient *telegram.Client, cancel context.CancelFunc,
+	tgClient *telegram.Client,
 ) *ipc.HTTPServer {
 	srv := ipc.NewHTTPServerOnAddress(address, secret, cors)
 	srv.SetFileRoots(fileRoots)
 	ipc.RegisterPingPong(srv)
 	telegramipc.RegisterHandlers(srv, tgClient)
-
-	registerControlHandlers(srv, tgClient, cancel)
-
 	return srv
 }
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/paths"
+	"agent-telegram/internal/peercache"
+	"agent-telegram/internal/policy"
+	"agent-telegram/internal/rules"
+	"agent-telegram/internal/sessionstore"
+	telegramipc "agent-telegram/internal/telegram/ipc"
+	"agent-telegram/internal/updateconsumer"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/internal/updatestate"
+	"agent-telegram/internal/webhook"
+	"agent-telegram/telegram"
+)
+
+// envServeProfiles lists additional profiles for serve and serve-api,
+// separated by commas.
+const envServeProfiles = "AGENT_TELEGRAM_PROFILES"
+
+const serveProfilesUsage = "Additional session profiles to host, selected per request by profile " +
+	"(default: " + envServeProfiles + ")"
+
+// profileHost is a server that routes requests to hosted profiles.
+type profileHost interface {
+	SetPrimaryProfile(name string)
+	AddProfile(name string) *ipc.ProfileScope
+}
+
+// hostedAccount is an additional Telegram account served next to the primary
+// one. Its update journal, update state, peer cache, update consumers,
+// webhooks, rules and audit journal live under paths.ProfileInstance, and its policy
+// under policy.ProfilePath.
+type hostedAccount struct {
+	profile   string
+	client    *telegram.Client
+	store     *telegram.UpdateStore
+	state     *updatestate.Store
+	peers     *peercache.Store
+	consumers *updateconsumer.Store
+	feed      *updatefeed.Feed
+	scope     *ipc.ProfileScope
+}
+
+// additionalProfiles reads --profiles, falling back to AGENT_TELEGRAM_PROFILES,
+// and validates the names. Duplicates are dropped.
+func additionalProfiles(values []string) ([]string, error) {
+	if len(values) == 0 {
+		values = strings.Split(os.Getenv(envServeProfiles), ",")
+	}
+	var profiles []string
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		profile, err := sessionstore.NormalizeProfile(value)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(profiles, profile) {
+			continue
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// hostProfiles starts one Telegram client per additional profile and
+// registers its Telegram, webhook and rules handlers on a scope of host. The primary
+// client's profile becomes the default route and is skipped if listed.
+// Control handlers are added by registerControlHandlers once every account
+// exists.
+func hostProfiles(
+	ctx context.Context,
+	host profileHost,
+	instance string,
+	appID int,
+	appHash string,
+	provider string,
+	primary *telegram.Client,
+	profiles []string,
+) []*hostedAccount {
+	primaryProfile := primary.SessionStorageStatus().Profile
+	host.SetPrimaryProfile(primaryProfile)
+	accounts := make([]*hostedAccount, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile == primaryProfile {
+			continue
+		}
+		accountInstance := paths.ProfileInstance(instance, profile)
+		account := &hostedAccount{
+			profile:   profile,
+			store:     openUpdateStore(accountInstance),
+			state:     openUpdateState(accountInstance),
+			peers:     openPeerStore(accountInstance),
+			consumers: openUpdateConsumers(accountInstance),
+			feed:      updatefeed.New(),
+			scope:     host.AddProfile(profile),
+		}
+		account.client = createTelegramClient(appID, appHash, telegramClientOptions{
+			Provider:    provider,
+			Profile:     profile,
+			UpdateStore: account.store,
+			UpdateState: account.state,
+			PeerStore:   account.peers,
+			Additional:  true,
+		})
+		startTelegramClient(ctx, account.client)
+		go waitForTelegramReady(ctx, account.client)
+
+		// The profile's policy file is re-resolved on every reload.
+		checker := policy.NewProfileEnforcer(profile, account.client)
+		account.scope.SetPolicyChecker(checker)
+		account.scope.SetAuditSocket(accountInstance)
+		hooks := openWebhooks(accountInstance, account.client, checker)
+		publishUpdates(account.store, account.feed, hooks)
+		go hooks.Run(ctx)
+		engine := openRules(accountInstance, accountInstance, account.client, checker)
+		go engine.Run(ctx, account.client, account.feed)
+
+		telegramipc.RegisterHandlers(account.scope, account.client)
+		telegramipc.RegisterSubscriptionHandlers(account.scope, account.client, account.feed)
+		telegramipc.RegisterWaitHandlers(account.scope, account.client, account.feed)
+		telegramipc.RegisterConsumerHandlers(account.scope, account.client, account.consumers)
+		webhook.RegisterHandlers(account.scope, hooks)
+		rules.RegisterHandlers(account.scope, engine)
+		accounts = append(accounts, account)
+		slog.Info("Hosting additional profile", "profile", profile)
+	}
+	return accounts
+}
+
+// accountStatuses reports the connection state of every hosted account,
+// primary first.
+func accountStatuses(ctx context.Context, primary *telegram.Client, accounts []*hostedAccount) []map[string]any {
+	statuses := []map[string]any{accountStatus(ctx, primary, true)}
+	for _, account := range accounts {
+		statuses = append(statuses, accountStatus(ctx, account.client, false))
+	}
+	return statuses
+}
+
+func accountStatus(ctx context.Context, client *telegram.Client, primary bool) map[string]any {
+	tgStatus := client.GetStatus(ctx)
+	storageStatus := client.SessionStorageStatus()
+	return map[string]any{
+		"profile":         storageStatus.Profile,
+		"primary":         primary,
+		"session_storage": storageStatus.Provider,
+		"initialized":     tgStatus.Initialized,
+		"authorized":      tgStatus.Authorized,
+		"telegram_state":  tgStatus.State,
+		"username":        tgStatus.Username,
+		"user_id":         tgStatus.UserID,
+		"flood_control":   client.FloodControlStatus(),
+	}
+}
+
+// closeAccounts closes the journals of hosted accounts on shutdown.
+func closeAccounts(accounts []*hostedAccount) {
+	for _, account := range accounts {
+		_ = account.store.Close()
+		if account.state != nil {
+			_ = account.state.Close()
+		}
+		if account.peers != nil {
+			_ = account.peers.Close()
+		}
+		_ = account.consumers.Close()
+	}
+}
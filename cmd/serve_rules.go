@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"log/slog"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/paths"
+	"agent-telegram/internal/rules"
+	telegramipc "agent-telegram/internal/telegram/ipc"
+	"agent-telegram/telegram"
+)
+
+// openRules loads the instance's rules.json, which is re-read whenever it
+// changes. Actions run the account's Telegram handlers under its policy and
+// are recorded in the audit journal of auditSocket. A path error leaves
+// rules disabled so the server still starts.
+func openRules(instance, auditSocket string, tgClient *telegram.Client, checker ipc.PolicyChecker) *rules.Engine {
+	path, err := paths.RulesFilePathForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve rules path; rules disabled", "error", err)
+	}
+	engine := rules.New(rules.Options{
+		Path:        path,
+		Policy:      checker,
+		Resolver:    tgClient,
+		AuditSocket: auditSocket,
+	})
+	telegramipc.RegisterHandlers(engine, tgClient)
+	return engine
+}
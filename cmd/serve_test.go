@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"agent-telegram/internal/policy"
@@ -45,6 +46,34 @@ func TestCreateTelegramClientUsesEnvSession(t *testing.T) {
 	}
 }
 
+func TestAdditionalProfileIgnoresEnvSession(t *testing.T) {
+	useMemorySessionStore(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(envTelegramSession, base64.StdEncoding.EncodeToString([]byte("env-session")))
+
+	tgClient := createTelegramClient(123, "app-hash", telegramClientOptions{Profile: "sales", Additional: true})
+	if got := tgClient.ExportSession(); len(got) != 0 {
+		t.Fatalf("additional profile exported %q, want no session", got)
+	}
+	if profile := tgClient.SessionStorageStatus().Profile; profile != "sales" {
+		t.Fatalf("profile = %q, want sales", profile)
+	}
+}
+
+func TestAdditionalProfilesFromFlagOrEnv(t *testing.T) {
+	t.Setenv(envServeProfiles, "sales, ops,sales")
+	profiles, err := additionalProfiles(nil)
+	if err != nil || strings.Join(profiles, ",") != "sales,ops" {
+		t.Fatalf("env profiles = %v, %v", profiles, err)
+	}
+	if profiles, _ = additionalProfiles([]string{"eu"}); strings.Join(profiles, ",") != "eu" {
+		t.Fatalf("flag profiles = %v, want flag to win", profiles)
+	}
+	if _, err := additionalProfiles([]string{"bad name"}); err == nil {
+		t.Fatal("invalid profile name should fail")
+	}
+}
+
 func TestImportSessionForMemoryStorage(t *testing.T) {
 	useMemorySessionStore(t)
 	t.Setenv("HOME", t.TempDir())
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"log/slog"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/paths"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/internal/webhook"
+	"agent-telegram/telegram"
+	"agent-telegram/telegram/types"
+)
+
+// openWebhooks loads the instance's webhooks.json and its persisted retry
+// queue. Deliveries are filtered by the account's policy. Errors are logged
+// and leave webhooks disabled so the server still starts.
+func openWebhooks(instance string, tgClient *telegram.Client, checker ipc.PolicyChecker) *webhook.Dispatcher {
+	disabled, _ := webhook.New(webhook.Config{}, webhook.Options{})
+	configPath, err := paths.WebhooksFilePathForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve webhook config path; webhooks disabled", "error", err)
+		return disabled
+	}
+	queuePath, err := paths.WebhookQueueFilePathForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve webhook queue path; webhooks disabled", "error", err)
+		return disabled
+	}
+	deadLetterPath, err := paths.WebhookDeadLetterFilePathForSocket(instance)
+	if err != nil {
+		slog.Warn("Failed to resolve webhook dead-letter path; webhooks disabled", "error", err)
+		return disabled
+	}
+	cfg, err := webhook.LoadConfig(configPath)
+	if err != nil {
+		slog.Warn("Failed to load webhooks; webhooks disabled", "path", configPath, "error", err)
+		return disabled
+	}
+	hooks, err := webhook.New(cfg, webhook.Options{
+		QueuePath:      queuePath,
+		DeadLetterPath: deadLetterPath,
+		Resolver:       tgClient,
+		Policy:         checker,
+	})
+	if err != nil {
+		slog.Warn("Failed to load webhook queue; webhooks disabled", "path", queuePath, "error", err)
+		return disabled
+	}
+	if len(cfg.Webhooks) > 0 {
+		slog.Info("Webhooks enabled", "count", len(cfg.Webhooks), "config", configPath)
+	}
+	return hooks
+}
+
+// publishUpdates fans stored updates out to streaming subscribers and
+// webhooks, and live-only updates to streaming subscribers. Both only buffer
+// in memory, so the Telegram dispatcher that stores the update never waits on
+// a slow receiver.
+func publishUpdates(store *telegram.UpdateStore, feed *updatefeed.Feed, hooks *webhook.Dispatcher) {
+	store.SetOnUpdate(func(update types.StoredUpdate) {
+		feed.Publish(update)
+		hooks.Publish(update)
+	})
+	store.SetOnLive(feed.PublishLive)
+}
@@ -28,6 +28,7 @@ func AddSessionCommand(rootCmd *cobra.Command) {
 	AddProvidersCommand(SessionCmd)
 	AddForgetCommand(SessionCmd)
 	AddUseCommand(SessionCmd)
+	AddRotateKeyCommand(SessionCmd)
 	rootCmd.AddCommand(SessionCmd)
 }
 
@@ -30,6 +30,7 @@ func newSessionTestRoot(t *testing.T, args ...string) (*cobra.Command, *bytes.Bu
 	AddStatusCommand(parent)
 	AddProvidersCommand(parent)
 	AddForgetCommand(parent)
+	AddRotateKeyCommand(parent)
 	root.AddCommand(parent)
 	root.SetArgs(append([]string{"session"}, args...))
 	out := &bytes.Buffer{}
@@ -88,3 +89,25 @@ func TestSessionExportRequiresConfirmation(t *testing.T) {
 		t.Fatalf("error = %v", err)
 	}
 }
+
+func TestSessionRotateKeyGeneratesKeyFile(t *testing.T) {
+	root, out := newSessionTestRoot(t, "rotate-key", "--generate", "--confirm")
+	t.Setenv(sessionstore.EnvSessionKey, "")
+	t.Setenv(sessionstore.EnvSessionKeyFile, "")
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	keyFile, _ := result["keyFile"].(string)
+	if keyFile == "" || !sessionstore.FileKeyConfigured() {
+		t.Fatalf("rotate-key result = %v", result)
+	}
+
+	root, _ = newSessionTestRoot(t, "rotate-key", "--generate", "--passphrase-from", "stdin", "--confirm")
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "exactly one") {
+		t.Fatalf("conflicting flags error = %v", err)
+	}
+}
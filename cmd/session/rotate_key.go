@@ -0,0 +1,131 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/sessionstore"
+)
+
+func AddRotateKeyCommand(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   "rotate-key",
+		Short: "Re-encrypt file-provider sessions with a new key",
+		Long: `Re-encrypt every session stored by the file provider with a new key.
+
+The current key comes from AGENT_TELEGRAM_SESSION_KEY (a passphrase) or the
+key file (AGENT_TELEGRAM_SESSION_KEY_FILE, default ~/.agent-telegram/session.key).
+With --generate a new random key file replaces the old one; on a fresh
+install this also creates the first key. With --passphrase-from the new key
+is a passphrase and AGENT_TELEGRAM_SESSION_KEY must be updated afterwards.
+Stop the daemon before rotating.
+
+Examples:
+  agent-telegram session rotate-key --generate --confirm
+  NEW_KEY=... agent-telegram session rotate-key --passphrase-from env:NEW_KEY --confirm`,
+		Args: cobra.NoArgs,
+		RunE: runRotateKey,
+	}
+	cmd.Flags().Bool("generate", false, "Generate a new random key file")
+	cmd.Flags().String("passphrase-from", "", "Read the new passphrase from stdin or env:NAME")
+	parent.AddCommand(cmd)
+}
+
+func runRotateKey(cmd *cobra.Command, _ []string) error {
+	if !confirmed(cmd) {
+		return printSessionError("--confirm is required to rotate the session key")
+	}
+	generate, _ := cmd.Flags().GetBool("generate")
+	passphraseFrom, _ := cmd.Flags().GetString("passphrase-from")
+	if generate == (passphraseFrom != "") {
+		return fmt.Errorf("choose exactly one of --generate or --passphrase-from")
+	}
+
+	current, err := sessionstore.ConfiguredFileKey()
+	noKey := errors.Is(err, sessionstore.ErrNoFileKey)
+	if err != nil && !noKey {
+		return err
+	}
+	dir, err := sessionstore.SessionsDir()
+	if err != nil {
+		return err
+	}
+	store := sessionstore.NewFileStore(dir, current)
+	if noKey {
+		profiles, err := store.Profiles()
+		if err != nil {
+			return err
+		}
+		if len(profiles) > 0 {
+			return fmt.Errorf("%d session files exist but no current key is configured", len(profiles))
+		}
+	}
+
+	var (
+		next    sessionstore.FileKey
+		keyFile string
+	)
+	if generate {
+		if next, err = sessionstore.GenerateFileKey(); err != nil {
+			return err
+		}
+		if keyFile, err = sessionstore.KeyFilePath(); err != nil {
+			return err
+		}
+	} else {
+		passphrase, err := readPassphrase(cmd, passphraseFrom)
+		if err != nil {
+			return err
+		}
+		next = sessionstore.PassphraseKey(passphrase)
+	}
+
+	profiles, err := store.Rekey(cmd.Context(), next, keyFile)
+	if err != nil {
+		return err
+	}
+	result := map[string]any{
+		"ok":       true,
+		"provider": sessionstore.FileProvider,
+		"profiles": profiles,
+	}
+	switch {
+	case keyFile != "":
+		result["keyFile"] = keyFile
+		if os.Getenv(sessionstore.EnvSessionKey) != "" {
+			result["warning"] = "Unset " + sessionstore.EnvSessionKey + "; it takes precedence over the key file."
+		}
+	default:
+		result["warning"] = "Set " + sessionstore.EnvSessionKey + " to the new passphrase before the next run."
+	}
+	return writeSessionJSON(cmd, result)
+}
+
+func readPassphrase(cmd *cobra.Command, source string) (string, error) {
+	var value string
+	switch {
+	case source == "stdin":
+		if sessionInputIsTerminal(cmd) {
+			return "", fmt.Errorf("passphrase must be piped on stdin")
+		}
+		raw, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return "", err
+		}
+		value = string(raw)
+	case strings.HasPrefix(source, "env:"):
+		value = os.Getenv(strings.TrimPrefix(source, "env:"))
+	default:
+		return "", fmt.Errorf("unknown passphrase source %q (use stdin or env:NAME)", source)
+	}
+	value = strings.TrimRight(value, "\r\n")
+	if len(value) < 12 {
+		return "", fmt.Errorf("passphrase must be at least 12 characters")
+	}
+	return value, nil
+}
@@ -11,7 +11,17 @@ func AddUseCommand(parent *cobra.Command) {
 	parent.AddCommand(&cobra.Command{
 		Use:   "use <provider> [profile]",
 		Short: "Select the default session provider and profile",
-		Args:  cobra.RangeArgs(1, 2),
+		Long: `Select the default session provider and profile.
+
+Providers that take an argument are written as name:argument. The exec
+provider delegates storage to a credential helper executable:
+
+  agent-telegram session use exec:vault work
+  agent-telegram session use "exec:/usr/local/bin/tg-pass --store team" work
+
+A bare helper name is looked up as agent-telegram-session-<name>, then as
+<name>, on PATH.`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			profile := sessionstore.DefaultProfile
 			if len(args) == 2 {
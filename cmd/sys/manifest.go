@@ -7,6 +7,7 @@ import (
 	"agent-telegram/internal/ipc"
 	"agent-telegram/internal/operations"
 	"agent-telegram/internal/skills"
+	"agent-telegram/telegram/types"
 )
 
 var manifestOpenAPI bool
@@ -26,10 +27,11 @@ Use --openapi to output an OpenAPI 3.1 document for serve-api.`,
 			payload = operations.OpenAPI("agent-telegram API", "dev")
 		} else {
 			payload = map[string]any{
-				"ok":         true,
-				"operations": operations.Manifest(),
-				"errorTypes": ipc.ErrorTypesManifest(),
-				"skills":     skills.Manifest(),
+				"ok":          true,
+				"operations":  operations.Manifest(),
+				"errorTypes":  ipc.ErrorTypesManifest(),
+				"updateTypes": types.UpdateTypesManifest(),
+				"skills":      skills.Manifest(),
 			}
 		}
 		cliutil.NewRunnerFromCmd(cmd, true).PrintJSON(payload)
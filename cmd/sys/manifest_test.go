@@ -24,6 +24,10 @@ func TestManifestCommandOutputsOperations(t *testing.T) {
 			Code      int    `json:"code"`
 			Retryable bool   `json:"retryable"`
 		} `json:"errorTypes"`
+		UpdateTypes []struct {
+			Type string   `json:"type"`
+			Data []string `json:"data"`
+		} `json:"updateTypes"`
 		Skills []struct {
 			Name           string `json:"name"`
 			InstallCommand string `json:"installCommand"`
@@ -50,6 +54,15 @@ func TestManifestCommandOutputsOperations(t *testing.T) {
 	if !foundTopicsError {
 		t.Fatal("manifest should include non-retryable TOPICS_NOT_ENABLED")
 	}
+	foundReadReceipts := false
+	for _, item := range body.UpdateTypes {
+		if item.Type == "read_outbox" && len(item.Data) > 0 {
+			foundReadReceipts = true
+		}
+	}
+	if !foundReadReceipts {
+		t.Fatal("manifest should document the read_outbox update type")
+	}
 	if len(body.Skills) == 0 || body.Skills[0].Name != "agent-telegram" || body.Skills[0].InstallCommand == "" {
 		t.Fatalf("manifest should include installable skills: %+v", body.Skills)
 	}
@@ -0,0 +1,60 @@
+package sys
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/mcp"
+	"agent-telegram/internal/observability"
+	"agent-telegram/internal/paths"
+)
+
+// MCPCmd serves the operation registry as a Model Context Protocol server.
+var MCPCmd = &cobra.Command{
+	GroupID: "server",
+	Use:     "mcp",
+	Short:   "Serve Telegram operations as an MCP server over stdio",
+	Long: `Speak the Model Context Protocol over stdin/stdout.
+
+Every operation in the manifest is exposed as a tool, with its summary as the
+description and its input schema as parameters. Destructive and paid tools
+are annotated and only run when called with "confirm": true, unless the
+server itself is started with --confirm. Chats, recent messages and stored
+updates are exposed as resources under telegram://.
+
+Calls are forwarded to the running server, so start it first with
+"agent-telegram server ensure". Tools that read or write local files, such as
+send_photo and download_media, only accept paths below a --file-root.
+
+Example MCP client configuration:
+  {"command": "agent-telegram", "args": ["mcp"]}`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		socketPath, _ := cmd.Flags().GetString("socket")
+		profile, _ := cmd.Flags().GetString("profile")
+		confirm, _ := cmd.Flags().GetBool("confirm")
+		runID, _ := cmd.Flags().GetString("run-id")
+		fileRoots, _ := cmd.Flags().GetStringSlice("file-root")
+		if runID == "" {
+			runID = observability.NewRunID()
+		}
+
+		server := mcp.NewServer(ipc.NewClient(socketPath).WithProfile(profile), mcp.Options{
+			Version:     cmd.Root().Version,
+			AuditSocket: paths.ProfileInstance(socketPath, profile),
+			RunID:       observability.SanitizeRunID(runID),
+			Confirm:     confirm,
+			FileRoots:   fileRoots,
+		})
+		return server.Serve(cmd.Context(), os.Stdin, os.Stdout)
+	},
+}
+
+// AddMCPCommand adds the mcp command to the root command.
+func AddMCPCommand(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(MCPCmd)
+	MCPCmd.Flags().StringSlice("file-root", nil,
+		"Allow file and output arguments only below these directories (repeatable)")
+}
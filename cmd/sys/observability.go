@@ -164,8 +164,15 @@ func followLogLines(path string, initial []string, traceID, runID string, mode o
 	}
 }
 
-func runAudit(cmd *cobra.Command, _ []string) {
+// auditInstance selects the audit journal for --socket and --profile.
+func auditInstance(cmd *cobra.Command) string {
 	socketPath, _ := cmd.Flags().GetString("socket")
+	profile, _ := cmd.Flags().GetString("profile")
+	return paths.ProfileInstance(socketPath, profile)
+}
+
+func runAudit(cmd *cobra.Command, _ []string) {
+	socketPath := auditInstance(cmd)
 	readLast := auditLast
 	if auditTraceID != "" || auditRunID != "" || auditMethod != "" || auditSince > 0 {
 		readLast = 0
@@ -198,7 +205,7 @@ func runRunInspect(cmd *cobra.Command, args []string) {
 }
 
 func inspectBy(cmd *cobra.Command, key, value string) {
-	socketPath, _ := cmd.Flags().GetString("socket")
+	socketPath := auditInstance(cmd)
 	mode := observability.ParseRedactionMode(inspectRedact)
 
 	events, err := observability.ReadAudit(socketPath, 0)
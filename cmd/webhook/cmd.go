@@ -0,0 +1,109 @@
+// Package webhook provides commands for inspecting and replaying webhook
+// deliveries.
+package webhook
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"agent-telegram/internal/cliutil"
+)
+
+// WebhookCmd represents the webhook command group.
+var WebhookCmd = &cobra.Command{
+	GroupID: "server",
+	Use:     "webhook",
+	Short:   "Inspect, test and replay webhook deliveries",
+	Long: `Commands for the webhooks configured in webhooks.json next to the
+instance's socket. The running server delivers matching updates as signed
+JSON POSTs, retries failures with exponential backoff, and moves deliveries
+that exhaust their retries to a dead-letter log.`,
+}
+
+// ListCmd represents the webhook list command.
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List webhooks with pending and dead-letter counts",
+	Long: `List configured webhooks. Secrets are never shown.
+
+Example:
+  agent-telegram webhook list`,
+	Args: cobra.NoArgs,
+}
+
+// TestCmd represents the webhook test command.
+var TestCmd = &cobra.Command{
+	Use:   "test <id>",
+	Short: "Send a signed test delivery to a webhook",
+	Long: `Send a synthetic "test" event to a webhook and report the response.
+Test deliveries are not retried or dead-lettered.
+
+Example:
+  agent-telegram webhook test alerts`,
+	Args: cobra.ExactArgs(1),
+}
+
+// ReplayCmd represents the webhook replay command.
+var ReplayCmd = &cobra.Command{
+	Use:   "replay [delivery-id...]",
+	Short: "Requeue dead-lettered deliveries",
+	Long: `Move dead-lettered deliveries back into the retry queue with a fresh
+attempt budget. Select deliveries by ID, by webhook, or all of them.
+
+Examples:
+  agent-telegram webhook replay 3f9c0a1b2c3d4e5f60718293
+  agent-telegram webhook replay --webhook alerts
+  agent-telegram webhook replay --all`,
+}
+
+var (
+	replayWebhook string
+	replayAll     bool
+)
+
+// AddWebhookCommand adds the webhook command group to the root command.
+func AddWebhookCommand(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(WebhookCmd)
+	WebhookCmd.AddCommand(ListCmd, TestCmd, ReplayCmd)
+
+	ReplayCmd.Flags().StringVar(&replayWebhook, "webhook", "", "Only replay deliveries for this webhook ID")
+	ReplayCmd.Flags().BoolVar(&replayAll, "all", false, "Replay every dead-lettered delivery")
+
+	ListCmd.Run = func(cmd *cobra.Command, _ []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		runner.SetIDKey("id")
+		result := runner.Call("list_webhooks", nil)
+		runner.PrintResult(result, nil)
+	}
+
+	TestCmd.Run = func(cmd *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		result := runner.Call("test_webhook", map[string]any{"id": args[0]})
+		runner.PrintResult(result, nil)
+		if m, ok := cliutil.ToMap(result); ok && m["success"] != true {
+			fmt.Fprintf(os.Stderr, "Webhook %s test failed\n", args[0])
+			cliutil.Exit(1)
+		}
+	}
+
+	ReplayCmd.Run = func(cmd *cobra.Command, args []string) {
+		runner := cliutil.NewRunnerFromCmd(cmd, true)
+		if len(args) == 0 && replayWebhook == "" && !replayAll {
+			runner.Fatal("specify delivery IDs, --webhook or --all")
+		}
+		params := map[string]any{}
+		if len(args) > 0 {
+			params["ids"] = args
+		}
+		if replayWebhook != "" {
+			params["webhook"] = replayWebhook
+		}
+		if replayAll {
+			params["all"] = true
+		}
+		result := runner.Call("replay_webhooks", params)
+		runner.PrintResult(result, nil)
+	}
+}
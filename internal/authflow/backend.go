@@ -48,3 +48,34 @@ func (b *TelegramBackend) SignInWithQR(
 func (b *TelegramBackend) ExportSession(_ context.Context) ([]byte, error) {
 	return b.service.ExportSession(), nil
 }
+
+// PhoneBackend is the Telegram surface used by phone-number sign-in. Each
+// step may run in a separate process, so the temporary session is carried
+// between steps in State and imported before the next call.
+type PhoneBackend interface {
+	ImportSession(ctx context.Context, data []byte) error
+	SendCode(ctx context.Context, phone string) (*types.SendCodeResult, error)
+	SignIn(ctx context.Context, phone, code, codeHash string) (*types.SignInResult, error)
+	SignInWith2FA(ctx context.Context, phone, password string) (*types.SignInResult, error)
+	ExportSession(ctx context.Context) ([]byte, error)
+}
+
+// ImportSession loads temporary auth session bytes saved by a previous step.
+func (b *TelegramBackend) ImportSession(ctx context.Context, data []byte) error {
+	return b.service.ImportSession(ctx, data)
+}
+
+// SendCode asks Telegram to send a login code to phone.
+func (b *TelegramBackend) SendCode(ctx context.Context, phone string) (*types.SendCodeResult, error) {
+	return b.service.SendCode(ctx, b.userID, phone)
+}
+
+// SignIn completes login with the code sent by SendCode.
+func (b *TelegramBackend) SignIn(ctx context.Context, phone, code, codeHash string) (*types.SignInResult, error) {
+	return b.service.SignIn(ctx, b.userID, phone, code, codeHash)
+}
+
+// SignInWith2FA completes login with the cloud password.
+func (b *TelegramBackend) SignInWith2FA(ctx context.Context, phone, password string) (*types.SignInResult, error) {
+	return b.service.SignInWith2FA(ctx, b.userID, phone, password)
+}
@@ -0,0 +1,111 @@
+package chatexport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"agent-telegram/internal/fsutil"
+)
+
+const checkpointVersion = 1
+
+// Filter bounds an export. Zero values are unbounded; all bounds are
+// inclusive and dates are Unix timestamps.
+type Filter struct {
+	MinID int64 `json:"minId,omitempty"`
+	MaxID int64 `json:"maxId,omitempty"`
+	Since int64 `json:"since,omitempty"`
+	Until int64 `json:"until,omitempty"`
+}
+
+// bounded reports whether the export ends at a fixed point, so a completed
+// export has nothing left to fetch.
+func (f Filter) bounded() bool {
+	return f.MaxID > 0 || f.Until > 0
+}
+
+// Checkpoint is the persisted progress of an export. The file sizes are the
+// lengths of the output files at the last completed page; a resumed export
+// truncates the files to them before fetching the next page.
+type Checkpoint struct {
+	Version      int    `json:"version"`
+	Peer         string `json:"peer"`
+	Filter       Filter `json:"filter"`
+	Transcript   string `json:"transcript,omitempty"`
+	Media        bool   `json:"media,omitempty"`
+	NextOffsetID int64  `json:"nextOffsetId"`
+	Exported     int64  `json:"exported"`
+	MediaFiles   int64  `json:"mediaFiles"`
+	LastID       int64  `json:"lastMessageId,omitempty"`
+	MessagesSize int64  `json:"messagesSize"`
+	// TranscriptSize excludes the closing HTML, which is rewritten each time
+	// an export completes.
+	TranscriptSize int64  `json:"transcriptSize,omitempty"`
+	LastDay        string `json:"lastDay,omitempty"`
+	LastFrom       string `json:"lastFrom,omitempty"`
+	Done           bool   `json:"done"`
+	UpdatedAt      int64  `json:"updatedAt"`
+}
+
+// matches reports whether a checkpoint belongs to an export with opts.
+func (c *Checkpoint) matches(opts Options) bool {
+	return c.Peer == opts.Peer && c.Filter == opts.Filter && c.Transcript == opts.Transcript && c.Media == opts.Media
+}
+
+// loadCheckpoint reads the checkpoint at path. A missing file returns nil.
+func loadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is chosen by the local user
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint %s has unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// save writes the checkpoint atomically.
+func (c *Checkpoint) save(path string, now time.Time) error {
+	c.UpdatedAt = now.Unix()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ParseDate parses an export date bound: RFC 3339, Unix seconds or a
+// YYYY-MM-DD day in loc. A day used as an upper bound covers the whole day.
+func ParseDate(value string, endOfDay bool, loc *time.Location) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil && seconds > 0 {
+		return seconds, nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at.Unix(), nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return 0, fmt.Errorf("date %q: want YYYY-MM-DD, RFC 3339 or Unix seconds", value)
+	}
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Unix() - 1, nil
+	}
+	return day.Unix(), nil
+}
@@ -0,0 +1,418 @@
+// Package chatexport writes the full history of a chat to JSONL, with an
+// optional HTML or Markdown transcript and downloaded media. Progress is
+// checkpointed after every page so an interrupted export resumes where it
+// stopped, and re-running a completed export appends newer messages.
+package chatexport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/telegram/types"
+)
+
+const (
+	messagesFile   = "messages.jsonl"
+	checkpointFile = "checkpoint.json"
+	mediaDir       = "media"
+)
+
+// Caller is the RPC surface an export uses. The takeout session is started
+// with Call so it is audited. Pages, downloads and finishing the session use
+// TryCallInternal, which returns errors instead of exiting, so a failed
+// export still finishes its takeout session.
+type Caller interface {
+	Call(method string, params any) any
+	TryCallInternal(method string, params any) (any, *ipc.ErrorObject)
+}
+
+// Options configures an export.
+type Options struct {
+	Peer string
+	// Dir receives messages.jsonl, the transcript and the media folder.
+	Dir string
+	// Checkpoint defaults to checkpoint.json in Dir.
+	Checkpoint string
+	// Transcript is FormatHTML, FormatMarkdown or empty for none.
+	Transcript string
+	Media      bool
+	Filter     Filter
+	// Takeout runs the export in a takeout session with relaxed flood limits.
+	Takeout bool
+	// PageSize defaults to types.MaxExportLimit.
+	PageSize int
+	// Location is the time zone of transcript dates; it defaults to local time.
+	Location *time.Location
+	// Progress, when set, is called after every page.
+	Progress func(Result)
+}
+
+// Result summarizes an export.
+type Result struct {
+	Peer       string `json:"peer"`
+	Messages   string `json:"messages"`
+	Transcript string `json:"transcript,omitempty"`
+	Media      string `json:"media,omitempty"`
+	Checkpoint string `json:"checkpoint"`
+	// Exported counts every message in the output; Added only this run's.
+	Exported      int64 `json:"exported"`
+	Added         int64 `json:"added"`
+	MediaFiles    int64 `json:"mediaFiles"`
+	Pages         int   `json:"pages"`
+	LastMessageID int64 `json:"lastMessageId,omitempty"`
+	Resumed       bool  `json:"resumed"`
+	Takeout       bool  `json:"takeout"`
+	Done          bool  `json:"done"`
+}
+
+// exporter holds the open output files of one run.
+type exporter struct {
+	caller     Caller
+	opts       Options
+	cp         *Checkpoint
+	messages   *os.File
+	transcript *os.File
+	render     *transcript
+	result     Result
+	now        func() time.Time
+}
+
+// Run exports the chat. Errors leave the output files and checkpoint at the
+// last completed page.
+func Run(caller Caller, opts Options) (*Result, error) {
+	if opts.Transcript != "" && transcriptFiles[opts.Transcript] == "" {
+		return nil, fmt.Errorf("unknown transcript format %q (use html or markdown)", opts.Transcript)
+	}
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output directory: %w", err)
+	}
+	opts.Dir = dir
+	if opts.Checkpoint == "" {
+		opts.Checkpoint = filepath.Join(dir, checkpointFile)
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = types.MaxExportLimit
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	e := &exporter{caller: caller, opts: opts, now: time.Now}
+	e.result = Result{
+		Peer:       opts.Peer,
+		Messages:   filepath.Join(dir, messagesFile),
+		Checkpoint: opts.Checkpoint,
+	}
+	if opts.Transcript != "" {
+		e.result.Transcript = filepath.Join(dir, transcriptFiles[opts.Transcript])
+	}
+	if opts.Media {
+		e.result.Media = filepath.Join(dir, mediaDir)
+	}
+	if err := e.open(); err != nil {
+		return nil, err
+	}
+	defer e.close()
+
+	if e.cp.Done && opts.Filter.bounded() {
+		// Nothing newer can match; restore the footer open truncated.
+		if err := e.finishTranscript(); err != nil {
+			return nil, err
+		}
+		e.summarize()
+		return &e.result, nil
+	}
+	if err := e.run(); err != nil {
+		return nil, err
+	}
+	e.summarize()
+	return &e.result, nil
+}
+
+// open loads or starts the checkpoint and opens the output files at its
+// sizes, dropping anything written after the last completed page.
+func (e *exporter) open() error {
+	if err := os.MkdirAll(e.opts.Dir, 0o700); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	cp, err := loadCheckpoint(e.opts.Checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp != nil && !cp.matches(e.opts) {
+		return fmt.Errorf("checkpoint %s belongs to a different export (peer, filters, transcript or media); "+
+			"use another output directory or remove the checkpoint", e.opts.Checkpoint)
+	}
+	if cp == nil {
+		if info, err := os.Stat(e.result.Messages); err == nil && info.Size() > 0 {
+			return fmt.Errorf("%s already exists without a checkpoint; use another output directory",
+				e.result.Messages)
+		}
+		cp = &Checkpoint{
+			Version:    checkpointVersion,
+			Peer:       e.opts.Peer,
+			Filter:     e.opts.Filter,
+			Transcript: e.opts.Transcript,
+			Media:      e.opts.Media,
+		}
+	} else {
+		e.result.Resumed = true
+	}
+	e.cp = cp
+	e.render = &transcript{format: cp.Transcript, loc: e.opts.Location, lastDay: cp.LastDay, lastFrom: cp.LastFrom}
+
+	if e.messages, err = openAt(e.result.Messages, cp.MessagesSize); err != nil {
+		return err
+	}
+	if e.opts.Transcript != "" {
+		if e.transcript, err = openAt(e.result.Transcript, cp.TranscriptSize); err != nil {
+			return err
+		}
+		if cp.TranscriptSize == 0 {
+			if _, err := io.WriteString(e.transcript, e.render.header(e.opts.Peer)); err != nil {
+				return fmt.Errorf("write transcript: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// openAt opens path for appending after truncating it to size.
+func openAt(path string, size int64) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600) // #nosec G304 -- path is chosen by the local user
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	if err := f.Truncate(size); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("truncate %s: %w", path, err)
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("seek %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func (e *exporter) close() {
+	_ = e.messages.Close()
+	if e.transcript != nil {
+		_ = e.transcript.Close()
+	}
+}
+
+func (e *exporter) run() (err error) {
+	var takeoutID int64
+	if e.opts.Takeout {
+		started := e.caller.Call("init_takeout_session", nil)
+		var session types.InitTakeoutResult
+		if err := decode(started, &session); err != nil {
+			return fmt.Errorf("init takeout session: %w", err)
+		}
+		takeoutID = session.TakeoutID
+		e.result.Takeout = true
+		// Finish the session on failure too, so it does not stay open on the
+		// account until Telegram expires it.
+		defer func() {
+			finishErr := e.call("finish_takeout_session",
+				map[string]any{"takeoutId": takeoutID, "success": err == nil}, &types.FinishTakeoutResult{})
+			if finishErr != nil && err == nil {
+				err = fmt.Errorf("finish takeout session: %w", finishErr)
+			}
+		}()
+	}
+
+	for {
+		page, err := e.fetch(takeoutID)
+		if err != nil {
+			return err
+		}
+		if err := e.write(page); err != nil {
+			return err
+		}
+		if !page.Done && page.NextOffsetID <= e.cp.NextOffsetID {
+			return fmt.Errorf("export did not advance past message %d", page.NextOffsetID)
+		}
+		e.cp.NextOffsetID = max(e.cp.NextOffsetID, page.NextOffsetID)
+		e.cp.Done = page.Done
+		if page.Done {
+			if err := e.finishTranscript(); err != nil {
+				return err
+			}
+		}
+		if err := e.cp.save(e.opts.Checkpoint, e.now()); err != nil {
+			return err
+		}
+		e.result.Pages++
+		if e.opts.Progress != nil {
+			e.summarize()
+			e.opts.Progress(e.result)
+		}
+		if page.Done {
+			break
+		}
+	}
+	return nil
+}
+
+func (e *exporter) fetch(takeoutID int64) (*types.ExportMessagesResult, error) {
+	params := map[string]any{
+		"peer":  e.opts.Peer,
+		"limit": e.opts.PageSize,
+	}
+	if e.cp.NextOffsetID > 0 {
+		params["offsetId"] = e.cp.NextOffsetID
+	}
+	if takeoutID != 0 {
+		params["takeoutId"] = takeoutID
+	}
+	f := e.opts.Filter
+	for key, value := range map[string]int64{"minId": f.MinID, "maxId": f.MaxID, "since": f.Since, "until": f.Until} {
+		if value > 0 {
+			params[key] = value
+		}
+	}
+	var page types.ExportMessagesResult
+	if err := e.call("export_messages", params, &page); err != nil {
+		return nil, fmt.Errorf("export messages: %w", err)
+	}
+	return &page, nil
+}
+
+// write appends a page to the output files, downloading media first so the
+// transcript can link to it.
+func (e *exporter) write(page *types.ExportMessagesResult) error {
+	for _, msg := range page.Messages {
+		var mediaPath string
+		if e.opts.Media && downloadable(msg) {
+			path, err := e.download(msg.ID)
+			if err != nil {
+				return err
+			}
+			mediaPath = path
+			e.cp.MediaFiles++
+		}
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("encode message %d: %w", msg.ID, err)
+		}
+		if _, err := e.messages.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("write messages: %w", err)
+		}
+		if e.transcript != nil {
+			if _, err := io.WriteString(e.transcript, e.render.message(msg, mediaPath)); err != nil {
+				return fmt.Errorf("write transcript: %w", err)
+			}
+		}
+		e.cp.Exported++
+		e.cp.LastID = msg.ID
+		e.result.Added++
+	}
+	return e.sync()
+}
+
+// sync flushes the output files and records their sizes in the checkpoint.
+func (e *exporter) sync() error {
+	size, err := syncedSize(e.messages)
+	if err != nil {
+		return err
+	}
+	e.cp.MessagesSize = size
+	if e.transcript != nil {
+		if e.cp.TranscriptSize, err = syncedSize(e.transcript); err != nil {
+			return err
+		}
+		e.cp.LastDay, e.cp.LastFrom = e.render.lastDay, e.render.lastFrom
+	}
+	return nil
+}
+
+func syncedSize(f *os.File) (int64, error) {
+	if err := f.Sync(); err != nil {
+		return 0, fmt.Errorf("sync %s: %w", f.Name(), err)
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("stat %s: %w", f.Name(), err)
+	}
+	return size, nil
+}
+
+// finishTranscript closes the HTML document. The checkpoint keeps the size
+// before the footer so the next run continues the history in place.
+func (e *exporter) finishTranscript() error {
+	if e.transcript == nil {
+		return nil
+	}
+	if _, err := io.WriteString(e.transcript, e.render.footer()); err != nil {
+		return fmt.Errorf("write transcript: %w", err)
+	}
+	if err := e.transcript.Sync(); err != nil {
+		return fmt.Errorf("sync transcript: %w", err)
+	}
+	return nil
+}
+
+// download saves the media of a message into its own folder under media/,
+// which keeps original file names from colliding. Completed files are
+// skipped by download_media, so a resumed page does not fetch them again.
+func (e *exporter) download(msgID int64) (string, error) {
+	output := filepath.Join(e.opts.Dir, mediaDir, strconv.FormatInt(msgID, 10)) + string(filepath.Separator)
+	var downloaded types.DownloadMediaResult
+	err := e.call("download_media", map[string]any{
+		"peer":      e.opts.Peer,
+		"messageId": msgID,
+		"output":    output,
+	}, &downloaded)
+	if err != nil {
+		return "", fmt.Errorf("download media of message %d: %w", msgID, err)
+	}
+	rel, err := filepath.Rel(e.opts.Dir, downloaded.Path)
+	if err != nil {
+		return "", fmt.Errorf("download media of message %d: %w", msgID, err)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (e *exporter) summarize() {
+	e.result.Exported = e.cp.Exported
+	e.result.MediaFiles = e.cp.MediaFiles
+	e.result.LastMessageID = e.cp.LastID
+	e.result.Done = e.cp.Done
+}
+
+// downloadable reports whether a message carries a photo or document.
+func downloadable(msg types.MessageResult) bool {
+	kind, _ := msg.Media["type"].(string)
+	return kind == "photo" || kind == "document"
+}
+
+// decode converts an RPC result into out. A nil result means the call
+// failed and the runner already reported it.
+// call runs an internal RPC and decodes its result into out.
+func (e *exporter) call(method string, params, out any) error {
+	result, rpcErr := e.caller.TryCallInternal(method, params)
+	if rpcErr != nil {
+		return errors.New(rpcErr.Message)
+	}
+	return decode(result, out)
+}
+
+func decode(result, out any) error {
+	if result == nil {
+		return fmt.Errorf("no result")
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,266 @@
+package chatexport
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/telegram/types"
+)
+
+// fakeServer pages through history like export_messages and saves media
+// like download_media. failAfter makes export_messages return an RPC error
+// once that many pages were served. finished records the success flag of every
+// finish_takeout_session call.
+type fakeServer struct {
+	t         *testing.T
+	history   []types.MessageResult
+	calls     []string
+	pages     int
+	failAfter int
+	takeouts  int
+	finished  []bool
+}
+
+func (s *fakeServer) Call(method string, params any) any {
+	result, _ := s.TryCallInternal(method, params)
+	return result
+}
+
+func (s *fakeServer) TryCallInternal(method string, params any) (any, *ipc.ErrorObject) {
+	s.calls = append(s.calls, method)
+	p, _ := params.(map[string]any)
+	switch method {
+	case "init_takeout_session":
+		s.takeouts++
+		return map[string]any{"takeoutId": json.Number("77")}, nil
+	case "finish_takeout_session":
+		s.finished = append(s.finished, p["success"].(bool))
+		return map[string]any{"success": true}, nil
+	case "download_media":
+		path := filepath.Join(p["output"].(string), "photo.jpg")
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			s.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("jpeg"), 0o600); err != nil {
+			s.t.Fatal(err)
+		}
+		return map[string]any{"path": path}, nil
+	case "export_messages":
+		if s.failAfter > 0 && s.pages == s.failAfter {
+			s.failAfter = 0
+			return nil, ipc.NewTypedError(ipc.ErrCodeInternalError, ipc.ErrorTypeInternal, "FLOOD_WAIT", nil)
+		}
+		s.pages++
+		return s.page(p), nil
+	}
+	s.t.Fatalf("unexpected call %s", method)
+	return nil, nil
+}
+
+func (s *fakeServer) page(p map[string]any) any {
+	offset, _ := p["offsetId"].(int64)
+	maxID, _ := p["maxId"].(int64)
+	limit := p["limit"].(int)
+	result := types.ExportMessagesResult{Messages: []types.MessageResult{}, NextOffsetID: offset}
+	for _, msg := range s.history {
+		if msg.ID < offset {
+			continue
+		}
+		if maxID > 0 && msg.ID > maxID {
+			result.Done = true
+			break
+		}
+		if len(result.Messages) == limit {
+			break
+		}
+		result.Messages = append(result.Messages, msg)
+		result.NextOffsetID = msg.ID + 1
+	}
+	result.Done = result.Done || len(result.Messages) < limit
+	result.Count = len(result.Messages)
+	var out map[string]any
+	data, _ := json.Marshal(result)
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+func history(n int) []types.MessageResult {
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).Unix()
+	msgs := make([]types.MessageResult, n)
+	for i := range msgs {
+		msgs[i] = types.MessageResult{
+			ID:       int64(i + 1),
+			Date:     day + int64(i)*3600*12,
+			Text:     "message <" + string(rune('a'+i)) + ">",
+			FromName: []string{"Ann", "Bob"}[i%2],
+			PeerID:   "user:42",
+		}
+	}
+	return msgs
+}
+
+func readIDs(t *testing.T, path string) []int64 {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var ids []int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg types.MessageResult
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestExportWritesJSONLTranscriptAndMedia(t *testing.T) {
+	server := &fakeServer{t: t, history: history(5)}
+	server.history[2].Media = map[string]any{"type": "photo"}
+	server.history[3].ReplyToMessageID = 3
+	dir := t.TempDir()
+
+	got, err := Run(server, Options{
+		Peer: "@support", Dir: dir, Transcript: FormatHTML, Media: true, Takeout: true,
+		PageSize: 2, Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Done || got.Exported != 5 || got.Pages != 3 || got.MediaFiles != 1 || !got.Takeout {
+		t.Fatalf("result = %+v", got)
+	}
+	if ids := readIDs(t, got.Messages); len(ids) != 5 || ids[0] != 1 || ids[4] != 5 {
+		t.Fatalf("exported ids = %v", ids)
+	}
+	if server.calls[0] != "init_takeout_session" || server.calls[len(server.calls)-1] != "finish_takeout_session" {
+		t.Fatalf("calls = %v", server.calls)
+	}
+
+	data, err := os.ReadFile(got.Transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+	for _, want := range []string{
+		"<title>@support</title>",
+		`<div class="body details">1 March 2024</div>`,
+		`<div class="body details">2 March 2024</div>`,
+		"message &lt;a&gt;",
+		`<a href="media/3/photo.jpg">photo</a>`,
+		`<a href="#message3">this message</a>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("transcript is missing %q", want)
+		}
+	}
+	if !strings.HasSuffix(page, "</html>\n") {
+		t.Error("transcript is not closed")
+	}
+}
+
+func TestExportResumesFromCheckpoint(t *testing.T) {
+	server := &fakeServer{t: t, history: history(5), failAfter: 1}
+	dir := t.TempDir()
+	opts := Options{Peer: "@support", Dir: dir, Transcript: FormatMarkdown, Takeout: true, PageSize: 2,
+		Location: time.UTC}
+
+	if _, err := Run(server, opts); err == nil || !strings.Contains(err.Error(), "FLOOD_WAIT") {
+		t.Fatalf("expected the failed page to stop the export with its RPC error, got %v", err)
+	}
+	if len(server.finished) != 1 || server.finished[0] {
+		t.Fatalf("a failed export should finish its takeout session unsuccessfully, got %v", server.finished)
+	}
+	// Simulate a crash after writing part of a page.
+	f, err := os.OpenFile(filepath.Join(dir, messagesFile), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"id":3,"date":`)
+	_ = f.Close()
+
+	got, err := Run(server, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Resumed || !got.Done || got.Exported != 5 || got.Added != 3 {
+		t.Fatalf("result = %+v", got)
+	}
+	if len(server.finished) != 2 || !server.finished[1] {
+		t.Fatalf("takeout sessions finished = %v, want the resumed run to succeed", server.finished)
+	}
+	if ids := readIDs(t, got.Messages); len(ids) != 5 || ids[2] != 3 {
+		t.Fatalf("exported ids = %v", ids)
+	}
+	data, _ := os.ReadFile(got.Transcript)
+	if strings.Count(string(data), "# @support") != 1 || strings.Count(string(data), "#3\n") != 0 ||
+		strings.Count(string(data), "· #3") != 1 {
+		t.Fatalf("transcript = %s", data)
+	}
+
+	opts.Filter = Filter{MaxID: 4}
+	if _, err := Run(server, opts); err == nil || !strings.Contains(err.Error(), "different export") {
+		t.Fatalf("mismatched checkpoint error = %v", err)
+	}
+}
+
+func TestExportRerunAppendsNewMessages(t *testing.T) {
+	server := &fakeServer{t: t, history: history(3)}
+	dir := t.TempDir()
+	opts := Options{Peer: "@support", Dir: dir, Transcript: FormatHTML, PageSize: 10, Location: time.UTC}
+	if _, err := Run(server, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	server.history = history(4)
+	got, err := Run(server, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Added != 1 || got.Exported != 4 {
+		t.Fatalf("result = %+v", got)
+	}
+	data, _ := os.ReadFile(got.Transcript)
+	if strings.Count(string(data), "</html>") != 1 || !strings.Contains(string(data), `id="message4"`) {
+		t.Fatalf("transcript = %s", data)
+	}
+
+	bounded := &fakeServer{t: t, history: history(3)}
+	boundedOpts := Options{Peer: "@support", Dir: t.TempDir(), Filter: Filter{MaxID: 2}, Takeout: true}
+	if _, err := Run(bounded, boundedOpts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(bounded, boundedOpts); err != nil {
+		t.Fatal(err)
+	}
+	if bounded.takeouts != 1 {
+		t.Fatalf("a completed bounded export should not start another takeout, got %d", bounded.takeouts)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	since, err := ParseDate("2024-03-01", false, time.UTC)
+	if err != nil || since != time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Unix() {
+		t.Fatalf("since = %d, %v", since, err)
+	}
+	until, err := ParseDate("2024-03-01", true, time.UTC)
+	if err != nil || until != time.Date(2024, 3, 1, 23, 59, 59, 0, time.UTC).Unix() {
+		t.Fatalf("until = %d, %v", until, err)
+	}
+	if at, err := ParseDate("2024-03-01T10:00:00+02:00", true, time.UTC); err != nil || at != 1709280000 {
+		t.Fatalf("rfc3339 = %d, %v", at, err)
+	}
+	if _, err := ParseDate("March 1", false, time.UTC); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,214 @@
+package chatexport
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"agent-telegram/telegram/types"
+)
+
+// Transcript formats.
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// transcriptFiles maps each transcript format to its file name.
+var transcriptFiles = map[string]string{
+	FormatHTML:     "messages.html",
+	FormatMarkdown: "messages.md",
+}
+
+// htmlStyle is a trimmed-down version of the Telegram Desktop export style.
+const htmlStyle = `body{margin:0;font:13px/18px "Open Sans","Lucida Grande",Arial,sans-serif;color:#000;background:#fff}
+.page_wrap{max-width:760px;margin:0 auto}
+.page_header{position:sticky;top:0;background:#fff;border-bottom:1px solid #e3e6e8;padding:14px 24px}
+.bold{font-weight:700}
+.history{padding:16px 0}
+.message{margin:0 -10px;padding:6px 34px 6px 24px}
+.message.service{text-align:center;padding:10px 24px}
+.message.joined{padding-top:0}
+.details{color:#70777b}
+.from_name{color:#3892db;font-weight:700;padding-bottom:4px}
+.pull_right{float:right}
+.date{padding-left:10px}
+.reply_to,.forwarded,.media_wrap{padding-bottom:4px}
+.text{word-wrap:break-word}
+a{color:#168acd;text-decoration:none}`
+
+// transcript renders messages as a Telegram-Desktop-like HTML page or as
+// Markdown. lastDay and lastFrom carry the grouping state across pages and
+// resumed runs.
+type transcript struct {
+	format   string
+	loc      *time.Location
+	lastDay  string
+	lastFrom string
+}
+
+func (t *transcript) header(title string) string {
+	if t.format == FormatMarkdown {
+		return "# " + title + "\n\n"
+	}
+	return `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8"/>
+<title>` + html.EscapeString(title) + `</title>
+<meta content="width=device-width, initial-scale=1.0" name="viewport"/>
+<style>
+` + htmlStyle + `
+</style>
+</head>
+<body>
+<div class="page_wrap">
+<div class="page_header"><div class="text bold">` + html.EscapeString(title) + `</div></div>
+<div class="page_body chat_page">
+<div class="history">
+`
+}
+
+func (t *transcript) footer() string {
+	if t.format == FormatMarkdown {
+		return ""
+	}
+	return "</div>\n</div>\n</div>\n</body>\n</html>\n"
+}
+
+// message renders one message. mediaPath is the downloaded file relative to
+// the transcript, or empty.
+func (t *transcript) message(msg types.MessageResult, mediaPath string) string {
+	date := time.Unix(msg.Date, 0).In(t.loc)
+	day := date.Format("2 January 2006")
+	from := senderName(msg)
+	joined := day == t.lastDay && from == t.lastFrom
+	var b strings.Builder
+	if day != t.lastDay {
+		t.writeDay(&b, day)
+	}
+	t.lastDay, t.lastFrom = day, from
+	if t.format == FormatMarkdown {
+		t.writeMarkdown(&b, msg, from, date, mediaPath)
+	} else {
+		t.writeHTML(&b, msg, from, date, mediaPath, joined)
+	}
+	return b.String()
+}
+
+func (t *transcript) writeDay(b *strings.Builder, day string) {
+	if t.format == FormatMarkdown {
+		fmt.Fprintf(b, "## %s\n\n", day)
+		return
+	}
+	fmt.Fprintf(b, "<div class=\"message service\"><div class=\"body details\">%s</div></div>\n", day)
+}
+
+func (t *transcript) writeHTML(b *strings.Builder, msg types.MessageResult, from string, date time.Time, mediaPath string, joined bool) {
+	class := "message default clearfix"
+	if joined {
+		class += " joined"
+	}
+	fmt.Fprintf(b, "<div class=\"%s\" id=\"message%d\">\n<div class=\"body\">\n", class, msg.ID)
+	fmt.Fprintf(b, "<div class=\"pull_right date details\" title=\"%s\">%s</div>\n",
+		date.Format("02.01.2006 15:04:05"), date.Format("15:04"))
+	if !joined {
+		fmt.Fprintf(b, "<div class=\"from_name\">%s</div>\n", html.EscapeString(from))
+	}
+	if fwd := forwardedFrom(msg); fwd != "" {
+		fmt.Fprintf(b, "<div class=\"forwarded details\">Forwarded from %s</div>\n", html.EscapeString(fwd))
+	}
+	if msg.ReplyToMessageID != 0 {
+		fmt.Fprintf(b, "<div class=\"reply_to details\">In reply to <a href=\"#message%d\">this message</a></div>\n",
+			msg.ReplyToMessageID)
+	}
+	if label := mediaLabel(msg); label != "" {
+		switch {
+		case mediaPath != "":
+			fmt.Fprintf(b, "<div class=\"media_wrap\"><a href=\"%s\">%s</a></div>\n",
+				html.EscapeString((&url.URL{Path: mediaPath}).String()), html.EscapeString(label))
+		case mediaURL(msg) != "":
+			fmt.Fprintf(b, "<div class=\"media_wrap\"><a href=\"%s\">%s</a></div>\n",
+				html.EscapeString(mediaURL(msg)), html.EscapeString(label))
+		default:
+			fmt.Fprintf(b, "<div class=\"media_wrap details\">%s</div>\n", html.EscapeString(label))
+		}
+	}
+	if msg.Text != "" {
+		text := strings.ReplaceAll(html.EscapeString(msg.Text), "\n", "<br>")
+		fmt.Fprintf(b, "<div class=\"text\">%s</div>\n", text)
+	}
+	b.WriteString("</div>\n</div>\n")
+}
+
+func (t *transcript) writeMarkdown(b *strings.Builder, msg types.MessageResult, from string, date time.Time, mediaPath string) {
+	lines := []string{fmt.Sprintf("**%s** · %s · #%d", from, date.Format("15:04"), msg.ID)}
+	if fwd := forwardedFrom(msg); fwd != "" {
+		lines = append(lines, "_Forwarded from "+fwd+"_")
+	}
+	if msg.ReplyToMessageID != 0 {
+		lines = append(lines, fmt.Sprintf("_In reply to #%d_", msg.ReplyToMessageID))
+	}
+	if label := mediaLabel(msg); label != "" {
+		switch {
+		case mediaPath != "":
+			lines = append(lines, fmt.Sprintf("[%s](%s)", label, (&url.URL{Path: mediaPath}).String()))
+		case mediaURL(msg) != "":
+			lines = append(lines, fmt.Sprintf("[%s](%s)", label, mediaURL(msg)))
+		default:
+			lines = append(lines, "_"+label+"_")
+		}
+	}
+	if msg.Text != "" {
+		lines = append(lines, strings.Split(msg.Text, "\n")...)
+	}
+	b.WriteString(strings.Join(lines, "  \n"))
+	b.WriteString("\n\n")
+}
+
+// senderName names the author of a message for the transcript.
+func senderName(msg types.MessageResult) string {
+	switch {
+	case msg.FromName != "":
+		return msg.FromName
+	case msg.PostAuthor != "":
+		return msg.PostAuthor
+	case msg.FromID != "":
+		return msg.FromID
+	case msg.Out:
+		return "You"
+	default:
+		return msg.PeerID
+	}
+}
+
+func forwardedFrom(msg types.MessageResult) string {
+	if !msg.Forwarded {
+		return ""
+	}
+	if name, _ := msg.FwdFrom["from_name"].(string); name != "" {
+		return name
+	}
+	if id, _ := msg.FwdFrom["from_id"].(string); id != "" {
+		return id
+	}
+	return "unknown"
+}
+
+func mediaLabel(msg types.MessageResult) string {
+	if msg.Media == nil {
+		return ""
+	}
+	label, _ := msg.Media["type"].(string)
+	if label == "" {
+		label = "media"
+	}
+	return label
+}
+
+func mediaURL(msg types.MessageResult) string {
+	link, _ := msg.Media["url"].(string)
+	return link
+}
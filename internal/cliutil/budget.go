@@ -26,6 +26,7 @@ type OutputBudgetOptions struct {
 	Include      []string
 	Omit         []string
 	Summary      bool
+	TextFormat   TextFormat
 }
 
 // ParseVerbosity parses a verbosity flag value.
@@ -82,9 +83,12 @@ func normalizeForBudget(result any) any {
 func applyProfile(value any, opts OutputBudgetOptions) any {
 	switch v := value.(type) {
 	case map[string]any:
+		rendered := renderMessageText(v, opts.TextFormat, textLimit(opts))
 		out := make(map[string]any, len(v))
 		for key, child := range v {
-			if arr, ok := child.([]any); ok {
+			if key == rendered {
+				out[key] = child
+			} else if arr, ok := child.([]any); ok {
 				out[key] = budgetArray(key, arr, opts)
 			} else if s, ok := child.(string); ok {
 				out[key] = budgetString(key, s, opts)
@@ -123,7 +127,7 @@ func budgetArray(key string, arr []any, opts OutputBudgetOptions) []any {
 	out := make([]any, 0, limit)
 	for _, item := range arr {
 		if opts.Verbosity == VerbosityMinimal {
-			out = append(out, minimalItem(item))
+			out = append(out, minimalItem(item, opts.TextFormat))
 			continue
 		}
 		out = append(out, applyProfile(item, opts))
@@ -140,11 +144,12 @@ func shouldTailArray(key string) bool {
 	}
 }
 
-func minimalItem(item any) any {
+func minimalItem(item any, format TextFormat) any {
 	m, ok := item.(map[string]any)
 	if !ok {
 		return item
 	}
+	rendered := renderMessageText(m, format, 80)
 	out := make(map[string]any)
 	for _, key := range []string{
 		"id", "messageId", "message_id", "peer", "username", "title", "type", "date",
@@ -156,7 +161,10 @@ func minimalItem(item any) any {
 	}
 	for _, key := range []string{"text", "message", "caption"} {
 		if value, ok := m[key].(string); ok && value != "" {
-			out[key+"Preview"] = truncateRunes(value, 80)
+			if key != rendered {
+				value = truncateRunes(value, 80)
+			}
+			out[key+"Preview"] = value
 			break
 		}
 	}
@@ -170,21 +178,22 @@ func budgetString(key, value string, opts OutputBudgetOptions) string {
 	if !isTextLikeKey(key) && key != "" {
 		return value
 	}
-	maxChars := opts.MaxTextChars
-	if maxChars <= 0 {
-		switch opts.Verbosity {
-		case VerbosityMinimal:
-			maxChars = 80
-		case VerbosityCompact:
-			maxChars = 200
-		case VerbosityFull, VerbosityRaw:
-			maxChars = 0
-		}
+	return truncateRunes(value, textLimit(opts))
+}
+
+// textLimit returns the character budget of text fields; 0 means unlimited.
+func textLimit(opts OutputBudgetOptions) int {
+	if opts.MaxTextChars > 0 {
+		return opts.MaxTextChars
 	}
-	if maxChars <= 0 {
-		return value
+	switch opts.Verbosity {
+	case VerbosityMinimal:
+		return 80
+	case VerbosityCompact:
+		return 200
+	default:
+		return 0
 	}
-	return truncateRunes(value, maxChars)
 }
 
 func isTextLikeKey(key string) bool {
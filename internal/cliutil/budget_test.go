@@ -110,3 +110,79 @@ func TestApplyOutputBudgetTailsAuditAndLogArrays(t *testing.T) {
 		t.Fatalf("messages = %+v, want head", messages)
 	}
 }
+
+func TestApplyOutputBudgetRendersTextFormat(t *testing.T) {
+	entity := func(kind string, offset, length int, extra ...any) map[string]any {
+		m := map[string]any{"type": kind, "offset": offset, "length": length}
+		for i := 0; i+1 < len(extra); i += 2 {
+			m[extra[i].(string)] = extra[i+1]
+		}
+		return m
+	}
+	// "😀" takes two UTF-16 code units, so every offset after it is shifted.
+	message := func() map[string]any {
+		return map[string]any{
+			"id": 1, "date": 2, "text": "😀 bold link <x> and more",
+			"entities": []any{
+				entity("bold", 3, 4),
+				entity("italic", 5, 7),
+				entity("text_url", 8, 4, "url", "https://example.com"),
+				entity("mention_name", 17, 3, "user_id", 7),
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts OutputBudgetOptions
+		want string
+	}{
+		{
+			name: "markdown nests and reopens overlapping entities",
+			opts: OutputBudgetOptions{TextFormat: TextFormatMarkdown},
+			want: "😀 **bo_ld_**_ [link](https://example.com)_ <x> [and](tg://user?id=7) more",
+		},
+		{
+			name: "html escapes text",
+			opts: OutputBudgetOptions{TextFormat: TextFormatHTML},
+			want: `😀 <b>bo<i>ld</i></b><i> <a href="https://example.com">link</a></i> &lt;x&gt; <a href="tg://user?id=7">and</a> more`,
+		},
+		{
+			name: "budget cuts plain text before markup",
+			opts: OutputBudgetOptions{TextFormat: TextFormatMarkdown, MaxTextChars: 10},
+			want: "😀 **bo_ld_**_ [lin](https://example.com)_... [truncated 14 chars]",
+		},
+		{
+			name: "plain drops entities",
+			opts: OutputBudgetOptions{TextFormat: TextFormatPlain},
+			want: "😀 bold link <x> and more",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := ApplyOutputBudget(map[string]any{"messages": []any{message()}}, tt.opts).(map[string]any)
+			first := out["messages"].([]any)[0].(map[string]any)
+			if first["text"] != tt.want {
+				t.Fatalf("text = %q\nwant %q", first["text"], tt.want)
+			}
+			if first["entities"] != nil {
+				t.Fatal("rendered messages should drop entities")
+			}
+		})
+	}
+
+	update := map[string]any{"type": "new_message", "message": message()}
+	rendered := renderTextFormat(update, OutputBudgetOptions{TextFormat: TextFormatMarkdown, Verbosity: VerbosityFull})
+	if text := rendered.(map[string]any)["message"].(map[string]any)["text"]; text != tests[0].want {
+		t.Fatalf("streamed update text = %q", text)
+	}
+
+	// Scheduled messages carry their text under "message".
+	scheduled := message()
+	scheduled["message"] = scheduled["text"]
+	delete(scheduled, "text")
+	out := ApplyOutputBudget(map[string]any{"messages": []any{scheduled}}, tests[0].opts).(map[string]any)
+	if text := out["messages"].([]any)[0].(map[string]any)["message"]; text != tests[0].want {
+		t.Fatalf("scheduled message text = %q", text)
+	}
+}
@@ -70,6 +70,7 @@ func getCLILogger(socketPath string) *slog.Logger {
 // Runner handles common command execution logic.
 type Runner struct {
 	socketFlag    string
+	profile       string
 	jsonOutput    bool
 	quiet         bool
 	lastDuration  time.Duration
@@ -119,6 +120,7 @@ func NewRunnerFromCmd(cmd *cobra.Command, _ bool) *Runner {
 
 	return &Runner{
 		socketFlag:    opts.socketPath,
+		profile:       opts.profile,
 		jsonOutput:    opts.format == OutputJSON,
 		quiet:         opts.quiet,
 		outputFormat:  opts.format,
@@ -185,8 +187,10 @@ func (r *Runner) TraceID() string {
 // NewRunnerFromRoot creates a runner from a root command with socket flag.
 func NewRunnerFromRoot(rootCmd *cobra.Command, jsonOutput bool) *Runner {
 	socketPath, _ := rootCmd.Flags().GetString("socket")
+	profile, _ := rootCmd.Flags().GetString("profile")
 	return &Runner{
 		socketFlag: socketPath,
+		profile:    profile,
 		jsonOutput: jsonOutput,
 		runID:      observability.NewRunID(),
 		traceID:    observability.NewTraceID(),
@@ -198,7 +202,12 @@ func NewRunnerFromRoot(rootCmd *cobra.Command, jsonOutput bool) *Runner {
 
 // Client creates a new IPC client.
 func (r *Runner) Client() RPCClient {
-	return ipc.NewClient(r.socketFlag)
+	return ipc.NewClient(r.socketFlag).WithProfile(r.profile)
+}
+
+// auditInstance selects the audit journal of the profile the runner targets.
+func (r *Runner) auditInstance() string {
+	return paths.ProfileInstance(r.socketFlag, r.profile)
 }
 
 // waitForServer waits for the server to become available.
@@ -270,6 +279,19 @@ func (r *Runner) CallInternal(method string, params any) any {
 	return r.call(method, params, false)
 }
 
+// TryCallInternal is CallInternal that returns RPC errors instead of exiting,
+// for multi-step commands that must clean up before they fail.
+func (r *Runner) TryCallInternal(method string, params any) (any, *ipc.ErrorObject) {
+	if err := r.ensureServer(); err != nil {
+		return nil, r.ensureErrorToRPC(err, method)
+	}
+	result, err, _ := r.callRPC(method, params)
+	if err != nil {
+		return nil, err
+	}
+	return r.applyResultFilters(result), nil
+}
+
 func (r *Runner) call(method string, params any, userVisible bool) any {
 	if userVisible {
 		r.recordCall(method)
@@ -318,7 +340,7 @@ func (r *Runner) writeAudit(method string, params, result any, rpcErr *ipc.Error
 	} else {
 		event.ResultSummary = observability.SummarizeResult(result)
 	}
-	if err := observability.WriteAudit(r.socketFlag, event); err != nil {
+	if err := observability.WriteAudit(r.auditInstance(), event); err != nil {
 		getCLILogger(r.socketFlag).Warn("cli: audit write failed", "error", err)
 	}
 }
@@ -467,7 +489,7 @@ func (r *Runner) FailTyped(err *ipc.ErrorObject, details FailureDetails) {
 		ErrorType:     errorType(err),
 		Error:         err.Message,
 	}
-	if auditErr := observability.WriteAudit(r.socketFlag, event); auditErr != nil {
+	if auditErr := observability.WriteAudit(r.auditInstance(), event); auditErr != nil {
 		log.Warn("cli: audit write failed", "error", auditErr)
 	}
 
@@ -86,3 +86,35 @@ func (r *Runner) applyResultFilters(result any) any {
 	}
 	return result
 }
+
+type subscribeRPCClient interface {
+	Subscribe(method string, params any, opts ipc.CallOptions) (*ipc.Subscription, *ipc.ErrorObject)
+}
+
+// Subscribe opens a server-push subscription. It returns false when the
+// client or daemon does not support the method, so callers can fall back to
+// polling; other errors are handled like Call.
+func (r *Runner) Subscribe(method string, params any) (*ipc.Subscription, bool) {
+	r.recordCall(method)
+	if !r.ensureServerReady(method) {
+		return nil, false
+	}
+	client, ok := r.Client().(subscribeRPCClient)
+	if !ok {
+		return nil, false
+	}
+	start := time.Now()
+	sub, err := client.Subscribe(method, params, ipc.CallOptions{TraceID: r.traceID, RunID: r.runID})
+	r.lastDuration = time.Since(start)
+	log := getCLILogger(r.socketFlag)
+	if err != nil {
+		if err.Code == ipc.ErrCodeMethodNotFound {
+			return nil, false
+		}
+		r.logCallError(log, method, params, err, r.lastDuration)
+		r.handleError(err)
+		return nil, false
+	}
+	r.logCallSuccess(log, method, params, sub.Result, r.lastDuration)
+	return sub, true
+}
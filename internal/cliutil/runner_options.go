@@ -9,6 +9,7 @@ import (
 
 type runnerFlagOptions struct {
 	socketPath   string
+	profile      string
 	quiet        bool
 	format       OutputFormat
 	fields       []string
@@ -16,6 +17,7 @@ type runnerFlagOptions struct {
 	verbosity    string
 	maxItems     int
 	maxTextChars int
+	textFormat   string
 	include      []string
 	omit         []string
 	summary      bool
@@ -29,6 +31,7 @@ type runnerFlagOptions struct {
 func runnerFlagOptionsFromCmd(cmd *cobra.Command) runnerFlagOptions {
 	opts := runnerFlagOptions{
 		socketPath: flagString(cmd, "socket"),
+		profile:    flagString(cmd, "profile"),
 		quiet:      flagBool(cmd, "quiet"),
 		format:     ParseOutputFormat(flagString(cmd, "output")),
 		fields:     flagStringSlice(cmd, "fields"),
@@ -42,6 +45,7 @@ func runnerFlagOptionsFromCmd(cmd *cobra.Command) runnerFlagOptions {
 		confirm:    flagBool(cmd, "confirm"),
 		agentMode:  flagBool(cmd, "agent"),
 		runID:      flagString(cmd, "run-id"),
+		textFormat: flagString(cmd, "text-format"),
 	}
 	opts.maxItems, _ = cmd.Flags().GetInt("max-items")
 	opts.maxTextChars, _ = cmd.Flags().GetInt("max-text-chars")
@@ -87,9 +91,19 @@ func (o runnerFlagOptions) outputBudget() OutputBudgetOptions {
 		Include:      o.include,
 		Omit:         o.omit,
 		Summary:      o.summary,
+		TextFormat:   o.parsedTextFormat(),
 	}
 }
 
+func (o runnerFlagOptions) parsedTextFormat() TextFormat {
+	format, err := ParseTextFormat(o.textFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		Exit(1)
+	}
+	return format
+}
+
 func flagString(cmd *cobra.Command, name string) string {
 	value, _ := cmd.Flags().GetString(name)
 	return value
@@ -0,0 +1,355 @@
+package cliutil
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// TextFormat selects how message text is rendered from its entities.
+type TextFormat string
+
+const (
+	// TextFormatEntities leaves text and entities as reported.
+	TextFormatEntities TextFormat = ""
+	TextFormatPlain    TextFormat = "plain"
+	TextFormatMarkdown TextFormat = "markdown"
+	TextFormatHTML     TextFormat = "html"
+)
+
+// ParseTextFormat parses a --text-format flag value.
+func ParseTextFormat(value string) (TextFormat, error) {
+	switch format := TextFormat(strings.ToLower(strings.TrimSpace(value))); format {
+	case TextFormatEntities, TextFormatPlain, TextFormatMarkdown, TextFormatHTML:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid --text-format %q (want markdown, html or plain)", value)
+	}
+}
+
+// RenderText renders the text of every message in value as selected by
+// --text-format, for output written without PrintResult such as streamed
+// updates.
+func (r *Runner) RenderText(value any) any {
+	return renderTextFormat(value, r.outputBudget)
+}
+
+func renderTextFormat(value any, opts OutputBudgetOptions) any {
+	if opts.TextFormat == TextFormatEntities {
+		return value
+	}
+	return renderMessages(normalizeForBudget(value), opts)
+}
+
+func renderMessages(value any, opts OutputBudgetOptions) any {
+	switch v := value.(type) {
+	case map[string]any:
+		renderMessageText(v, opts.TextFormat, textLimit(opts))
+		for key, child := range v {
+			v[key] = renderMessages(child, opts)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = renderMessages(item, opts)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// renderMessageText replaces the text of a message with its entities rendered
+// in format and drops the entities, whose offsets no longer apply. The plain
+// text is cut to limit characters before rendering, so markup is never split.
+// It returns the rendered key, "text" or "message", or "" if m was left as is.
+func renderMessageText(m map[string]any, format TextFormat, limit int) string {
+	if format == TextFormatEntities || !isMessageMap(m) {
+		return ""
+	}
+	key := "text"
+	text, ok := m[key].(string)
+	if !ok {
+		key = "message"
+		if text, ok = m[key].(string); !ok {
+			return ""
+		}
+	}
+	entities, _ := m["entities"].([]any)
+	delete(m, "entities")
+
+	runes := []rune(text)
+	truncated := 0
+	if limit > 0 && len(runes) > limit {
+		truncated = len(runes) - limit
+		runes = runes[:limit]
+	}
+	rendered := renderEntities(runes, parseEntities(entities), format)
+	if truncated > 0 {
+		rendered += fmt.Sprintf("... [truncated %d chars]", truncated)
+	}
+	m[key] = rendered
+	return key
+}
+
+// isMessageMap reports whether m looks like a message rather than, for
+// example, a button, which also has a text field.
+func isMessageMap(m map[string]any) bool {
+	_, hasID := m["id"]
+	_, hasDate := m["date"]
+	return hasID && hasDate
+}
+
+// textEntity is a message entity with its range in UTF-16 code units.
+type textEntity struct {
+	kind       string
+	start, end int
+	url        string
+	userID     int64
+	language   string
+	documentID int64
+}
+
+func parseEntities(items []any) []textEntity {
+	entities := make([]textEntity, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		kind, _ := m["type"].(string)
+		start := int(ExtractInt64(m, "offset"))
+		e := textEntity{kind: kind, start: start, end: start + int(ExtractInt64(m, "length"))}
+		e.url, _ = m["url"].(string)
+		e.language, _ = m["language"].(string)
+		e.userID = ExtractInt64(m, "user_id")
+		e.documentID = ExtractInt64(m, "document_id")
+		entities = append(entities, e)
+	}
+	return entities
+}
+
+// renderEntities writes text with markup for entities. Overlapping entities
+// that do not nest are closed and reopened around each other.
+func renderEntities(runes []rune, entities []textEntity, format TextFormat) string {
+	units := utf16.Encode(runes)
+	kept := entities[:0]
+	for _, e := range entities {
+		e.end = min(e.end, len(units))
+		if e.start >= 0 && e.start < e.end && hasMarkup(e.kind, format) {
+			kept = append(kept, e)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].start != kept[j].start {
+			return kept[i].start < kept[j].start
+		}
+		return kept[i].end > kept[j].end
+	})
+
+	bounds := []int{0, len(units)}
+	for _, e := range kept {
+		bounds = append(bounds, e.start, e.end)
+	}
+	sort.Ints(bounds)
+	bounds = slices.Compact(bounds)
+
+	w := &markupWriter{format: format}
+	next := 0
+	for i, pos := range bounds {
+		var reopen []textEntity
+		for endsAt(w.open, pos) {
+			top := w.open[len(w.open)-1]
+			w.open = w.open[:len(w.open)-1]
+			w.closeTag(top)
+			if top.end != pos {
+				reopen = append(reopen, top)
+			}
+		}
+		for k := len(reopen) - 1; k >= 0; k-- {
+			w.openTag(reopen[k])
+		}
+		for ; next < len(kept) && kept[next].start == pos; next++ {
+			w.openTag(kept[next])
+		}
+		if i+1 < len(bounds) {
+			w.text(string(utf16.Decode(units[pos:bounds[i+1]])))
+		}
+	}
+	return w.b.String()
+}
+
+func endsAt(open []textEntity, pos int) bool {
+	for _, e := range open {
+		if e.end == pos {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMarkup(kind string, format TextFormat) bool {
+	switch format {
+	case TextFormatMarkdown:
+		switch kind {
+		case "bold", "italic", "strike", "spoiler", "code", "pre", "text_url", "mention_name", "blockquote":
+			return true
+		}
+	case TextFormatHTML:
+		switch kind {
+		case "bold", "italic", "underline", "strike", "spoiler", "code", "pre", "text_url",
+			"mention_name", "blockquote", "custom_emoji":
+			return true
+		}
+	}
+	return false
+}
+
+type markupWriter struct {
+	format TextFormat
+	b      strings.Builder
+	open   []textEntity
+}
+
+func (w *markupWriter) inside(kind string) bool {
+	for _, e := range w.open {
+		if e.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *markupWriter) text(s string) {
+	switch {
+	case w.format == TextFormatHTML:
+		s = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	case w.format == TextFormatMarkdown && w.inside("blockquote"):
+		s = strings.ReplaceAll(s, "\n", "\n> ")
+	}
+	w.b.WriteString(s)
+}
+
+// lineStart starts a new line unless the output is already at one.
+func (w *markupWriter) lineStart() {
+	if out := w.b.String(); out != "" && !strings.HasSuffix(out, "\n") {
+		w.b.WriteByte('\n')
+	}
+}
+
+func (w *markupWriter) openTag(e textEntity) {
+	w.open = append(w.open, e)
+	if w.format == TextFormatHTML {
+		w.b.WriteString(htmlOpen(e))
+		return
+	}
+	switch e.kind {
+	case "bold":
+		w.b.WriteString("**")
+	case "italic":
+		w.b.WriteString("_")
+	case "strike":
+		w.b.WriteString("~~")
+	case "spoiler":
+		w.b.WriteString("||")
+	case "code":
+		w.b.WriteString("`")
+	case "pre":
+		w.lineStart()
+		w.b.WriteString("```" + e.language + "\n")
+	case "text_url", "mention_name":
+		w.b.WriteString("[")
+	case "blockquote":
+		w.lineStart()
+		w.b.WriteString("> ")
+	}
+}
+
+func (w *markupWriter) closeTag(e textEntity) {
+	if w.format == TextFormatHTML {
+		w.b.WriteString(htmlClose(e))
+		return
+	}
+	switch e.kind {
+	case "bold":
+		w.b.WriteString("**")
+	case "italic":
+		w.b.WriteString("_")
+	case "strike":
+		w.b.WriteString("~~")
+	case "spoiler":
+		w.b.WriteString("||")
+	case "code":
+		w.b.WriteString("`")
+	case "pre":
+		w.b.WriteString("\n```")
+	case "text_url":
+		w.b.WriteString("](" + e.url + ")")
+	case "mention_name":
+		w.b.WriteString("](tg://user?id=" + strconv.FormatInt(e.userID, 10) + ")")
+	}
+}
+
+func htmlOpen(e textEntity) string {
+	switch e.kind {
+	case "bold":
+		return "<b>"
+	case "italic":
+		return "<i>"
+	case "underline":
+		return "<u>"
+	case "strike":
+		return "<s>"
+	case "spoiler":
+		return "<tg-spoiler>"
+	case "code":
+		return "<code>"
+	case "pre":
+		if e.language != "" {
+			return `<pre><code class="language-` + html.EscapeString(e.language) + `">`
+		}
+		return "<pre>"
+	case "text_url":
+		return `<a href="` + html.EscapeString(e.url) + `">`
+	case "mention_name":
+		return `<a href="tg://user?id=` + strconv.FormatInt(e.userID, 10) + `">`
+	case "blockquote":
+		return "<blockquote>"
+	case "custom_emoji":
+		return `<tg-emoji emoji-id="` + strconv.FormatInt(e.documentID, 10) + `">`
+	}
+	return ""
+}
+
+func htmlClose(e textEntity) string {
+	switch e.kind {
+	case "bold":
+		return "</b>"
+	case "italic":
+		return "</i>"
+	case "underline":
+		return "</u>"
+	case "strike":
+		return "</s>"
+	case "spoiler":
+		return "</tg-spoiler>"
+	case "code":
+		return "</code>"
+	case "pre":
+		if e.language != "" {
+			return "</code></pre>"
+		}
+		return "</pre>"
+	case "text_url", "mention_name":
+		return "</a>"
+	case "blockquote":
+		return "</blockquote>"
+	case "custom_emoji":
+		return "</tg-emoji>"
+	}
+	return ""
+}
@@ -100,6 +100,7 @@ func writeLLMDocumentation(b *strings.Builder, rootCmd *cobra.Command) {
 	b.WriteString("- Prefer `--agent` for automation; it enables compact JSON receipts, run IDs, and structured error envelopes with diagnosis/nextActions.\n")
 	b.WriteString("- Use `--run-id ...` or `AGENT_TELEGRAM_RUN_ID` to correlate multiple commands in one agent task.\n")
 	b.WriteString("- Use `--verbosity minimal|compact|full|raw`, `--max-items`, `--max-text-chars`, `--summary`, `--include`, and `--omit` to control token usage.\n")
+	b.WriteString("- Use `--text-format markdown|html|plain` to read formatted message text instead of offset-based `entities`; it is applied before `--max-text-chars`, so markup is never cut.\n")
 	b.WriteString("- Use `--receipt` to wrap JSON output with trace/action metadata.\n")
 	b.WriteString("- Use `audit --trace-id ...`, `audit --run-id ...`, `trace inspect ...`, and `run inspect ...` to analyze recent actions. Audit/log output defaults to `--redaction safe`; `full` redaction mode is intentionally not exposed.\n")
 	b.WriteString("- Use `server ensure` before RPC-backed commands; commands do not auto-start the daemon implicitly.\n")
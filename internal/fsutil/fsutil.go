@@ -0,0 +1,87 @@
+// Package fsutil holds the file helpers shared by the JSON-file backed
+// stores: atomic owner-only writes and delayed, batched flushes.
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// WriteFileAtomic replaces path with data. The data is written and synced to
+// an owner-only temporary file in the same directory, which is then renamed
+// over path, so readers never see a partial file.
+func WriteFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		_ = os.Remove(path) // Windows rename does not replace an existing file.
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	return nil
+}
+
+// FlushTimer batches the writes of a store: a burst of changes schedules one
+// flush after a delay. The zero value is ready to use.
+type FlushTimer struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+// Schedule runs flush once delay has passed, unless a flush is already
+// pending or the timer was stopped. flush runs on its own goroutine and must
+// take the store's lock itself.
+func (t *FlushTimer) Schedule(delay time.Duration, flush func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil || t.stopped {
+		return
+	}
+	t.timer = time.AfterFunc(delay, func() {
+		t.mu.Lock()
+		t.timer = nil
+		t.mu.Unlock()
+		flush()
+	})
+}
+
+// Stop cancels a pending flush and ignores later calls to Schedule. Stores
+// call it on Close before their final synchronous flush.
+func (t *FlushTimer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
@@ -0,0 +1,64 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomicReplacesOwnerOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	if err := WriteFileAtomic(path, []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "two" {
+		t.Fatalf("content = %q, %v", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Fatalf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestFlushTimerBatchesAndStops(t *testing.T) {
+	var timer FlushTimer
+	var flushes atomic.Int32
+	done := make(chan struct{}, 1)
+	flush := func() {
+		flushes.Add(1)
+		done <- struct{}{}
+	}
+	for range 3 {
+		timer.Schedule(10*time.Millisecond, flush)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("flush did not run")
+	}
+	if got := flushes.Load(); got != 1 {
+		t.Fatalf("flushes = %d, want one per burst", got)
+	}
+
+	timer.Schedule(time.Hour, flush)
+	timer.Stop()
+	timer.Schedule(time.Millisecond, flush)
+	time.Sleep(20 * time.Millisecond)
+	if got := flushes.Load(); got != 1 {
+		t.Fatalf("flushes after Stop = %d, want 1", got)
+	}
+}
@@ -0,0 +1,169 @@
+package ipc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"agent-telegram/internal/observability"
+)
+
+const (
+	// EnvBatchConcurrency sets how many elements of a JSON-RPC batch run at
+	// once. The default of 1 runs elements in order, one after another.
+	EnvBatchConcurrency = "AGENT_TELEGRAM_BATCH_CONCURRENCY"
+
+	// MaxBatchSize bounds the number of calls in one JSON-RPC batch.
+	MaxBatchSize = 100
+	// MaxBatchConcurrency caps EnvBatchConcurrency and per-request overrides.
+	MaxBatchConcurrency = 16
+)
+
+// BatchConcurrency returns the default number of batch elements executed in
+// parallel.
+func BatchConcurrency() int {
+	if raw := os.Getenv(EnvBatchConcurrency); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return min(n, MaxBatchConcurrency)
+		}
+	}
+	return 1
+}
+
+// isBatch reports whether a JSON-RPC message is a batch (a JSON array).
+func isBatch(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// decodeBatch splits a batch into its elements. An empty batch is an invalid
+// request per JSON-RPC 2.0.
+func decodeBatch(data []byte) ([]json.RawMessage, *ErrorObject) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, ErrParseError
+	}
+	if len(elements) == 0 {
+		return nil, ErrInvalidRequest
+	}
+	if len(elements) > MaxBatchSize {
+		return nil, NewTypedError(ErrCodeInvalidRequest, ErrorTypeValidation,
+			"batch exceeds "+strconv.Itoa(MaxBatchSize)+" calls", nil)
+	}
+	return elements, nil
+}
+
+// decodeBatchElement decodes one batch element. Elements that are not
+// request objects get an Invalid Request response with a null ID.
+func decodeBatchElement(raw json.RawMessage) (*Request, bool) {
+	var req Request
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) && json.Unmarshal(raw, &req) == nil {
+		return &req, true
+	}
+	return nil, false
+}
+
+// runBatch calls fn for every index with at most limit calls in flight and
+// returns the non-nil responses in element order. Notifications (nil
+// responses) are dropped, as the spec requires.
+func runBatch(ctx context.Context, n, limit int, fn func(ctx context.Context, i int) *Response) []*Response {
+	responses := make([]*Response, n)
+	if limit <= 1 {
+		for i := range n {
+			responses[i] = fn(ctx, i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, limit)
+		for i := range n {
+			sem <- struct{}{}
+			wg.Go(func() {
+				defer func() { <-sem }()
+				responses[i] = fn(ctx, i)
+			})
+		}
+		wg.Wait()
+	}
+
+	out := make([]*Response, 0, n)
+	for _, resp := range responses {
+		if resp != nil {
+			out = append(out, resp)
+		}
+	}
+	return out
+}
+
+// handleBatch runs a JSON-RPC batch received on a persistent connection.
+// Each element is validated, policy-checked and audited on its own. It
+// returns nil when the batch only contained notifications.
+func (s *Server) handleBatch(ctx context.Context, data []byte) any {
+	elements, rpcErr := decodeBatch(data)
+	if rpcErr != nil {
+		return &Response{JSONRPC: "2.0", Error: rpcErr}
+	}
+	s.mu.RLock()
+	limit := s.batchConcurrency
+	s.mu.RUnlock()
+
+	responses := runBatch(ctx, len(elements), limit, func(ctx context.Context, i int) *Response {
+		req, ok := decodeBatchElement(elements[i])
+		if !ok {
+			return &Response{JSONRPC: "2.0", Error: ErrInvalidRequest}
+		}
+		start := time.Now()
+		resp := s.handleRequest(ctx, req)
+		s.writeBatchAudit(ctx, req, resp, time.Since(start))
+		if req.ID == nil {
+			return nil
+		}
+		return resp
+	})
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// writeBatchAudit records a batch element. Single socket calls are audited by
+// the CLI that sends them; batches come straight from agents, so the server
+// records each element itself.
+func (s *Server) writeBatchAudit(ctx context.Context, req *Request, resp *Response, duration time.Duration) {
+	surface := SurfaceFromContext(ctx)
+	if surface == "" {
+		surface = SurfaceIPC
+	}
+	event := observability.AuditEvent{
+		Time:       time.Now().UTC(),
+		RunID:      req.RunID,
+		TraceID:    req.TraceID,
+		Surface:    surface,
+		Method:     req.Method,
+		Safety:     operationSafety(req.Method),
+		Status:     "ok",
+		DurationMs: duration.Milliseconds(),
+		Params:     req.Params,
+	}
+	if resp.Error != nil {
+		event.Status = "error"
+		event.ErrorCode = resp.Error.Code
+		event.ErrorType = errorType(resp.Error)
+		event.Error = resp.Error.Message
+	} else {
+		event.ResultSummary = observability.SummarizeResult(resp.Result)
+	}
+	s.mu.RLock()
+	auditSocket := s.auditSocket
+	s.mu.RUnlock()
+	if scope, _ := s.profiles.resolve(req.Profile); scope != nil {
+		auditSocket = scope.audit()
+	}
+	if err := observability.WriteAudit(auditSocket, event); err != nil {
+		slog.Warn("ipc audit write failed", "trace_id", req.TraceID, "error", err)
+	}
+}
@@ -0,0 +1,198 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-telegram/internal/observability"
+)
+
+func registerBatchTestMethods(r MethodRegistrar, inFlight, peak *atomic.Int32) {
+	r.Register("batch_echo", func(_ context.Context, params json.RawMessage) (interface{}, *ErrorObject) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return json.RawMessage(params), nil
+	})
+	r.Register("boom", func(context.Context, json.RawMessage) (interface{}, *ErrorObject) {
+		panic("boom")
+	})
+}
+
+func TestServerServesBatch(t *testing.T) {
+	server, client := pipeConn()
+	srv := NewServer()
+	var inFlight, peak atomic.Int32
+	registerBatchTestMethods(srv, &inFlight, &peak)
+	srv.SetPolicyChecker(denyPeerChecker{peer: "@blocked"})
+	go func() { _ = srv.Serve(context.Background(), server) }()
+	defer func() { _ = client.Close() }()
+
+	batch := `[
+		{"jsonrpc":"2.0","method":"batch_echo","params":{"n":1},"id":1},
+		{"jsonrpc":"2.0","method":"batch_echo","params":{"n":2}},
+		{"jsonrpc":"2.0","method":"batch_echo","params":{"peer":"@blocked"},"id":3},
+		{"jsonrpc":"2.0","method":"missing","id":4},
+		42,
+		{"jsonrpc":"2.0","method":"boom","id":6}
+	]` + "\n"
+	if _, err := client.Write([]byte(batch)); err != nil {
+		t.Fatal(err)
+	}
+	decoder := json.NewDecoder(client)
+	var responses []Response
+	if err := decoder.Decode(&responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 5 {
+		t.Fatalf("responses = %+v, want 5 (notification omitted)", responses)
+	}
+	if responses[0].ID != float64(1) || responses[0].Error != nil {
+		t.Fatalf("first response = %+v", responses[0])
+	}
+	wantCodes := []int{ErrCodePolicyDenied, ErrCodeMethodNotFound, ErrCodeInvalidRequest, ErrCodeInternalError}
+	for i, code := range wantCodes {
+		if resp := responses[i+1]; resp.Error == nil || resp.Error.Code != code {
+			t.Fatalf("response %d = %+v, want error %d", i+1, resp, code)
+		}
+	}
+
+	// The connection keeps serving single requests after a batch.
+	if _, err := client.Write([]byte(`{"jsonrpc":"2.0","method":"batch_echo","params":{},"id":7}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	var single Response
+	if err := decoder.Decode(&single); err != nil || single.ID != float64(7) {
+		t.Fatalf("single response = %+v, err = %v", single, err)
+	}
+
+	events, err := observability.ReadAudit("", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audited := 0
+	for _, event := range events {
+		if event.Surface == SurfaceIPC {
+			audited++
+		}
+	}
+	if audited < 5 {
+		t.Fatalf("audit events = %d, want one per request element", audited)
+	}
+}
+
+func TestServerRejectsEmptyBatch(t *testing.T) {
+	srv := NewServer()
+	resp, ok := srv.handleMessage(context.Background(), json.RawMessage(`[]`))
+	r, isResp := resp.(*Response)
+	if !ok || !isResp || r.Error == nil || r.Error.Code != ErrCodeInvalidRequest {
+		t.Fatalf("response = %+v, want invalid request", resp)
+	}
+
+	resp, ok = srv.handleMessage(context.Background(), json.RawMessage(`[{"jsonrpc":"2.0","method":"missing"}]`))
+	if !ok || resp != nil {
+		t.Fatalf("notification-only batch response = %+v, want none", resp)
+	}
+}
+
+func TestServerBatchConcurrencyIsBounded(t *testing.T) {
+	srv := NewServer()
+	var inFlight, peak atomic.Int32
+	registerBatchTestMethods(srv, &inFlight, &peak)
+	srv.SetBatchConcurrency(3)
+
+	calls := make([]string, 9)
+	for i := range calls {
+		calls[i] = `{"jsonrpc":"2.0","method":"batch_echo","params":{},"id":` + strconv.Itoa(i+1) + `}`
+	}
+	resp := srv.handleBatch(context.Background(), []byte("["+strings.Join(calls, ",")+"]"))
+	responses, ok := resp.([]*Response)
+	if !ok || len(responses) != len(calls) {
+		t.Fatalf("responses = %+v", resp)
+	}
+	for i, r := range responses {
+		if r.ID != float64(i+1) {
+			t.Fatalf("response %d has id %v; batch order not preserved", i, r.ID)
+		}
+	}
+	if got := peak.Load(); got < 2 || got > 3 {
+		t.Fatalf("peak concurrency = %d, want 2..3", got)
+	}
+}
+
+func TestHTTPServerServesBatch(t *testing.T) {
+	srv := NewHTTPServer(0, "secret", "")
+	var inFlight, peak atomic.Int32
+	registerBatchTestMethods(srv, &inFlight, &peak)
+	srv.SetPolicyChecker(denyPeerChecker{peer: "@blocked"})
+
+	body := `[{"jsonrpc":"2.0","method":"batch_echo","params":{"n":1},"id":"a","traceId":"trace-a"},` +
+		`{"jsonrpc":"2.0","method":"batch_echo","params":{"peer":"@blocked"},"id":"b"},` +
+		`{"jsonrpc":"2.0","method":"batch_echo","params":{"n":3}}]`
+	req := httptest.NewRequest(http.MethodPost, "/rpc?concurrency=2", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var responses []Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 || responses[0].ID != "a" || responses[0].TraceID != "trace-a" || responses[0].Error != nil {
+		t.Fatalf("responses = %+v", responses)
+	}
+	if responses[1].Error == nil || responses[1].Error.Code != ErrCodePolicyDenied {
+		t.Fatalf("denied element = %+v", responses[1])
+	}
+
+	events, err := observability.ReadAudit("", 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, event := range events {
+		if event.Surface == "http" && event.TraceID == "trace-a" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("batch element was not audited")
+	}
+}
+
+func TestHTTPServerBatchErrors(t *testing.T) {
+	srv := NewHTTPServer(0, "", "")
+	cases := []struct {
+		path, body string
+		want       int
+	}{
+		{"/rpc", `[`, http.StatusBadRequest},
+		{"/rpc", `[]`, http.StatusBadRequest},
+		{"/rpc?concurrency=99", `[{}]`, http.StatusBadRequest},
+		{"/rpc", `{"jsonrpc":"2.0","method":"missing"}`, http.StatusNoContent},
+		{"/rpc", `{"jsonrpc":"2.0","method":"missing","id":1}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != tc.want {
+			t.Errorf("%s %s: status = %d, want %d", tc.path, tc.body, rec.Code, tc.want)
+		}
+	}
+}
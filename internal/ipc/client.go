@@ -13,6 +13,7 @@ import (
 type Client struct {
 	path    string
 	timeout time.Duration
+	profile string
 }
 
 // CallOptions carries request metadata that is independent from method params.
@@ -20,6 +21,8 @@ type CallOptions struct {
 	TraceID string
 	RunID   string
 	Confirm bool
+	// Profile overrides the client's profile for one call.
+	Profile string
 }
 
 // NewClient creates a new JSON-RPC client.
@@ -47,6 +50,14 @@ func NewClientWithTimeout(path string, timeout time.Duration) *Client {
 	}
 }
 
+// WithProfile returns a copy of the client that routes every call to a
+// session profile hosted by the daemon. Empty selects the primary profile.
+func (c *Client) WithProfile(profile string) *Client {
+	clone := *c
+	clone.profile = strings.TrimSpace(profile)
+	return &clone
+}
+
 // Call calls a JSON-RPC method.
 func (c *Client) Call(method string, params interface{}) (interface{}, *ErrorObject) {
 	return c.CallWithTrace(method, params, "")
@@ -64,6 +75,64 @@ func (c *Client) CallWithTraceAndRun(method string, params interface{}, traceID,
 
 // CallWithOptions calls a JSON-RPC method with transport-level metadata.
 func (c *Client) CallWithOptions(method string, params interface{}, opts CallOptions) (interface{}, *ErrorObject) {
+	conn, _, resp, rpcErr := c.roundTrip(method, params, opts)
+	if conn != nil {
+		_ = conn.Close()
+	}
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return resp.Result, resp.Error
+}
+
+// Subscription is an open connection receiving server notifications after a
+// subscribe call.
+type Subscription struct {
+	// Result is the subscribe method's response result.
+	Result  interface{}
+	conn    net.Conn
+	decoder *json.Decoder
+}
+
+// Subscribe calls a subscription method and keeps the connection open for
+// its notifications. The client timeout only applies to the initial call.
+func (c *Client) Subscribe(method string, params interface{}, opts CallOptions) (*Subscription, *ErrorObject) {
+	conn, decoder, resp, rpcErr := c.roundTrip(method, params, opts)
+	if rpcErr == nil && resp.Error != nil {
+		rpcErr = resp.Error
+	}
+	if rpcErr != nil {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return nil, rpcErr
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &Subscription{Result: resp.Result, conn: conn, decoder: decoder}, nil
+}
+
+// Next blocks until the next notification arrives. It returns io.EOF when the
+// server closes the connection.
+func (s *Subscription) Next() (*Notification, error) {
+	var notification Notification
+	if err := s.decoder.Decode(&notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// Close closes the connection, ending every subscription on it.
+func (s *Subscription) Close() error {
+	return s.conn.Close()
+}
+
+// roundTrip sends one request and reads its response. The returned
+// connection, when non-nil, is owned by the caller.
+func (c *Client) roundTrip(
+	method string,
+	params interface{},
+	opts CallOptions,
+) (net.Conn, *json.Decoder, *Response, *ErrorObject) {
 	// Create request
 	req := Request{
 		JSONRPC: "2.0",
@@ -72,11 +141,15 @@ func (c *Client) CallWithOptions(method string, params interface{}, opts CallOpt
 		RunID:   opts.RunID,
 		TraceID: opts.TraceID,
 		Confirm: opts.Confirm,
+		Profile: opts.Profile,
+	}
+	if req.Profile == "" {
+		req.Profile = c.profile
 	}
 	if params != nil {
 		data, err := json.Marshal(params)
 		if err != nil {
-			return nil, ErrInternalError
+			return nil, nil, nil, ErrInternalError
 		}
 		req.Params = data
 	}
@@ -90,14 +163,13 @@ func (c *Client) CallWithOptions(method string, params interface{}, opts CallOpt
 		if strings.Contains(errStr, "no such file") ||
 			strings.Contains(errStr, "connection refused") ||
 			strings.Contains(errStr, "connect: no such file or directory") {
-			return nil, ErrServerNotRunning
+			return nil, nil, nil, ErrServerNotRunning
 		}
-		return nil, &ErrorObject{
+		return nil, nil, nil, &ErrorObject{
 			Code:    -32000,
 			Message: "Failed to connect: " + errStr,
 		}
 	}
-	defer func() { _ = conn.Close() }()
 
 	// Set deadline
 	_ = conn.SetDeadline(time.Now().Add(c.timeout))
@@ -105,7 +177,7 @@ func (c *Client) CallWithOptions(method string, params interface{}, opts CallOpt
 	// Send request
 	encoder := json.NewEncoder(conn)
 	if err := encoder.Encode(req); err != nil {
-		return nil, ErrInternalError
+		return conn, nil, nil, ErrInternalError
 	}
 
 	// Receive response (UseNumber preserves int64 precision for large IDs)
@@ -113,10 +185,9 @@ func (c *Client) CallWithOptions(method string, params interface{}, opts CallOpt
 	decoder.UseNumber()
 	var resp Response
 	if err := decoder.Decode(&resp); err != nil {
-		return nil, ErrInternalError
+		return conn, nil, nil, ErrInternalError
 	}
-
-	return resp.Result, resp.Error
+	return conn, decoder, &resp, nil
 }
 
 // Ping sends a ping request.
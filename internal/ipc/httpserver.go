@@ -13,6 +13,8 @@ import (
 
 	"agent-telegram/internal/operations"
 	"agent-telegram/internal/skills"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
 )
 
 const (
@@ -24,12 +26,23 @@ const (
 // It implements MethodRegistrar so RegisterHandlers works identically to SocketServer.
 type HTTPServer struct {
 	methods   map[string]Handler
+	profiles  profileRoutes
 	mu        sync.RWMutex
 	secret    string
 	cors      string
 	srv       *http.Server
 	policy    PolicyChecker
 	fileRoots []string
+	// batchConcurrency is the default parallelism for POST /rpc batches.
+	batchConcurrency int
+
+	updateSource   updatefeed.Source
+	updateFeed     *updatefeed.Feed
+	eventHeartbeat time.Duration
+	// streamCtx is cancelled on shutdown to end /events and /ws streams,
+	// which http.Server.Shutdown would otherwise wait for.
+	streamCtx    context.Context
+	streamCancel context.CancelFunc
 }
 
 // NewHTTPServer creates a new HTTP API server.
@@ -40,17 +53,23 @@ func NewHTTPServer(port int, secret, cors string) *HTTPServer {
 // NewHTTPServerOnAddress creates an HTTP API server on an explicit address.
 func NewHTTPServerOnAddress(address, secret, cors string) *HTTPServer {
 	s := &HTTPServer{
-		methods: make(map[string]Handler),
-		secret:  secret,
-		cors:    cors,
+		methods:          make(map[string]Handler),
+		secret:           secret,
+		cors:             cors,
+		eventHeartbeat:   defaultEventHeartbeat,
+		batchConcurrency: BatchConcurrency(),
 	}
+	s.streamCtx, s.streamCancel = context.WithCancel(context.Background())
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /health", s.handleHealth)
 	mux.HandleFunc("GET /methods", s.handleMethods)
 	mux.HandleFunc("GET /manifest", s.handleManifest)
 	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("POST /rpc", s.handleRPCBatch)
 	mux.HandleFunc("POST /rpc/{method}", s.handleRPC)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /ws", s.handleWebSocket)
 
 	var handler http.Handler = mux
 	if cors != "" {
@@ -92,6 +111,17 @@ func (s *HTTPServer) SetPolicyChecker(policy PolicyChecker) {
 	s.policy = policy
 }
 
+// SetPrimaryProfile names the profile served by the server's own handlers.
+func (s *HTTPServer) SetPrimaryProfile(name string) {
+	s.profiles.setPrimary(name)
+}
+
+// AddProfile hosts an additional profile, selected per request with the
+// X-Agent-Telegram-Profile header.
+func (s *HTTPServer) AddProfile(name string) *ProfileScope {
+	return s.profiles.add(name)
+}
+
 // Start starts the HTTP server. Blocks until ctx is cancelled.
 func (s *HTTPServer) Start(ctx context.Context) error {
 	lc := &net.ListenConfig{}
@@ -115,6 +145,7 @@ func (s *HTTPServer) Start(ctx context.Context) error {
 		return err
 	}
 
+	s.streamCancel()
 	shutCtx, shutCancel := context.WithTimeout(context.Background(), httpShutdownWait)
 	defer shutCancel()
 
@@ -137,10 +168,11 @@ func (s *HTTPServer) handleMethods(w http.ResponseWriter, _ *http.Request) {
 
 func (s *HTTPServer) handleManifest(w http.ResponseWriter, _ *http.Request) {
 	writeJSONResponse(w, http.StatusOK, map[string]any{
-		"ok":         true,
-		"operations": operations.Manifest(),
-		"errorTypes": ErrorTypesManifest(),
-		"skills":     skills.Manifest(),
+		"ok":          true,
+		"operations":  operations.Manifest(),
+		"errorTypes":  ErrorTypesManifest(),
+		"updateTypes": types.UpdateTypesManifest(),
+		"skills":      skills.Manifest(),
 	})
 }
 
@@ -150,7 +182,13 @@ func (s *HTTPServer) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
 
 func (s *HTTPServer) handleRPC(w http.ResponseWriter, r *http.Request) {
 	req := newHTTPRPCRequest(w, r)
-	handler, ok := s.lookupHandler(req.method)
+	scope, rpcErr := s.resolveProfile(r)
+	if rpcErr != nil {
+		s.writeRPCError(w, req, nil, rpcErr, errorToHTTPStatus(rpcErr), false)
+		return
+	}
+	req.scope = scope
+	handler, ok := s.lookupHandler(scope, req.method)
 	if !ok {
 		rpcErr := NewTypedError(ErrCodeMethodNotFound, ErrorTypeMethodNotFound, "method not found", nil)
 		s.writeRPCError(w, req, nil, rpcErr, http.StatusNotFound, false)
@@ -176,9 +214,10 @@ func (s *HTTPServer) handleRPC(w http.ResponseWriter, r *http.Request) {
 	if uploadRoot != "" {
 		fileRoots = append(fileRoots, uploadRoot)
 	}
-	requestCtx := WithSurface(r.Context(), SurfaceHTTP)
+	requestCtx := withProfileScope(WithSurface(r.Context(), SurfaceHTTP), scope)
 	requestCtx = WithFileRoots(requestCtx, fileRoots)
 	requestCtx = WithConfirmation(requestCtx, httpRequestConfirmed(r))
+	requestCtx = WithTrace(requestCtx, req.runID, req.traceID)
 	if operations.HasSchema(req.method) {
 		if err := operations.ValidateParams(req.method, params); err != nil {
 			rpcErr := NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation, err.Error(), nil)
@@ -0,0 +1,179 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-telegram/internal/observability"
+	"agent-telegram/internal/operations"
+)
+
+// SetBatchConcurrency sets how many elements of a POST /rpc batch run in
+// parallel by default. Callers may lower or raise it per request with
+// ?concurrency=N up to MaxBatchConcurrency.
+func (s *HTTPServer) SetBatchConcurrency(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchConcurrency = min(max(n, 1), MaxBatchConcurrency)
+}
+
+// handleRPCBatch serves standard JSON-RPC 2.0 over POST /rpc. The body is a
+// single request object or a batch array; every element is validated,
+// policy-checked and audited independently.
+func (s *HTTPServer) handleRPCBatch(w http.ResponseWriter, r *http.Request) {
+	body, rpcErr, status := readHTTPRPCBody(r)
+	if rpcErr != nil {
+		writeJSONResponse(w, status, &Response{JSONRPC: "2.0", Error: rpcErr})
+		return
+	}
+	limit, rpcErr := s.httpBatchConcurrency(r)
+	if rpcErr != nil {
+		writeJSONResponse(w, http.StatusBadRequest, &Response{JSONRPC: "2.0", Error: rpcErr})
+		return
+	}
+
+	if !json.Valid(body) {
+		writeJSONResponse(w, http.StatusBadRequest, &Response{JSONRPC: "2.0", Error: ErrParseError})
+		return
+	}
+
+	ctx := s.httpBatchContext(r)
+	if !isBatch(body) {
+		resp := s.callHTTPBatchElement(ctx, r, body)
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, resp)
+		return
+	}
+
+	elements, rpcErr := decodeBatch(body)
+	if rpcErr != nil {
+		writeJSONResponse(w, http.StatusBadRequest, &Response{JSONRPC: "2.0", Error: rpcErr})
+		return
+	}
+	responses := runBatch(ctx, len(elements), limit, func(ctx context.Context, i int) *Response {
+		return s.callHTTPBatchElement(ctx, r, elements[i])
+	})
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, responses)
+}
+
+func (s *HTTPServer) httpBatchConcurrency(r *http.Request) (int, *ErrorObject) {
+	raw := strings.TrimSpace(r.URL.Query().Get("concurrency"))
+	if raw == "" {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.batchConcurrency, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > MaxBatchConcurrency {
+		return 0, NewTypedError(ErrCodeInvalidRequest, ErrorTypeValidation,
+			fmt.Sprintf("concurrency must be between 1 and %d", MaxBatchConcurrency), nil)
+	}
+	return n, nil
+}
+
+func (s *HTTPServer) httpBatchContext(r *http.Request) context.Context {
+	s.mu.RLock()
+	fileRoots := append([]string(nil), s.fileRoots...)
+	s.mu.RUnlock()
+	ctx := WithSurface(r.Context(), SurfaceHTTP)
+	return WithFileRoots(ctx, fileRoots)
+}
+
+// callHTTPBatchElement runs one JSON-RPC request received over HTTP. It
+// returns nil for notifications.
+func (s *HTTPServer) callHTTPBatchElement(ctx context.Context, r *http.Request, raw json.RawMessage) *Response {
+	req, ok := decodeBatchElement(raw)
+	if !ok {
+		return &Response{JSONRPC: "2.0", Error: ErrInvalidRequest}
+	}
+	call := httpRPCRequest{start: time.Now(), method: req.Method, runID: req.RunID, traceID: req.TraceID}
+	if call.runID == "" {
+		call.runID = strings.TrimSpace(r.Header.Get("X-Run-Id"))
+	}
+	if call.runID != "" {
+		call.runID = observability.SanitizeRunID(call.runID)
+	}
+	if call.traceID == "" {
+		call.traceID = observability.NewTraceID()
+	}
+
+	params := req.Params
+	if len(params) == 0 {
+		params = json.RawMessage(`{}`)
+	}
+	var rpcErr *ErrorObject
+	// A profile on the element wins over the request header.
+	profile := req.Profile
+	if profile == "" {
+		profile = r.Header.Get(ProfileHeader)
+	}
+	var result any
+	call.scope, rpcErr = s.profiles.resolve(profile)
+	if rpcErr == nil {
+		elementCtx := WithTrace(withProfileScope(ctx, call.scope), call.runID, call.traceID)
+		result, rpcErr = s.invokeHTTPBatchElement(elementCtx, r, req, params)
+	}
+	if rpcErr != nil {
+		s.writeHTTPAudit(call.scope, call.runID, call.traceID, call.method, params, nil, rpcErr, time.Since(call.start), false)
+	} else {
+		s.writeHTTPAudit(call.scope, call.runID, call.traceID, call.method, params, result, nil, time.Since(call.start), false)
+	}
+	if req.ID == nil {
+		return nil
+	}
+	return &Response{
+		JSONRPC: "2.0",
+		Result:  result,
+		Error:   rpcErr,
+		ID:      req.ID,
+		RunID:   call.runID,
+		TraceID: call.traceID,
+	}
+}
+
+func (s *HTTPServer) invokeHTTPBatchElement(
+	ctx context.Context,
+	r *http.Request,
+	req *Request,
+	params json.RawMessage,
+) (result any, rpcErr *ErrorObject) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, ErrInvalidRequest
+	}
+	handler, ok := s.lookupHandler(profileScopeFromContext(ctx), req.Method)
+	if !ok {
+		return nil, NewTypedError(ErrCodeMethodNotFound, ErrorTypeMethodNotFound, "method not found", nil)
+	}
+	if operations.HasSchema(req.Method) {
+		if err := operations.ValidateParams(req.Method, params); err != nil {
+			return nil, NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation, err.Error(), nil)
+		}
+	}
+	ctx = WithConfirmation(ctx, req.Confirm || httpRequestConfirmed(r))
+	if rpcErr := s.checkHTTPPolicy(ctx, req.Method, params); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// Batch elements may run on their own goroutines, where a panic would
+	// take down the whole server instead of one request.
+	defer func() {
+		if p := recover(); p != nil {
+			slog.Error("http: handler panic", "method", req.Method, "panic", p)
+			result, rpcErr = nil, &ErrorObject{Code: ErrCodeInternalError, Message: fmt.Sprintf("Handler panic: %v", p)}
+		}
+	}()
+	return handler(ctx, params)
+}
@@ -0,0 +1,400 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
+)
+
+const (
+	// defaultEventHeartbeat keeps idle streams alive through proxies and lets
+	// clients detect dead connections.
+	defaultEventHeartbeat = 15 * time.Second
+	// eventsMethod is the operation checked by policy for /events and /ws.
+	eventsMethod = "subscribe_updates"
+	// accessTokenParam carries the bearer token for browser EventSource and
+	// WebSocket clients, which cannot set an Authorization header.
+	accessTokenParam = "access_token"
+)
+
+// Event kinds sent on /events and /ws.
+const (
+	eventReady     = "ready"
+	eventUpdate    = "update"
+	eventGap       = "gap"
+	eventHeartbeat = "heartbeat"
+)
+
+// streamEvent is one frame on an update stream. ID is the resume cursor
+// ("<epoch>:<offset>") for ready, update and gap frames.
+type streamEvent struct {
+	Type       string              `json:"type"`
+	ID         string              `json:"id,omitempty"`
+	Update     *types.StoredUpdate `json:"update,omitempty"`
+	Epoch      string              `json:"epoch,omitempty"`
+	NextOffset int64               `json:"nextOffset,omitempty"`
+	Time       int64               `json:"time,omitempty"`
+}
+
+// eventStreamRequest is a parsed /events or /ws request.
+type eventStreamRequest struct {
+	params types.SubscribeUpdatesParams
+	// resumed is set when the cursor came from Last-Event-ID.
+	resumed bool
+}
+
+// SetUpdateFeed enables the GET /events (Server-Sent Events) and GET /ws
+// (WebSocket) update streams. Without a feed both endpoints return 503.
+func (s *HTTPServer) SetUpdateFeed(src updatefeed.Source, feed *updatefeed.Feed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateSource = src
+	s.updateFeed = feed
+}
+
+// updatesFor returns the update source and feed of the profile a stream was
+// routed to.
+func (s *HTTPServer) updatesFor(ctx context.Context) (updatefeed.Source, *updatefeed.Feed) {
+	if scope := profileScopeFromContext(ctx); scope != nil {
+		return scope.updates()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updateSource, s.updateFeed
+}
+
+func (s *HTTPServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	stream, ok := s.openEventStream(w, r)
+	if !ok {
+		return
+	}
+	defer stream.cancel()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	err := s.streamUpdates(stream.ctx, stream.req, func(event streamEvent) error {
+		if err := writeSSEEvent(w, event); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+	logStreamEnd("sse", err)
+}
+
+func (s *HTTPServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	stream, ok := s.openEventStream(w, r)
+	if !ok {
+		return
+	}
+	defer stream.cancel()
+
+	conn, err := websocket.Accept(w, r, s.websocketAcceptOptions())
+	if err != nil {
+		slog.Debug("http: websocket accept failed", "error", err)
+		return
+	}
+	defer func() { _ = conn.CloseNow() }()
+
+	// Clients only receive; CloseRead handles control frames and cancels ctx
+	// when the peer closes the connection.
+	ctx := conn.CloseRead(stream.ctx)
+	err = s.streamUpdates(ctx, stream.req, func(event streamEvent) error {
+		return wsjson.Write(ctx, conn, event)
+	})
+	logStreamEnd("websocket", err)
+	if errors.Is(err, context.Canceled) {
+		_ = conn.Close(websocket.StatusGoingAway, "")
+		return
+	}
+	_ = conn.Close(websocket.StatusInternalError, "stream ended")
+}
+
+// eventStream is an accepted /events or /ws request.
+type eventStream struct {
+	ctx    context.Context
+	cancel func()
+	req    eventStreamRequest
+}
+
+// openEventStream validates the request and policy and prepares the
+// connection for a long-lived response. It writes an error response and
+// returns false on failure.
+func (s *HTTPServer) openEventStream(w http.ResponseWriter, r *http.Request) (eventStream, bool) {
+	scope, rpcErr := s.resolveProfile(r)
+	if rpcErr != nil {
+		writeJSONResponse(w, errorToHTTPStatus(rpcErr), map[string]any{"ok": false, "error": errorResponse(rpcErr)})
+		return eventStream{}, false
+	}
+	requestCtx := withProfileScope(WithSurface(r.Context(), SurfaceHTTP), scope)
+	if src, feed := s.updatesFor(requestCtx); src == nil || feed == nil {
+		writeJSONResponse(w, http.StatusServiceUnavailable, map[string]any{
+			"ok":    false,
+			"error": map[string]any{"code": ErrCodeNotInitialized, "message": "update stream is not available"},
+		})
+		return eventStream{}, false
+	}
+
+	req, rpcErr := parseEventStreamRequest(r)
+	if rpcErr == nil {
+		params, _ := json.Marshal(req.params)
+		rpcErr = s.checkHTTPPolicy(requestCtx, eventsMethod, params)
+	}
+	if rpcErr != nil {
+		writeJSONResponse(w, errorToHTTPStatus(rpcErr), map[string]any{"ok": false, "error": errorResponse(rpcErr)})
+		return eventStream{}, false
+	}
+
+	// Streams outlive the server read/write timeouts.
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(withProfileScope(r.Context(), scope))
+	stop := context.AfterFunc(s.streamCtx, cancel)
+	return eventStream{ctx: ctx, cancel: func() { stop(); cancel() }, req: req}, true
+}
+
+// streamUpdates sends a ready frame with the starting cursor, then matching
+// updates and periodic heartbeats until ctx ends or send fails.
+func (s *HTTPServer) streamUpdates(ctx context.Context, req eventStreamRequest, send func(streamEvent) error) error {
+	src, feed := s.updatesFor(ctx)
+	s.mu.RLock()
+	heartbeat := s.eventHeartbeat
+	s.mu.RUnlock()
+
+	filter := updatefeed.Filter{Peer: req.params.Peer, Types: req.params.Types, Direction: req.params.Direction}
+	filter.Allow = s.eventPolicyFilter(ctx)
+	wake, stopWatch := feed.Watch()
+	defer stopWatch()
+	live, stopLive := feed.WatchLive()
+	defer stopLive()
+	cursor := updatefeed.Cursor{Offset: req.params.Offset, Epoch: req.params.Epoch}
+	if head := updatefeed.Head(src); req.params.Offset == 0 && !req.resumed {
+		cursor = head
+	} else if cursor.Epoch == "" {
+		cursor.Epoch = head.Epoch
+	}
+
+	var mu sync.Mutex
+	write := func(event streamEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return send(event)
+	}
+	ready := streamEvent{Type: eventReady, ID: eventID(cursor.Epoch, cursor.Offset), Epoch: cursor.Epoch,
+		NextOffset: cursor.Offset}
+	if err := write(ready); err != nil {
+		return err
+	}
+
+	// The heartbeat goroutine must stop before the handler returns and the
+	// response writer becomes invalid.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	wg.Go(func() { sendHeartbeats(ctx, heartbeat, write, cancel) })
+
+	err := updatefeed.Follow(ctx, src, wake, live, filter, cursor, func(batch updatefeed.Batch) error {
+		for _, event := range batchEvents(batch) {
+			if err := write(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+		return cause
+	}
+	return err
+}
+
+// sendHeartbeats writes a heartbeat frame every interval until ctx ends. A
+// failed write cancels the stream.
+func sendHeartbeats(
+	ctx context.Context,
+	interval time.Duration,
+	write func(streamEvent) error,
+	cancel context.CancelCauseFunc,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := write(streamEvent{Type: eventHeartbeat, Time: now.Unix()}); err != nil {
+				cancel(err)
+				return
+			}
+		}
+	}
+}
+
+// batchEvents flattens a feed batch into stream frames. A gap frame comes
+// first so clients can reset state before the updates that follow it.
+func batchEvents(batch updatefeed.Batch) []streamEvent {
+	events := make([]streamEvent, 0, len(batch.Updates)+1)
+	if batch.Gap {
+		events = append(events, streamEvent{Type: eventGap, Epoch: batch.Epoch, NextOffset: batch.NextOffset})
+	}
+	for i := range batch.Updates {
+		update := batch.Updates[i]
+		event := streamEvent{Type: eventUpdate, Update: &update}
+		if !update.Live {
+			// Live updates are not stored, so they cannot be resumed from.
+			event.ID = eventID(batch.Epoch, update.ID)
+		}
+		events = append(events, event)
+	}
+	if batch.Gap && len(batch.Updates) == 0 {
+		events[0].ID = eventID(batch.Epoch, batch.NextOffset)
+	}
+	return events
+}
+
+// eventPolicyFilter re-checks peer policy for every delivered update, so a
+// stream without a peer filter never leaks chats the policy denies and picks
+// up reloaded policies immediately.
+func (s *HTTPServer) eventPolicyFilter(ctx context.Context) func(types.StoredUpdate) bool {
+	policyChecker := s.policyFor(ctx)
+	if policyChecker == nil {
+		return nil
+	}
+	ctx = WithSurface(ctx, SurfaceHTTP)
+	return func(update types.StoredUpdate) bool {
+		peer := updatefeed.UpdatePeer(update)
+		if peer == "" {
+			return true
+		}
+		params, _ := json.Marshal(map[string]string{"peer": peer})
+		return policyChecker.Check(ctx, eventsMethod, params) == nil
+	}
+}
+
+// parseEventStreamRequest reads filters from the query string and the resume
+// cursor from the Last-Event-ID header, the lastEventId query parameter, or
+// offset/epoch, in that order.
+func parseEventStreamRequest(r *http.Request) (eventStreamRequest, *ErrorObject) {
+	query := r.URL.Query()
+	var req eventStreamRequest
+	req.params.Peer = strings.TrimSpace(query.Get("peer"))
+	req.params.Direction = strings.TrimSpace(query.Get("direction"))
+	for _, value := range query["types"] {
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				req.params.Types = append(req.params.Types, types.UpdateType(item))
+			}
+		}
+	}
+
+	lastEventID := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if lastEventID == "" {
+		lastEventID = strings.TrimSpace(query.Get("lastEventId"))
+	}
+	var err error
+	switch {
+	case lastEventID != "":
+		req.params.Epoch, req.params.Offset, err = parseEventID(lastEventID)
+		req.resumed = true
+	case query.Get("offset") != "":
+		req.params.Offset, err = strconv.ParseInt(query.Get("offset"), 10, 64)
+		req.params.Epoch = query.Get("epoch")
+	}
+	if err == nil {
+		err = req.params.Validate()
+	}
+	if err != nil {
+		return req, NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation, err.Error(), nil)
+	}
+	return req, nil
+}
+
+func eventID(epoch string, offset int64) string {
+	return epoch + ":" + strconv.FormatInt(offset, 10)
+}
+
+func parseEventID(id string) (string, int64, error) {
+	sep := strings.LastIndex(id, ":")
+	if sep < 0 {
+		return "", 0, fmt.Errorf("invalid Last-Event-ID %q: want <epoch>:<offset>", id)
+	}
+	offset, err := strconv.ParseInt(id[sep+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid Last-Event-ID %q: want <epoch>:<offset>", id)
+	}
+	return id[:sep], offset, nil
+}
+
+func writeSSEEvent(w http.ResponseWriter, event streamEvent) error {
+	var b strings.Builder
+	if event.Type == eventHeartbeat {
+		// Comment frames keep the connection alive without waking listeners.
+		fmt.Fprintf(&b, ": heartbeat %d\n\n", event.Time)
+		_, err := w.Write([]byte(b.String()))
+		return err
+	}
+	data, err := json.Marshal(eventPayload(event))
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	if event.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", event.ID)
+	}
+	fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", event.Type, data)
+	_, err = w.Write([]byte(b.String()))
+	return err
+}
+
+// eventPayload is the SSE data field: the stored update itself for update
+// events and the cursor otherwise.
+func eventPayload(event streamEvent) any {
+	if event.Update != nil {
+		return event.Update
+	}
+	return map[string]any{"epoch": event.Epoch, "nextOffset": event.NextOffset}
+}
+
+func (s *HTTPServer) websocketAcceptOptions() *websocket.AcceptOptions {
+	opts := &websocket.AcceptOptions{}
+	switch {
+	case s.cors == "*":
+		opts.InsecureSkipVerify = true
+	case s.cors != "":
+		for item := range strings.SplitSeq(s.cors, ",") {
+			if u, err := url.Parse(strings.TrimSpace(item)); err == nil && u.Host != "" {
+				opts.OriginPatterns = append(opts.OriginPatterns, u.Host)
+			}
+		}
+	}
+	return opts
+}
+
+func isEventStreamPath(path string) bool {
+	return path == "/events" || path == "/ws"
+}
+
+func logStreamEnd(kind string, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Debug("http: update stream ended", "stream", kind, "error", err)
+	}
+}
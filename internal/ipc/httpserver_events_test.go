@@ -0,0 +1,227 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram"
+	"agent-telegram/telegram/types"
+)
+
+type eventStoreSource struct{ store *telegram.UpdateStore }
+
+func (s eventStoreSource) GetUpdatePage(limit int, offset int64, epoch string) telegram.UpdatePage {
+	return s.store.Page(limit, offset, epoch)
+}
+
+// denyPeerChecker denies calls naming one peer.
+type denyPeerChecker struct{ peer string }
+
+func (c denyPeerChecker) Check(_ context.Context, method string, params json.RawMessage) error {
+	if strings.Contains(string(params), `"`+c.peer+`"`) {
+		return NewPolicyDeniedError(method, "peer "+c.peer+" is denied")
+	}
+	return nil
+}
+
+func eventMessage(peer string) types.StoredUpdate {
+	return types.StoredUpdate{
+		Type: types.UpdateTypeNewMessage,
+		Data: map[string]any{"message": map[string]any{"peer": peer}},
+	}
+}
+
+func newEventTestServer(t *testing.T) (*HTTPServer, *telegram.UpdateStore, *httptest.Server) {
+	t.Helper()
+	store := telegram.NewUpdateStore(10)
+	feed := updatefeed.New()
+	store.SetOnUpdate(feed.Publish)
+	srv := NewHTTPServer(0, "secret", "")
+	srv.SetUpdateFeed(eventStoreSource{store}, feed)
+	srv.SetPolicyChecker(denyPeerChecker{peer: "user:9"})
+	ts := httptest.NewServer(srv.srv.Handler)
+	t.Cleanup(func() {
+		srv.streamCancel()
+		ts.Close()
+	})
+	return srv, store, ts
+}
+
+// sseReader returns the next event's fields, skipping comment frames unless
+// they are requested.
+type sseReader struct{ scanner *bufio.Scanner }
+
+func (r sseReader) next(t *testing.T) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if comment, ok := strings.CutPrefix(line, ":"); ok {
+			fields["comment"] = strings.TrimSpace(comment)
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		fields[key] = value
+	}
+	t.Fatalf("stream ended: %v", r.scanner.Err())
+	return nil
+}
+
+func TestHTTPEventsResumesFromLastEventID(t *testing.T) {
+	_, store, ts := newEventTestServer(t)
+	store.Add(eventMessage("user:1"))
+	store.Add(eventMessage("user:9"))
+	store.Add(eventMessage("user:2"))
+	epoch := store.Page(1, 0, "").Epoch
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events", http.NoBody)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Last-Event-ID", epoch+":1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, content type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	events := sseReader{bufio.NewScanner(resp.Body)}
+
+	if ready := events.next(t); ready["event"] != eventReady || ready["id"] != epoch+":1" {
+		t.Fatalf("ready = %v", ready)
+	}
+	// Update 2 is for a denied peer and must be filtered out.
+	if got := events.next(t); got["event"] != eventUpdate || got["id"] != epoch+":3" {
+		t.Fatalf("resumed event = %v", got)
+	}
+
+	store.Add(eventMessage("user:4"))
+	got := events.next(t)
+	var update types.StoredUpdate
+	if err := json.Unmarshal([]byte(got["data"]), &update); err != nil {
+		t.Fatal(err)
+	}
+	if got["id"] != epoch+":4" || update.ID != 4 {
+		t.Fatalf("live event = %v", got)
+	}
+}
+
+func TestHTTPEventsSendsHeartbeats(t *testing.T) {
+	srv, _, ts := newEventTestServer(t)
+	srv.eventHeartbeat = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events?access_token=secret", http.NoBody)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), ": heartbeat") {
+			return
+		}
+	}
+	t.Fatalf("no heartbeat before stream ended: %v", scanner.Err())
+}
+
+func TestHTTPEventsRequiresAuthAndPolicy(t *testing.T) {
+	_, _, ts := newEventTestServer(t)
+
+	cases := []struct {
+		path   string
+		header string
+		want   int
+	}{
+		{"/events", "", http.StatusUnauthorized},
+		{"/events?access_token=wrong", "", http.StatusUnauthorized},
+		{"/events?peer=user:9", "Bearer secret", http.StatusForbidden},
+		{"/events?direction=sideways", "Bearer secret", http.StatusBadRequest},
+		{"/ws", "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL+tc.path, http.NoBody)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.path, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestHTTPEventsUnavailableWithoutFeed(t *testing.T) {
+	srv := NewHTTPServer(0, "", "")
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", http.NoBody))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHTTPWebSocketStreamsUpdates(t *testing.T) {
+	_, store, ts := newEventTestServer(t)
+	store.Add(eventMessage("user:1"))
+	epoch := store.Page(1, 0, "").Epoch
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws?access_token=secret&types=new_message&lastEventId=" + epoch + ":0"
+	conn, resp, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	defer func() { _ = conn.CloseNow() }()
+
+	var ready, replayed, live streamEvent
+	if err := wsjson.Read(ctx, conn, &ready); err != nil || ready.Type != eventReady {
+		t.Fatalf("ready = %+v, err = %v", ready, err)
+	}
+	if err := wsjson.Read(ctx, conn, &replayed); err != nil || replayed.Update == nil || replayed.Update.ID != 1 {
+		t.Fatalf("replayed = %+v, err = %v", replayed, err)
+	}
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeOther, Data: map[string]any{}})
+	store.Add(eventMessage("user:2"))
+	if err := wsjson.Read(ctx, conn, &live); err != nil || live.ID != epoch+":3" {
+		t.Fatalf("live = %+v, err = %v", live, err)
+	}
+	_ = conn.Close(websocket.StatusNormalClosure, "")
+}
+
+func TestParseEventID(t *testing.T) {
+	epoch, offset, err := parseEventID("abc:def:42")
+	if err != nil || epoch != "abc:def" || offset != 42 {
+		t.Fatalf("parseEventID = %q, %d, %v", epoch, offset, err)
+	}
+	if _, _, err := parseEventID("42"); err == nil {
+		t.Fatal("parseEventID accepted an ID without epoch")
+	}
+}
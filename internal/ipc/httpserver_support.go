@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +27,8 @@ type httpRPCRequest struct {
 	method  string
 	runID   string
 	traceID string
+	// scope is the profile the request was routed to; nil for the primary.
+	scope *ProfileScope
 }
 
 func (s *HTTPServer) authMiddleware(next http.Handler) http.Handler {
@@ -35,6 +40,10 @@ func (s *HTTPServer) authMiddleware(next http.Handler) http.Handler {
 		}
 
 		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok && isEventStreamPath(r.URL.Path) {
+			token = r.URL.Query().Get(accessTokenParam)
+			ok = token != ""
+		}
 		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.secret)) != 1 {
 			writeJSONResponse(w, http.StatusUnauthorized, map[string]any{
 				"ok":    false,
@@ -53,7 +62,7 @@ func (s *HTTPServer) corsMiddleware(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Vary", "Origin")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+ProfileHeader)
 		}
 
 		if r.Method == http.MethodOptions {
@@ -132,6 +141,7 @@ func errorResponse(err *ErrorObject) map[string]any {
 }
 
 func (s *HTTPServer) writeHTTPAudit(
+	scope *ProfileScope,
 	runID string,
 	traceID, method string,
 	params, result any,
@@ -159,7 +169,11 @@ func (s *HTTPServer) writeHTTPAudit(
 	} else {
 		event.ResultSummary = observability.SummarizeResult(result)
 	}
-	if err := observability.WriteAudit("", event); err != nil {
+	auditSocket := ""
+	if scope != nil {
+		auditSocket = scope.audit()
+	}
+	if err := observability.WriteAudit(auditSocket, event); err != nil {
 		slog.Warn("http audit write failed", "trace_id", traceID, "error", err)
 	}
 }
@@ -224,7 +238,15 @@ func newHTTPRPCRequest(w http.ResponseWriter, r *http.Request) httpRPCRequest {
 	}
 }
 
-func (s *HTTPServer) lookupHandler(method string) (Handler, bool) {
+func (s *HTTPServer) lookupHandler(scope *ProfileScope, method string) (Handler, bool) {
+	if scope != nil {
+		if handler, ok := scope.handler(method); ok {
+			return handler, true
+		}
+		if !slices.Contains(sharedMethods, method) {
+			return nil, false
+		}
+	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	handler, ok := s.methods[method]
@@ -271,12 +293,14 @@ func readHTTPMultipartPayload(
 			_ = r.MultipartForm.RemoveAll()
 		}
 	}
-	file, header, err := r.FormFile("file")
-	if err != nil {
+	var headers []*multipart.FileHeader
+	if r.MultipartForm != nil {
+		headers = r.MultipartForm.File["file"]
+	}
+	if len(headers) == 0 {
 		rpcErr = NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation, "multipart file field is required", nil)
 		return nil, "", cleanup, rpcErr, http.StatusBadRequest
 	}
-	defer func() { _ = file.Close() }()
 
 	dir, err := os.MkdirTemp("", "agent-telegram-upload-*")
 	if err != nil {
@@ -288,27 +312,20 @@ func readHTTPMultipartPayload(
 		_ = os.RemoveAll(dir)
 		formCleanup()
 	}
-	name := filepath.Base(header.Filename)
-	if name == "." || name == string(filepath.Separator) || name == "" {
-		name = "upload.bin"
-	}
-	path := filepath.Join(dir, name)
-	output, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
-	if err != nil {
-		cleanup()
-		rpcErr = NewTypedError(ErrCodeInternalError, ErrorTypeInternal, "create upload file", nil)
-		return nil, "", func() {}, rpcErr, http.StatusInternalServerError
-	}
-	if _, err := io.Copy(output, file); err != nil {
-		_ = output.Close()
-		cleanup()
-		rpcErr = NewTypedError(ErrCodeInvalidRequest, ErrorTypeValidation, "store upload file", nil)
-		return nil, "", func() {}, rpcErr, http.StatusBadRequest
-	}
-	if err := output.Close(); err != nil {
-		cleanup()
-		rpcErr = NewTypedError(ErrCodeInternalError, ErrorTypeInternal, "close upload file", nil)
-		return nil, "", func() {}, rpcErr, http.StatusInternalServerError
+	paths := make([]string, len(headers))
+	for i, header := range headers {
+		// Each part gets its own directory so equal file names do not clash
+		// and Telegram still sees the original name.
+		partDir := dir
+		if len(headers) > 1 {
+			partDir = filepath.Join(dir, strconv.Itoa(i+1))
+		}
+		path, rpcErr, status := storeMultipartFile(partDir, header)
+		if rpcErr != nil {
+			cleanup()
+			return nil, "", func() {}, rpcErr, status
+		}
+		paths[i] = path
 	}
 
 	params := map[string]any{}
@@ -319,7 +336,10 @@ func readHTTPMultipartPayload(
 			return nil, "", func() {}, rpcErr, http.StatusBadRequest
 		}
 	}
-	params["file"] = path
+	if rpcErr := attachMultipartFiles(params, paths); rpcErr != nil {
+		cleanup()
+		return nil, "", func() {}, rpcErr, http.StatusBadRequest
+	}
 	body, err = json.Marshal(params)
 	if err != nil {
 		cleanup()
@@ -329,6 +349,73 @@ func readHTTPMultipartPayload(
 	return body, dir, cleanup, nil, http.StatusOK
 }
 
+// storeMultipartFile copies an uploaded part into dir under its base name.
+func storeMultipartFile(dir string, header *multipart.FileHeader) (string, *ErrorObject, int) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", NewTypedError(ErrCodeInternalError, ErrorTypeInternal, "create upload directory", nil),
+			http.StatusInternalServerError
+	}
+	file, err := header.Open()
+	if err != nil {
+		return "", NewTypedError(ErrCodeInvalidRequest, ErrorTypeValidation, "read upload file", nil),
+			http.StatusBadRequest
+	}
+	defer func() { _ = file.Close() }()
+
+	name := filepath.Base(header.Filename)
+	if name == "." || name == string(filepath.Separator) || name == "" {
+		name = "upload.bin"
+	}
+	path := filepath.Join(dir, name)
+	output, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", NewTypedError(ErrCodeInternalError, ErrorTypeInternal, "create upload file", nil),
+			http.StatusInternalServerError
+	}
+	if _, err := io.Copy(output, file); err != nil {
+		_ = output.Close()
+		return "", NewTypedError(ErrCodeInvalidRequest, ErrorTypeValidation, "store upload file", nil),
+			http.StatusBadRequest
+	}
+	if err := output.Close(); err != nil {
+		return "", NewTypedError(ErrCodeInternalError, ErrorTypeInternal, "close upload file", nil),
+			http.StatusInternalServerError
+	}
+	return path, nil, http.StatusOK
+}
+
+// attachMultipartFiles sets the stored upload paths on params. A single part
+// fills `file`; several parts, or params that already list `items`, fill the
+// `file` of each album item in order.
+func attachMultipartFiles(params map[string]any, paths []string) *ErrorObject {
+	rawItems, hasItems := params["items"]
+	if len(paths) == 1 && !hasItems {
+		params["file"] = paths[0]
+		return nil
+	}
+	items, ok := rawItems.([]any)
+	if !hasItems {
+		items, ok = make([]any, len(paths)), true
+	}
+	if !ok || len(items) != len(paths) {
+		return NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation,
+			"multipart file parts must match params items", nil)
+	}
+	for i, path := range paths {
+		item, ok := items[i].(map[string]any)
+		if items[i] == nil {
+			item, ok = map[string]any{}, true
+		}
+		if !ok {
+			return NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation, "params items must be objects", nil)
+		}
+		item["file"] = path
+		items[i] = item
+	}
+	params["items"] = items
+	return nil
+}
+
 func parseHTTPRPCParams(r *http.Request, body []byte) (json.RawMessage, bool, bool, *ErrorObject) {
 	params, dryRun, validateOnly, err := parseRPCBody(body)
 	if err != nil {
@@ -344,10 +431,23 @@ func parseHTTPRPCParams(r *http.Request, body []byte) (json.RawMessage, bool, bo
 	return params, dryRun, validateOnly, nil
 }
 
-func (s *HTTPServer) checkHTTPPolicy(ctx context.Context, method string, params json.RawMessage) *ErrorObject {
+// policyFor returns the policy of the profile a request was routed to.
+func (s *HTTPServer) policyFor(ctx context.Context) PolicyChecker {
+	if scope := profileScopeFromContext(ctx); scope != nil {
+		return scope.policyChecker()
+	}
 	s.mu.RLock()
-	policyChecker := s.policy
-	s.mu.RUnlock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+// resolveProfile routes a request by its profile header.
+func (s *HTTPServer) resolveProfile(r *http.Request) (*ProfileScope, *ErrorObject) {
+	return s.profiles.resolve(r.Header.Get(ProfileHeader))
+}
+
+func (s *HTTPServer) checkHTTPPolicy(ctx context.Context, method string, params json.RawMessage) *ErrorObject {
+	policyChecker := s.policyFor(ctx)
 	if policyChecker == nil {
 		return nil
 	}
@@ -369,7 +469,7 @@ func (s *HTTPServer) writeRPCError(
 	status int,
 	auditDryRun bool,
 ) {
-	s.writeHTTPAudit(req.runID, req.traceID, req.method, params, nil, rpcErr, time.Since(req.start), auditDryRun)
+	s.writeHTTPAudit(req.scope, req.runID, req.traceID, req.method, params, nil, rpcErr, time.Since(req.start), auditDryRun)
 	writeJSONResponse(w, status, map[string]any{
 		"ok":      false,
 		"runId":   req.runID,
@@ -393,7 +493,7 @@ func (s *HTTPServer) handleHTTPDryRun(
 
 	op, _ := operations.Get(req.method)
 	result := map[string]any{"dryRun": dryRun, "validateOnly": validateOnly}
-	s.writeHTTPAudit(req.runID, req.traceID, req.method, params, result, nil, time.Since(req.start), true)
+	s.writeHTTPAudit(req.scope, req.runID, req.traceID, req.method, params, result, nil, time.Since(req.start), true)
 	writeJSONResponse(w, http.StatusOK, map[string]any{
 		"ok":           true,
 		"runId":        req.runID,
@@ -408,7 +508,7 @@ func (s *HTTPServer) handleHTTPDryRun(
 }
 
 func (s *HTTPServer) writeRPCSuccess(w http.ResponseWriter, req httpRPCRequest, params, result any) {
-	s.writeHTTPAudit(req.runID, req.traceID, req.method, params, result, nil, time.Since(req.start), false)
+	s.writeHTTPAudit(req.scope, req.runID, req.traceID, req.method, params, result, nil, time.Since(req.start), false)
 	writeJSONResponse(w, http.StatusOK, map[string]any{
 		"ok":      true,
 		"runId":   req.runID,
@@ -465,3 +565,9 @@ func (w *statusWriter) WriteHeader(status int) {
 	w.status = status
 	w.ResponseWriter.WriteHeader(status)
 }
+
+// Unwrap lets http.ResponseController and WebSocket upgrades reach the
+// underlying writer for flushing, deadlines and hijacking.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -115,6 +116,62 @@ func TestHTTPServerMultipartUploadIsTemporary(t *testing.T) {
 	}
 }
 
+func TestHTTPServerMultipartAlbumFillsItems(t *testing.T) {
+	srv := NewHTTPServer(0, "secret", "")
+	var items []struct {
+		File    string `json:"file"`
+		Caption string `json:"caption"`
+	}
+	srv.Register("send_album", func(_ context.Context, params json.RawMessage) (interface{}, *ErrorObject) {
+		var payload struct {
+			Items json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(params, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(payload.Items, &items); err != nil {
+			t.Fatal(err)
+		}
+		return map[string]any{"ok": true}, nil
+	})
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("params", `{"peer":"@user","items":[{"caption":"first"},{}]}`); err != nil {
+		t.Fatal(err)
+	}
+	// Both parts share a name; each must still be stored.
+	for _, content := range []string{"one", "two"} {
+		part, err := writer.CreateFormFile("file", "photo.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/send_album", &body)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(items) != 2 || items[0].Caption != "first" || items[0].File == items[1].File {
+		t.Fatalf("items = %+v", items)
+	}
+	for _, item := range items {
+		if filepath.Base(item.File) != "photo.jpg" {
+			t.Fatalf("item file = %q, want original name", item.File)
+		}
+	}
+}
+
 func TestHTTPServerDryRunValidatesWithoutExecuting(t *testing.T) {
 	called := false
 	srv := NewHTTPServer(0, "secret", "")
@@ -0,0 +1,157 @@
+package ipc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"agent-telegram/internal/updatefeed"
+)
+
+// ProfileHeader routes an HTTP request to a hosted session profile, like the
+// "profile" field of a JSON-RPC request.
+const ProfileHeader = "X-Agent-Telegram-Profile"
+
+// sharedMethods are the account-independent methods a profile scope serves
+// with the server's own handlers. Every other method must be registered on
+// the scope, so a request for one account never runs on another.
+var sharedMethods = []string{"ping", "echo", "shutdown"}
+
+// ProfileScope holds the handlers, policy and journals of one additional
+// account hosted by a server. Requests naming its profile are dispatched
+// here; the shared methods fall back to the server's own handlers but still
+// pass the scope's policy, and any other method it does not register is not
+// found.
+type ProfileScope struct {
+	name         string
+	mu           sync.RWMutex
+	methods      map[string]Handler
+	policy       PolicyChecker
+	auditSocket  string
+	updateSource updatefeed.Source
+	updateFeed   *updatefeed.Feed
+}
+
+// Name returns the session profile served by the scope.
+func (p *ProfileScope) Name() string { return p.name }
+
+// Register implements MethodRegistrar.
+func (p *ProfileScope) Register(name string, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.methods[name] = handler
+}
+
+// SetPolicyChecker sets the policy applied to every request for the profile.
+func (p *ProfileScope) SetPolicyChecker(policy PolicyChecker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policy = policy
+}
+
+// SetAuditSocket selects the audit journal for requests the server records
+// itself (batch elements and HTTP calls).
+func (p *ProfileScope) SetAuditSocket(instance string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.auditSocket = instance
+}
+
+// SetUpdateFeed enables HTTP update streams for the profile.
+func (p *ProfileScope) SetUpdateFeed(src updatefeed.Source, feed *updatefeed.Feed) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.updateSource = src
+	p.updateFeed = feed
+}
+
+func (p *ProfileScope) handler(method string) (Handler, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	handler, ok := p.methods[method]
+	return handler, ok
+}
+
+func (p *ProfileScope) policyChecker() PolicyChecker {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.policy
+}
+
+func (p *ProfileScope) audit() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.auditSocket
+}
+
+func (p *ProfileScope) updates() (updatefeed.Source, *updatefeed.Feed) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.updateSource, p.updateFeed
+}
+
+// profileRoutes maps profile names to scopes. The primary profile is served
+// by the server itself and has no scope.
+type profileRoutes struct {
+	mu      sync.RWMutex
+	primary string
+	scopes  map[string]*ProfileScope
+}
+
+func (r *profileRoutes) setPrimary(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.primary = strings.TrimSpace(name)
+}
+
+func (r *profileRoutes) add(name string) *ProfileScope {
+	name = strings.TrimSpace(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scopes == nil {
+		r.scopes = make(map[string]*ProfileScope)
+	}
+	if scope, ok := r.scopes[name]; ok {
+		return scope
+	}
+	scope := &ProfileScope{name: name, methods: make(map[string]Handler)}
+	r.scopes[name] = scope
+	return scope
+}
+
+// resolve returns the scope for a requested profile, or nil for the primary
+// profile. An empty name selects the primary profile.
+func (r *profileRoutes) resolve(name string) (*ProfileScope, *ErrorObject) {
+	name = strings.TrimSpace(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name == "" || name == r.primary {
+		return nil, nil
+	}
+	if scope, ok := r.scopes[name]; ok {
+		return scope, nil
+	}
+	profiles := append([]string{r.primary}, slices.Sorted(maps.Keys(r.scopes))...)
+	return nil, NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation,
+		fmt.Sprintf("profile %q is not served by this daemon", name),
+		map[string]any{"profile": name, "profiles": profiles})
+}
+
+type profileScopeContextKey struct{}
+
+// withProfileScope attaches the scope a request was routed to. The primary
+// profile has no scope.
+func withProfileScope(ctx context.Context, scope *ProfileScope) context.Context {
+	if scope == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, profileScopeContextKey{}, scope)
+}
+
+func profileScopeFromContext(ctx context.Context) *ProfileScope {
+	scope, _ := ctx.Value(profileScopeContextKey{}).(*ProfileScope)
+	return scope
+}
@@ -0,0 +1,95 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func whoami(name string) Handler {
+	return func(context.Context, json.RawMessage) (interface{}, *ErrorObject) {
+		return map[string]any{"account": name}, nil
+	}
+}
+
+func TestServerRoutesRequestsByProfile(t *testing.T) {
+	srv := NewServer()
+	srv.SetPrimaryProfile("support")
+	srv.Register("whoami", whoami("support"))
+	srv.Register("shutdown", whoami("server"))
+	checker := &testPolicyChecker{}
+	sales := srv.AddProfile("sales")
+	sales.SetPolicyChecker(checker)
+	sales.Register("whoami", whoami("sales"))
+
+	call := func(profile, method string) *Response {
+		return srv.handleRequest(context.Background(), &Request{JSONRPC: "2.0", Method: method, ID: 1, Profile: profile})
+	}
+	for profile, want := range map[string]string{"": "support", "support": "support", "sales": "sales"} {
+		resp := call(profile, "whoami")
+		if resp.Error != nil || resp.Result.(map[string]any)["account"] != want {
+			t.Fatalf("profile %q: response = %+v, want account %s", profile, resp, want)
+		}
+	}
+	if resp := call("sales", "shutdown"); resp.Error != nil || resp.Result.(map[string]any)["account"] != "server" {
+		t.Fatalf("shared method response = %+v", resp)
+	}
+	if checker.calls != 2 {
+		t.Fatalf("profile policy calls = %d, want 2", checker.calls)
+	}
+	srv.Register("get_me", whoami("support"))
+	if resp := call("sales", "get_me"); resp.Error == nil || resp.Error.Code != ErrCodeMethodNotFound {
+		t.Fatalf("primary-only method on a profile = %+v, want method not found", resp)
+	}
+
+	resp := call("ops", "whoami")
+	if resp.Error == nil || resp.Error.Code != ErrCodeInvalidParams || !strings.Contains(resp.Error.Message, `"ops"`) {
+		t.Fatalf("unknown profile response = %+v", resp)
+	}
+}
+
+func TestHTTPServerRoutesProfileHeader(t *testing.T) {
+	srv := NewHTTPServer(0, "", "")
+	srv.SetPrimaryProfile("support")
+	srv.Register("whoami", whoami("support"))
+	srv.AddProfile("sales").Register("whoami", whoami("sales"))
+
+	post := func(path, profile, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if profile != "" {
+			req.Header.Set(ProfileHeader, profile)
+		}
+		rec := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/rpc/whoami", "sales", `{}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"sales"`) {
+		t.Fatalf("header route = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post("/rpc/whoami", "", `{}`); !strings.Contains(rec.Body.String(), `"support"`) {
+		t.Fatalf("default route = %s", rec.Body.String())
+	}
+	if rec := post("/rpc/whoami", "ops", `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown profile status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	srv.Register("get_me", whoami("support"))
+	if rec := post("/rpc/get_me", "sales", `{}`); rec.Code == http.StatusOK || strings.Contains(rec.Body.String(), `"support"`) {
+		t.Fatalf("primary-only method on a profile = %d %s", rec.Code, rec.Body.String())
+	}
+
+	batch := `[{"jsonrpc":"2.0","method":"whoami","id":1},{"jsonrpc":"2.0","method":"whoami","id":2,"profile":"support"}]`
+	rec := post("/rpc", "sales", batch)
+	var responses []Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &responses); err != nil || len(responses) != 2 {
+		t.Fatalf("batch response = %s (%v)", rec.Body.String(), err)
+	}
+	for i, want := range []string{"sales", "support"} {
+		if account := responses[i].Result.(map[string]any)["account"]; account != want {
+			t.Fatalf("batch element %d account = %v, want %s", i, account, want)
+		}
+	}
+}
@@ -5,10 +5,16 @@ import "context"
 const (
 	SurfaceIPC  = "ipc"
 	SurfaceHTTP = "http"
+	SurfaceMCP  = "mcp"
+	// SurfaceRules marks operations run by the rules engine.
+	SurfaceRules = "rules"
 )
 
 type surfaceContextKey struct{}
 type fileRootsContextKey struct{}
+type traceContextKey struct{}
+
+type requestTrace struct{ runID, traceID string }
 
 // WithSurface annotates a request with its transport surface.
 func WithSurface(ctx context.Context, surface string) context.Context {
@@ -31,3 +37,15 @@ func FileRootsFromContext(ctx context.Context) []string {
 	roots, _ := ctx.Value(fileRootsContextKey{}).([]string)
 	return append([]string(nil), roots...)
 }
+
+// WithTrace attaches the run and trace IDs of a request so code below the
+// handler, such as Telegram flood control, can log against them.
+func WithTrace(ctx context.Context, runID, traceID string) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, requestTrace{runID: runID, traceID: traceID})
+}
+
+// TraceFromContext returns the run and trace IDs of a request.
+func TraceFromContext(ctx context.Context) (runID, traceID string) {
+	trace, _ := ctx.Value(traceContextKey{}).(requestTrace)
+	return trace.runID, trace.traceID
+}
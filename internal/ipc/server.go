@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -67,15 +68,19 @@ var (
 
 // Server represents a JSON-RPC server.
 type Server struct {
-	methods map[string]Handler
-	policy  PolicyChecker
-	mu      sync.RWMutex
+	methods          map[string]Handler
+	policy           PolicyChecker
+	batchConcurrency int
+	auditSocket      string
+	profiles         profileRoutes
+	mu               sync.RWMutex
 }
 
 // NewServer creates a new JSON-RPC server.
 func NewServer() *Server {
 	return &Server{
-		methods: make(map[string]Handler),
+		methods:          make(map[string]Handler),
+		batchConcurrency: BatchConcurrency(),
 	}
 }
 
@@ -93,32 +98,91 @@ func (s *Server) SetPolicyChecker(policy PolicyChecker) {
 	s.policy = policy
 }
 
+// SetBatchConcurrency sets how many batch elements run in parallel. Values
+// below 2 run elements sequentially in order.
+func (s *Server) SetBatchConcurrency(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchConcurrency = min(max(n, 1), MaxBatchConcurrency)
+}
+
+// SetAuditSocket selects the instance audit journal used for batch elements.
+func (s *Server) SetAuditSocket(socketPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditSocket = socketPath
+}
+
+// SetPrimaryProfile names the profile served by the server's own handlers.
+// Requests without a profile, or naming this one, go to it.
+func (s *Server) SetPrimaryProfile(name string) {
+	s.profiles.setPrimary(name)
+}
+
+// AddProfile hosts an additional profile and returns the scope its handlers
+// are registered on.
+func (s *Server) AddProfile(name string) *ProfileScope {
+	return s.profiles.add(name)
+}
+
 // Serve starts the JSON-RPC server on the given io.ReadWriteCloser.
+// The connection stays open between requests, so handlers may push
+// notifications through the Stream in their context.
 func (s *Server) Serve(ctx context.Context, rwc io.ReadWriteCloser) error {
-	defer func() { _ = rwc.Close() }()
+	connCtx, cancel := context.WithCancel(ctx)
+	stream := newStream(connCtx, json.NewEncoder(rwc))
+	defer func() {
+		cancel()
+		stream.close()
+		_ = rwc.Close()
+	}()
+	ctx = WithStream(connCtx, stream)
 	decoder := json.NewDecoder(rwc)
-	encoder := json.NewEncoder(rwc)
 
 	for {
-		var req Request
-		if err := decoder.Decode(&req); err != nil {
+		var message json.RawMessage
+		if err := decoder.Decode(&message); err != nil {
 			if err == io.EOF {
 				return nil
 			}
 			slog.Warn("ipc: failed to decode request", "error", err)
-			s.sendError(encoder, nil, ErrParseError)
+			s.sendError(stream, nil, ErrParseError)
 			return nil
 		}
 
-		resp := s.handleRequest(ctx, &req)
-		if req.ID == nil {
-			continue
+		resp, ok := s.handleMessage(ctx, message)
+		if !ok {
+			slog.Warn("ipc: failed to decode request", "error", "not a request object")
+			s.sendError(stream, nil, ErrParseError)
+			return nil
 		}
+		if resp != nil {
+			if err := stream.write(resp); err != nil {
+				return fmt.Errorf("encode response: %w", err)
+			}
+		}
+		stream.startPending()
+	}
+}
 
-		if err := encoder.Encode(resp); err != nil {
-			return fmt.Errorf("encode response: %w", err)
+// handleMessage answers a single request or a batch. It returns a nil
+// response for notifications and false when message is not a request.
+func (s *Server) handleMessage(ctx context.Context, message json.RawMessage) (any, bool) {
+	if isBatch(message) {
+		if resp := s.handleBatch(ctx, message); resp != nil {
+			return resp, true
 		}
+		return nil, true
 	}
+	var req Request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return nil, false
+	}
+	resp := s.handleRequest(ctx, &req)
+	if req.ID == nil {
+		return nil, true
+	}
+	return resp, true
 }
 
 // ServeStdinStdout serves JSON-RPC over stdin/stdout.
@@ -132,7 +196,7 @@ func (s *Server) ServeStdinStdout(ctx context.Context) error {
 
 func (s *Server) handleRequest(ctx context.Context, req *Request) (resp *Response) {
 	start := time.Now()
-	ctx = WithConfirmation(ctx, req.Confirm)
+	ctx = WithTrace(WithConfirmation(ctx, req.Confirm), req.RunID, req.TraceID)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -160,11 +224,17 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (resp *Respons
 		}
 	}
 
-	s.mu.RLock()
-	handler, ok := s.methods[req.Method]
-	policyChecker := s.policy
-	s.mu.RUnlock()
-
+	scope, rpcErr := s.profiles.resolve(req.Profile)
+	if rpcErr != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			Error:   rpcErr,
+			ID:      req.ID,
+			RunID:   req.RunID,
+			TraceID: req.TraceID,
+		}
+	}
+	handler, policyChecker, ok := s.lookup(scope, req.Method)
 	if !ok {
 		return &Response{
 			JSONRPC: "2.0",
@@ -224,6 +294,26 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (resp *Respons
 	}
 }
 
+// lookup returns the handler and policy for a method on a profile scope, or
+// on the server itself when scope is nil.
+func (s *Server) lookup(scope *ProfileScope, method string) (Handler, PolicyChecker, bool) {
+	if scope != nil {
+		if handler, ok := scope.handler(method); ok {
+			return handler, scope.policyChecker(), true
+		}
+		if !slices.Contains(sharedMethods, method) {
+			return nil, nil, false
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	handler, ok := s.methods[method]
+	if scope != nil {
+		return handler, scope.policyChecker(), ok
+	}
+	return handler, s.policy, ok
+}
+
 // logRequest logs an IPC request and its response.
 func (s *Server) logRequest(req *Request, resp *Response, duration time.Duration) {
 	params := truncateJSON(req.Params, maxLogSize)
@@ -231,6 +321,7 @@ func (s *Server) logRequest(req *Request, resp *Response, duration time.Duration
 		slog.Info("ipc: request",
 			"run_id", req.RunID,
 			"trace_id", req.TraceID,
+			"profile", req.Profile,
 			"method", req.Method,
 			"params", params,
 			"duration_ms", duration.Milliseconds(),
@@ -245,6 +336,7 @@ func (s *Server) logRequest(req *Request, resp *Response, duration time.Duration
 		slog.Info("ipc: request",
 			"run_id", req.RunID,
 			"trace_id", req.TraceID,
+			"profile", req.Profile,
 			"method", req.Method,
 			"params", params,
 			"duration_ms", duration.Milliseconds(),
@@ -273,13 +365,13 @@ func truncateJSON(data json.RawMessage, maxLen int) string {
 	return s
 }
 
-func (s *Server) sendError(encoder *json.Encoder, id any, err *ErrorObject) {
+func (s *Server) sendError(stream *Stream, id any, err *ErrorObject) {
 	resp := &Response{
 		JSONRPC: "2.0",
 		Error:   err,
 		ID:      id,
 	}
-	if encErr := encoder.Encode(resp); encErr != nil {
+	if encErr := stream.write(resp); encErr != nil {
 		// Last resort error logging
 		slog.Error("failed to encode error response", "error", encErr)
 	}
@@ -38,8 +38,10 @@ func NewSocketServer(path string) *SocketServer {
 	if path == "" {
 		path = defaultSocketPath
 	}
+	server := NewServer()
+	server.SetAuditSocket(path)
 	return &SocketServer{
-		server:      NewServer(),
+		server:      server,
 		path:        path,
 		connections: make(map[net.Conn]struct{}),
 	}
@@ -185,3 +187,13 @@ func (s *SocketServer) Register(name string, handler Handler) {
 func (s *SocketServer) SetPolicyChecker(policy PolicyChecker) {
 	s.server.SetPolicyChecker(policy)
 }
+
+// SetPrimaryProfile names the profile served by the server's own handlers.
+func (s *SocketServer) SetPrimaryProfile(name string) {
+	s.server.SetPrimaryProfile(name)
+}
+
+// AddProfile hosts an additional profile on the socket.
+func (s *SocketServer) AddProfile(name string) *ProfileScope {
+	return s.server.AddProfile(name)
+}
@@ -0,0 +1,142 @@
+// Package ipc provides inter-process communication via JSON-RPC.
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"agent-telegram/internal/operations"
+	"agent-telegram/internal/strictjson"
+)
+
+// Notification is a server-initiated JSON-RPC 2.0 message. It has no ID and
+// expects no response.
+type Notification struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type streamContextKey struct{}
+
+// Stream is the persistent connection a request arrived on. Handlers use it
+// to push notifications after their response, e.g. for subscriptions.
+// One-shot transports such as HTTP do not provide a Stream.
+type Stream struct {
+	ctx     context.Context
+	writeMu sync.Mutex
+	encoder *json.Encoder
+
+	mu      sync.Mutex
+	nextID  int
+	subs    map[string]context.CancelFunc
+	pending []func()
+	wg      sync.WaitGroup
+}
+
+func newStream(ctx context.Context, encoder *json.Encoder) *Stream {
+	return &Stream{ctx: ctx, encoder: encoder, subs: make(map[string]context.CancelFunc)}
+}
+
+// WithStream attaches a persistent connection to a request context.
+func WithStream(ctx context.Context, stream *Stream) context.Context {
+	return context.WithValue(ctx, streamContextKey{}, stream)
+}
+
+// StreamFromContext returns the connection a request arrived on, if the
+// transport keeps connections open.
+func StreamFromContext(ctx context.Context) (*Stream, bool) {
+	stream, ok := ctx.Value(streamContextKey{}).(*Stream)
+	return stream, ok && stream != nil
+}
+
+// Notify writes a notification to the connection.
+func (s *Stream) Notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+	return s.write(Notification{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+func (s *Stream) write(message any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.encoder.Encode(message)
+}
+
+// Subscribe starts run once the current response has been written, so the
+// caller always sees the subscription ID before the first notification. The
+// context passed to run is cancelled by Unsubscribe or when the connection
+// closes.
+func (s *Stream) Subscribe(run func(ctx context.Context)) string {
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := fmt.Sprintf("sub-%d", s.nextID)
+	s.subs[id] = cancel
+	s.pending = append(s.pending, func() {
+		s.wg.Go(func() {
+			defer s.Unsubscribe(id)
+			run(ctx)
+		})
+	})
+	return id
+}
+
+// Unsubscribe cancels a subscription started on this connection.
+func (s *Stream) Unsubscribe(id string) bool {
+	s.mu.Lock()
+	cancel, ok := s.subs[id]
+	delete(s.subs, id)
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// startPending runs subscriptions registered by the request just answered.
+func (s *Stream) startPending() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, start := range pending {
+		start()
+	}
+}
+
+// close cancels all subscriptions and waits for them to stop writing.
+// Subscriptions that never started still run with a cancelled context so
+// their cleanup executes.
+func (s *Stream) close() {
+	s.mu.Lock()
+	for id, cancel := range s.subs {
+		cancel()
+		delete(s.subs, id)
+	}
+	s.mu.Unlock()
+	s.startPending()
+	s.wg.Wait()
+}
+
+// RegisterUnsubscribe registers the unsubscribe method for connection-scoped
+// subscriptions.
+func RegisterUnsubscribe(srv MethodRegistrar) {
+	srv.Register("unsubscribe", func(ctx context.Context, params json.RawMessage) (interface{}, *ErrorObject) {
+		var p operations.UnsubscribeParams
+		if err := strictjson.Decode(params, &p); err != nil || p.SubscriptionID == "" {
+			return nil, NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation, "subscriptionId is required", nil)
+		}
+		stream, ok := StreamFromContext(ctx)
+		if !ok || !stream.Unsubscribe(p.SubscriptionID) {
+			return nil, NewTypedError(ErrCodeInvalidParams, ErrorTypeValidation,
+				"unknown subscription on this connection: "+p.SubscriptionID, nil)
+		}
+		return operations.ControlResult{Success: true}, nil
+	})
+}
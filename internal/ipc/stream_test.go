@@ -0,0 +1,160 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func startTestSocketServer(t *testing.T, register func(*SocketServer)) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "ipc-stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "s.sock")
+
+	srv := NewSocketServer(path)
+	register(srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !IsServerRunning(context.Background(), path) {
+		if time.Now().After(deadline) {
+			t.Fatal("socket server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return path
+}
+
+func TestSubscriptionPushesNotificationsAfterResponse(t *testing.T) {
+	stopped := make(chan struct{})
+	path := startTestSocketServer(t, func(srv *SocketServer) {
+		RegisterUnsubscribe(srv)
+		srv.Register("tick", func(ctx context.Context, _ json.RawMessage) (interface{}, *ErrorObject) {
+			stream, ok := StreamFromContext(ctx)
+			if !ok {
+				return nil, ErrInternalError
+			}
+			id := stream.Subscribe(func(ctx context.Context) {
+				defer close(stopped)
+				for n := 1; ; n++ {
+					if err := stream.Notify("tick", map[string]int{"n": n}); err != nil {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(5 * time.Millisecond):
+					}
+				}
+			})
+			return map[string]string{"subscriptionId": id}, nil
+		})
+	})
+
+	sub, rpcErr := NewClient(path).Subscribe("tick", nil, CallOptions{})
+	if rpcErr != nil {
+		t.Fatalf("subscribe: %+v", rpcErr)
+	}
+	defer func() { _ = sub.Close() }()
+	result, ok := sub.Result.(map[string]interface{})
+	if !ok || result["subscriptionId"] != "sub-1" {
+		t.Fatalf("result = %#v", sub.Result)
+	}
+
+	for want := 1; want <= 2; want++ {
+		notification, err := sub.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var params map[string]int
+		if err := json.Unmarshal(notification.Params, &params); err != nil {
+			t.Fatal(err)
+		}
+		if notification.Method != "tick" || params["n"] != want {
+			t.Fatalf("notification %d = %s %s", want, notification.Method, notification.Params)
+		}
+	}
+
+	_ = sub.Close()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription did not stop when the connection closed")
+	}
+}
+
+func TestUnsubscribeStopsSubscription(t *testing.T) {
+	server, client := pipeConn()
+	srv := NewServer()
+	RegisterUnsubscribe(srv)
+	stopped := make(chan struct{})
+	srv.Register("watch", func(ctx context.Context, _ json.RawMessage) (interface{}, *ErrorObject) {
+		stream, _ := StreamFromContext(ctx)
+		return stream.Subscribe(func(ctx context.Context) {
+			<-ctx.Done()
+			close(stopped)
+		}), nil
+	})
+	go func() { _ = srv.Serve(context.Background(), server) }()
+	defer func() { _ = client.Close() }()
+
+	encoder := json.NewEncoder(client)
+	decoder := json.NewDecoder(client)
+	call := func(method string, params any) Response {
+		t.Helper()
+		data, _ := json.Marshal(params)
+		if err := encoder.Encode(Request{JSONRPC: "2.0", Method: method, Params: data, ID: 1}); err != nil {
+			t.Fatal(err)
+		}
+		var resp Response
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := call("watch", map[string]any{})
+	id, _ := resp.Result.(string)
+	if resp.Error != nil || id == "" {
+		t.Fatalf("watch response = %+v", resp)
+	}
+	if resp := call("unsubscribe", map[string]any{"subscriptionId": "sub-99"}); resp.Error == nil {
+		t.Fatal("unknown subscription should fail")
+	}
+	if resp := call("unsubscribe", map[string]any{"subscriptionId": id}); resp.Error != nil {
+		t.Fatalf("unsubscribe: %+v", resp.Error)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("unsubscribe did not cancel the subscription")
+	}
+}
+
+type pipeEnd struct {
+	io.Reader
+	io.WriteCloser
+}
+
+func pipeConn() (server, client io.ReadWriteCloser) {
+	serverRead, clientWrite := io.Pipe()
+	clientRead, serverWrite := io.Pipe()
+	return &pipeEnd{Reader: serverRead, WriteCloser: serverWrite}, &pipeEnd{Reader: clientRead, WriteCloser: clientWrite}
+}
@@ -15,6 +15,9 @@ type Request struct {
 	RunID   string          `json:"runId,omitempty"`
 	TraceID string          `json:"traceId,omitempty"`
 	Confirm bool            `json:"confirm,omitempty"`
+	// Profile routes the request to a session profile hosted by the daemon.
+	// Empty selects the primary profile.
+	Profile string `json:"profile,omitempty"`
 }
 
 // Response represents a JSON-RPC 2.0 response.
@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"agent-telegram/internal/ipc"
+)
+
+const (
+	chatsURI   = "telegram://chats"
+	updatesURI = "telegram://updates"
+
+	resourceLimit = 100
+	messagesLimit = 50
+	jsonMIMEType  = "application/json"
+)
+
+// Resource is an MCP resource or resource template descriptor.
+type Resource struct {
+	URI         string `json:"uri,omitempty"`
+	URITemplate string `json:"uriTemplate,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MIMEType    string `json:"mimeType"`
+}
+
+var resources = []Resource{
+	{
+		URI:         chatsURI,
+		Name:        "chats",
+		Description: "Most recent chats and dialogs",
+		MIMEType:    jsonMIMEType,
+	},
+	{
+		URI:         updatesURI,
+		Name:        "updates",
+		Description: "Recently stored Telegram updates",
+		MIMEType:    jsonMIMEType,
+	},
+}
+
+var resourceTemplates = []Resource{
+	{
+		URITemplate: chatsURI + "/{peer}/messages",
+		Name:        "chat-messages",
+		Description: "Recent messages in one chat (@username, user:123, or numeric ID)",
+		MIMEType:    jsonMIMEType,
+	},
+	{
+		URITemplate: updatesURI + "/{peer}",
+		Name:        "chat-updates",
+		Description: "Recently stored updates for one chat",
+		MIMEType:    jsonMIMEType,
+	},
+}
+
+type readResourceParams struct {
+	URI string `json:"uri"`
+}
+
+// readResource maps a resource URI to a read operation and returns its
+// result as JSON text.
+func (s *Server) readResource(params json.RawMessage) (any, *ipc.ErrorObject) {
+	var p readResourceParams
+	if err := json.Unmarshal(params, &p); err != nil || p.URI == "" {
+		return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, "uri is required", nil)
+	}
+	method, args, ok := resourceCall(p.URI)
+	if !ok {
+		return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation,
+			"unknown resource: "+p.URI, nil)
+	}
+	result, rpcErr := s.forward(method, args, false)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, ipc.NewTypedError(ipc.ErrCodeInternalError, ipc.ErrorTypeInternal, err.Error(), nil)
+	}
+	return map[string]any{
+		"contents": []map[string]any{{"uri": p.URI, "mimeType": jsonMIMEType, "text": string(data)}},
+	}, nil
+}
+
+// resourceCall resolves a resource URI to the operation that reads it.
+func resourceCall(uri string) (string, map[string]any, bool) {
+	switch uri {
+	case chatsURI:
+		return "get_chats", map[string]any{"limit": resourceLimit}, true
+	case updatesURI:
+		return "get_updates", map[string]any{"limit": resourceLimit}, true
+	}
+	if rest, ok := strings.CutPrefix(uri, chatsURI+"/"); ok {
+		if peer, ok := strings.CutSuffix(rest, "/messages"); ok {
+			if peer, ok := unescapePeer(peer); ok {
+				return "get_messages", map[string]any{"username": peer, "limit": messagesLimit}, true
+			}
+		}
+	}
+	if rest, ok := strings.CutPrefix(uri, updatesURI+"/"); ok {
+		if peer, ok := unescapePeer(rest); ok {
+			return "get_updates", map[string]any{"peer": peer, "limit": resourceLimit}, true
+		}
+	}
+	return "", nil, false
+}
+
+func unescapePeer(segment string) (string, bool) {
+	peer, err := url.PathUnescape(segment)
+	if err != nil || peer == "" || strings.Contains(peer, "/") {
+		return "", false
+	}
+	return peer, true
+}
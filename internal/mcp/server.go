@@ -0,0 +1,214 @@
+// Package mcp serves the operation registry over the Model Context Protocol.
+// Every registered operation becomes a tool and chats and updates become
+// resources. Calls are forwarded to the running daemon, so policy, audit and
+// confirmation checks stay in one place.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/observability"
+	"agent-telegram/internal/operations"
+)
+
+// ProtocolVersion is the MCP revision this server implements. Clients that
+// ask for another revision are answered with this one, as the spec allows.
+const ProtocolVersion = "2025-06-18"
+
+// maxMessageSize bounds one newline-delimited JSON-RPC message on stdin.
+const maxMessageSize = 16 << 20
+
+// Caller forwards operations to the daemon. *ipc.Client implements it.
+type Caller interface {
+	CallWithOptions(method string, params any, opts ipc.CallOptions) (any, *ipc.ErrorObject)
+}
+
+// Options configures a Server.
+type Options struct {
+	// Name and Version are reported as serverInfo during initialize.
+	Name    string
+	Version string
+	// AuditSocket selects the instance audit journal. Each tool call is
+	// recorded with surface "mcp".
+	AuditSocket string
+	// RunID is attached to every forwarded call and audit event.
+	RunID string
+	// Confirm pre-approves destructive and paid tools for the whole session,
+	// as --confirm does for a single CLI command.
+	Confirm bool
+	// FileRoots are the directories tools may read files from or write
+	// downloads to. Without them, file and output arguments are rejected.
+	FileRoots []string
+}
+
+// Server is an MCP server speaking JSON-RPC 2.0 over a byte stream.
+type Server struct {
+	caller Caller
+	opts   Options
+	tools  map[string]operations.ManifestOperation
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+}
+
+// NewServer creates an MCP server that forwards calls to caller.
+func NewServer(caller Caller, opts Options) *Server {
+	if opts.Name == "" {
+		opts.Name = "agent-telegram"
+	}
+	if opts.Version == "" {
+		opts.Version = "dev"
+	}
+	tools := make(map[string]operations.ManifestOperation)
+	for _, op := range operations.Manifest() {
+		if exposed(op.Method) {
+			tools[op.Method] = op
+		}
+	}
+	return &Server{caller: caller, opts: opts, tools: tools}
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is closed or ctx is cancelled. Requests run
+// concurrently so a slow Telegram call does not block pings.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.enc = json.NewEncoder(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxMessageSize)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := append([]byte(nil), scanner.Bytes()...)
+		if len(line) == 0 {
+			continue
+		}
+		var req ipc.Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.write(&ipc.Response{JSONRPC: "2.0", Error: ipc.ErrParseError})
+			continue
+		}
+		if req.Method == "" {
+			// Responses to server-initiated requests; none are sent.
+			continue
+		}
+		wg.Go(func() {
+			result, rpcErr := s.handle(&req)
+			if req.ID == nil {
+				return
+			}
+			s.write(&ipc.Response{JSONRPC: "2.0", Result: result, Error: rpcErr, ID: req.ID})
+		})
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("read mcp input: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) write(resp *ipc.Response) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.enc.Encode(resp); err != nil {
+		slog.Warn("mcp: write failed", "error", err)
+	}
+}
+
+// handle dispatches one MCP request. Notifications such as
+// notifications/initialized and notifications/cancelled need no answer.
+func (s *Server) handle(req *ipc.Request) (any, *ipc.ErrorObject) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.toolList()}, nil
+	case "tools/call":
+		return s.callTool(req.Params)
+	case "resources/list":
+		return map[string]any{"resources": resources}, nil
+	case "resources/templates/list":
+		return map[string]any{"resourceTemplates": resourceTemplates}, nil
+	case "resources/read":
+		return s.readResource(req.Params)
+	}
+	if req.ID == nil {
+		return nil, nil
+	}
+	return nil, ipc.NewTypedError(ipc.ErrCodeMethodNotFound, ipc.ErrorTypeMethodNotFound,
+		"method not found: "+req.Method, nil)
+}
+
+func (s *Server) initialize() map[string]any {
+	return map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities": map[string]any{
+			"tools":     map[string]any{"listChanged": false},
+			"resources": map[string]any{"listChanged": false, "subscribe": false},
+		},
+		"serverInfo": map[string]any{"name": s.opts.Name, "version": s.opts.Version},
+		"instructions": "Telegram operations backed by the agent-telegram daemon. " +
+			"Destructive and paid tools only run when called with confirm: true. " +
+			"Read telegram://chats and telegram://updates for context.",
+	}
+}
+
+// forward calls the daemon and records an audit event for the call.
+func (s *Server) forward(method string, params map[string]any, confirm bool) (any, *ipc.ErrorObject) {
+	traceID := observability.NewTraceID()
+	start := time.Now()
+	result, rpcErr := s.caller.CallWithOptions(method, params, ipc.CallOptions{
+		TraceID: traceID,
+		RunID:   s.opts.RunID,
+		Confirm: confirm,
+	})
+	s.writeAudit(traceID, method, params, result, rpcErr, time.Since(start))
+	return result, rpcErr
+}
+
+func (s *Server) writeAudit(
+	traceID, method string,
+	params, result any,
+	rpcErr *ipc.ErrorObject,
+	duration time.Duration,
+) {
+	event := observability.AuditEvent{
+		Time:       time.Now().UTC(),
+		RunID:      s.opts.RunID,
+		TraceID:    traceID,
+		Surface:    ipc.SurfaceMCP,
+		Method:     method,
+		Status:     "ok",
+		DurationMs: duration.Milliseconds(),
+		Params:     params,
+	}
+	if op, ok := operations.Get(method); ok {
+		event.Safety = op.Safety
+	}
+	if rpcErr != nil {
+		event.Status = "error"
+		event.ErrorCode = rpcErr.Code
+		event.Error = rpcErr.Message
+		if data, ok := rpcErr.Data.(map[string]any); ok {
+			event.ErrorType, _ = data["type"].(string)
+		}
+	} else {
+		event.ResultSummary = observability.SummarizeResult(result)
+	}
+	if err := observability.WriteAudit(s.opts.AuditSocket, event); err != nil {
+		slog.Warn("mcp: audit write failed", "trace_id", traceID, "error", err)
+	}
+}
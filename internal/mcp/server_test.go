@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"agent-telegram/internal/ipc"
+)
+
+type recordedCall struct {
+	method string
+	params map[string]any
+	opts   ipc.CallOptions
+}
+
+type fakeCaller struct {
+	mu     sync.Mutex
+	calls  []recordedCall
+	result any
+	err    *ipc.ErrorObject
+}
+
+func (f *fakeCaller) CallWithOptions(method string, params any, opts ipc.CallOptions) (any, *ipc.ErrorObject) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, _ := params.(map[string]any)
+	f.calls = append(f.calls, recordedCall{method: method, params: m, opts: opts})
+	return f.result, f.err
+}
+
+func serveLines(t *testing.T, server *Server, lines ...string) map[string]ipc.Response {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	var out bytes.Buffer
+	in := strings.NewReader(strings.Join(lines, "\n") + "\n")
+	if err := server.Serve(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+	responses := map[string]ipc.Response{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var resp ipc.Response
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		id, _ := json.Marshal(resp.ID)
+		responses[string(id)] = resp
+	}
+	return responses
+}
+
+func resultMap(t *testing.T, resp ipc.Response) map[string]any {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	m, ok := resp.Result.(map[string]any)
+	if !ok {
+		t.Fatalf("result = %#v, want object", resp.Result)
+	}
+	return m
+}
+
+func TestServerInitializeAndListTools(t *testing.T) {
+	server := NewServer(&fakeCaller{}, Options{Version: "test"})
+	responses := serveLines(t, server,
+		`{"jsonrpc":"2.0","method":"initialize","params":{"protocolVersion":"2024-11-05"},"id":1}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","method":"tools/list","id":2}`,
+		`{"jsonrpc":"2.0","method":"nope","id":3}`,
+	)
+	if len(responses) != 3 {
+		t.Fatalf("got %d responses, want 3", len(responses))
+	}
+
+	init := resultMap(t, responses["1"])
+	if init["protocolVersion"] != ProtocolVersion {
+		t.Fatalf("protocolVersion = %v", init["protocolVersion"])
+	}
+
+	tools, _ := resultMap(t, responses["2"])["tools"].([]any)
+	byName := map[string]map[string]any{}
+	for _, raw := range tools {
+		tool, _ := raw.(map[string]any)
+		name, _ := tool["name"].(string)
+		byName[name] = tool
+	}
+	if _, ok := byName["subscribe_updates"]; ok {
+		t.Fatal("subscribe_updates should not be exposed")
+	}
+	send, ok := byName["send_message"]
+	if !ok || send["inputSchema"] == nil {
+		t.Fatalf("send_message tool missing or without schema: %#v", send)
+	}
+	deleteTool, ok := byName["delete_message"]
+	if !ok {
+		t.Fatal("delete_message tool missing")
+	}
+	annotations, _ := deleteTool["annotations"].(map[string]any)
+	if annotations["destructiveHint"] != true {
+		t.Fatalf("delete_message annotations = %#v", annotations)
+	}
+	props, _ := deleteTool["inputSchema"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := props[confirmArg]; !ok {
+		t.Fatal("delete_message schema lacks confirm argument")
+	}
+
+	if responses["3"].Error == nil || responses["3"].Error.Code != ipc.ErrCodeMethodNotFound {
+		t.Fatalf("unknown method response = %+v", responses["3"])
+	}
+}
+
+func TestServerCallToolRequiresConfirm(t *testing.T) {
+	caller := &fakeCaller{result: map[string]any{"success": true}}
+	server := NewServer(caller, Options{})
+	responses := serveLines(t, server,
+		`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"delete_message","arguments":{"peer":"@a","messageId":5}},"id":1}`,
+	)
+	result := resultMap(t, responses["1"])
+	if result["isError"] != true {
+		t.Fatalf("unconfirmed destructive call = %#v, want isError", result)
+	}
+	if len(caller.calls) != 0 {
+		t.Fatalf("unconfirmed call was forwarded: %+v", caller.calls)
+	}
+
+	responses = serveLines(t, server,
+		`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"delete_message","arguments":{"peer":"@a","messageId":5,"confirm":true}},"id":2}`,
+	)
+	result = resultMap(t, responses["2"])
+	if result["isError"] == true {
+		t.Fatalf("confirmed call failed: %#v", result)
+	}
+	if len(caller.calls) != 1 {
+		t.Fatalf("got %d forwarded calls, want 1", len(caller.calls))
+	}
+	call := caller.calls[0]
+	if call.method != "delete_message" || !call.opts.Confirm {
+		t.Fatalf("forwarded call = %+v", call)
+	}
+	if _, ok := call.params[confirmArg]; ok {
+		t.Fatal("confirm argument was forwarded as a param")
+	}
+}
+
+func TestServerConfirmOptionPreApproves(t *testing.T) {
+	caller := &fakeCaller{result: map[string]any{"success": true}}
+	server := NewServer(caller, Options{Confirm: true})
+	responses := serveLines(t, server,
+		`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"delete_message","arguments":{"peer":"@a","messageId":5}},"id":1}`,
+	)
+	if resultMap(t, responses["1"])["isError"] == true || len(caller.calls) != 1 || !caller.calls[0].opts.Confirm {
+		t.Fatalf("pre-approved call not forwarded with confirm: %+v", caller.calls)
+	}
+}
+
+func TestServerCallToolChecksFileRoots(t *testing.T) {
+	root := t.TempDir()
+	caller := &fakeCaller{result: map[string]any{"path": "ok"}}
+	outside := `{"jsonrpc":"2.0","method":"tools/call","params":{"name":"download_media","arguments":{"peer":"@a","messageId":5,"output":"/etc/cron.d/x"}},"id":1}`
+
+	responses := serveLines(t, NewServer(caller, Options{}), outside)
+	if resultMap(t, responses["1"])["isError"] != true || len(caller.calls) != 0 {
+		t.Fatalf("output without file roots was forwarded: %+v", caller.calls)
+	}
+	responses = serveLines(t, NewServer(caller, Options{FileRoots: []string{root}}), outside)
+	if resultMap(t, responses["1"])["isError"] != true || len(caller.calls) != 0 {
+		t.Fatalf("output outside file roots was forwarded: %+v", caller.calls)
+	}
+	inside := `{"jsonrpc":"2.0","method":"tools/call","params":{"name":"download_media","arguments":{"peer":"@a","messageId":5,"output":` +
+		strconv.Quote(root+"/photo.jpg") + `}},"id":2}`
+	responses = serveLines(t, NewServer(caller, Options{FileRoots: []string{root}}), inside)
+	if resultMap(t, responses["2"])["isError"] == true || len(caller.calls) != 1 {
+		t.Fatalf("output under a file root was rejected: %+v", responses["2"])
+	}
+}
+
+func TestServerCallToolReportsDaemonErrors(t *testing.T) {
+	caller := &fakeCaller{err: ipc.ErrServerNotRunning}
+	server := NewServer(caller, Options{})
+	responses := serveLines(t, server,
+		`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"get_me","arguments":{}},"id":1}`,
+		`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"missing_tool"},"id":2}`,
+	)
+	if resultMap(t, responses["1"])["isError"] != true {
+		t.Fatalf("daemon error should be a tool error: %+v", responses["1"])
+	}
+	if responses["2"].Error == nil || responses["2"].Error.Code != ipc.ErrCodeInvalidParams {
+		t.Fatalf("unknown tool response = %+v", responses["2"])
+	}
+}
+
+func TestResourceCall(t *testing.T) {
+	tests := []struct {
+		uri    string
+		method string
+		key    string
+		value  any
+	}{
+		{chatsURI, "get_chats", "limit", resourceLimit},
+		{updatesURI, "get_updates", "limit", resourceLimit},
+		{chatsURI + "/%40durov/messages", "get_messages", "username", "@durov"},
+		{updatesURI + "/user:42", "get_updates", "peer", "user:42"},
+	}
+	for _, tt := range tests {
+		method, args, ok := resourceCall(tt.uri)
+		if !ok || method != tt.method || args[tt.key] != tt.value {
+			t.Errorf("resourceCall(%q) = %q, %v, %v", tt.uri, method, args, ok)
+		}
+	}
+	for _, uri := range []string{"telegram://other", chatsURI + "/a/b/messages", updatesURI + "/"} {
+		if _, _, ok := resourceCall(uri); ok {
+			t.Errorf("resourceCall(%q) should fail", uri)
+		}
+	}
+}
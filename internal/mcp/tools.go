@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/operations"
+	telegramipc "agent-telegram/internal/telegram/ipc"
+)
+
+// confirmArg is the extra tool argument that confirms a destructive or paid
+// call. It mirrors the CLI --confirm flag and is stripped before forwarding.
+const confirmArg = "confirm"
+
+// unexposed methods need a persistent socket connection that a forwarded
+// one-shot call cannot provide.
+var unexposed = []string{"subscribe_updates", "unsubscribe"}
+
+func exposed(method string) bool {
+	return !slices.Contains(unexposed, method)
+}
+
+// Tool is an MCP tool definition.
+type Tool struct {
+	Name        string                `json:"name"`
+	Title       string                `json:"title,omitempty"`
+	Description string                `json:"description"`
+	InputSchema operations.JSONSchema `json:"inputSchema"`
+	Annotations ToolAnnotations       `json:"annotations"`
+}
+
+// ToolAnnotations are the MCP behaviour hints derived from operation safety.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint"`
+	DestructiveHint bool   `json:"destructiveHint"`
+	IdempotentHint  bool   `json:"idempotentHint"`
+	OpenWorldHint   bool   `json:"openWorldHint"`
+}
+
+func (s *Server) toolList() []Tool {
+	tools := make([]Tool, 0, len(s.tools))
+	for _, method := range slices.Sorted(maps.Keys(s.tools)) {
+		tools = append(tools, toolFor(s.tools[method]))
+	}
+	return tools
+}
+
+// toolFor converts a manifest entry into a tool. Operations that need
+// confirmation get a confirm argument and say so in their description.
+func toolFor(op operations.ManifestOperation) Tool {
+	description := op.Summary + " (" + op.Category + ", " + op.Safety + ")."
+	schema := op.InputSchema
+	if op.RequiresConfirmation {
+		description += " Requires confirm: true; without it the call is rejected."
+		schema = withConfirmArg(schema)
+	}
+	return Tool{
+		Name:        op.Method,
+		Title:       op.Summary,
+		Description: description,
+		InputSchema: schema,
+		Annotations: ToolAnnotations{
+			Title:           op.Summary,
+			ReadOnlyHint:    op.Safety == operations.SafetyRead,
+			DestructiveHint: op.Safety == operations.SafetyDestructive || op.Safety == operations.SafetyPaid,
+			IdempotentHint:  op.Idempotent,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func withConfirmArg(schema operations.JSONSchema) operations.JSONSchema {
+	out := maps.Clone(schema)
+	properties := map[string]any{}
+	if existing, ok := schema["properties"].(map[string]any); ok {
+		properties = maps.Clone(existing)
+	}
+	properties[confirmArg] = map[string]any{
+		"type":        "boolean",
+		"description": "Set to true to confirm this destructive or paid operation",
+	}
+	out["properties"] = properties
+	return out
+}
+
+type callToolParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// callTool forwards a tools/call to the daemon. Operation failures are tool
+// results with isError set, so the model can read and react to them;
+// protocol errors are reserved for unknown tools and malformed requests.
+func (s *Server) callTool(params json.RawMessage) (any, *ipc.ErrorObject) {
+	var p callToolParams
+	// UseNumber keeps 64-bit peer and message IDs exact.
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.UseNumber()
+	if err := decoder.Decode(&p); err != nil {
+		return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+	}
+	op, ok := s.tools[p.Name]
+	if !ok {
+		return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, "unknown tool: "+p.Name, nil)
+	}
+
+	args := maps.Clone(p.Arguments)
+	if args == nil {
+		args = map[string]any{}
+	}
+	confirm, _ := args[confirmArg].(bool)
+	confirm = confirm || s.opts.Confirm
+	delete(args, confirmArg)
+	if op.RequiresConfirmation && !confirm {
+		return toolError(ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation,
+			p.Name+" is "+op.Safety+"; call again with confirm: true to proceed", nil)), nil
+	}
+	if raw, err := json.Marshal(args); err == nil {
+		if err := operations.ValidateParams(p.Name, raw); err != nil {
+			return toolError(ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)), nil
+		}
+		// The daemon trusts its socket, so file paths are checked here.
+		ctx := ipc.WithFileRoots(ipc.WithSurface(context.Background(), ipc.SurfaceMCP), s.opts.FileRoots)
+		if err := telegramipc.ValidateFileParams(ctx, raw); err != nil {
+			return toolError(ipc.NewTypedError(ipc.ErrCodeForbidden, ipc.ErrorTypeForbidden, err.Error(), nil)), nil
+		}
+	}
+
+	result, rpcErr := s.forward(p.Name, args, confirm)
+	if rpcErr != nil {
+		return toolError(rpcErr), nil
+	}
+	return toolResult(result), nil
+}
+
+func toolResult(result any) map[string]any {
+	out := map[string]any{"content": []map[string]any{textContent(result)}}
+	if _, ok := result.(map[string]any); ok {
+		out["structuredContent"] = result
+	}
+	return out
+}
+
+func toolError(rpcErr *ipc.ErrorObject) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{textContent(map[string]any{"error": rpcErr})},
+		"isError": true,
+	}
+}
+
+func textContent(v any) map[string]any {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		data = []byte(err.Error())
+	}
+	return map[string]any{"type": "text", "text": string(data)}
+}
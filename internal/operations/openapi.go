@@ -31,15 +31,17 @@ func OpenAPI(title, version string) map[string]any {
 					"200": response("Manifest", JSONSchema{
 						"type": "object",
 						"properties": map[string]any{
-							"ok":         JSONSchema{"type": "boolean"},
-							"operations": JSONSchema{"type": "array", "items": JSONSchema{"type": "object", "additionalProperties": true}},
-							"errorTypes": JSONSchema{"type": "array", "items": JSONSchema{"type": "object", "additionalProperties": true}},
-							"skills":     JSONSchema{"type": "array", "items": JSONSchema{"type": "object", "additionalProperties": true}},
+							"ok":          JSONSchema{"type": "boolean"},
+							"operations":  JSONSchema{"type": "array", "items": JSONSchema{"type": "object", "additionalProperties": true}},
+							"errorTypes":  JSONSchema{"type": "array", "items": JSONSchema{"type": "object", "additionalProperties": true}},
+							"updateTypes": JSONSchema{"type": "array", "items": JSONSchema{"type": "object", "additionalProperties": true}},
+							"skills":      JSONSchema{"type": "array", "items": JSONSchema{"type": "object", "additionalProperties": true}},
 						},
 					}),
 				},
 			},
 		},
+		"/rpc": rpcBatchPath(),
 	}
 
 	for _, manifest := range Manifest() {
@@ -65,7 +67,10 @@ func OpenAPI(title, version string) map[string]any {
 							"type": "object",
 							"properties": map[string]any{
 								"params": JSONSchema{"type": "string", "description": "JSON object containing non-file parameters"},
-								"file":   JSONSchema{"type": "string", "format": "binary"},
+								"file": JSONSchema{
+									"type": "string", "format": "binary",
+									"description": "Upload; repeat for send_album, where parts fill items in order",
+								},
 							},
 						}},
 					},
@@ -107,6 +112,68 @@ func OpenAPI(title, version string) map[string]any {
 	}
 }
 
+// Batch limits enforced by the ipc package; mirrored here because operations
+// must not import ipc.
+const (
+	maxBatchSize        = 100
+	maxBatchConcurrency = 16
+)
+
+// rpcBatchPath describes POST /rpc, which takes a standard JSON-RPC 2.0
+// request object or batch array.
+func rpcBatchPath() map[string]any {
+	request := JSONSchema{
+		"type":     "object",
+		"required": []string{"jsonrpc", "method"},
+		"properties": map[string]any{
+			"jsonrpc": JSONSchema{"const": "2.0"},
+			"method":  JSONSchema{"type": "string"},
+			"params":  JSONSchema{"type": "object", "additionalProperties": true},
+			"id":      JSONSchema{"type": []string{"string", "integer"}},
+			"runId":   JSONSchema{"type": "string"},
+			"traceId": JSONSchema{"type": "string"},
+			"confirm": JSONSchema{"type": "boolean"},
+		},
+	}
+	rpcResponse := JSONSchema{"type": "object", "additionalProperties": true}
+	return map[string]any{
+		"post": map[string]any{
+			"summary":     "JSON-RPC 2.0 request or batch",
+			"description": "Each batch element is validated, policy-checked and audited independently.",
+			"operationId": "rpc_batch",
+			"security":    []map[string][]string{{"bearerAuth": []string{}}},
+			"parameters": []map[string]any{
+				headerParam("X-Run-Id", "Optional run ID applied to elements without runId."),
+				{
+					"name":        "concurrency",
+					"in":          "query",
+					"required":    false,
+					"description": "Batch elements executed in parallel (default 1, in order).",
+					"schema":      JSONSchema{"type": "integer", "minimum": 1, "maximum": maxBatchConcurrency},
+				},
+				boolQueryParam("confirm", "Explicitly confirm destructive or paid elements."),
+			},
+			"requestBody": map[string]any{
+				"required": true,
+				"content": map[string]any{"application/json": map[string]any{"schema": JSONSchema{
+					"oneOf": []any{request, JSONSchema{"type": "array", "items": request, "minItems": 1, "maxItems": maxBatchSize}},
+				}}},
+			},
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "Response object or array of responses",
+					"content": map[string]any{"application/json": map[string]any{"schema": JSONSchema{
+						"oneOf": []any{rpcResponse, JSONSchema{"type": "array", "items": rpcResponse}},
+					}}},
+				},
+				"204": map[string]any{"description": "Only notifications were sent"},
+				"400": response("Bad request", rpcResponse),
+				"401": response("Unauthorized", errorSchema()),
+			},
+		},
+	}
+}
+
 func response(description string, schema any) map[string]any {
 	return map[string]any{
 		"description": description,
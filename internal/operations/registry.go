@@ -40,11 +40,124 @@ type ReloadSessionParams struct {
 	Profile  string `json:"profile,omitempty"`
 }
 
+// UnsubscribeParams identifies a subscription started on the same connection.
+type UnsubscribeParams struct {
+	SubscriptionID string `json:"subscriptionId" validate:"required"`
+}
+
 type ControlResult struct {
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
 }
 
+type WebhookTestParams struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// WebhookReplayParams selects dead-lettered deliveries to requeue.
+type WebhookReplayParams struct {
+	IDs     []string `json:"ids,omitempty"`
+	Webhook string   `json:"webhook,omitempty"`
+	All     bool     `json:"all,omitempty"`
+}
+
+// Validate requires an explicit selection so an empty call never replays
+// the whole dead-letter log.
+func (p WebhookReplayParams) Validate() error {
+	if len(p.IDs) == 0 && p.Webhook == "" && !p.All {
+		return fmt.Errorf("ids, webhook or all is required")
+	}
+	return nil
+}
+
+// WebhookInfo describes a configured webhook. Secrets are never returned.
+type WebhookInfo struct {
+	ID          string             `json:"id"`
+	URL         string             `json:"url"`
+	Types       []types.UpdateType `json:"types,omitempty"`
+	Peers       []string           `json:"peers,omitempty"`
+	Disabled    bool               `json:"disabled,omitempty"`
+	SecretEnv   string             `json:"secretEnv,omitempty"`
+	Pending     int                `json:"pending"`
+	DeadLetters int                `json:"deadLetters"`
+}
+
+type ListWebhooksResult struct {
+	Webhooks []WebhookInfo `json:"webhooks"`
+	Count    int           `json:"count"`
+}
+
+type WebhookTestResult struct {
+	Webhook    string `json:"webhook"`
+	Success    bool   `json:"success"`
+	Status     int    `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type WebhookReplayResult struct {
+	Replayed    int      `json:"replayed"`
+	DeliveryIDs []string `json:"deliveryIds,omitempty"`
+}
+
+// RulesTestParams holds a stored update to evaluate against the rules.
+type RulesTestParams struct {
+	Update types.StoredUpdate `json:"update"`
+}
+
+// Validate requires an update type, which every stored update has.
+func (p RulesTestParams) Validate() error {
+	if p.Update.Type == "" {
+		return fmt.Errorf("update.type is required")
+	}
+	return nil
+}
+
+// RuleInfo describes a loaded automation rule and its counters since the
+// daemon started. LastError is the failure of the latest run and is cleared
+// by a run that succeeds.
+type RuleInfo struct {
+	ID          string             `json:"id"`
+	Disabled    bool               `json:"disabled,omitempty"`
+	DryRun      bool               `json:"dryRun,omitempty"`
+	Types       []types.UpdateType `json:"types,omitempty"`
+	Methods     []string           `json:"methods"`
+	Matched     int64              `json:"matched"`
+	Executed    int64              `json:"executed"`
+	Failed      int64              `json:"failed"`
+	RateLimited int64              `json:"rateLimited"`
+	LastRunAt   int64              `json:"lastRunAt,omitempty"`
+	LastError   string             `json:"lastError,omitempty"`
+}
+
+type ListRulesResult struct {
+	Rules []RuleInfo `json:"rules"`
+	Count int        `json:"count"`
+	// Error is the last reload failure; the previous valid rules stay active.
+	Error string `json:"error,omitempty"`
+}
+
+// RuleActionPlan is an action a matching rule would run, with its rendered
+// params and the policy decision.
+type RuleActionPlan struct {
+	Method  string         `json:"method"`
+	Safety  string         `json:"safety"`
+	Params  map[string]any `json:"params,omitempty"`
+	Allowed bool           `json:"allowed"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type RuleMatch struct {
+	Rule    string           `json:"rule"`
+	DryRun  bool             `json:"dryRun,omitempty"`
+	Actions []RuleActionPlan `json:"actions"`
+}
+
+type RulesTestResult struct {
+	Matches []RuleMatch `json:"matches"`
+	Count   int         `json:"count"`
+}
+
 // Operation describes one IPC/HTTP operation for agents and documentation.
 type Operation struct {
 	Method               string
@@ -261,6 +374,37 @@ func registerCore() {
 	write("reload_session", "Replace the in-memory Telegram session", "system", ReloadSessionParams{}, ControlResult{})
 	read("get_me", "Get the authorized account profile", "account", NoParams{}, types.GetMeResult{})
 	read("get_updates", "Get pending Telegram updates", "updates", types.GetUpdatesParams{}, types.GetUpdatesResult{})
+	read("subscribe_updates", "Push updates as notifications on this socket connection", "updates",
+		types.SubscribeUpdatesParams{}, types.SubscribeUpdatesResult{},
+		map[string]any{"peer": "@username", "types": []string{"new_message"}, "direction": "in"})
+	read("unsubscribe", "Stop a subscription on this socket connection", "updates", UnsubscribeParams{}, ControlResult{})
+	read("wait_for_message", "Wait for incoming messages matching predicates", "updates",
+		types.WaitForMessageParams{}, types.WaitForMessageResult{},
+		map[string]any{"peer": "@bot", "afterId": 120, "text": "(?i)done", "timeout": 20})
+	read("consume_updates", "Get unacknowledged updates for a named durable consumer", "updates",
+		types.ConsumeUpdatesParams{}, types.ConsumeUpdatesResult{},
+		map[string]any{"consumer": "triage", "types": []string{"new_message"}, "redeliverAfter": 120})
+	write("ack_updates", "Acknowledge updates and advance a consumer's committed offset", "updates",
+		types.AckUpdatesParams{}, types.AckUpdatesResult{}, map[string]any{"consumer": "triage", "ids": []int{41, 42}})
+	read("list_consumers", "List named update consumers and their offsets", "updates",
+		NoParams{}, types.ListConsumersResult{})
+	write("delete_consumer", "Forget a named update consumer and its offset", "updates",
+		types.ConsumerParams{}, types.DeleteConsumerResult{}, map[string]any{"consumer": "triage"})
+	read("list_webhooks", "List configured webhooks with queue and dead-letter counts", "webhooks",
+		NoParams{}, ListWebhooksResult{})
+	write("test_webhook", "Send a signed test delivery to a webhook", "webhooks",
+		WebhookTestParams{}, WebhookTestResult{}, map[string]any{"id": "alerts"})
+	write("replay_webhooks", "Requeue dead-lettered webhook deliveries", "webhooks",
+		WebhookReplayParams{}, WebhookReplayResult{}, map[string]any{"webhook": "alerts"})
+	read("list_rules", "List automation rules with match and run counters", "rules",
+		NoParams{}, ListRulesResult{})
+	read("test_rules", "Show which rules an update matches and the actions they would run", "rules",
+		RulesTestParams{}, RulesTestResult{},
+		map[string]any{"update": map[string]any{"id": 41, "type": "new_message",
+			"data": map[string]any{"message": map[string]any{"id": 120, "peer": "user:42", "text": "help"}}}})
+	read("peer_cache_stats", "Count cached peers and access hashes", "peers", NoParams{}, types.PeerCacheStats{})
+	write("clear_peer_cache", "Forget cached peers so they are resolved again", "peers",
+		NoParams{}, types.PeerCacheClearResult{})
 	read("get_balance", "Get Stars and TON balance", "gifts", types.GetBalanceParams{}, types.GetBalanceResult{})
 }
 
@@ -271,7 +415,8 @@ func registerMessages() {
 	write("send_reply", "Send a reply to a message", "messages", types.SendReplyParams{}, types.SendReplyResult{})
 	write("update_message", "Edit a previously sent message", "messages", types.UpdateMessageParams{}, types.UpdateMessageResult{})
 	destructive("delete_message", "Delete one or more messages", "messages", types.DeleteMessageParams{}, types.DeleteMessageResult{})
-	write("forward_message", "Forward a message to another peer", "messages", types.ForwardMessageParams{}, types.ForwardMessageResult{})
+	write("forward_message", "Forward one or more messages to another peer", "messages", types.ForwardMessageParams{}, types.ForwardMessageResult{})
+	write("copy_message", "Re-send a message's text and media without a forward header", "messages", types.CopyMessageParams{}, types.CopyMessageResult{})
 	destructive("clear_messages", "Clear selected messages", "messages", types.ClearMessagesParams{}, types.ClearMessagesResult{})
 	destructive("clear_history", "Clear chat history", "messages", types.ClearHistoryParams{}, types.ClearHistoryResult{})
 	read("inspect_inline_buttons", "Inspect inline buttons on a message", "buttons", types.InspectInlineButtonsParams{}, types.InspectInlineButtonsResult{})
@@ -285,8 +430,14 @@ func registerMessages() {
 	write("read_messages", "Mark messages as read", "messages", types.ReadMessagesParams{}, types.ReadMessagesResult{})
 	write("set_typing", "Send typing indicator", "messages", types.SetTypingParams{}, types.SetTypingResult{})
 	read("get_scheduled_messages", "List scheduled messages", "messages", types.GetScheduledMessagesParams{}, types.GetScheduledMessagesResult{})
+	write("send_scheduled_messages", "Send scheduled messages now", "messages", types.ScheduledMessageIDsParams{}, types.ScheduledMessageIDsResult{})
+	destructive("delete_scheduled_messages", "Delete scheduled messages", "messages", types.ScheduledMessageIDsParams{}, types.ScheduledMessageIDsResult{})
+	write("edit_scheduled_message", "Edit the text or send time of a scheduled message", "messages", types.EditScheduledMessageParams{}, types.EditScheduledMessageResult{})
 	read("get_replies", "Get replies/comments for a channel post", "messages", types.GetRepliesParams{}, types.GetRepliesResult{})
 	write("reply_to_comment", "Reply to a channel post comment", "messages", types.ReplyToCommentParams{}, types.ReplyToCommentResult{})
+	write("init_takeout_session", "Start a takeout session with relaxed flood limits for exports", "messages", types.InitTakeoutParams{}, types.InitTakeoutResult{})
+	write("finish_takeout_session", "Finish a takeout session", "messages", types.FinishTakeoutParams{}, types.FinishTakeoutResult{})
+	read("export_messages", "Export a page of chat history, oldest first, with ID and date bounds", "messages", types.ExportMessagesParams{}, types.ExportMessagesResult{}, map[string]any{"peer": "@username", "offsetId": 1, "limit": 100})
 }
 
 func registerMedia() {
@@ -295,6 +446,7 @@ func registerMedia() {
 	write("send_file", "Send a generic file", "media", types.SendFileParams{}, types.SendFileResult{})
 	write("send_document", "Send a document", "media", types.SendFileParams{}, types.SendFileResult{})
 	write("send_audio", "Send an audio file", "media", types.SendFileParams{}, types.SendFileResult{})
+	write("send_album", "Send up to 10 photos, videos or files as one album", "media", types.SendAlbumParams{}, types.SendAlbumResult{}, map[string]any{"peer": "@username", "items": []map[string]any{{"file": "/tmp/a.jpg", "caption": "Before"}, {"file": "/tmp/b.jpg"}}})
 	write("send_location", "Send a location", "media", types.SendLocationParams{}, types.SendLocationResult{})
 	write("send_contact", "Send a contact", "media", types.SendContactParams{}, types.SendContactResult{})
 	write("send_poll", "Send a poll", "media", types.SendPollParams{}, types.SendPollResult{})
@@ -305,6 +457,7 @@ func registerMedia() {
 	read("get_sticker_packs", "List sticker packs", "media", types.GetStickerPacksParams{}, types.GetStickerPacksResult{})
 	write("send_gif", "Send a GIF or animation", "media", types.SendGIFParams{}, types.SendGIFResult{})
 	write("send_dice", "Send a Telegram dice/game roll", "media", types.SendDiceParams{}, types.SendDiceResult{})
+	write("download_media", "Download message media to a local file, resuming partial downloads", "media", types.DownloadMediaParams{}, types.DownloadMediaResult{}, map[string]any{"peer": "@username", "messageId": 123, "output": "/tmp/downloads/"})
 }
 
 func registerChats() {
@@ -38,6 +38,20 @@ func EnsureConfigDir() (string, error) {
 	return dir, nil
 }
 
+// DownloadsDir returns the default media download directory
+// (~/.agent-telegram/downloads), creating it if needed.
+func DownloadsDir() (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	downloads := filepath.Join(dir, "downloads")
+	if err := os.MkdirAll(downloads, 0700); err != nil {
+		return "", fmt.Errorf("failed to create downloads directory: %w", err)
+	}
+	return downloads, nil
+}
+
 // LogFilePath returns the path to the log file.
 func LogFilePath() (string, error) {
 	return LogFilePathForSocket("")
@@ -80,6 +94,86 @@ func AuditFilePathForSocket(socketPath string) (string, error) {
 	return filepath.Join(dir, instanceFileName("audit", socketPath, "jsonl")), nil
 }
 
+// UpdatesDirForSocket returns the update journal directory for a socket
+// instance (~/.agent-telegram/updates or updates-<hash>).
+func UpdatesDirForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceName("updates", socketPath)), nil
+}
+
+// UpdateStateFilePathForSocket returns the MTProto update state (pts/qts/seq)
+// file for a socket instance.
+func UpdateStateFilePathForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceFileName("update-state", socketPath, "json")), nil
+}
+
+// PeerCacheFilePathForSocket returns the persistent peer cache (IDs, access
+// hashes and usernames) for a socket instance.
+func PeerCacheFilePathForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceFileName("peers", socketPath, "json")), nil
+}
+
+// UpdateConsumersFilePathForSocket returns the committed offsets of named
+// update consumers for a socket instance.
+func UpdateConsumersFilePathForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceFileName("update-consumers", socketPath, "json")), nil
+}
+
+// RulesFilePathForSocket returns the automation rules file for a socket
+// instance.
+func RulesFilePathForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceFileName("rules", socketPath, "json")), nil
+}
+
+// WebhooksFilePathForSocket returns the webhook configuration file for a
+// socket instance.
+func WebhooksFilePathForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceFileName("webhooks", socketPath, "json")), nil
+}
+
+// WebhookQueueFilePathForSocket returns the pending webhook delivery queue
+// for a socket instance.
+func WebhookQueueFilePathForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceFileName("webhook-queue", socketPath, "json")), nil
+}
+
+// WebhookDeadLetterFilePathForSocket returns the log of webhook deliveries
+// that exhausted their retries for a socket instance.
+func WebhookDeadLetterFilePathForSocket(socketPath string) (string, error) {
+	dir, err := EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceFileName("webhook-dead-letter", socketPath, "jsonl")), nil
+}
+
 // PIDFilePath returns the path to the PID file.
 func PIDFilePath() (string, error) {
 	return PIDFilePathForSocket("")
@@ -108,13 +202,31 @@ func LockFilePathForSocket(socketPath string) (string, error) {
 	return filepath.Join(dir, instanceFileName("server", socketPath, "lock")), nil
 }
 
+// ProfileInstance returns the instance key for a session profile hosted next
+// to the primary one by the daemon at instance. Paths derived from it (update
+// journal, update state, webhooks, audit) are scoped to both; an empty
+// profile keeps the daemon's own paths.
+func ProfileInstance(instance, profile string) string {
+	if profile == "" {
+		return instance
+	}
+	if instance == "" {
+		instance = DefaultSocketPath
+	}
+	return instance + "#" + profile
+}
+
 func instanceFileName(prefix, socketPath, ext string) string {
+	return fmt.Sprintf("%s.%s", instanceName(prefix, socketPath), ext)
+}
+
+func instanceName(prefix, socketPath string) string {
 	if isDefaultSocket(socketPath) {
-		return fmt.Sprintf("%s.%s", prefix, ext)
+		return prefix
 	}
 	sum := sha256.Sum256([]byte(socketPath))
 	key := hex.EncodeToString(sum[:])[:12]
-	return fmt.Sprintf("%s-%s.%s", prefix, key, ext)
+	return fmt.Sprintf("%s-%s", prefix, key)
 }
 
 func isDefaultSocket(socketPath string) bool {
@@ -17,6 +17,23 @@ func TestInstanceFileNamesKeepDefaultPaths(t *testing.T) {
 		{name: "audit", fn: AuditFilePath, baseName: "audit.jsonl"},
 		{name: "pid", fn: PIDFilePath, baseName: "server.pid"},
 		{name: "lock", fn: LockFilePath, baseName: "server.lock"},
+		{name: "downloads", fn: DownloadsDir, baseName: "downloads"},
+		{name: "updates", fn: func() (string, error) { return UpdatesDirForSocket("") }, baseName: "updates"},
+		{name: "update state", fn: func() (string, error) { return UpdateStateFilePathForSocket("") }, baseName: "update-state.json"},
+		{name: "peer cache", fn: func() (string, error) { return PeerCacheFilePathForSocket("") }, baseName: "peers.json"},
+		{
+			name:     "update consumers",
+			fn:       func() (string, error) { return UpdateConsumersFilePathForSocket("") },
+			baseName: "update-consumers.json",
+		},
+		{name: "rules", fn: func() (string, error) { return RulesFilePathForSocket("") }, baseName: "rules.json"},
+		{name: "webhooks", fn: func() (string, error) { return WebhooksFilePathForSocket("") }, baseName: "webhooks.json"},
+		{name: "webhook queue", fn: func() (string, error) { return WebhookQueueFilePathForSocket("") }, baseName: "webhook-queue.json"},
+		{
+			name:     "webhook dead letter",
+			fn:       func() (string, error) { return WebhookDeadLetterFilePathForSocket("") },
+			baseName: "webhook-dead-letter.jsonl",
+		},
 	}
 
 	for _, tt := range tests {
@@ -52,3 +69,24 @@ func TestInstanceFileNamesUseSocketScopedSuffix(t *testing.T) {
 		t.Fatalf("custom PID file extension = %q, want .pid", filepath.Ext(customPID))
 	}
 }
+
+func TestProfileInstanceScopesPaths(t *testing.T) {
+	if got := ProfileInstance("/tmp/alt.sock", ""); got != "/tmp/alt.sock" {
+		t.Fatalf("empty profile instance = %q", got)
+	}
+	primary, err := UpdatesDirForSocket("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	work, err := UpdatesDirForSocket(ProfileInstance("", "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	personal, err := UpdatesDirForSocket(ProfileInstance(DefaultSocketPath, "personal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work == primary || personal == primary || work == personal {
+		t.Fatalf("profile paths must differ: %q %q %q", primary, work, personal)
+	}
+}
@@ -0,0 +1,360 @@
+// Package peercache persists users, chats and channels seen by the daemon
+// together with their access hashes, so peers resolve after a restart without
+// contacts.resolveUsername or a dialog scan.
+package peercache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-telegram/internal/fsutil"
+	"agent-telegram/telegram/types"
+)
+
+const (
+	// defaultFlushDelay batches writes: busy responses touch many peers and
+	// losing the last second of them only costs a later lookup.
+	defaultFlushDelay = 2 * time.Second
+	// maxPeers bounds one account's cache; the least recently seen peers are
+	// evicted first.
+	maxPeers = 50000
+	// seenRefresh throttles how often an unchanged peer's UpdatedAt is moved
+	// forward, so peers in steady use stay ahead of eviction without every
+	// response dirtying the file.
+	seenRefresh = time.Hour
+)
+
+type accountPeers struct {
+	Peers map[string]types.CachedPeer `json:"peers"`
+	// usernames maps lowercase usernames to peer keys.
+	usernames map[string]string
+}
+
+type fileFormat struct {
+	Version  int                     `json:"version"`
+	Account  int64                   `json:"account,omitempty"`
+	Accounts map[int64]*accountPeers `json:"accounts"`
+}
+
+// Store is a JSON-file backed peer cache keyed by the authorized account, so
+// access hashes of one account are never used by another. Writes are atomic
+// and owner-only.
+type Store struct {
+	mu         sync.Mutex
+	path       string
+	account    int64
+	accounts   map[int64]*accountPeers
+	dirty      bool
+	flushTimer fsutil.FlushTimer
+	flushDelay time.Duration
+	now        func() time.Time
+}
+
+// Open loads the cache file at path, starting empty when it does not exist.
+// Until BindAccount is called, lookups use the account that was connected
+// last, so cached peers resolve while Telegram is unreachable.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:       path,
+		accounts:   make(map[int64]*accountPeers),
+		flushDelay: defaultFlushDelay,
+		now:        time.Now,
+	}
+	// #nosec G304 -- path is under the owner-only config directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read peer cache: %w", err)
+	}
+	var file fileFormat
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode peer cache: %w", err)
+	}
+	s.account = file.Account
+	for id, account := range file.Accounts {
+		if account == nil {
+			continue
+		}
+		if account.Peers == nil {
+			account.Peers = make(map[string]types.CachedPeer)
+		}
+		account.index()
+		s.accounts[id] = account
+	}
+	return s, nil
+}
+
+// Path returns the cache file.
+func (s *Store) Path() string { return s.path }
+
+// BindAccount selects the authorized account whose peers are read and written.
+func (s *Store) BindAccount(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.account == userID {
+		return
+	}
+	s.account = userID
+	s.markDirty()
+}
+
+// LookupPeer returns a cached peer by kind and ID.
+func (s *Store) LookupPeer(kind string, id int64) (types.CachedPeer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := s.current(false)
+	if account == nil {
+		return types.CachedPeer{}, false
+	}
+	peer, ok := account.Peers[key(kind, id)]
+	return peer, ok
+}
+
+// LookupUsername returns a cached peer by username, ignoring case and a
+// leading "@".
+func (s *Store) LookupUsername(username string) (types.CachedPeer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := s.current(false)
+	if account == nil {
+		return types.CachedPeer{}, false
+	}
+	peerKey, ok := account.usernames[normalizeUsername(username)]
+	if !ok {
+		return types.CachedPeer{}, false
+	}
+	peer, ok := account.Peers[peerKey]
+	return peer, ok
+}
+
+// StorePeers records peers seen in a response or update. Peers that did not
+// change are only rewritten when their last sighting is older than
+// seenRefresh.
+func (s *Store) StorePeers(peers []types.CachedPeer) {
+	if len(peers) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := s.current(true)
+	if account == nil {
+		return
+	}
+	now := s.now().UTC()
+	changed := false
+	for _, peer := range peers {
+		if peer.ID == 0 || peer.Kind == "" {
+			continue
+		}
+		peerKey := key(peer.Kind, peer.ID)
+		previous, exists := account.Peers[peerKey]
+		if exists && previous.AccessHash != 0 && peer.AccessHash == 0 {
+			peer.AccessHash = previous.AccessHash
+		}
+		if exists && samePeer(previous, peer) {
+			if now.Sub(previous.UpdatedAt) >= seenRefresh {
+				previous.UpdatedAt = now
+				account.Peers[peerKey] = previous
+				changed = true
+			}
+			continue
+		}
+		if exists {
+			account.unindex(previous, peerKey)
+		}
+		peer.UpdatedAt = now
+		account.Peers[peerKey] = peer
+		if peer.Username != "" {
+			account.usernames[normalizeUsername(peer.Username)] = peerKey
+		}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if len(account.Peers) > maxPeers {
+		account.evict(len(account.Peers) - maxPeers*9/10)
+	}
+	s.markDirty()
+}
+
+// ForgetPeer drops a peer whose access hash Telegram rejected.
+func (s *Store) ForgetPeer(kind string, id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := s.current(false)
+	if account == nil {
+		return
+	}
+	peerKey := key(kind, id)
+	peer, ok := account.Peers[peerKey]
+	if !ok {
+		return
+	}
+	delete(account.Peers, peerKey)
+	account.unindex(peer, peerKey)
+	s.markDirty()
+}
+
+// ForgetUsername drops the username of a cached peer, for example after
+// USERNAME_NOT_OCCUPIED. The peer itself stays addressable by ID.
+func (s *Store) ForgetUsername(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := s.current(false)
+	if account == nil {
+		return
+	}
+	name := normalizeUsername(username)
+	peerKey, ok := account.usernames[name]
+	if !ok {
+		return
+	}
+	delete(account.usernames, name)
+	if peer, ok := account.Peers[peerKey]; ok {
+		peer.Username = ""
+		account.Peers[peerKey] = peer
+	}
+	s.markDirty()
+}
+
+// Stats counts the cached peers of the current account.
+func (s *Store) Stats() types.PeerCacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := types.PeerCacheStats{Persistent: true, Path: s.path, Account: s.account}
+	account := s.current(false)
+	if account == nil {
+		return stats
+	}
+	for _, peer := range account.Peers {
+		switch peer.Kind {
+		case types.PeerKindUser:
+			stats.Users++
+		case types.PeerKindChat:
+			stats.Chats++
+		case types.PeerKindChannel:
+			stats.Channels++
+		}
+	}
+	stats.Usernames = len(account.usernames)
+	return stats
+}
+
+// Clear removes the current account's peers and writes the file at once. It
+// returns the number of peers removed.
+func (s *Store) Clear() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := s.current(false)
+	if account == nil {
+		return 0, nil
+	}
+	cleared := len(account.Peers)
+	delete(s.accounts, s.account)
+	s.dirty = true
+	return cleared, s.flushLocked()
+}
+
+// Flush writes pending changes immediately.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+// Close flushes pending changes and stops background writes.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushTimer.Stop()
+	return s.flushLocked()
+}
+
+// current returns the bound account's peers, creating them when asked.
+// Callers hold s.mu.
+func (s *Store) current(create bool) *accountPeers {
+	if s.account == 0 {
+		return nil
+	}
+	account, ok := s.accounts[s.account]
+	if !ok && create {
+		account = &accountPeers{Peers: make(map[string]types.CachedPeer)}
+		account.index()
+		s.accounts[s.account] = account
+	}
+	return account
+}
+
+func (a *accountPeers) index() {
+	a.usernames = make(map[string]string)
+	for peerKey, peer := range a.Peers {
+		if peer.Username != "" {
+			a.usernames[normalizeUsername(peer.Username)] = peerKey
+		}
+	}
+}
+
+// unindex removes a peer's username unless it now belongs to another peer.
+func (a *accountPeers) unindex(peer types.CachedPeer, peerKey string) {
+	name := normalizeUsername(peer.Username)
+	if name != "" && a.usernames[name] == peerKey {
+		delete(a.usernames, name)
+	}
+}
+
+// evict drops the n least recently updated peers.
+func (a *accountPeers) evict(n int) {
+	keys := make([]string, 0, len(a.Peers))
+	for peerKey := range a.Peers {
+		keys = append(keys, peerKey)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return a.Peers[keys[i]].UpdatedAt.Before(a.Peers[keys[j]].UpdatedAt)
+	})
+	for _, peerKey := range keys[:n] {
+		a.unindex(a.Peers[peerKey], peerKey)
+		delete(a.Peers, peerKey)
+	}
+}
+
+// markDirty schedules a write of the cache file. Callers hold s.mu.
+func (s *Store) markDirty() {
+	s.dirty = true
+	s.flushTimer.Schedule(s.flushDelay, func() { _ = s.Flush() })
+}
+
+func (s *Store) flushLocked() error {
+	if !s.dirty {
+		return nil
+	}
+	data, err := json.Marshal(fileFormat{Version: 1, Account: s.account, Accounts: s.accounts})
+	if err != nil {
+		return fmt.Errorf("encode peer cache: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("write peer cache: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+func samePeer(a, b types.CachedPeer) bool {
+	return a.AccessHash == b.AccessHash && a.Username == b.Username && a.Title == b.Title
+}
+
+func key(kind string, id int64) string {
+	return kind + ":" + strconv.FormatInt(id, 10)
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+}
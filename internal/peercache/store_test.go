@@ -0,0 +1,135 @@
+package peercache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agent-telegram/telegram/types"
+)
+
+func TestStoreRoundTripAcrossOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.StorePeers([]types.CachedPeer{{Kind: types.PeerKindUser, ID: 1, AccessHash: 11, Username: "alice"}})
+	if _, ok := s.LookupPeer(types.PeerKindUser, 1); ok {
+		t.Fatal("peers stored before BindAccount should be dropped")
+	}
+	s.BindAccount(100)
+	s.StorePeers([]types.CachedPeer{
+		{Kind: types.PeerKindUser, ID: 1, AccessHash: 11, Username: "Alice"},
+		{Kind: types.PeerKindChannel, ID: 2, AccessHash: 22, Username: "news", Title: "News"},
+		{Kind: types.PeerKindChat, ID: 3, Title: "Team"},
+	})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("cache file mode = %o, want 600", perm)
+	}
+
+	// A reopened store resolves with the last bound account before it
+	// connects again.
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peer, ok := s.LookupUsername("@ALICE"); !ok || peer.ID != 1 || peer.AccessHash != 11 {
+		t.Fatalf("username lookup = %+v, %v", peer, ok)
+	}
+	if peer, ok := s.LookupPeer(types.PeerKindChannel, 2); !ok || peer.AccessHash != 22 {
+		t.Fatalf("channel lookup = %+v, %v", peer, ok)
+	}
+	if stats := s.Stats(); stats.Users != 1 || stats.Channels != 1 || stats.Chats != 1 || stats.Usernames != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	// Another account never sees these access hashes.
+	s.BindAccount(200)
+	if _, ok := s.LookupPeer(types.PeerKindUser, 1); ok {
+		t.Fatal("peer leaked across accounts")
+	}
+}
+
+func TestStoreInvalidation(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "peers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	s.BindAccount(100)
+	s.StorePeers([]types.CachedPeer{
+		{Kind: types.PeerKindUser, ID: 1, AccessHash: 11, Username: "alice"},
+		{Kind: types.PeerKindChannel, ID: 2, AccessHash: 22, Username: "news"},
+	})
+
+	// A username taken over by another peer follows it.
+	s.StorePeers([]types.CachedPeer{{Kind: types.PeerKindUser, ID: 5, AccessHash: 55, Username: "alice"}})
+	if peer, ok := s.LookupUsername("alice"); !ok || peer.ID != 5 {
+		t.Fatalf("moved username lookup = %+v, %v", peer, ok)
+	}
+	s.StorePeers([]types.CachedPeer{{Kind: types.PeerKindUser, ID: 1, AccessHash: 11, Title: "Alice"}})
+	if peer, ok := s.LookupUsername("alice"); !ok || peer.ID != 5 {
+		t.Fatalf("username lost after old owner changed: %+v, %v", peer, ok)
+	}
+
+	s.ForgetUsername("alice")
+	if _, ok := s.LookupUsername("alice"); ok {
+		t.Fatal("forgotten username still resolves")
+	}
+	if _, ok := s.LookupPeer(types.PeerKindUser, 5); !ok {
+		t.Fatal("forgetting a username should keep the peer")
+	}
+
+	s.ForgetPeer(types.PeerKindChannel, 2)
+	if _, ok := s.LookupPeer(types.PeerKindChannel, 2); ok {
+		t.Fatal("forgotten channel still resolves")
+	}
+	if _, ok := s.LookupUsername("news"); ok {
+		t.Fatal("forgotten channel username still resolves")
+	}
+
+	cleared, err := s.Clear()
+	if err != nil || cleared != 2 {
+		t.Fatalf("Clear() = %d, %v; want 2", cleared, err)
+	}
+	if stats := s.Stats(); stats.Users != 0 {
+		t.Fatalf("stats after clear = %+v", stats)
+	}
+}
+
+func TestStorePeersRefreshesLastSeen(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "peers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	now := time.Unix(1_700_000_000, 0).UTC()
+	s.now = func() time.Time { return now }
+	s.BindAccount(100)
+	alice := types.CachedPeer{Kind: types.PeerKindUser, ID: 1, AccessHash: 11, Username: "alice"}
+	s.StorePeers([]types.CachedPeer{alice})
+	first := now
+
+	// Sightings within seenRefresh leave the peer untouched.
+	now = now.Add(seenRefresh / 2)
+	s.StorePeers([]types.CachedPeer{alice})
+	if peer, _ := s.LookupPeer(types.PeerKindUser, 1); !peer.UpdatedAt.Equal(first) {
+		t.Fatalf("UpdatedAt = %s, want %s", peer.UpdatedAt, first)
+	}
+
+	// A later sighting of the unchanged peer counts for eviction.
+	now = now.Add(seenRefresh)
+	s.StorePeers([]types.CachedPeer{alice})
+	if peer, _ := s.LookupPeer(types.PeerKindUser, 1); !peer.UpdatedAt.Equal(now) {
+		t.Fatalf("UpdatedAt = %s, want %s", peer.UpdatedAt, now)
+	}
+}
@@ -89,6 +89,21 @@ func DefaultPath() (string, error) {
 	return filepath.Join(dir, "policy.json"), nil
 }
 
+// ProfilePath returns the policy path for an additional profile hosted by the
+// daemon: policy.<profile>.json when it exists, otherwise the shared default
+// policy, so a new profile never starts with looser rules than the primary.
+func ProfilePath(profile string) (string, error) {
+	dir, err := paths.EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "policy."+profile+".json")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return filepath.Join(dir, "policy.json"), nil
+}
+
 // LoadDefault loads the default policy file, returning defaults if it is absent.
 func LoadDefault() (Policy, error) {
 	path, err := DefaultPath()
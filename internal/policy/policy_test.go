@@ -3,6 +3,7 @@ package policy
 import (
 	"context"
 	"encoding/json"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -114,3 +115,26 @@ func TestEnforcerRejectsUnknownAndRequiresConfirmation(t *testing.T) {
 		t.Fatalf("confirmed logout denied: %v", err)
 	}
 }
+
+func TestProfilePathFallsBackToSharedPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	shared, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := ProfilePath("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != shared {
+		t.Fatalf("path without profile file = %q, want %q", path, shared)
+	}
+
+	own := filepath.Join(filepath.Dir(shared), "policy.work.json")
+	if err := os.WriteFile(own, []byte(`{}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if path, err = ProfilePath("work"); err != nil || path != own {
+		t.Fatalf("path = %q, %v; want %q", path, err, own)
+	}
+}
@@ -12,6 +12,7 @@ import (
 )
 
 type fileFingerprint struct {
+	path   string
 	exists bool
 	digest [sha256.Size]byte
 }
@@ -20,7 +21,7 @@ type fileFingerprint struct {
 // Its current Enforcer is an immutable snapshot, and invalid replacements never
 // displace the last valid snapshot.
 type ReloadingEnforcer struct {
-	path        string
+	path        func() (string, error)
 	resolver    PeerResolver
 	mu          sync.Mutex
 	current     *Enforcer
@@ -31,6 +32,17 @@ type ReloadingEnforcer struct {
 
 // NewReloadingEnforcer creates an enforcer backed by a reloadable policy file.
 func NewReloadingEnforcer(path string, resolver PeerResolver) *ReloadingEnforcer {
+	return newReloadingEnforcer(func() (string, error) { return path, nil }, resolver)
+}
+
+// NewProfileEnforcer creates a reloading enforcer for a hosted profile. The
+// file is chosen with ProfilePath on every refresh, so creating or removing
+// policy.<profile>.json takes effect without a restart.
+func NewProfileEnforcer(profile string, resolver PeerResolver) *ReloadingEnforcer {
+	return newReloadingEnforcer(func() (string, error) { return ProfilePath(profile) }, resolver)
+}
+
+func newReloadingEnforcer(path func() (string, error), resolver PeerResolver) *ReloadingEnforcer {
 	e := &ReloadingEnforcer{
 		path:     path,
 		resolver: resolver,
@@ -58,13 +70,18 @@ func (e *ReloadingEnforcer) refresh() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	data, err := os.ReadFile(e.path)
+	path, err := e.path()
+	if err != nil {
+		e.logReadFailure(err)
+		return
+	}
+	data, err := os.ReadFile(path)
 	if err != nil && !os.IsNotExist(err) {
 		e.logReadFailure(err)
 		return
 	}
 
-	fingerprint := fileFingerprint{exists: err == nil}
+	fingerprint := fileFingerprint{path: path, exists: err == nil}
 	if err == nil {
 		fingerprint.digest = sha256.Sum256(data)
 	}
@@ -83,6 +100,7 @@ func (e *ReloadingEnforcer) refresh() {
 		if err != nil {
 			e.lastFailure = err.Error()
 			slog.Warn("policy reload failed",
+				"path", path,
 				"exists", true,
 				"digest", fingerprint.digestString(),
 				"error", err,
@@ -94,6 +112,7 @@ func (e *ReloadingEnforcer) refresh() {
 	e.current = NewEnforcer(p, e.resolver)
 	e.lastFailure = ""
 	slog.Info("policy reloaded",
+		"path", path,
 		"exists", fingerprint.exists,
 		"digest", fingerprint.digestString(),
 	)
@@ -142,3 +142,30 @@ func writePolicyFile(t *testing.T, path, body string) {
 		t.Fatal(err)
 	}
 }
+
+func TestProfileEnforcerFollowsProfileFileCreation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	shared, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePolicyFile(t, shared, `{"version":1,"allowPeers":["@grace"]}`)
+	params := json.RawMessage(`{"peer":"@ada","message":"hello"}`)
+	enforcer := NewProfileEnforcer("work", nil)
+	if err := enforcer.Check(context.Background(), "send_message", params); err == nil {
+		t.Fatal("@ada should be denied by the shared policy")
+	}
+
+	own := filepath.Join(filepath.Dir(shared), "policy.work.json")
+	writePolicyFile(t, own, `{"version":1,"allowPeers":["@ada"]}`)
+	if err := enforcer.Check(context.Background(), "send_message", params); err != nil {
+		t.Fatalf("profile policy created after start was not applied: %v", err)
+	}
+
+	if err := os.Remove(own); err != nil {
+		t.Fatal(err)
+	}
+	if err := enforcer.Check(context.Background(), "send_message", params); err == nil {
+		t.Fatal("removing the profile policy should fall back to the shared one")
+	}
+}
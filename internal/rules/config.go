@@ -0,0 +1,179 @@
+// Package rules runs declarative automation rules inside the daemon. A stored
+// update that matches a rule's trigger runs registered operations with params
+// templated from the update, through the account's policy and audit journal.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"agent-telegram/internal/operations"
+	"agent-telegram/telegram/types"
+)
+
+const version = 1
+
+// Match directions. Rules match incoming updates unless they say otherwise,
+// so a reply never triggers the rule that sent it.
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+	DirectionAny = "any"
+)
+
+// MediaNone matches messages without media.
+const MediaNone = "none"
+
+// DefaultRateLimit applies to rules without a rateLimit.
+var DefaultRateLimit = RateLimit{Max: 30, Per: "1m"}
+
+var mediaTypes = []string{"photo", "document", "webpage", "geo", "contact", "poll", "dice", "unknown", MediaNone}
+
+// Config is the per-instance rules.json file.
+type Config struct {
+	Version int    `json:"version"`
+	Rules   []Rule `json:"rules"`
+	// FileRoots are the directories actions may read files from or write
+	// downloads to. Without them, file and output params are rejected.
+	FileRoots []string `json:"fileRoots,omitempty"`
+}
+
+// Rule is one trigger and the actions it runs, in order. An action that
+// fails stops the remaining actions of that run.
+type Rule struct {
+	ID       string `json:"id"`
+	Disabled bool   `json:"disabled,omitempty"`
+	// DryRun validates, policy-checks and audits the actions without
+	// running them.
+	DryRun  bool     `json:"dryRun,omitempty"`
+	Match   Match    `json:"match"`
+	Actions []Action `json:"actions"`
+	// RateLimit caps how often the rule runs. Nil uses DefaultRateLimit.
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+}
+
+// Match selects updates. Zero fields match everything.
+type Match struct {
+	Types []types.UpdateType `json:"types,omitempty"`
+	// Peers and Senders are allow lists of chats and message authors
+	// (user:123, 123, @username).
+	Peers   []string `json:"peers,omitempty"`
+	Senders []string `json:"senders,omitempty"`
+	// Text is a regular expression matched against the message text. Its
+	// groups are available to templates as {{match.1}} or {{match.name}}.
+	Text string `json:"text,omitempty"`
+	// Media lists message media types, or "none" for messages without media.
+	Media []string `json:"media,omitempty"`
+	// Direction is "in" (default), "out" or "any".
+	Direction string `json:"direction,omitempty"`
+}
+
+// Action is an operation run when a rule matches. String params may contain
+// {{path}} placeholders filled from the update.
+type Action struct {
+	Method string         `json:"method"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// RateLimit allows at most Max runs of a rule per sliding window Per
+// (a Go duration such as "1m").
+type RateLimit struct {
+	Max int    `json:"max"`
+	Per string `json:"per"`
+}
+
+// compiledRule is a validated rule ready for matching.
+type compiledRule struct {
+	Rule
+	text   *regexp.Regexp
+	max    int
+	window time.Duration
+}
+
+// ParseConfig decodes and validates a rules file.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode rules: %w", err)
+	}
+	if _, err := cfg.compile(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// compile validates every rule and prepares its regular expression and rate
+// limit.
+func (c Config) compile() ([]*compiledRule, error) {
+	if c.Version != 0 && c.Version != version {
+		return nil, fmt.Errorf("unsupported rules version %d", c.Version)
+	}
+	seen := make(map[string]struct{}, len(c.Rules))
+	compiled := make([]*compiledRule, 0, len(c.Rules))
+	for i, rule := range c.Rules {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("rule %d: id is required", i)
+		}
+		if _, ok := seen[rule.ID]; ok {
+			return nil, fmt.Errorf("rule %q: duplicate id", rule.ID)
+		}
+		seen[rule.ID] = struct{}{}
+		r, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.ID, err)
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+func compileRule(rule Rule) (*compiledRule, error) {
+	r := &compiledRule{Rule: rule}
+	switch rule.Match.Direction {
+	case "", DirectionIn, DirectionOut, DirectionAny:
+	default:
+		return nil, fmt.Errorf("direction must be %q, %q or %q", DirectionIn, DirectionOut, DirectionAny)
+	}
+	if rule.Match.Text != "" {
+		text, err := regexp.Compile(rule.Match.Text)
+		if err != nil {
+			return nil, fmt.Errorf("text: %w", err)
+		}
+		r.text = text
+	}
+	for _, media := range rule.Match.Media {
+		if !slices.Contains(mediaTypes, media) {
+			return nil, fmt.Errorf("unknown media type %q", media)
+		}
+	}
+
+	if len(rule.Actions) == 0 {
+		return nil, fmt.Errorf("at least one action is required")
+	}
+	for i, action := range rule.Actions {
+		op, ok := operations.Get(action.Method)
+		if !ok {
+			return nil, fmt.Errorf("action %d: unknown method %q", i, action.Method)
+		}
+		if op.RequiresConfirmation {
+			return nil, fmt.Errorf("action %d: %s requires confirmation and cannot run from a rule", i, action.Method)
+		}
+		if err := checkTemplates(action.Params); err != nil {
+			return nil, fmt.Errorf("action %d: %w", i, err)
+		}
+	}
+
+	limit := DefaultRateLimit
+	if rule.RateLimit != nil {
+		limit = *rule.RateLimit
+	}
+	window, err := time.ParseDuration(limit.Per)
+	if err != nil || window <= 0 || limit.Max <= 0 {
+		return nil, fmt.Errorf("rateLimit needs a positive max and a duration per, e.g. {\"max\": 5, \"per\": \"1m\"}")
+	}
+	r.max, r.window = limit.Max, window
+	return r, nil
+}
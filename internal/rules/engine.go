@@ -0,0 +1,464 @@
+package rules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/observability"
+	"agent-telegram/internal/operations"
+	"agent-telegram/internal/policy"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
+)
+
+// resolveTTL is how long a failed @username resolution is remembered.
+const resolveTTL = 5 * time.Minute
+
+// Options configures an Engine.
+type Options struct {
+	// Path is the rules file. It is re-read when its content changes.
+	Path string
+	// Policy checks every action, as for requests from clients.
+	Policy ipc.PolicyChecker
+	// Resolver maps @username peers and senders to typed peers.
+	Resolver policy.PeerResolver
+	// AuditSocket selects the instance audit journal actions are recorded in.
+	AuditSocket string
+}
+
+type fileFingerprint struct {
+	exists bool
+	digest [sha256.Size]byte
+}
+
+type ruleStats struct {
+	matched, executed, failed, rateLimited int64
+	lastRun                                time.Time
+	lastError                              string
+	// runs holds run times inside the rate limit window.
+	runs []time.Time
+}
+
+type resolved struct {
+	peer string
+	at   time.Time
+}
+
+// Engine matches stored updates against the rules file and runs the actions
+// of matching rules. Operations are registered on it like on a server, so
+// actions run the same handlers as client requests.
+type Engine struct {
+	opts Options
+
+	handlersMu sync.RWMutex
+	handlers   map[string]ipc.Handler
+
+	mu          sync.Mutex
+	rules       []*compiledRule
+	fileRoots   []string
+	attempted   fileFingerprint
+	hasAttempt  bool
+	lastFailure string
+	stats       map[string]*ruleStats
+
+	resolveMu sync.Mutex
+	resolved  map[string]resolved
+
+	now func() time.Time
+}
+
+// New creates an engine and loads the rules file. A missing file means no
+// rules; an invalid one is logged and retried when it changes.
+func New(opts Options) *Engine {
+	e := &Engine{
+		opts:     opts,
+		handlers: make(map[string]ipc.Handler),
+		stats:    make(map[string]*ruleStats),
+		resolved: make(map[string]resolved),
+		now:      time.Now,
+	}
+	e.refresh()
+	return e
+}
+
+// Register implements ipc.MethodRegistrar.
+func (e *Engine) Register(name string, handler ipc.Handler) {
+	e.handlersMu.Lock()
+	defer e.handlersMu.Unlock()
+	e.handlers[name] = handler
+}
+
+// Run applies the rules to every update stored after it starts, until ctx is
+// cancelled.
+func (e *Engine) Run(ctx context.Context, src updatefeed.Source, feed *updatefeed.Feed) {
+	wake, stop := feed.Watch()
+	defer stop()
+	err := updatefeed.Follow(ctx, src, wake, nil, updatefeed.Filter{}, updatefeed.Head(src),
+		func(batch updatefeed.Batch) error {
+			for _, update := range batch.Updates {
+				e.Handle(ctx, update)
+			}
+			return nil
+		})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Warn("rules: update feed ended", "error", err)
+	}
+}
+
+// Handle runs the actions of every enabled rule that matches update.
+func (e *Engine) Handle(ctx context.Context, update types.StoredUpdate) {
+	rules := e.snapshot()
+	if len(rules) == 0 {
+		return
+	}
+	ev := newEvent(update)
+	resolve := func(name string) string { return e.resolve(ctx, name) }
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		groups, ok := rule.match(ev, resolve)
+		if !ok {
+			continue
+		}
+		if !e.admit(rule) {
+			slog.Warn("rules: rate limit exceeded", "rule", rule.ID, "update_id", update.ID)
+			continue
+		}
+		e.execute(ctx, rule, ev.templateScope(groups), update.ID)
+	}
+}
+
+// execute runs a rule's actions in order, stopping at the first failure.
+func (e *Engine) execute(ctx context.Context, rule *compiledRule, scope map[string]any, updateID int64) {
+	runID := "rule:" + rule.ID
+	var failure string
+	for _, action := range rule.Actions {
+		if rpcErr := e.runAction(ctx, runID, rule.DryRun, action, scope); rpcErr != nil {
+			failure = fmt.Sprintf("%s: %s", action.Method, rpcErr.Message)
+			slog.Warn("rules: action failed", "rule", rule.ID, "update_id", updateID,
+				"method", action.Method, "error", rpcErr.Message)
+			break
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := e.ruleStats(rule.ID)
+	if failure != "" {
+		stats.failed++
+		stats.lastError = failure
+		return
+	}
+	stats.executed++
+	stats.lastError = ""
+}
+
+// runAction renders, validates, policy-checks and (unless dryRun) runs one
+// action, and records it in the audit journal.
+func (e *Engine) runAction(
+	ctx context.Context, runID string, dryRun bool, action Action, scope map[string]any,
+) *ipc.ErrorObject {
+	start := time.Now()
+	traceID := observability.NewTraceID()
+	ctx = ipc.WithTrace(ipc.WithSurface(ctx, ipc.SurfaceRules), runID, traceID)
+	e.mu.Lock()
+	ctx = ipc.WithFileRoots(ctx, e.fileRoots)
+	e.mu.Unlock()
+
+	params, result, rpcErr := e.plan(ctx, action, scope)
+	if rpcErr == nil && !dryRun {
+		result, rpcErr = e.call(ctx, action.Method, params)
+	}
+	e.audit(runID, traceID, action.Method, params, result, rpcErr, time.Since(start), dryRun)
+	return rpcErr
+}
+
+// plan renders an action's params and checks them against the operation
+// schema and the policy, without running the action.
+func (e *Engine) plan(ctx context.Context, action Action, scope map[string]any) (json.RawMessage, any, *ipc.ErrorObject) {
+	rendered, err := render(action.Params, scope)
+	if err != nil {
+		return nil, nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+	}
+	if rendered == nil {
+		rendered = map[string]any{}
+	}
+	params, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+	}
+	if err := operations.ValidateParams(action.Method, params); err != nil {
+		return params, nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+	}
+	if e.opts.Policy != nil {
+		checkCtx, cancel := context.WithTimeout(ctx, ipc.RequestTimeout())
+		defer cancel()
+		if err := e.opts.Policy.Check(checkCtx, action.Method, params); err != nil {
+			rpcErr := ipc.ErrorObjectFromError(err)
+			if rpcErr == nil {
+				rpcErr = ipc.NewTypedError(ipc.ErrCodeForbidden, ipc.ErrorTypeForbidden, err.Error(), nil)
+			}
+			return params, nil, rpcErr
+		}
+	}
+	return params, nil, nil
+}
+
+func (e *Engine) call(ctx context.Context, method string, params json.RawMessage) (any, *ipc.ErrorObject) {
+	e.handlersMu.RLock()
+	handler, ok := e.handlers[method]
+	e.handlersMu.RUnlock()
+	if !ok {
+		return nil, ipc.NewTypedError(ipc.ErrCodeMethodNotFound, ipc.ErrorTypeMethodNotFound,
+			method+" cannot run from a rule", nil)
+	}
+	return handler(ctx, params)
+}
+
+func (e *Engine) audit(
+	runID, traceID, method string,
+	params json.RawMessage,
+	result any,
+	rpcErr *ipc.ErrorObject,
+	duration time.Duration,
+	dryRun bool,
+) {
+	event := observability.AuditEvent{
+		Time:       time.Now().UTC(),
+		RunID:      runID,
+		TraceID:    traceID,
+		Surface:    ipc.SurfaceRules,
+		Method:     method,
+		DryRun:     dryRun,
+		Status:     "ok",
+		DurationMs: duration.Milliseconds(),
+		Params:     params,
+	}
+	if op, ok := operations.Get(method); ok {
+		event.Safety = op.Safety
+	}
+	if rpcErr != nil {
+		event.Status = "error"
+		event.ErrorCode = rpcErr.Code
+		event.Error = rpcErr.Message
+		if data, ok := rpcErr.Data.(map[string]any); ok {
+			event.ErrorType, _ = data["type"].(string)
+		}
+	} else {
+		event.ResultSummary = observability.SummarizeResult(result)
+	}
+	if err := observability.WriteAudit(e.opts.AuditSocket, event); err != nil {
+		slog.Warn("rules: audit write failed", "trace_id", traceID, "error", err)
+	}
+}
+
+// Test reports the rules update matches and the actions they would run,
+// with rendered params and policy decisions. Nothing runs and rate limits
+// and counters are left alone.
+func (e *Engine) Test(ctx context.Context, update types.StoredUpdate) operations.RulesTestResult {
+	rules := e.snapshot()
+	ev := newEvent(update)
+	resolve := func(name string) string { return e.resolve(ctx, name) }
+	result := operations.RulesTestResult{Matches: []operations.RuleMatch{}}
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		groups, ok := rule.match(ev, resolve)
+		if !ok {
+			continue
+		}
+		match := operations.RuleMatch{Rule: rule.ID, DryRun: rule.DryRun}
+		scope := ev.templateScope(groups)
+		for _, action := range rule.Actions {
+			plan := operations.RuleActionPlan{Method: action.Method}
+			if op, ok := operations.Get(action.Method); ok {
+				plan.Safety = op.Safety
+			}
+			params, _, rpcErr := e.plan(ctx, action, scope)
+			if len(params) > 0 {
+				_ = json.Unmarshal(params, &plan.Params)
+			}
+			plan.Allowed = rpcErr == nil
+			if rpcErr != nil {
+				plan.Error = rpcErr.Message
+			}
+			match.Actions = append(match.Actions, plan)
+		}
+		result.Matches = append(result.Matches, match)
+	}
+	result.Count = len(result.Matches)
+	return result
+}
+
+// List describes the loaded rules with their counters.
+func (e *Engine) List() operations.ListRulesResult {
+	rules := e.snapshot()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	result := operations.ListRulesResult{Rules: []operations.RuleInfo{}, Error: e.lastFailure}
+	for _, rule := range rules {
+		info := operations.RuleInfo{
+			ID:       rule.ID,
+			Disabled: rule.Disabled,
+			DryRun:   rule.DryRun,
+			Types:    rule.Match.Types,
+			Methods:  []string{},
+		}
+		for _, action := range rule.Actions {
+			info.Methods = append(info.Methods, action.Method)
+		}
+		if stats, ok := e.stats[rule.ID]; ok {
+			info.Matched = stats.matched
+			info.Executed = stats.executed
+			info.Failed = stats.failed
+			info.RateLimited = stats.rateLimited
+			info.LastError = stats.lastError
+			if !stats.lastRun.IsZero() {
+				info.LastRunAt = stats.lastRun.Unix()
+			}
+		}
+		result.Rules = append(result.Rules, info)
+	}
+	result.Count = len(result.Rules)
+	return result
+}
+
+// admit counts a match and reports whether the rule is within its rate
+// limit, recording the run if so.
+func (e *Engine) admit(rule *compiledRule) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	stats := e.ruleStats(rule.ID)
+	stats.matched++
+	cutoff := now.Add(-rule.window)
+	kept := stats.runs[:0]
+	for _, run := range stats.runs {
+		if run.After(cutoff) {
+			kept = append(kept, run)
+		}
+	}
+	stats.runs = kept
+	if len(stats.runs) >= rule.max {
+		stats.rateLimited++
+		return false
+	}
+	stats.runs = append(stats.runs, now)
+	stats.lastRun = now
+	return true
+}
+
+// ruleStats returns the counters of a rule. Callers hold e.mu. Counters are
+// keyed by rule ID so they survive reloads.
+func (e *Engine) ruleStats(id string) *ruleStats {
+	stats, ok := e.stats[id]
+	if !ok {
+		stats = &ruleStats{}
+		e.stats[id] = stats
+	}
+	return stats
+}
+
+// snapshot reloads the rules file if it changed and returns the current rules.
+func (e *Engine) snapshot() []*compiledRule {
+	e.refresh()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rules
+}
+
+// refresh re-reads the rules file. Invalid replacements never displace the
+// last valid rules.
+func (e *Engine) refresh() {
+	if e.opts.Path == "" {
+		return
+	}
+	// #nosec G304 -- path is under the owner-only config directory
+	data, err := os.ReadFile(e.opts.Path)
+	if err != nil && !os.IsNotExist(err) {
+		e.fail(fmt.Sprintf("read rules: %v", err))
+		return
+	}
+	fingerprint := fileFingerprint{exists: err == nil}
+	if err == nil {
+		fingerprint.digest = sha256.Sum256(data)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.hasAttempt && e.attempted == fingerprint {
+		return
+	}
+	e.attempted = fingerprint
+	e.hasAttempt = true
+
+	var rules []*compiledRule
+	var cfg Config
+	if fingerprint.exists {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			e.failLocked(fmt.Sprintf("decode rules: %v", err))
+			return
+		}
+		if rules, err = cfg.compile(); err != nil {
+			e.failLocked(err.Error())
+			return
+		}
+	}
+	e.rules = rules
+	e.fileRoots = cfg.FileRoots
+	e.lastFailure = ""
+	if fingerprint.exists {
+		slog.Info("rules reloaded", "path", e.opts.Path, "count", len(rules))
+	}
+}
+
+func (e *Engine) fail(failure string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failLocked(failure)
+}
+
+func (e *Engine) failLocked(failure string) {
+	if e.lastFailure == failure {
+		return
+	}
+	e.lastFailure = failure
+	slog.Warn("rules reload failed", "path", e.opts.Path, "error", failure)
+}
+
+// resolve maps an @username to a typed peer, caching the answer. Failures
+// are cached for resolveTTL so an unknown name does not hit Telegram for
+// every update.
+func (e *Engine) resolve(ctx context.Context, name string) string {
+	if e.opts.Resolver == nil {
+		return ""
+	}
+	e.resolveMu.Lock()
+	cached, ok := e.resolved[name]
+	e.resolveMu.Unlock()
+	if ok && (cached.peer != "" || time.Since(cached.at) < resolveTTL) {
+		return cached.peer
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	peer, err := e.opts.Resolver.ResolvePeerID(ctx, name)
+	if err != nil {
+		slog.Warn("rules: failed to resolve peer", "peer", name, "error", err)
+		peer = ""
+	}
+	e.resolveMu.Lock()
+	e.resolved[name] = resolved{peer: peer, at: time.Now()}
+	e.resolveMu.Unlock()
+	return peer
+}
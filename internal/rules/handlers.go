@@ -0,0 +1,28 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/operations"
+	"agent-telegram/internal/strictjson"
+)
+
+// RegisterHandlers registers list_rules and test_rules.
+func RegisterHandlers(srv ipc.MethodRegistrar, e *Engine) {
+	srv.Register("list_rules", func(context.Context, json.RawMessage) (any, *ipc.ErrorObject) {
+		return e.List(), nil
+	})
+
+	srv.Register("test_rules", func(ctx context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+		var p operations.RulesTestParams
+		if err := strictjson.Decode(params, &p); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		return e.Test(ctx, p.Update), nil
+	})
+}
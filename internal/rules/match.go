@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"agent-telegram/internal/policy"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
+)
+
+// event is an update prepared for matching and templating.
+type event struct {
+	update types.StoredUpdate
+	// scope is the template scope: update, data, message, peer, sender and
+	// text, decoded from the update's JSON form.
+	scope   map[string]any
+	message map[string]any
+	peer    string
+	sender  string
+	text    string
+	media   string
+	out     bool
+}
+
+func newEvent(update types.StoredUpdate) event {
+	ev := event{update: update, peer: updatefeed.UpdatePeer(update)}
+	var raw map[string]any
+	if data, err := json.Marshal(update); err == nil {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		_ = decoder.Decode(&raw)
+	}
+	payload, _ := raw["data"].(map[string]any)
+	ev.message, _ = payload["message"].(map[string]any)
+
+	source := payload
+	if ev.message != nil {
+		source = ev.message
+		ev.text, _ = ev.message["text"].(string)
+		ev.media = MediaNone
+		if media, ok := ev.message["media"].(map[string]any); ok {
+			ev.media, _ = media["type"].(string)
+		}
+	}
+	ev.out, _ = source["out"].(bool)
+	ev.sender, _ = source["from"].(string)
+	if ev.sender == "" && !ev.out && strings.HasPrefix(ev.peer, policy.PeerTypeUser+":") {
+		ev.sender = ev.peer
+	}
+
+	ev.scope = map[string]any{"update": raw, "data": payload}
+	if ev.message != nil {
+		ev.scope["message"] = ev.message
+		ev.scope["text"] = ev.text
+	}
+	if ev.peer != "" {
+		ev.scope["peer"] = ev.peer
+	}
+	if ev.sender != "" {
+		ev.scope["sender"] = ev.sender
+	}
+	return ev
+}
+
+// match reports whether the rule's trigger matches ev and returns the text
+// pattern's groups. resolve maps @usernames to typed peers.
+func (r *compiledRule) match(ev event, resolve func(string) string) (map[string]any, bool) {
+	m := r.Match
+	if len(m.Types) > 0 && !slices.Contains(m.Types, ev.update.Type) {
+		return nil, false
+	}
+	switch m.Direction {
+	case "", DirectionIn:
+		if ev.out {
+			return nil, false
+		}
+	case DirectionOut:
+		if !ev.out {
+			return nil, false
+		}
+	}
+	if !peerListMatches(m.Peers, ev.peer, resolve) || !peerListMatches(m.Senders, ev.sender, resolve) {
+		return nil, false
+	}
+	if len(m.Media) > 0 && (ev.message == nil || !slices.Contains(m.Media, ev.media)) {
+		return nil, false
+	}
+
+	groups := map[string]any{}
+	if r.text != nil {
+		if ev.message == nil {
+			return nil, false
+		}
+		found := r.text.FindStringSubmatch(ev.text)
+		if found == nil {
+			return nil, false
+		}
+		for i, value := range found {
+			groups[strconv.Itoa(i)] = value
+		}
+		for i, name := range r.text.SubexpNames() {
+			if name != "" {
+				groups[name] = found[i]
+			}
+		}
+	}
+	return groups, true
+}
+
+// peerListMatches reports whether peer (typed, e.g. "user:123") is in list.
+// An empty list matches everything; a non-empty list never matches an
+// update without that peer.
+func peerListMatches(list []string, peer string, resolve func(string) string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	if peer == "" {
+		return false
+	}
+	_, id, _ := strings.Cut(peer, ":")
+	for _, entry := range list {
+		entry = policy.NormalizePeer(entry)
+		if strings.HasPrefix(entry, "@") && resolve != nil {
+			entry = policy.NormalizePeer(resolve(entry))
+		}
+		if entry != "" && (entry == peer || entry == id) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateScope returns the scope for one matched rule.
+func (ev event) templateScope(groups map[string]any) map[string]any {
+	scope := maps.Clone(ev.scope)
+	scope["match"] = groups
+	return scope
+}
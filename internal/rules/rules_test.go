@@ -0,0 +1,293 @@
+package rules
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/observability"
+	"agent-telegram/internal/paths"
+	"agent-telegram/telegram/types"
+)
+
+// denyReactions is a policy that blocks add_reaction.
+type denyReactions struct{}
+
+func (denyReactions) Check(_ context.Context, method string, _ json.RawMessage) error {
+	if method == "add_reaction" {
+		return ipc.NewPolicyDeniedError(method, "reactions are disabled")
+	}
+	return nil
+}
+
+// recorder is a fake Telegram handler that records the params of each call.
+type recorder struct {
+	mu    sync.Mutex
+	calls []map[string]any
+}
+
+func (r *recorder) handler(_ context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+	var p map[string]any
+	_ = json.Unmarshal(params, &p)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, p)
+	return map[string]any{"success": true}, nil
+}
+
+func newEngine(t *testing.T, rules string, checker ipc.PolicyChecker) (*Engine, *recorder) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e := New(Options{Path: path, Policy: checker})
+	rec := &recorder{}
+	for _, method := range []string{"send_reply", "add_reaction", "read_messages"} {
+		e.Register(method, rec.handler)
+	}
+	return e, rec
+}
+
+func message(id int64, peer, text string, out bool) types.StoredUpdate {
+	return types.StoredUpdate{
+		ID:   id,
+		Type: types.UpdateTypeNewMessage,
+		Data: map[string]any{"message": map[string]any{
+			"id":   id * 10,
+			"peer": peer,
+			"text": text,
+			"out":  out,
+		}},
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	cases := map[string]string{
+		"missing id":      `{"rules":[{"actions":[{"method":"read_messages"}]}]}`,
+		"duplicate id":    `{"rules":[{"id":"a","actions":[{"method":"read_messages"}]},{"id":"a","actions":[{"method":"read_messages"}]}]}`,
+		"no actions":      `{"rules":[{"id":"a"}]}`,
+		"unknown method":  `{"rules":[{"id":"a","actions":[{"method":"nope"}]}]}`,
+		"confirmation":    `{"rules":[{"id":"a","actions":[{"method":"delete_message"}]}]}`,
+		"bad regex":       `{"rules":[{"id":"a","match":{"text":"("},"actions":[{"method":"read_messages"}]}]}`,
+		"bad media":       `{"rules":[{"id":"a","match":{"media":["video"]},"actions":[{"method":"read_messages"}]}]}`,
+		"bad placeholder": `{"rules":[{"id":"a","actions":[{"method":"send_reply","params":{"text":"{{peer"}}]}]}`,
+		"bad rate limit":  `{"rules":[{"id":"a","rateLimit":{"max":0,"per":"1m"},"actions":[{"method":"read_messages"}]}]}`,
+		"bad version":     `{"version":2,"rules":[]}`,
+	}
+	for name, data := range cases {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := ParseConfig([]byte(`{"version":1,"rules":[{"id":"a","actions":[{"method":"read_messages","params":{"peer":"{{peer}}"}}]}]}`)); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+}
+
+func TestRenderKeepsPlaceholderTypes(t *testing.T) {
+	ev := newEvent(message(7, "user:42", "order 1234 please", false))
+	scope := ev.templateScope(map[string]any{"1": "1234"})
+	got, err := render(map[string]any{
+		"peer":      "{{peer}}",
+		"messageId": "{{message.id}}",
+		"text":      "Got order #{{match.1}} from {{ sender }}",
+	}, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(got)
+	want := `{"messageId":70,"peer":"user:42","text":"Got order #1234 from user:42"}`
+	if string(data) != want {
+		t.Fatalf("render = %s, want %s", data, want)
+	}
+	if _, err := render(map[string]any{"text": "{{message.missing}}"}, scope); err == nil {
+		t.Fatal("unset placeholder should fail")
+	}
+}
+
+func TestEngineRunsMatchingActions(t *testing.T) {
+	e, rec := newEngine(t, `{
+		"version": 1,
+		"rules": [{
+			"id": "orders",
+			"match": {"types": ["new_message"], "peers": ["42"], "text": "order (?P<num>\\d+)", "media": ["none"]},
+			"actions": [
+				{"method": "send_reply", "params": {"peer": "{{peer}}", "messageId": "{{message.id}}", "text": "Order {{match.num}} received"}},
+				{"method": "read_messages", "params": {"peer": "{{peer}}"}}
+			]
+		}]
+	}`, nil)
+	ctx := context.Background()
+
+	e.Handle(ctx, message(1, "user:42", "order 99", false))
+	e.Handle(ctx, message(2, "user:42", "order 99", true))
+	e.Handle(ctx, message(3, "user:7", "order 99", false))
+	e.Handle(ctx, message(4, "user:42", "hello", false))
+
+	if len(rec.calls) != 2 {
+		t.Fatalf("calls = %v, want one send_reply and one read_messages", rec.calls)
+	}
+	if rec.calls[0]["text"] != "Order 99 received" || rec.calls[0]["messageId"] != float64(10) {
+		t.Fatalf("send_reply params = %v", rec.calls[0])
+	}
+
+	list := e.List()
+	if list.Count != 1 || list.Rules[0].Matched != 1 || list.Rules[0].Executed != 1 {
+		t.Fatalf("list = %+v", list)
+	}
+
+	path, err := paths.AuditFilePathForSocket("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var events []observability.AuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event observability.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 2 || events[0].Surface != ipc.SurfaceRules || events[0].RunID != "rule:orders" ||
+		events[0].Method != "send_reply" || events[0].Status != "ok" {
+		t.Fatalf("audit events = %+v", events)
+	}
+}
+
+func TestEngineActionsCarryFileRoots(t *testing.T) {
+	e, _ := newEngine(t, `{"version": 1, "fileRoots": ["/srv/downloads"], "rules": [{
+		"id": "save", "actions": [{"method": "read_messages", "params": {"peer": "{{peer}}"}}]
+	}]}`, nil)
+	var surface string
+	var roots []string
+	e.Register("read_messages", func(ctx context.Context, _ json.RawMessage) (any, *ipc.ErrorObject) {
+		surface, roots = ipc.SurfaceFromContext(ctx), ipc.FileRootsFromContext(ctx)
+		return map[string]any{"success": true}, nil
+	})
+
+	e.Handle(context.Background(), message(1, "user:42", "hi", false))
+	if surface != ipc.SurfaceRules || len(roots) != 1 || roots[0] != "/srv/downloads" {
+		t.Fatalf("action context surface = %q, roots = %v", surface, roots)
+	}
+}
+
+func TestEngineDryRunPolicyAndRateLimit(t *testing.T) {
+	e, rec := newEngine(t, `{
+		"rules": [
+			{"id": "dry", "dryRun": true, "actions": [{"method": "read_messages", "params": {"peer": "{{peer}}"}}]},
+			{"id": "react", "actions": [{"method": "add_reaction", "params": {"peer": "{{peer}}", "messageId": "{{message.id}}", "emoji": "👍"}}]},
+			{"id": "limited", "rateLimit": {"max": 2, "per": "1m"}, "actions": [{"method": "send_reply", "params": {"peer": "{{peer}}", "messageId": "{{message.id}}", "text": "hi"}}]}
+		]
+	}`, denyReactions{})
+	now := time.Unix(1000, 0)
+	e.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := range int64(3) {
+		e.Handle(ctx, message(i+1, "user:42", "hello", false))
+	}
+	if len(rec.calls) != 2 {
+		t.Fatalf("calls = %v, want only the two rate-limited replies", rec.calls)
+	}
+	now = now.Add(2 * time.Minute)
+	e.Handle(ctx, message(4, "user:42", "hello", false))
+	if len(rec.calls) != 3 {
+		t.Fatalf("rate limit window should slide, calls = %d", len(rec.calls))
+	}
+
+	stats := map[string]int64{}
+	for _, rule := range e.List().Rules {
+		switch rule.ID {
+		case "dry":
+			stats["dry"] = rule.Executed
+		case "react":
+			stats["react"] = rule.Failed
+			if !strings.Contains(rule.LastError, "denied by local policy") {
+				t.Errorf("react last error = %q", rule.LastError)
+			}
+		case "limited":
+			stats["limited"] = rule.RateLimited
+		}
+	}
+	if stats["dry"] != 4 || stats["react"] != 4 || stats["limited"] != 1 {
+		t.Fatalf("stats = %v", stats)
+	}
+}
+
+func TestEngineClearsLastErrorAfterSuccess(t *testing.T) {
+	e, _ := newEngine(t, `{
+		"rules": [
+			{"id": "reply", "actions": [{"method": "send_reply", "params": {"peer": "{{peer}}", "messageId": "{{message.id}}", "text": "hi"}}]}
+		]
+	}`, nil)
+	fail := true
+	e.Register("send_reply", func(context.Context, json.RawMessage) (any, *ipc.ErrorObject) {
+		if fail {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInternalError, ipc.ErrorTypeInternal, "FLOOD_WAIT_5", nil)
+		}
+		return map[string]any{"success": true}, nil
+	})
+	ctx := context.Background()
+
+	e.Handle(ctx, message(1, "user:42", "hello", false))
+	if rule := e.List().Rules[0]; rule.Failed != 1 || !strings.Contains(rule.LastError, "FLOOD_WAIT_5") {
+		t.Fatalf("after failure: %+v", rule)
+	}
+	fail = false
+	e.Handle(ctx, message(2, "user:42", "hello", false))
+	if rule := e.List().Rules[0]; rule.Executed != 1 || rule.LastError != "" {
+		t.Fatalf("after success: %+v", rule)
+	}
+}
+
+func TestEngineTestDoesNotRun(t *testing.T) {
+	e, rec := newEngine(t, `{
+		"rules": [
+			{"id": "react", "actions": [{"method": "add_reaction", "params": {"peer": "{{peer}}", "messageId": "{{message.id}}", "emoji": "👍"}}]},
+			{"id": "reply", "match": {"text": "^ping$"}, "actions": [{"method": "send_reply", "params": {"peer": "{{peer}}", "messageId": "{{message.id}}", "text": "pong"}}]}
+		]
+	}`, denyReactions{})
+
+	result := e.Test(context.Background(), message(1, "user:42", "ping", false))
+	if len(rec.calls) != 0 {
+		t.Fatalf("test should not run actions: %v", rec.calls)
+	}
+	if result.Count != 2 || result.Matches[0].Actions[0].Allowed || !result.Matches[1].Actions[0].Allowed {
+		t.Fatalf("test result = %+v", result)
+	}
+	if text := result.Matches[1].Actions[0].Params["text"]; text != "pong" {
+		t.Fatalf("rendered text = %v", text)
+	}
+	if list := e.List(); list.Rules[0].Matched != 0 {
+		t.Fatalf("test should not touch counters: %+v", list.Rules[0])
+	}
+}
+
+func TestEngineKeepsLastValidRules(t *testing.T) {
+	e, rec := newEngine(t, `{"rules":[{"id":"read","actions":[{"method":"read_messages","params":{"peer":"{{peer}}"}}]}]}`, nil)
+	if err := os.WriteFile(e.opts.Path, []byte(`{"rules":[{"id":"read"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e.Handle(context.Background(), message(1, "user:42", "hi", false))
+	if len(rec.calls) != 1 {
+		t.Fatal("an invalid rules file should keep the last valid rules")
+	}
+	if list := e.List(); list.Error == "" || list.Count != 1 {
+		t.Fatalf("list = %+v, want the reload error reported", list)
+	}
+}
@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderPattern matches {{path}} placeholders such as {{message.id}}.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// checkTemplates rejects unbalanced or malformed placeholders in params.
+func checkTemplates(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, item := range v {
+			if err := checkTemplates(item); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := checkTemplates(item); err != nil {
+				return err
+			}
+		}
+	case string:
+		rest := placeholderPattern.ReplaceAllString(v, "")
+		if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+			return fmt.Errorf("malformed placeholder in %q", v)
+		}
+	}
+	return nil
+}
+
+// render fills {{path}} placeholders in the string values of value from
+// scope. A string that is exactly one placeholder takes the value's own JSON
+// type, so "messageId": "{{message.id}}" stays a number.
+func render(value any, scope map[string]any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			rendered, err := render(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = rendered
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			rendered, err := render(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	case string:
+		return renderString(v, scope)
+	default:
+		return value, nil
+	}
+}
+
+func renderString(text string, scope map[string]any) (any, error) {
+	if match := placeholderPattern.FindStringSubmatch(text); match != nil && match[0] == text {
+		return lookup(scope, match[1])
+	}
+	var firstErr error
+	rendered := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		path := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		value, err := lookup(scope, path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return ""
+		}
+		return stringify(value)
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return rendered, nil
+}
+
+// lookup walks a dotted path through maps and lists.
+func lookup(scope map[string]any, path string) (any, error) {
+	var current any = scope
+	for part := range strings.SplitSeq(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			value, ok := v[part]
+			if !ok {
+				return nil, fmt.Errorf("template: %s is not set", path)
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("template: %s is not set", path)
+			}
+			current = v[index]
+		default:
+			return nil, fmt.Errorf("template: %s is not set", path)
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("template: %s is not set", path)
+	}
+	return current, nil
+}
+
+// stringify formats a scope value for embedding in text.
+func stringify(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
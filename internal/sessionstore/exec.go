@@ -0,0 +1,165 @@
+package sessionstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExecProvider delegates storage to an external credential helper, selected
+// as "exec:<helper>".
+const ExecProvider = "exec"
+
+// execHelperPrefix is tried first for bare helper names, the way git runs
+// "git-credential-<name>" for credential.helper=<name>.
+const execHelperPrefix = "agent-telegram-session-"
+
+// execHelperTimeout bounds one helper invocation.
+var execHelperTimeout = 30 * time.Second
+
+func init() {
+	RegisterArgProvider(ExecProvider, "helper", func(helper string) (Store, error) {
+		return NewExecStore(helper)
+	})
+}
+
+// ExecStore runs a helper executable for each operation. The protocol follows
+// git-credential: the helper is invoked with "get", "store" or "erase" as its
+// last argument and reads newline-separated key=value attributes, ended by a
+// blank line or EOF, on stdin:
+//
+//	protocol=agent-telegram
+//	profile=<profile>
+//	session=<base64>        (store only)
+//
+// For "get" it prints "session=<base64>" on stdout, or nothing when the
+// profile has no session. A non-zero exit status is an error; the helper's
+// stderr is included in the message.
+type ExecStore struct {
+	helper string
+	argv   []string
+}
+
+// NewExecStore resolves helper, which may carry arguments separated by
+// spaces. Bare names are looked up as agent-telegram-session-<name> and then
+// as <name> on PATH.
+func NewExecStore(helper string) (*ExecStore, error) {
+	fields := strings.Fields(helper)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("exec helper is required")
+	}
+	path, err := resolveHelper(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	return &ExecStore{helper: strings.Join(fields, " "), argv: append([]string{path}, fields[1:]...)}, nil
+}
+
+func resolveHelper(name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) || strings.Contains(name, "/") {
+		return exec.LookPath(name)
+	}
+	if path, err := exec.LookPath(execHelperPrefix + name); err == nil {
+		return path, nil
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("session helper %q not found as %s%s or %s on PATH", name, execHelperPrefix, name, name)
+	}
+	return path, nil
+}
+
+func (s *ExecStore) Provider() string { return ExecProvider + ":" + s.helper }
+func (s *ExecStore) Persistent() bool { return true }
+
+func (s *ExecStore) Load(ctx context.Context, profile string) ([]byte, error) {
+	out, err := s.run(ctx, "get", profile, nil)
+	if err != nil {
+		return nil, err
+	}
+	attrs := parseHelperAttributes(out)
+	encoded, ok := attrs["session"]
+	if !ok || encoded == "" {
+		return nil, ErrNotFound
+	}
+	// Padding is optional: shell helpers that split on '=' often drop it.
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, fmt.Errorf("session helper returned invalid base64: %w", err)
+	}
+	return data, nil
+}
+
+func (s *ExecStore) Save(ctx context.Context, profile string, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("session is empty")
+	}
+	_, err := s.run(ctx, "store", profile, data)
+	return err
+}
+
+func (s *ExecStore) Delete(ctx context.Context, profile string) error {
+	_, err := s.run(ctx, "erase", profile, nil)
+	return err
+}
+
+func (s *ExecStore) run(ctx context.Context, action, profile string, data []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, execHelperTimeout)
+	defer cancel()
+
+	var input bytes.Buffer
+	input.WriteString("protocol=agent-telegram\n")
+	input.WriteString("profile=" + profile + "\n")
+	if data != nil {
+		input.WriteString("session=" + base64.StdEncoding.EncodeToString(data) + "\n")
+	}
+	input.WriteString("\n")
+
+	argv := append(append([]string(nil), s.argv...), action)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = &input
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("session helper %s timed out after %s", action, execHelperTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("session helper %s failed: %s", action, truncateHelperMessage(message))
+		}
+		return nil, fmt.Errorf("session helper %s failed: %w", action, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func parseHelperAttributes(out []byte) map[string]string {
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			attrs[key] = value
+		}
+	}
+	return attrs
+}
+
+func truncateHelperMessage(message string) string {
+	const limit = 300
+	if len(message) <= limit {
+		return message
+	}
+	return message[:limit] + "..."
+}
@@ -0,0 +1,95 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeTestHelper installs a shell helper that keeps sessions as files in a
+// directory, like a minimal pass or vault wrapper would.
+func writeTestHelper(t *testing.T) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell helper requires a Unix shell")
+	}
+	binDir := t.TempDir()
+	dataDir := t.TempDir()
+	script := `#!/bin/sh
+set -e
+while IFS='=' read -r key value; do
+  [ -z "$key" ] && break
+  case "$key" in
+    profile) profile="$value" ;;
+    session) session="$value" ;;
+  esac
+done
+file="` + dataDir + `/$profile"
+case "$1" in
+  get) [ -f "$file" ] && printf 'session=%s\n' "$(cat "$file")" ;;
+  store) printf '%s' "$session" > "$file" ;;
+  erase) rm -f "$file" ;;
+  *) echo "unknown action $1" >&2; exit 2 ;;
+esac
+exit 0
+`
+	path := filepath.Join(binDir, execHelperPrefix+"test")
+	if err := os.WriteFile(path, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return path, dataDir
+}
+
+func TestExecProviderRoundTrip(t *testing.T) {
+	_, dataDir := writeTestHelper(t)
+	storage, err := Open("exec:test", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if storage.Provider() != "exec:test" || !storage.Persistent() {
+		t.Fatalf("selection = %+v", storage.Selection())
+	}
+	ctx := context.Background()
+	if _, err := storage.LoadSession(ctx); err == nil {
+		t.Fatal("empty helper should report not found")
+	}
+	if err := storage.StoreSession(ctx, []byte("helper-session")); err != nil {
+		t.Fatal(err)
+	}
+	if raw, err := os.ReadFile(filepath.Join(dataDir, "work")); err != nil || strings.Contains(string(raw), "helper-session") {
+		t.Fatalf("helper received %q, %v; want base64", raw, err)
+	}
+	data, err := storage.LoadSession(ctx)
+	if err != nil || string(data) != "helper-session" {
+		t.Fatalf("loaded = %q, %v", data, err)
+	}
+	if err := storage.Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if data := storage.ExportSession(); data != nil {
+		t.Fatalf("deleted session = %q", data)
+	}
+}
+
+func TestExecProviderReportsHelperErrors(t *testing.T) {
+	helper, _ := writeTestHelper(t)
+	store, err := NewExecStore(helper + " extra-arg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The helper treats "extra-arg" as its action and fails on stderr.
+	if err := store.Save(context.Background(), "p", []byte("x")); err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Fatalf("save error = %v", err)
+	}
+	if _, err := NewExecStore("definitely-missing-helper"); err == nil {
+		t.Fatal("missing helper should fail")
+	}
+	if _, err := store.Load(context.Background(), "p"); errors.Is(err, ErrNotFound) || err == nil {
+		t.Fatalf("load error = %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"agent-telegram/internal/paths"
+)
+
+// FileProvider stores sessions as encrypted files under the config directory.
+const FileProvider = "file"
+
+const (
+	// EnvSessionKey holds a passphrase for the file provider.
+	EnvSessionKey = "AGENT_TELEGRAM_SESSION_KEY"
+	// EnvSessionKeyFile overrides the key file location.
+	EnvSessionKeyFile = "AGENT_TELEGRAM_SESSION_KEY_FILE"
+
+	sessionsDirName = "sessions"
+	keyFileName     = "session.key"
+	sessionFileExt  = ".session"
+)
+
+// ErrNoFileKey is returned when the file provider has no key configured.
+var ErrNoFileKey = errors.New("no session key configured: set " + EnvSessionKey +
+	" or create a key file with: agent-telegram session rotate-key --generate --confirm")
+
+func init() {
+	RegisterProvider(FileProvider, func() (Store, error) {
+		key, err := ConfiguredFileKey()
+		if err != nil {
+			return nil, err
+		}
+		dir, err := SessionsDir()
+		if err != nil {
+			return nil, err
+		}
+		return NewFileStore(dir, key), nil
+	})
+}
+
+// FileStore persists each profile as an authenticated-encrypted file.
+type FileStore struct {
+	dir   string
+	key   FileKey
+	mu    sync.Mutex
+	cache keyCache
+}
+
+// NewFileStore creates a file store in dir that encrypts with key.
+func NewFileStore(dir string, key FileKey) *FileStore {
+	return &FileStore{dir: dir, key: key}
+}
+
+// SessionsDir returns the directory holding encrypted session files.
+func SessionsDir() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sessionsDirName), nil
+}
+
+// KeyFilePath returns the key file location, honoring EnvSessionKeyFile.
+func KeyFilePath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(EnvSessionKeyFile)); path != "" {
+		return path, nil
+	}
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, keyFileName), nil
+}
+
+// FileKeyConfigured reports whether the file provider can open without
+// further setup: a passphrase is set or the key file exists.
+func FileKeyConfigured() bool {
+	if os.Getenv(EnvSessionKey) != "" {
+		return true
+	}
+	path, err := KeyFilePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// ConfiguredFileKey returns the passphrase from EnvSessionKey, falling back
+// to the key file.
+func ConfiguredFileKey() (FileKey, error) {
+	if passphrase := os.Getenv(EnvSessionKey); passphrase != "" {
+		return PassphraseKey(passphrase), nil
+	}
+	path, err := KeyFilePath()
+	if err != nil {
+		return FileKey{}, err
+	}
+	key, err := LoadKeyFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return FileKey{}, ErrNoFileKey
+	}
+	return key, err
+}
+
+func (s *FileStore) Provider() string { return FileProvider }
+func (s *FileStore) Persistent() bool { return true }
+
+func (s *FileStore) Load(ctx context.Context, profile string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path(profile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session file: %w", err)
+	}
+	return s.cache.open(s.key, profile, data)
+}
+
+func (s *FileStore) Save(ctx context.Context, profile string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("session is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sealed, err := s.cache.seal(s.key, profile, data)
+	if err != nil {
+		return err
+	}
+	tmpPath, err := writeTemp(s.dir, sealed)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpPath) }()
+	return publish(tmpPath, s.path(profile))
+}
+
+func (s *FileStore) Delete(ctx context.Context, profile string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(profile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete session file: %w", err)
+	}
+	return nil
+}
+
+// Profiles lists the profiles that have a session file.
+func (s *FileStore) Profiles() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list session files: %w", err)
+	}
+	var profiles []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), sessionFileExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if _, err := normalizeProfile(name); err == nil {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// Rekey re-encrypts every profile with next and switches the store to it.
+// All files are decrypted and re-sealed to temporary files before any is
+// replaced, so a wrong current key leaves the directory untouched. When
+// keyFile is set, next is written there after the sessions are replaced.
+func (s *FileStore) Rekey(ctx context.Context, next FileKey, keyFile string) ([]string, error) {
+	profiles, err := s.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var nextCache keyCache
+	staged := make(map[string]string, len(profiles))
+	defer func() {
+		for _, tmpPath := range staged {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	for _, profile := range profiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		raw, err := os.ReadFile(s.path(profile))
+		if err != nil {
+			return nil, fmt.Errorf("read session file %s: %w", profile, err)
+		}
+		data, err := s.cache.open(s.key, profile, raw)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile, err)
+		}
+		sealed, err := nextCache.seal(next, profile, data)
+		if err != nil {
+			return nil, err
+		}
+		tmpPath, err := writeTemp(s.dir, sealed)
+		if err != nil {
+			return nil, err
+		}
+		staged[profile] = tmpPath
+	}
+	var keyTmp string
+	if keyFile != "" {
+		if keyTmp, err = writeTemp(filepath.Dir(keyFile), next.secret); err != nil {
+			return nil, err
+		}
+		defer func() { _ = os.Remove(keyTmp) }()
+	}
+
+	for _, profile := range profiles {
+		if err := publish(staged[profile], s.path(profile)); err != nil {
+			return nil, err
+		}
+		delete(staged, profile)
+	}
+	if keyTmp != "" {
+		if err := publish(keyTmp, keyFile); err != nil {
+			return nil, err
+		}
+	}
+	s.key = next
+	s.cache = nextCache
+	return profiles, nil
+}
+
+func (s *FileStore) path(profile string) string {
+	return filepath.Join(s.dir, profile+sessionFileExt)
+}
+
+// writeTemp writes data to an owner-only temporary file in dir and syncs it.
+func writeTemp(dir string, data []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("create session directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".session-*")
+	if err != nil {
+		return "", fmt.Errorf("create session file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("write session file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("sync session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("close session file: %w", err)
+	}
+	return tmpPath, nil
+}
+
+func publish(tmpPath, path string) error {
+	if runtime.GOOS == "windows" {
+		_ = os.Remove(path) // Windows rename does not replace an existing file.
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("publish session file: %w", err)
+	}
+	return nil
+}
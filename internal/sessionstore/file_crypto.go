@@ -0,0 +1,187 @@
+package sessionstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+const (
+	fileEnvelopeVersion = 1
+	kdfPBKDF2           = "pbkdf2-sha256"
+	kdfHKDF             = "hkdf-sha256"
+	fileKeySize         = 32
+	fileSaltSize        = 16
+)
+
+// passphraseIterations is the PBKDF2 work factor for new files. Existing
+// files record their own count, so raising it does not break them.
+var passphraseIterations = 600_000
+
+// FileKey is the secret material the file provider derives its AES-256-GCM
+// keys from. Passphrases go through PBKDF2; key files, which are already
+// random, through HKDF.
+type FileKey struct {
+	kdf    string
+	secret []byte
+}
+
+// PassphraseKey returns a key derived from a human passphrase.
+func PassphraseKey(passphrase string) FileKey {
+	return FileKey{kdf: kdfPBKDF2, secret: []byte(passphrase)}
+}
+
+// GenerateFileKey returns a new random key in key file format.
+func GenerateFileKey() (FileKey, error) {
+	var buf [fileKeySize]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return FileKey{}, fmt.Errorf("generate session key: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(buf[:]) + "\n"
+	return FileKey{kdf: kdfHKDF, secret: []byte(encoded)}, nil
+}
+
+// LoadKeyFile reads a key file. Files readable by group or others are
+// rejected on Unix, like ssh does for private keys.
+func LoadKeyFile(path string) (FileKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileKey{}, err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return FileKey{}, fmt.Errorf("session key file %s must not be accessible by others (chmod 600)", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FileKey{}, fmt.Errorf("read session key file: %w", err)
+	}
+	if len(strings.TrimSpace(string(data))) < 16 {
+		return FileKey{}, fmt.Errorf("session key file %s is too short", path)
+	}
+	return FileKey{kdf: kdfHKDF, secret: data}, nil
+}
+
+// IsPassphrase reports whether the key came from a passphrase.
+func (k FileKey) IsPassphrase() bool { return k.kdf == kdfPBKDF2 }
+
+type fileEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// keyCache remembers derived keys by salt so PBKDF2 runs once per process
+// rather than on every session write.
+type keyCache struct {
+	salt    []byte
+	derived map[string][]byte
+}
+
+func (c *keyCache) derive(key FileKey, kdf string, salt []byte, iterations int) ([]byte, error) {
+	if kdf != key.kdf {
+		return nil, fmt.Errorf("session file was sealed with %s but the configured key uses %s", kdf, key.kdf)
+	}
+	cacheKey := fmt.Sprintf("%s/%d/%x", kdf, iterations, salt)
+	if derived, ok := c.derived[cacheKey]; ok {
+		return derived, nil
+	}
+	var (
+		derived []byte
+		err     error
+	)
+	switch kdf {
+	case kdfPBKDF2:
+		derived, err = pbkdf2.Key(sha256.New, string(key.secret), salt, iterations, fileKeySize)
+	case kdfHKDF:
+		secret := []byte(strings.TrimSpace(string(key.secret)))
+		derived, err = hkdf.Key(sha256.New, secret, salt, "agent-telegram session", fileKeySize)
+	default:
+		err = fmt.Errorf("unsupported session key derivation %q", kdf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.derived == nil {
+		c.derived = make(map[string][]byte)
+	}
+	c.derived[cacheKey] = derived
+	return derived, nil
+}
+
+func (c *keyCache) seal(key FileKey, profile string, plaintext []byte) ([]byte, error) {
+	if c.salt == nil {
+		c.salt = make([]byte, fileSaltSize)
+		if _, err := rand.Read(c.salt); err != nil {
+			return nil, fmt.Errorf("generate session salt: %w", err)
+		}
+	}
+	envelope := fileEnvelope{Version: fileEnvelopeVersion, KDF: key.kdf, Salt: c.salt}
+	if key.kdf == kdfPBKDF2 {
+		envelope.Iterations = passphraseIterations
+	}
+	derived, err := c.derive(key, envelope.KDF, envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(derived)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return nil, fmt.Errorf("generate session nonce: %w", err)
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, plaintext, sessionAAD(profile))
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+func (c *keyCache) open(key FileKey, profile string, sealed []byte) ([]byte, error) {
+	var envelope fileEnvelope
+	if err := json.Unmarshal(sealed, &envelope); err != nil {
+		return nil, fmt.Errorf("parse session file: %w", err)
+	}
+	if envelope.Version != fileEnvelopeVersion {
+		return nil, fmt.Errorf("unsupported session file version: %d", envelope.Version)
+	}
+	derived, err := c.derive(key, envelope.KDF, envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(derived)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("session file has an invalid nonce")
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, sessionAAD(profile))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt session file: wrong key or corrupted file")
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sessionAAD binds ciphertext to its profile so files cannot be swapped.
+func sessionAAD(profile string) []byte {
+	return []byte("agent-telegram session v1 " + profile)
+}
@@ -0,0 +1,148 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func useFastPassphraseKDF(t *testing.T) {
+	t.Helper()
+	old := passphraseIterations
+	passphraseIterations = 1000
+	t.Cleanup(func() { passphraseIterations = old })
+}
+
+func TestFileStoreRoundTripAndPermissions(t *testing.T) {
+	useFastPassphraseKDF(t)
+	dir := t.TempDir()
+	store := NewFileStore(dir, PassphraseKey("correct horse battery"))
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "work"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("empty load error = %v", err)
+	}
+	if err := store.Save(ctx, "work", []byte("session-bytes")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "work"+sessionFileExt)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0o600 {
+		t.Fatalf("session file mode = %v, want 0600", got)
+	}
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "session-bytes") {
+		t.Fatal("session file contains plaintext")
+	}
+
+	reopened := NewFileStore(dir, PassphraseKey("correct horse battery"))
+	data, err := reopened.Load(ctx, "work")
+	if err != nil || string(data) != "session-bytes" {
+		t.Fatalf("loaded = %q, %v", data, err)
+	}
+	if _, err := NewFileStore(dir, PassphraseKey("wrong passphrase")).Load(ctx, "work"); err == nil {
+		t.Fatal("wrong passphrase should fail")
+	}
+
+	// A file copied to another profile must not decrypt there.
+	if err := os.WriteFile(filepath.Join(dir, "other"+sessionFileExt), raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Load(ctx, "other"); err == nil {
+		t.Fatal("swapped profile file should fail authentication")
+	}
+
+	if err := reopened.Delete(ctx, "work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Load(ctx, "work"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("load after delete = %v", err)
+	}
+}
+
+func TestFileStoreRekey(t *testing.T) {
+	useFastPassphraseKDF(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+	store := NewFileStore(dir, PassphraseKey("first passphrase"))
+	for _, profile := range []string{"a", "b"} {
+		if err := store.Save(ctx, profile, []byte("data-"+profile)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next, err := GenerateFileKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "session.key")
+	profiles, err := store.Rekey(ctx, next, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(profiles, []string{"a", "b"}) {
+		t.Fatalf("rekeyed profiles = %v", profiles)
+	}
+	loadedKey, err := LoadKeyFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := NewFileStore(dir, loadedKey).Load(ctx, "b")
+	if err != nil || string(data) != "data-b" {
+		t.Fatalf("load with new key = %q, %v", data, err)
+	}
+	if _, err := NewFileStore(dir, PassphraseKey("first passphrase")).Load(ctx, "a"); err == nil {
+		t.Fatal("old key should no longer decrypt")
+	}
+
+	wrong := NewFileStore(dir, PassphraseKey("not the key"))
+	if _, err := wrong.Rekey(ctx, PassphraseKey("another passphrase"), ""); err == nil {
+		t.Fatal("rekey with wrong current key should fail")
+	}
+	if _, err := NewFileStore(dir, loadedKey).Load(ctx, "a"); err != nil {
+		t.Fatalf("failed rekey modified files: %v", err)
+	}
+}
+
+func TestFileProviderSelection(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvSessionKey, "")
+	t.Setenv(EnvSessionKeyFile, "")
+	if FileKeyConfigured() {
+		t.Fatal("no key should be configured")
+	}
+	if _, err := Open(FileProvider, "default"); !errors.Is(err, ErrNoFileKey) {
+		t.Fatalf("open without key error = %v", err)
+	}
+
+	keyFile := filepath.Join(home, "custom.key")
+	key, err := GenerateFileKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, key.secret, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvSessionKeyFile, keyFile)
+	if _, err := Open(FileProvider, "default"); err == nil {
+		t.Fatal("world-readable key file should be rejected")
+	}
+	if err := os.Chmod(keyFile, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	storage, err := Open(FileProvider, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !storage.Persistent() || storage.Provider() != FileProvider {
+		t.Fatalf("selection = %+v", storage.Selection())
+	}
+}
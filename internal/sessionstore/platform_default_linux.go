@@ -0,0 +1,10 @@
+package sessionstore
+
+// platformDefaultProvider prefers encrypted files once a key is configured,
+// since Linux has no keychain the daemon can rely on.
+func platformDefaultProvider() string {
+	if FileKeyConfigured() {
+		return FileProvider
+	}
+	return MemoryProvider
+}
@@ -35,11 +35,16 @@ type Store interface {
 // process startup without coupling auth or daemon packages to the backend.
 type Factory func() (Store, error)
 
+// ArgFactory creates a provider configured by the text after the colon in a
+// "name:argument" selection, such as the helper in "exec:vault".
+type ArgFactory func(arg string) (Store, error)
+
 // ProviderInfo describes a registered session provider.
 type ProviderInfo struct {
 	Name       string `json:"name"`
 	Default    bool   `json:"default"`
 	Persistent bool   `json:"persistent"`
+	Usage      string `json:"usage,omitempty"`
 }
 
 // Selection identifies the configured session location.
@@ -49,35 +54,61 @@ type Selection struct {
 	Persistent bool   `json:"persistent"`
 }
 
+type providerEntry struct {
+	factory ArgFactory
+	// parameter names the required argument; empty for plain providers.
+	parameter string
+}
+
 var providerRegistry = struct {
 	sync.RWMutex
-	factories map[string]Factory
-}{factories: make(map[string]Factory)}
+	entries map[string]providerEntry
+}{entries: make(map[string]providerEntry)}
 
 // RegisterProvider adds a session provider factory. Names are normalized to
 // lowercase and duplicate registrations panic during program initialization.
 func RegisterProvider(name string, factory Factory) {
+	if factory == nil {
+		panic("sessionstore: provider name and factory are required")
+	}
+	register(name, "", func(arg string) (Store, error) {
+		if arg != "" {
+			return nil, fmt.Errorf("provider %q takes no argument", name)
+		}
+		return factory()
+	})
+}
+
+// RegisterArgProvider adds a provider selected as "name:<parameter>".
+func RegisterArgProvider(name, parameter string, factory ArgFactory) {
+	if parameter == "" || factory == nil {
+		panic("sessionstore: provider parameter and factory are required")
+	}
+	register(name, parameter, factory)
+}
+
+func register(name, parameter string, factory ArgFactory) {
 	name = normalizeProvider(name)
-	if name == "" || factory == nil {
+	if name == "" || strings.Contains(name, ":") {
 		panic("sessionstore: provider name and factory are required")
 	}
 	providerRegistry.Lock()
 	defer providerRegistry.Unlock()
-	if _, exists := providerRegistry.factories[name]; exists {
+	if _, exists := providerRegistry.entries[name]; exists {
 		panic("sessionstore: provider already registered: " + name)
 	}
-	providerRegistry.factories[name] = factory
+	providerRegistry.entries[name] = providerEntry{factory: factory, parameter: parameter}
 }
 
 // Open creates a storage adapter for provider and profile.
 func Open(provider, profile string) (*Storage, error) {
-	provider = normalizeProvider(provider)
-	if provider == "" {
-		provider = normalizeProvider(os.Getenv(EnvProvider))
-		if provider == "" {
+	if strings.TrimSpace(provider) == "" {
+		provider = os.Getenv(EnvProvider)
+		if strings.TrimSpace(provider) == "" {
 			provider = DefaultProvider()
 		}
 	}
+	name, arg := splitProvider(provider)
 	if strings.TrimSpace(profile) == "" {
 		profile = os.Getenv(EnvProfile)
 	}
@@ -87,14 +118,17 @@ func Open(provider, profile string) (*Storage, error) {
 	}
 
 	providerRegistry.RLock()
-	factory := providerRegistry.factories[provider]
+	entry, ok := providerRegistry.entries[name]
 	providerRegistry.RUnlock()
-	if factory == nil {
-		return nil, fmt.Errorf("unknown session provider %q (available: %s)", provider, strings.Join(ProviderNames(), ", "))
+	if !ok {
+		return nil, fmt.Errorf("unknown session provider %q (available: %s)", name, strings.Join(ProviderNames(), ", "))
 	}
-	store, err := factory()
+	if entry.parameter != "" && arg == "" {
+		return nil, fmt.Errorf("session provider %q needs a %s: use %s:<%s>", name, entry.parameter, name, entry.parameter)
+	}
+	store, err := entry.factory(arg)
 	if err != nil {
-		return nil, fmt.Errorf("open session provider %q: %w", provider, err)
+		return nil, fmt.Errorf("open session provider %q: %w", name, err)
 	}
 	return NewStorage(store, profile), nil
 }
@@ -112,8 +146,8 @@ func DefaultProvider() string {
 // ProviderNames returns registered providers in stable order.
 func ProviderNames() []string {
 	providerRegistry.RLock()
-	names := make([]string, 0, len(providerRegistry.factories))
-	for name := range providerRegistry.factories {
+	names := make([]string, 0, len(providerRegistry.entries))
+	for name := range providerRegistry.entries {
 		names = append(names, name)
 	}
 	providerRegistry.RUnlock()
@@ -127,6 +161,20 @@ func Providers() []ProviderInfo {
 	names := ProviderNames()
 	result := make([]ProviderInfo, 0, len(names))
 	for _, name := range names {
+		providerRegistry.RLock()
+		parameter := providerRegistry.entries[name].parameter
+		providerRegistry.RUnlock()
+		if parameter != "" {
+			// Argument providers cannot be probed without a configuration;
+			// they exist to reach external persistent stores.
+			result = append(result, ProviderInfo{
+				Name:       name,
+				Default:    name == defaultName,
+				Persistent: true,
+				Usage:      name + ":<" + parameter + ">",
+			})
+			continue
+		}
 		storage, err := Open(name, DefaultProfile)
 		result = append(result, ProviderInfo{
 			Name:       name,
@@ -141,6 +189,19 @@ func normalizeProvider(value string) string {
 	return strings.ToLower(strings.TrimSpace(value))
 }
 
+// splitProvider separates "name:argument". Only the name is case-folded;
+// the argument may be a case-sensitive path.
+func splitProvider(value string) (string, string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(value), ":")
+	return normalizeProvider(name), strings.TrimSpace(arg)
+}
+
+// NormalizeProfile validates a profile name, returning DefaultProfile for an
+// empty one.
+func NormalizeProfile(value string) (string, error) {
+	return normalizeProfile(value)
+}
+
 func normalizeProfile(value string) (string, error) {
 	value = strings.TrimSpace(value)
 	if value == "" {
@@ -62,3 +62,21 @@ func TestProviderRegistryAndProfileValidation(t *testing.T) {
 		t.Fatal("unsafe profile should fail")
 	}
 }
+
+func TestProvidersListArgumentProviders(t *testing.T) {
+	var exec ProviderInfo
+	for _, info := range Providers() {
+		if info.Name == ExecProvider {
+			exec = info
+		}
+	}
+	if exec.Usage != "exec:<helper>" || !exec.Persistent {
+		t.Fatalf("exec provider info = %+v", exec)
+	}
+	if _, err := Open(ExecProvider, DefaultProfile); err == nil {
+		t.Fatal("exec provider without helper should fail")
+	}
+	if _, err := Open("Memory:extra", DefaultProfile); err == nil {
+		t.Fatal("plain provider with argument should fail")
+	}
+}
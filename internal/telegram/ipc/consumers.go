@@ -0,0 +1,98 @@
+// Package ipc provides Telegram IPC handlers.
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/strictjson"
+	"agent-telegram/internal/updateconsumer"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
+)
+
+// RegisterConsumerHandlers registers consume_updates, ack_updates,
+// list_consumers and delete_consumer for the named consumers in consumers.
+func RegisterConsumerHandlers(srv ipc.MethodRegistrar, client Client, consumers *updateconsumer.Store) {
+	srv.Register("consume_updates", ConsumeUpdatesHandler(client, consumers))
+	srv.Register("ack_updates", AckUpdatesHandler(client, consumers))
+	srv.Register("list_consumers", func(context.Context, json.RawMessage) (any, *ipc.ErrorObject) {
+		return consumers.List(), nil
+	})
+	srv.Register("delete_consumer", func(_ context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+		var p types.ConsumerParams
+		if errObj := decodeConsumerParams(params, &p); errObj != nil {
+			return nil, errObj
+		}
+		if !consumers.Delete(p.Consumer) {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation,
+				"unknown consumer: "+p.Consumer, nil)
+		}
+		return types.DeleteConsumerResult{Success: true, Consumer: p.Consumer}, nil
+	})
+}
+
+// ConsumeUpdatesHandler returns a handler for consume_updates requests. Each
+// returned update stays reserved for the consumer until it is acknowledged
+// with ack_updates or redeliverAfter seconds pass.
+func ConsumeUpdatesHandler(client Client, consumers *updateconsumer.Store) ipc.Handler {
+	return func(_ context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+		var p types.ConsumeUpdatesParams
+		if errObj := decodeConsumerParams(params, &p); errObj != nil {
+			return nil, errObj
+		}
+		if p.Limit == 0 {
+			p.Limit = types.DefaultConsumeLimit
+		}
+		if p.RedeliverAfter == 0 {
+			p.RedeliverAfter = types.DefaultRedeliverAfter
+		}
+		filter := updatefeed.Filter{Peer: p.Peer, Types: p.Types, Direction: p.Direction}
+		if filter.Peer == "" {
+			filter.Peer = p.Username
+		}
+		result, err := consumers.Consume(client, p.Consumer, updateconsumer.ConsumeOptions{
+			Limit:          p.Limit,
+			Filter:         filter,
+			RedeliverAfter: time.Duration(p.RedeliverAfter) * time.Second,
+		})
+		if err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		return result, nil
+	}
+}
+
+// AckUpdatesHandler returns a handler for ack_updates requests.
+func AckUpdatesHandler(client Client, consumers *updateconsumer.Store) ipc.Handler {
+	return func(_ context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+		var p types.AckUpdatesParams
+		if errObj := decodeConsumerParams(params, &p); errObj != nil {
+			return nil, errObj
+		}
+		result, err := consumers.Ack(client, p.Consumer, p.IDs, p.UpTo)
+		if errors.Is(err, updateconsumer.ErrUnknownConsumer) || errors.Is(err, updateconsumer.ErrInvalidAck) {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		if err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInternalError, ipc.ErrorTypeInternal, err.Error(), nil)
+		}
+		return result, nil
+	}
+}
+
+// decodeConsumerParams strictly decodes and validates consumer params.
+func decodeConsumerParams(params json.RawMessage, p interface{ Validate() error }) *ipc.ErrorObject {
+	if len(params) > 0 {
+		if err := strictjson.Decode(params, p); err != nil {
+			return ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Package ipc provides Telegram IPC handlers.
+package ipc
+
+import (
+	"context"
+	"log/slog"
+
+	baseipc "agent-telegram/internal/ipc"
+	"agent-telegram/internal/paths"
+	"agent-telegram/telegram/media"
+	"agent-telegram/telegram/types"
+)
+
+// downloadProgressStep is the minimum byte delta between progress log lines.
+const downloadProgressStep = 8 * 1024 * 1024
+
+// DownloadMediaHandler returns a handler for download_media requests.
+// Local callers without an output path download into the instance-wide
+// downloads directory; HTTP callers must name a path under their file roots.
+func DownloadMediaHandler(client Client) HandlerFunc {
+	return Handler(func(ctx context.Context, p types.DownloadMediaParams) (*types.DownloadMediaResult, error) {
+		if p.Output == "" && baseipc.SurfaceFromContext(ctx) != baseipc.SurfaceHTTP {
+			dir, err := paths.DownloadsDir()
+			if err != nil {
+				return nil, err
+			}
+			p.Output = dir
+		}
+		return client.Media().DownloadMedia(media.WithProgress(ctx, logDownloadProgress(p)), p)
+	}, "download media")
+}
+
+// logDownloadProgress logs download progress at most every downloadProgressStep
+// bytes and once on completion.
+func logDownloadProgress(p types.DownloadMediaParams) media.ProgressFunc {
+	var lastLogged int64
+	return func(progress media.DownloadProgress) {
+		done := progress.Total > 0 && progress.Downloaded >= progress.Total
+		if !done && progress.Downloaded-lastLogged < downloadProgressStep {
+			return
+		}
+		lastLogged = progress.Downloaded
+		slog.Info("download progress",
+			"peer", p.Peer,
+			"message_id", p.MessageID,
+			"path", progress.Path,
+			"downloaded", progress.Downloaded,
+			"total", progress.Total,
+		)
+	}
+}
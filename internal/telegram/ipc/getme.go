@@ -37,6 +37,8 @@ type Client interface {
 	GetMe(ctx context.Context) (*tg.User, error)
 	GetUpdates(limit int, offset ...int64) []types.StoredUpdate
 	GetUpdatePage(limit int, offset int64, epoch string) telegram.UpdatePage
+	PeerCacheStats() types.PeerCacheStats
+	ClearPeerCache() (types.PeerCacheClearResult, error)
 	Chat() telegram.ChatClient
 	Message() telegram.MessageClient
 	Media() telegram.MediaClient
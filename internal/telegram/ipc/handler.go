@@ -52,27 +52,91 @@ func Handler[T any, R any](
 	}
 }
 
-// ValidateFileParams enforces the HTTP server-side file allowlist before a
-// Telegram handler can open a path supplied by a remote caller.
+// ValidateFileParams enforces the server-side file allowlist before a
+// Telegram handler can open or write a path supplied by a caller other than
+// the local CLI: HTTP clients, MCP sessions and rule actions. The `file` key
+// and every album item `file` must exist under a root; an `output`
+// destination may not exist yet, so its parent directory is checked instead.
 func ValidateFileParams(ctx context.Context, params json.RawMessage) error {
-	if baseipc.SurfaceFromContext(ctx) != baseipc.SurfaceHTTP || len(params) == 0 {
+	surface := baseipc.SurfaceFromContext(ctx)
+	if surface == "" || surface == baseipc.SurfaceIPC || len(params) == 0 {
 		return nil
 	}
 	var payload struct {
-		File string `json:"file"`
+		File   string  `json:"file"`
+		Output *string `json:"output"`
+		Items  []struct {
+			File string `json:"file"`
+		} `json:"items"`
 	}
-	if err := json.Unmarshal(params, &payload); err != nil || payload.File == "" {
+	if err := json.Unmarshal(params, &payload); err != nil {
+		return nil
+	}
+	files := make([]string, 0, len(payload.Items)+1)
+	if payload.File != "" {
+		files = append(files, payload.File)
+	}
+	for _, item := range payload.Items {
+		if item.File != "" {
+			files = append(files, item.File)
+		}
+	}
+	if len(files) == 0 && payload.Output == nil {
 		return nil
 	}
 	roots := baseipc.FileRootsFromContext(ctx)
 	if len(roots) == 0 {
-		return fmt.Errorf("server-side file paths are disabled over HTTP")
+		return fmt.Errorf("server-side file paths are disabled over %s", surface)
 	}
-	file, err := filepath.EvalSymlinks(payload.File)
+	for _, path := range files {
+		file, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return fmt.Errorf("resolve file path: %w", err)
+		}
+		if err := checkPathInRoots(file, roots); err != nil {
+			return err
+		}
+	}
+	if payload.Output != nil {
+		if *payload.Output == "" {
+			return fmt.Errorf("output path is required over %s", surface)
+		}
+		output, err := resolveOutputPath(*payload.Output)
+		if err != nil {
+			return err
+		}
+		if err := checkPathInRoots(output, roots); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveOutputPath resolves symlinks in the nearest existing ancestor of a
+// destination path that may not exist yet.
+func resolveOutputPath(output string) (string, error) {
+	abs, err := filepath.Abs(output)
 	if err != nil {
-		return fmt.Errorf("resolve file path: %w", err)
+		return "", fmt.Errorf("resolve absolute output path: %w", err)
+	}
+	var missing []string
+	dir := abs
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("resolve output path: %w", err)
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+		dir = parent
 	}
-	file, err = filepath.Abs(file)
+}
+
+func checkPathInRoots(path string, roots []string) error {
+	file, err := filepath.Abs(path)
 	if err != nil {
 		return fmt.Errorf("resolve absolute file path: %w", err)
 	}
@@ -90,7 +154,7 @@ func ValidateFileParams(ctx context.Context, params json.RawMessage) error {
 			return nil
 		}
 	}
-	return fmt.Errorf("file path is outside configured HTTP file roots")
+	return fmt.Errorf("file path is outside configured file roots")
 }
 
 // FileHandler returns a handler that validates file existence before calling the method.
@@ -98,13 +162,32 @@ func FileHandler[T any, R any](
 	getFile func(T) string,
 	callFn func(context.Context, T) (R, error),
 	methodName string,
+) HandlerFunc {
+	return FilesHandler(func(p T) []string { return []string{getFile(p)} }, callFn, methodName)
+}
+
+// FilesHandler returns a handler that validates the existence of every file
+// before calling the method.
+func FilesHandler[T any, R any](
+	getFiles func(T) []string,
+	callFn func(context.Context, T) (R, error),
+	methodName string,
 ) HandlerFunc {
 	return Handler(func(ctx context.Context, p T) (R, error) {
-		file := getFile(p)
-		if _, err := os.Stat(file); os.IsNotExist(err) {
-			var zero R
-			return zero, fmt.Errorf("file not found: %s", file)
+		for _, file := range getFiles(p) {
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				var zero R
+				return zero, fmt.Errorf("file not found: %s", file)
+			}
 		}
 		return callFn(ctx, p)
 	}, methodName)
 }
+
+func albumFiles(p types.SendAlbumParams) []string {
+	files := make([]string, len(p.Items))
+	for i, item := range p.Items {
+		files[i] = item.File
+	}
+	return files
+}
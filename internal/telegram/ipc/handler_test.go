@@ -68,9 +68,6 @@ func TestFilterUpdatesByPeer(t *testing.T) {
 	if peerMatches(map[string]any{"message": "bad"}, "42") {
 		t.Fatal("bad message should not match")
 	}
-	if !isNumeric("42") || isNumeric("abc") {
-		t.Fatal("isNumeric mismatch")
-	}
 }
 
 func TestValidateFileParamsRequiresHTTPAllowlist(t *testing.T) {
@@ -92,4 +89,41 @@ func TestValidateFileParamsRequiresHTTPAllowlist(t *testing.T) {
 	if err := ValidateFileParams(outside, params); err == nil {
 		t.Fatal("file outside roots should be denied")
 	}
+	album := json.RawMessage(`{"items":[{"file":` + strconv.Quote(file) + `}]}`)
+	if err := ValidateFileParams(outside, album); err == nil {
+		t.Fatal("album item outside roots should be denied")
+	}
+	if err := ValidateFileParams(allowed, album); err != nil {
+		t.Fatalf("allowed album item denied: %v", err)
+	}
+}
+
+func TestValidateFileParamsChecksOutputParent(t *testing.T) {
+	root := t.TempDir()
+	httpCtx := baseipc.WithFileRoots(baseipc.WithSurface(context.Background(), baseipc.SurfaceHTTP), []string{root})
+
+	inside := json.RawMessage(`{"output":` + strconv.Quote(filepath.Join(root, "new", "report.pdf")) + `}`)
+	if err := ValidateFileParams(httpCtx, inside); err != nil {
+		t.Fatalf("output under root denied: %v", err)
+	}
+	escape := json.RawMessage(`{"output":` + strconv.Quote(filepath.Join(root, "..", "report.pdf")) + `}`)
+	if err := ValidateFileParams(httpCtx, escape); err == nil {
+		t.Fatal("output outside roots should be denied")
+	}
+	if err := ValidateFileParams(httpCtx, json.RawMessage(`{"output":""}`)); err == nil {
+		t.Fatal("empty output over HTTP should be denied")
+	}
+	local := json.RawMessage(`{"output":"/anywhere/report.pdf"}`)
+	if err := ValidateFileParams(context.Background(), local); err != nil {
+		t.Fatalf("local output should not be restricted: %v", err)
+	}
+	socket := baseipc.WithSurface(context.Background(), baseipc.SurfaceIPC)
+	if err := ValidateFileParams(socket, local); err != nil {
+		t.Fatalf("socket output should not be restricted: %v", err)
+	}
+	for _, surface := range []string{baseipc.SurfaceMCP, baseipc.SurfaceRules} {
+		if err := ValidateFileParams(baseipc.WithSurface(context.Background(), surface), local); err == nil {
+			t.Fatalf("%s output without file roots should be denied", surface)
+		}
+	}
 }
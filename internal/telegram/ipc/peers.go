@@ -0,0 +1,26 @@
+// Package ipc provides Telegram IPC handlers for the peer cache.
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PeerCacheStatsHandler returns a handler for peer_cache_stats requests.
+func PeerCacheStatsHandler(client Client) HandlerFunc {
+	return func(context.Context, json.RawMessage) (any, error) {
+		return client.PeerCacheStats(), nil
+	}
+}
+
+// ClearPeerCacheHandler returns a handler for clear_peer_cache requests.
+func ClearPeerCacheHandler(client Client) HandlerFunc {
+	return func(context.Context, json.RawMessage) (any, error) {
+		result, err := client.ClearPeerCache()
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear peer cache: %w", err)
+		}
+		return result, nil
+	}
+}
@@ -28,12 +28,17 @@ var methodHandlers = map[string]func(Client) HandlerFunc{
 	"get_messages":  func(c Client) HandlerFunc { return Handler(c.Message().GetMessages, "get messages") },
 	"get_user_info": func(c Client) HandlerFunc { return Handler(c.User().GetUserInfo, "get user info") },
 
+	// Peer cache
+	"peer_cache_stats": PeerCacheStatsHandler,
+	"clear_peer_cache": ClearPeerCacheHandler,
+
 	// Messages
 	"send_message":    func(c Client) HandlerFunc { return Handler(c.Message().SendMessage, "send message") },
 	"send_reply":      func(c Client) HandlerFunc { return Handler(c.Message().SendReply, "send reply") },
 	"update_message":  func(c Client) HandlerFunc { return Handler(c.Message().UpdateMessage, "update message") },
 	"delete_message":  func(c Client) HandlerFunc { return Handler(c.Message().DeleteMessage, "delete message") },
 	"forward_message": func(c Client) HandlerFunc { return Handler(c.Message().ForwardMessage, "forward message") },
+	"copy_message":    func(c Client) HandlerFunc { return Handler(c.Message().CopyMessage, "copy message") },
 	"clear_messages":  func(c Client) HandlerFunc { return Handler(c.Chat().ClearMessages, "clear messages") },
 	"clear_history":   func(c Client) HandlerFunc { return Handler(c.Chat().ClearHistory, "clear history") },
 
@@ -53,6 +58,9 @@ var methodHandlers = map[string]func(Client) HandlerFunc{
 	"send_audio": func(c Client) HandlerFunc { // alias for send_file
 		return FileHandler(func(p types.SendFileParams) string { return p.File }, c.Media().SendFile, "send file")
 	},
+	"send_album": func(c Client) HandlerFunc {
+		return FilesHandler(albumFiles, c.Media().SendAlbum, "send album")
+	},
 	"send_location":  func(c Client) HandlerFunc { return Handler(c.Media().SendLocation, "send location") },
 	"send_contact":   func(c Client) HandlerFunc { return Handler(c.Media().SendContact, "send contact") },
 	"send_poll":      SendPollHandler,
@@ -68,7 +76,8 @@ var methodHandlers = map[string]func(Client) HandlerFunc{
 	"send_gif": func(c Client) HandlerFunc {
 		return FileHandler(func(p types.SendGIFParams) string { return p.File }, c.Media().SendGIF, "send gif")
 	},
-	"send_dice": func(c Client) HandlerFunc { return Handler(c.Media().SendDice, "send dice") },
+	"send_dice":      func(c Client) HandlerFunc { return Handler(c.Media().SendDice, "send dice") },
+	"download_media": DownloadMediaHandler,
 
 	// Inline/keyboard
 	"inspect_inline_buttons": func(c Client) HandlerFunc {
@@ -141,9 +150,27 @@ var methodHandlers = map[string]func(Client) HandlerFunc{
 	"get_scheduled_messages": func(c Client) HandlerFunc {
 		return Handler(c.Message().GetScheduledMessages, "get scheduled messages")
 	},
+	"send_scheduled_messages": func(c Client) HandlerFunc {
+		return Handler(c.Message().SendScheduledMessages, "send scheduled messages")
+	},
+	"delete_scheduled_messages": func(c Client) HandlerFunc {
+		return Handler(c.Message().DeleteScheduledMessages, "delete scheduled messages")
+	},
+	"edit_scheduled_message": func(c Client) HandlerFunc {
+		return Handler(c.Message().EditScheduledMessage, "edit scheduled message")
+	},
 	"get_replies":      func(c Client) HandlerFunc { return Handler(c.Message().GetReplies, "get replies") },
 	"reply_to_comment": func(c Client) HandlerFunc { return Handler(c.Message().ReplyToComment, "reply to comment") },
 
+	// Export
+	"init_takeout_session": func(c Client) HandlerFunc {
+		return Handler(c.Message().InitTakeout, "init takeout session")
+	},
+	"finish_takeout_session": func(c Client) HandlerFunc {
+		return Handler(c.Message().FinishTakeout, "finish takeout session")
+	},
+	"export_messages": func(c Client) HandlerFunc { return Handler(c.Message().ExportMessages, "export messages") },
+
 	// Gift operations
 	"get_star_gifts":     func(c Client) HandlerFunc { return Handler(c.Gift().GetStarGifts, "get star gifts") },
 	"send_star_gift":     func(c Client) HandlerFunc { return Handler(c.Gift().SendStarGift, "send star gift") },
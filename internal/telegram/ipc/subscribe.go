@@ -0,0 +1,77 @@
+// Package ipc provides Telegram IPC handlers.
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/strictjson"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
+)
+
+// RegisterSubscriptionHandlers registers subscribe_updates and unsubscribe.
+// Subscriptions need a persistent connection, so they are only registered on
+// the Unix socket and stdio servers.
+func RegisterSubscriptionHandlers(srv ipc.MethodRegistrar, client Client, feed *updatefeed.Feed) {
+	srv.Register("subscribe_updates", SubscribeUpdatesHandler(client, feed))
+	ipc.RegisterUnsubscribe(srv)
+}
+
+// SubscribeUpdatesHandler returns a handler for subscribe_updates requests.
+// The response carries the subscription ID and starting cursor; matching
+// updates then arrive as "updates" notifications on the same connection
+// until unsubscribe or disconnect.
+func SubscribeUpdatesHandler(client Client, feed *updatefeed.Feed) ipc.Handler {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, *ipc.ErrorObject) {
+		var p types.SubscribeUpdatesParams
+		if len(params) > 0 {
+			if err := strictjson.Decode(params, &p); err != nil {
+				return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+			}
+		}
+		if err := p.Validate(); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		stream, ok := ipc.StreamFromContext(ctx)
+		if !ok {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidRequest, ipc.ErrorTypeValidation,
+				"subscribe_updates requires a persistent connection (Unix socket or stdio)", nil)
+		}
+
+		filter := updatefeed.Filter{Peer: p.Peer, Types: p.Types, Direction: p.Direction}
+		if filter.Peer == "" {
+			filter.Peer = p.Username
+		}
+		wake, stop := feed.Watch()
+		live, stopLive := feed.WatchLive()
+		cursor := updatefeed.Cursor{Offset: p.Offset, Epoch: p.Epoch}
+		if head := updatefeed.Head(client); p.Offset == 0 {
+			cursor = head
+		} else if cursor.Epoch == "" {
+			cursor.Epoch = head.Epoch
+		}
+
+		var id string
+		id = stream.Subscribe(func(ctx context.Context) {
+			defer stop()
+			defer stopLive()
+			err := updatefeed.Follow(ctx, client, wake, live, filter, cursor, func(batch updatefeed.Batch) error {
+				return stream.Notify(types.UpdatesNotificationMethod, types.UpdatesNotification{
+					SubscriptionID: id,
+					Updates:        batch.Updates,
+					NextOffset:     batch.NextOffset,
+					Epoch:          batch.Epoch,
+					Gap:            batch.Gap,
+				})
+			})
+			if err != nil && !errors.Is(err, context.Canceled) {
+				slog.Debug("ipc: update subscription ended", "subscription_id", id, "error", err)
+			}
+		})
+		return types.SubscribeUpdatesResult{SubscriptionID: id, Offset: cursor.Offset, Epoch: cursor.Epoch}, nil
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"agent-telegram/internal/strictjson"
+	"agent-telegram/internal/updatefeed"
 	"agent-telegram/telegram/types"
 )
 
@@ -85,40 +85,6 @@ func filterByPeer(updates []types.StoredUpdate, peer, username string) []types.S
 
 // peerMatches checks if an update's peer matches the filter value.
 func peerMatches(data map[string]any, filterValue string) bool {
-	// Check inside message data for peer info
 	msg, ok := data["message"].(map[string]any)
-	if !ok {
-		return false
-	}
-
-	// Check peer field in message (format: user:123, chat:123, channel:123)
-	if peer, ok := msg["peer"].(string); ok {
-		// If filter is numeric, compare with ID portion
-		if isNumeric(filterValue) {
-			parts := strings.Split(peer, ":")
-			if len(parts) == 2 && parts[1] == filterValue {
-				return true
-			}
-		}
-		// Case-insensitive partial match for username
-		if strings.Contains(strings.ToLower(peer), strings.ToLower(filterValue)) {
-			return true
-		}
-	}
-
-	// Try to match against from_name (display name or username)
-	if fromName, ok := msg["from_name"].(string); ok {
-		// Case-insensitive partial match for username
-		if strings.Contains(strings.ToLower(fromName), strings.ToLower(filterValue)) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// isNumeric checks if a string is a number.
-func isNumeric(s string) bool {
-	_, err := strconv.ParseInt(s, 10, 64)
-	return err == nil
+	return ok && updatefeed.PeerMatches(msg, filterValue)
 }
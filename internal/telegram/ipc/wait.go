@@ -0,0 +1,237 @@
+// Package ipc provides Telegram IPC handlers.
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/strictjson"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
+)
+
+// waitLookback is the number of stored updates a wait without a cursor
+// re-reads for new messages after afterId, so a reply stored between the
+// caller's send and its wait is not missed. When older updates exist the
+// result reports gap, and the caller checks history once instead.
+const waitLookback = 200
+
+// errWaitDone stops following once enough messages matched.
+var errWaitDone = errors.New("wait complete")
+
+// WaitClient is the part of the Telegram client wait_for_message uses.
+type WaitClient interface {
+	updatefeed.Source
+	ResolvePeerID(ctx context.Context, peer string) (string, error)
+}
+
+// RegisterWaitHandlers registers wait_for_message. Unlike subscriptions, a
+// wait is a single long-poll request, so it works on every server.
+func RegisterWaitHandlers(srv ipc.MethodRegistrar, client WaitClient, feed *updatefeed.Feed) {
+	srv.Register("wait_for_message", WaitForMessageHandler(client, feed))
+}
+
+// WaitForMessageHandler returns a handler for wait_for_message requests. It
+// follows the update stream until count incoming messages match or the
+// timeout, capped by the RPC timeout, passes.
+func WaitForMessageHandler(client WaitClient, feed *updatefeed.Feed) ipc.Handler {
+	return func(ctx context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+		var p types.WaitForMessageParams
+		if err := strictjson.Decode(params, &p); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		if p.Count == 0 {
+			p.Count = types.DefaultWaitCount
+		}
+		if p.Timeout == 0 {
+			p.Timeout = types.DefaultWaitTimeout
+		}
+		ctx, cancel := context.WithTimeout(ctx, min(time.Duration(p.Timeout)*time.Second, ipc.RequestTimeout()))
+		defer cancel()
+
+		matcher, rpcErr := newMessageMatcher(ctx, client, p)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+
+		// Watch before reading the head so no update stored in between is missed.
+		wake, stop := feed.Watch()
+		defer stop()
+		head := updatefeed.Head(client)
+		cursor := updatefeed.Cursor{Offset: p.Offset, Epoch: p.Epoch}
+		hasCursor := p.Offset != 0 || p.Epoch != ""
+		if !hasCursor {
+			// Without a cursor only updates stored from now on can be edits
+			// the caller is waiting for; new messages are bounded by afterId.
+			cursor = head
+			matcher.editsAfter = head.Offset
+			if p.AfterID > 0 {
+				cursor.Offset = max(head.Offset-waitLookback, 0)
+			}
+		} else if cursor.Epoch == "" {
+			cursor.Epoch = head.Epoch
+		}
+
+		result := types.WaitForMessageResult{
+			Messages:   []types.WaitedMessage{},
+			NextOffset: cursor.Offset,
+			Epoch:      cursor.Epoch,
+			// A lookback that does not reach the first update of the epoch
+			// may have skipped the reply.
+			Gap: !hasCursor && p.AfterID > 0 && cursor.Offset > 0,
+		}
+		err := updatefeed.Follow(ctx, client, wake, nil, updatefeed.Filter{}, cursor, func(batch updatefeed.Batch) error {
+			result.Gap = result.Gap || batch.Gap
+			result.Epoch = batch.Epoch
+			for _, update := range batch.Updates {
+				result.NextOffset = update.ID
+				if event, ok := matcher.match(update); ok {
+					result.Messages = append(result.Messages, types.WaitedMessage{
+						UpdateID: update.ID,
+						Event:    event,
+						Message:  update.Data["message"].(map[string]any),
+					})
+					if len(result.Messages) == p.Count {
+						return errWaitDone
+					}
+				}
+			}
+			result.NextOffset = batch.NextOffset
+			return nil
+		})
+		if err != nil && !errors.Is(err, errWaitDone) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, context.Canceled) {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInternalError, ipc.ErrorTypeInternal, err.Error(), nil)
+		}
+		result.Count = len(result.Messages)
+		result.Completed = result.Count == p.Count
+		return result, nil
+	}
+}
+
+// messageMatcher applies the wait_for_message predicates to stored updates.
+type messageMatcher struct {
+	peer       string
+	from       string
+	threadID   int64
+	afterID    int64
+	editOf     int64
+	text       *regexp.Regexp
+	hasButtons *bool
+	// editsAfter is the update ID before which edits are ignored.
+	editsAfter int64
+}
+
+func newMessageMatcher(ctx context.Context, client WaitClient, p types.WaitForMessageParams) (*messageMatcher, *ipc.ErrorObject) {
+	m := &messageMatcher{
+		threadID:   p.ThreadID,
+		afterID:    p.AfterID,
+		editOf:     p.EditOf,
+		hasButtons: p.HasButtons,
+	}
+	peer := p.Peer
+	if peer == "" {
+		peer = p.Username
+	}
+	var err error
+	if m.peer, err = client.ResolvePeerID(ctx, peer); err != nil {
+		return nil, classifyRPCError(err)
+	}
+	if p.From != "" {
+		if m.from, err = client.ResolvePeerID(ctx, p.From); err != nil {
+			return nil, classifyRPCError(err)
+		}
+	}
+	if p.Text != "" {
+		m.text = regexp.MustCompile(p.Text) // validated by Validate
+	}
+	return m, nil
+}
+
+// match reports whether update is a matching incoming message and whether
+// it is a new message or an edit.
+func (m *messageMatcher) match(update types.StoredUpdate) (string, bool) {
+	msg, ok := update.Data["message"].(map[string]any)
+	if !ok || updatefeed.UpdatePeer(update) != m.peer {
+		return "", false
+	}
+	if out, _ := msg["out"].(bool); out {
+		return "", false
+	}
+	id := int64Value(msg["id"])
+
+	var event string
+	switch update.Type {
+	case types.UpdateTypeNewMessage:
+		if id <= m.afterID {
+			return "", false
+		}
+		event = types.WaitEventNewMessage
+	case types.UpdateTypeEditMessage:
+		if m.editOf == 0 || id != m.editOf || update.ID <= m.editsAfter {
+			return "", false
+		}
+		event = types.WaitEventMessageEdited
+	default:
+		return "", false
+	}
+
+	if m.threadID != 0 && int64Value(msg["thread_id"]) != m.threadID {
+		return "", false
+	}
+	if m.from != "" {
+		from, _ := msg["from"].(string)
+		if from == "" && strings.HasPrefix(m.peer, "user:") {
+			// Private messages from the other side carry no sender.
+			from = m.peer
+		}
+		if from != m.from {
+			return "", false
+		}
+	}
+	if m.text != nil {
+		text, _ := msg["text"].(string)
+		if !m.text.MatchString(text) {
+			return "", false
+		}
+	}
+	if m.hasButtons != nil {
+		buttons, _ := msg["buttons"].([]map[string]any)
+		hasButtons := len(buttons) > 0
+		if list, ok := msg["buttons"].([]any); ok {
+			hasButtons = len(list) > 0
+		}
+		if hasButtons != *m.hasButtons {
+			return "", false
+		}
+	}
+	return event, true
+}
+
+// int64Value reads a numeric field of update data, which holds Go integers
+// for live updates and float64 for updates reloaded from the journal.
+func int64Value(value any) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	default:
+		return 0
+	}
+}
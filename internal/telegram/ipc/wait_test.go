@@ -0,0 +1,138 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram"
+	"agent-telegram/telegram/types"
+)
+
+// waitClient serves pages from an UpdateStore and resolves "@bot" to
+// user:42.
+type waitClient struct{ store *telegram.UpdateStore }
+
+func (c waitClient) GetUpdatePage(limit int, offset int64, epoch string) telegram.UpdatePage {
+	return c.store.Page(limit, offset, epoch)
+}
+
+func (waitClient) ResolvePeerID(_ context.Context, peer string) (string, error) {
+	if peer == "@bot" {
+		return "user:42", nil
+	}
+	return strings.TrimPrefix(peer, "@"), nil
+}
+
+func addMessage(store *telegram.UpdateStore, updateType types.UpdateType, message map[string]any) {
+	store.Add(types.StoredUpdate{Type: updateType, Data: map[string]any{"message": message}})
+}
+
+func waitFor(t *testing.T, client waitClient, feed *updatefeed.Feed, params string) types.WaitForMessageResult {
+	t.Helper()
+	result, rpcErr := WaitForMessageHandler(client, feed)(context.Background(), json.RawMessage(params))
+	if rpcErr != nil {
+		t.Fatalf("wait_for_message: %s", rpcErr.Message)
+	}
+	return result.(types.WaitForMessageResult)
+}
+
+func TestWaitForMessageSeesRepliesStoredBeforeTheWait(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	client := waitClient{store}
+	addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 10, "peer": "user:42", "text": "old"})
+	addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 11, "peer": "user:42", "text": "/start", "out": true})
+	addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 12, "peer": "user:7", "text": "other chat"})
+	addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 13, "peer": "user:42", "text": "Welcome"})
+
+	got := waitFor(t, client, updatefeed.New(), `{"peer":"@bot","afterId":11,"timeout":1}`)
+	if !got.Completed || got.Count != 1 || got.Messages[0].Message["text"] != "Welcome" ||
+		got.Messages[0].Event != types.WaitEventNewMessage {
+		t.Fatalf("result = %+v", got)
+	}
+}
+
+func TestWaitForMessageFollowsNewUpdatesUntilCount(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	client := waitClient{store}
+	feed := updatefeed.New()
+	store.SetOnUpdate(feed.Publish)
+	addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 5, "peer": "user:42", "text": "menu"})
+	cursor := updatefeed.Head(client)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		addMessage(store, types.UpdateTypeEditMessage, map[string]any{"id": 5, "peer": "user:42", "text": "menu v2",
+			"buttons": []any{map[string]any{"text": "Next"}}})
+		addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 6, "peer": "user:42", "text": "no buttons"})
+		addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 7, "peer": "user:42", "text": "done",
+			"buttons": []any{map[string]any{"text": "Again"}}})
+	}()
+
+	got := waitFor(t, client, feed, `{"peer":"@bot","editOf":5,"afterId":5,"hasButtons":true,"count":2,"timeout":5,`+
+		`"offset":`+jsonInt(cursor.Offset)+`,"epoch":"`+cursor.Epoch+`"}`)
+	if !got.Completed || got.Count != 2 {
+		t.Fatalf("result = %+v", got)
+	}
+	if got.Messages[0].Event != types.WaitEventMessageEdited || got.Messages[1].Message["text"] != "done" {
+		t.Fatalf("messages = %+v", got.Messages)
+	}
+	if got.NextOffset != got.Messages[1].UpdateID {
+		t.Fatalf("next offset = %d, want the last matched update %d", got.NextOffset, got.Messages[1].UpdateID)
+	}
+}
+
+func TestWaitForMessageResumesFromEmptyStoreCursor(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	client := waitClient{store}
+	// A cursor taken while the store is empty has offset 0; the edit that
+	// follows the press must still count.
+	cursor := updatefeed.Head(client)
+	addMessage(store, types.UpdateTypeEditMessage, map[string]any{"id": 5, "peer": "user:42", "text": "pressed"})
+
+	got := waitFor(t, client, updatefeed.New(), `{"peer":"@bot","editOf":5,"timeout":1,`+
+		`"offset":0,"epoch":"`+cursor.Epoch+`"}`)
+	if cursor.Offset != 0 || !got.Completed || got.Messages[0].Event != types.WaitEventMessageEdited {
+		t.Fatalf("cursor = %+v, result = %+v", cursor, got)
+	}
+}
+
+func TestWaitForMessageTimesOutWithCursor(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	client := waitClient{store}
+	// Edits stored before a wait without a cursor are stale.
+	addMessage(store, types.UpdateTypeEditMessage, map[string]any{"id": 5, "peer": "user:42", "text": "old edit"})
+	addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 6, "peer": "user:42", "text": "hello"})
+
+	got := waitFor(t, client, updatefeed.New(), `{"peer":"@bot","editOf":5,"text":"^bye$","timeout":1}`)
+	if got.Completed || got.Count != 0 || got.NextOffset != 2 || got.Epoch == "" {
+		t.Fatalf("result = %+v", got)
+	}
+}
+
+func TestWaitForMessageReportsGapBeyondLookback(t *testing.T) {
+	store := telegram.NewUpdateStore(1000)
+	client := waitClient{store}
+	addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 10, "peer": "user:42", "text": "Welcome"})
+	for i := range waitLookback {
+		addMessage(store, types.UpdateTypeNewMessage, map[string]any{"id": 100 + i, "peer": "user:7", "text": "busy"})
+	}
+
+	// The reply is older than the lookback; the caller must check history.
+	got := waitFor(t, client, updatefeed.New(), `{"peer":"@bot","afterId":9,"timeout":1}`)
+	if got.Completed || !got.Gap {
+		t.Fatalf("result = %+v", got)
+	}
+	got = waitFor(t, client, updatefeed.New(), `{"peer":"@bot","text":"^bye$","timeout":1}`)
+	if got.Gap {
+		t.Fatalf("a wait without afterId starts at the head: %+v", got)
+	}
+}
+
+func jsonInt(n int64) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
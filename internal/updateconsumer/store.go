@@ -0,0 +1,436 @@
+// Package updateconsumer tracks named, durable consumers of the update store.
+// Each consumer has a committed offset, acknowledgements received out of
+// order above it, and in-flight deliveries that are handed out again when
+// they are not acknowledged in time.
+package updateconsumer
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-telegram/internal/fsutil"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram"
+	"agent-telegram/telegram/types"
+)
+
+const (
+	// defaultFlushDelay batches offset writes. Losing the last second of acks
+	// only redelivers those updates (at-least-once).
+	defaultFlushDelay = time.Second
+	// pageSize is the number of stored updates read per scan step.
+	pageSize = 100
+)
+
+var (
+	// ErrUnknownConsumer is returned when acknowledging for a consumer that
+	// has never consumed.
+	ErrUnknownConsumer = errors.New("unknown consumer")
+	// ErrInvalidAck is returned for acknowledgements the consumer cannot apply.
+	ErrInvalidAck = errors.New("invalid acknowledgement")
+	// ErrFilterMismatch is returned when consuming with a filter other than
+	// the one the consumer was created with.
+	ErrFilterMismatch = errors.New("consumer filter mismatch")
+)
+
+// consumerState is the persisted part of a consumer. Filter is nil for
+// consumers saved before filters were recorded; they adopt the filter of
+// their next Consume call.
+type consumerState struct {
+	Epoch     string          `json:"epoch"`
+	Committed int64           `json:"committed"`
+	Acked     []int64         `json:"acked,omitempty"`
+	Filter    *consumerFilter `json:"filter,omitempty"`
+	UpdatedAt int64           `json:"updatedAt"`
+}
+
+// consumerFilter is the part of updatefeed.Filter a consumer is bound to.
+// Types are sorted so that equal filters compare equal.
+type consumerFilter struct {
+	Peer      string             `json:"peer,omitempty"`
+	Types     []types.UpdateType `json:"types,omitempty"`
+	Direction string             `json:"direction,omitempty"`
+}
+
+type fileFormat struct {
+	Version   int                       `json:"version"`
+	Consumers map[string]*consumerState `json:"consumers"`
+}
+
+// consumer is the progress of one named consumer. Leases are kept in memory
+// only: after a restart every unacknowledged update is delivered again.
+type consumer struct {
+	epoch     string
+	committed int64
+	acked     map[int64]struct{}
+	leases    map[int64]time.Time
+	filter    *consumerFilter
+	updatedAt time.Time
+}
+
+// ConsumeOptions controls one Consume call.
+type ConsumeOptions struct {
+	Limit int
+	// Filter selects the updates to deliver. A consumer is bound to the
+	// filter it was created with, since updates the filter rejects are
+	// acknowledged automatically; Consume refuses any other filter.
+	Filter updatefeed.Filter
+	// RedeliverAfter is how long a delivered update stays reserved before it
+	// is delivered again unacknowledged.
+	RedeliverAfter time.Duration
+}
+
+// Store is a JSON-file backed set of named consumers. Writes are atomic and
+// owner-only.
+type Store struct {
+	mu         sync.Mutex
+	path       string
+	consumers  map[string]*consumer
+	dirty      bool
+	flushTimer fsutil.FlushTimer
+	flushDelay time.Duration
+	now        func() time.Time
+}
+
+// New creates a Store that keeps consumer offsets in memory only.
+func New() *Store {
+	return &Store{consumers: make(map[string]*consumer), flushDelay: defaultFlushDelay, now: time.Now}
+}
+
+// Open loads the consumer file at path, starting empty when it does not exist.
+func Open(path string) (*Store, error) {
+	s := New()
+	s.path = path
+	// #nosec G304 -- path is under the owner-only config directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read update consumers: %w", err)
+	}
+	var file fileFormat
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode update consumers: %w", err)
+	}
+	for name, state := range file.Consumers {
+		if state == nil {
+			continue
+		}
+		c := newConsumer(state.Epoch, state.Committed)
+		for _, id := range state.Acked {
+			if id > c.committed {
+				c.acked[id] = struct{}{}
+			}
+		}
+		c.filter = state.Filter
+		c.updatedAt = time.Unix(state.UpdatedAt, 0)
+		s.consumers[name] = c
+	}
+	return s, nil
+}
+
+func newConsumer(epoch string, committed int64) *consumer {
+	return &consumer{
+		epoch:     epoch,
+		committed: committed,
+		acked:     make(map[int64]struct{}),
+		leases:    make(map[int64]time.Time),
+	}
+}
+
+// Consume returns up to opts.Limit updates after the consumer's committed
+// offset that are neither acknowledged nor reserved by an earlier delivery
+// that has not timed out. A consumer seen for the first time starts at the
+// newest stored update and is bound to opts.Filter.
+func (s *Store) Consume(src updatefeed.Source, name string, opts ConsumeOptions) (types.ConsumeUpdatesResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	filter := newConsumerFilter(opts.Filter)
+	c, ok := s.consumers[name]
+	if !ok {
+		head := updatefeed.Head(src)
+		c = newConsumer(head.Epoch, head.Offset)
+		s.consumers[name] = c
+	}
+	switch {
+	case c.filter == nil:
+		c.filter = filter
+	case !c.filter.equal(filter):
+		return types.ConsumeUpdatesResult{}, fmt.Errorf(
+			"%w: consumer %s was created with %s; delete it to change the filter",
+			ErrFilterMismatch, name, c.filter)
+	}
+
+	result := types.ConsumeUpdatesResult{Consumer: name, Updates: []types.StoredUpdate{}}
+	offset, epoch := c.committed, c.epoch
+	for len(result.Updates) < opts.Limit {
+		page := src.GetUpdatePage(pageSize, offset, epoch)
+		if page.Gap {
+			result.Gap = true
+			c.rebase(page)
+		}
+		for _, update := range page.Updates {
+			if !c.open(update.ID) {
+				continue
+			}
+			if !opts.Filter.Match(update) {
+				c.acked[update.ID] = struct{}{}
+				continue
+			}
+			lease, leased := c.leases[update.ID]
+			if leased && now.Before(lease) {
+				continue
+			}
+			if leased {
+				result.Redelivered++
+			}
+			c.leases[update.ID] = now.Add(opts.RedeliverAfter)
+			result.Updates = append(result.Updates, update)
+			if len(result.Updates) == opts.Limit {
+				break
+			}
+		}
+		if len(page.Updates) < pageSize {
+			break
+		}
+		offset, epoch = page.NextOffset, page.Epoch
+	}
+	c.compact()
+	c.updatedAt = now
+	s.markDirty()
+
+	result.Count = len(result.Updates)
+	result.Committed = c.committed
+	result.Epoch = c.epoch
+	result.Pending = len(c.leases)
+	return result, nil
+}
+
+// Ack acknowledges ids and, when upTo is set, every update up to and
+// including upTo. The committed offset then advances over every contiguous
+// acknowledged update.
+func (s *Store) Ack(src updatefeed.Source, name string, ids []int64, upTo int64) (types.AckUpdatesResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.consumers[name]
+	if !ok {
+		return types.AckUpdatesResult{}, fmt.Errorf("%w: %s", ErrUnknownConsumer, name)
+	}
+	head := updatefeed.Head(src)
+	if head.Epoch != c.epoch {
+		return types.AckUpdatesResult{}, fmt.Errorf(
+			"%w: consumer %s is behind a daemon epoch change; consume again", ErrInvalidAck, name)
+	}
+	if upTo > head.Offset || slices.ContainsFunc(ids, func(id int64) bool { return id > head.Offset }) {
+		return types.AckUpdatesResult{}, fmt.Errorf(
+			"%w: update IDs must not exceed the newest update %d", ErrInvalidAck, head.Offset)
+	}
+
+	acked := 0
+	if upTo > c.committed {
+		acked += int(upTo - c.committed)
+		for id := range c.acked {
+			if id <= upTo {
+				acked--
+			}
+		}
+		c.committed = upTo
+		c.prune()
+	}
+	for _, id := range ids {
+		if !c.open(id) {
+			continue
+		}
+		c.acked[id] = struct{}{}
+		delete(c.leases, id)
+		acked++
+	}
+	c.compact()
+	c.updatedAt = s.now()
+	s.markDirty()
+
+	return types.AckUpdatesResult{
+		Consumer:  name,
+		Acked:     acked,
+		Committed: c.committed,
+		Pending:   len(c.leases),
+	}, nil
+}
+
+// List describes every consumer, sorted by name.
+func (s *Store) List() types.ListConsumersResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := types.ListConsumersResult{Consumers: []types.ConsumerInfo{}}
+	for name, c := range s.consumers {
+		info := types.ConsumerInfo{
+			Name:      name,
+			Committed: c.committed,
+			Epoch:     c.epoch,
+			Acked:     len(c.acked),
+			Pending:   len(c.leases),
+			UpdatedAt: c.updatedAt.Unix(),
+		}
+		if c.filter != nil {
+			info.Peer, info.Types, info.Direction = c.filter.Peer, c.filter.Types, c.filter.Direction
+		}
+		result.Consumers = append(result.Consumers, info)
+	}
+	slices.SortFunc(result.Consumers, func(a, b types.ConsumerInfo) int { return cmp.Compare(a.Name, b.Name) })
+	result.Count = len(result.Consumers)
+	return result
+}
+
+// Delete forgets a consumer. It reports whether the consumer existed.
+func (s *Store) Delete(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.consumers[name]; !ok {
+		return false
+	}
+	delete(s.consumers, name)
+	s.markDirty()
+	return true
+}
+
+// Flush writes pending offsets immediately.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+// Close flushes pending offsets and stops background writes.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushTimer.Stop()
+	return s.flushLocked()
+}
+
+// open reports whether id is neither committed nor acknowledged.
+func (c *consumer) open(id int64) bool {
+	if id <= c.committed {
+		return false
+	}
+	_, acked := c.acked[id]
+	return !acked
+}
+
+// rebase moves the consumer past history that is no longer retained. An
+// epoch change drops all progress, since update IDs restart with it.
+func (c *consumer) rebase(page telegram.UpdatePage) {
+	if page.Epoch != c.epoch {
+		c.epoch = page.Epoch
+		c.committed = 0
+		clear(c.acked)
+		clear(c.leases)
+	}
+	if len(page.Updates) > 0 && page.Updates[0].ID-1 > c.committed {
+		c.committed = page.Updates[0].ID - 1
+		c.prune()
+	}
+}
+
+// compact advances the committed offset over contiguous acknowledgements.
+func (c *consumer) compact() {
+	for {
+		if _, ok := c.acked[c.committed+1]; !ok {
+			return
+		}
+		delete(c.acked, c.committed+1)
+		delete(c.leases, c.committed+1)
+		c.committed++
+	}
+}
+
+// prune drops acknowledgements and leases at or below the committed offset.
+func (c *consumer) prune() {
+	for id := range c.acked {
+		if id <= c.committed {
+			delete(c.acked, id)
+		}
+	}
+	for id := range c.leases {
+		if id <= c.committed {
+			delete(c.leases, id)
+		}
+	}
+}
+
+func (c *consumer) state() *consumerState {
+	state := &consumerState{Epoch: c.epoch, Committed: c.committed, Filter: c.filter, UpdatedAt: c.updatedAt.Unix()}
+	for id := range c.acked {
+		state.Acked = append(state.Acked, id)
+	}
+	slices.Sort(state.Acked)
+	return state
+}
+
+func newConsumerFilter(f updatefeed.Filter) *consumerFilter {
+	filterTypes := slices.Clone(f.Types)
+	slices.Sort(filterTypes)
+	return &consumerFilter{Peer: f.Peer, Types: slices.Compact(filterTypes), Direction: f.Direction}
+}
+
+func (f *consumerFilter) equal(other *consumerFilter) bool {
+	return f.Peer == other.Peer && f.Direction == other.Direction && slices.Equal(f.Types, other.Types)
+}
+
+// String describes the filter for error messages.
+func (f *consumerFilter) String() string {
+	var parts []string
+	if f.Peer != "" {
+		parts = append(parts, "peer "+f.Peer)
+	}
+	if len(f.Types) > 0 {
+		parts = append(parts, fmt.Sprintf("types %v", f.Types))
+	}
+	if f.Direction != "" {
+		parts = append(parts, "direction "+f.Direction)
+	}
+	if len(parts) == 0 {
+		return "no filter"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// markDirty schedules a write of the offsets; stores without a path stay in
+// memory. Callers hold s.mu.
+func (s *Store) markDirty() {
+	s.dirty = true
+	if s.path != "" {
+		s.flushTimer.Schedule(s.flushDelay, func() { _ = s.Flush() })
+	}
+}
+
+func (s *Store) flushLocked() error {
+	if !s.dirty || s.path == "" {
+		return nil
+	}
+	file := fileFormat{Version: 1, Consumers: make(map[string]*consumerState, len(s.consumers))}
+	for name, c := range s.consumers {
+		file.Consumers[name] = c.state()
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("encode update consumers: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("write update consumers: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,197 @@
+package updateconsumer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram"
+	"agent-telegram/telegram/types"
+)
+
+type storeSource struct{ store *telegram.UpdateStore }
+
+func (s storeSource) GetUpdatePage(limit int, offset int64, epoch string) telegram.UpdatePage {
+	return s.store.Page(limit, offset, epoch)
+}
+
+func addMessages(store *telegram.UpdateStore, n int) {
+	for range n {
+		store.Add(types.StoredUpdate{
+			Type: types.UpdateTypeNewMessage,
+			Data: map[string]any{"message": map[string]any{"peer": "user:1"}},
+		})
+	}
+}
+
+func ids(updates []types.StoredUpdate) []int64 {
+	out := make([]int64, 0, len(updates))
+	for _, update := range updates {
+		out = append(out, update.ID)
+	}
+	return out
+}
+
+func consume(t *testing.T, s *Store, src updatefeed.Source, name string,
+	opts ConsumeOptions) types.ConsumeUpdatesResult {
+	t.Helper()
+	result, err := s.Consume(src, name, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestConsumersTrackProgressIndependently(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	src := storeSource{store}
+	addMessages(store, 2)
+
+	path := filepath.Join(t.TempDir(), "update-consumers.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	opts := ConsumeOptions{Limit: 10, RedeliverAfter: time.Minute}
+
+	if got := consume(t, s, src, "triage", opts); got.Count != 0 || got.Committed != 2 {
+		t.Fatalf("new consumer should start at the newest update: %+v", got)
+	}
+	consume(t, s, src, "archive", opts)
+	addMessages(store, 3)
+
+	first := consume(t, s, src, "triage", opts)
+	if got := ids(first.Updates); len(got) != 3 || got[0] != 3 || first.Pending != 3 {
+		t.Fatalf("first delivery = %v (pending %d), want 3..5", got, first.Pending)
+	}
+	if again := consume(t, s, src, "triage", opts); again.Count != 0 {
+		t.Fatalf("in-flight updates should not be delivered again before the timeout: %v", ids(again.Updates))
+	}
+	if other := consume(t, s, src, "archive", opts); other.Count != 3 {
+		t.Fatalf("another consumer should get its own copy, got %v", ids(other.Updates))
+	}
+
+	ack, err := s.Ack(src, "triage", []int64{3, 5}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ack.Acked != 2 || ack.Committed != 3 || ack.Pending != 1 {
+		t.Fatalf("ack = %+v, want committed 3 with update 4 pending", ack)
+	}
+
+	now = now.Add(2 * time.Minute)
+	redelivered := consume(t, s, src, "triage", opts)
+	if got := ids(redelivered.Updates); len(got) != 1 || got[0] != 4 || redelivered.Redelivered != 1 {
+		t.Fatalf("redelivery = %v (redelivered %d), want [4]", got, redelivered.Redelivered)
+	}
+	if ack, err = s.Ack(src, "triage", []int64{4}, 0); err != nil || ack.Committed != 5 || ack.Pending != 0 {
+		t.Fatalf("ack = %+v, err = %v, want committed 5", ack, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("consumer file mode = %o, want 600", perm)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := s.List()
+	if list.Count != 2 || list.Consumers[0].Name != "archive" || list.Consumers[1].Committed != 5 {
+		t.Fatalf("reopened consumers = %+v", list.Consumers)
+	}
+	if got := consume(t, s, src, "archive", opts); got.Count != 3 {
+		t.Fatalf("unacknowledged updates should be delivered again after a restart, got %v", ids(got.Updates))
+	}
+}
+
+func TestConsumeAcknowledgesFilteredUpdates(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	src := storeSource{store}
+	s := New()
+	opts := ConsumeOptions{
+		Limit:          10,
+		RedeliverAfter: time.Minute,
+		Filter:         updatefeed.Filter{Types: []types.UpdateType{types.UpdateTypeEditMessage}},
+	}
+	consume(t, s, src, "edits", opts)
+
+	addMessages(store, 2)
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeEditMessage, Data: map[string]any{}})
+
+	got := consume(t, s, src, "edits", opts)
+	if ids := ids(got.Updates); len(ids) != 1 || ids[0] != 3 {
+		t.Fatalf("filtered delivery = %v, want [3]", ids)
+	}
+	if got.Committed != 2 {
+		t.Fatalf("committed = %d, want 2 after skipping filtered updates", got.Committed)
+	}
+}
+
+func TestConsumeRejectsAnotherFilter(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	src := storeSource{store}
+	path := filepath.Join(t.TempDir(), "update-consumers.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits := updatefeed.Filter{Types: []types.UpdateType{types.UpdateTypeEditMessage, types.UpdateTypeNewMessage}}
+	consume(t, s, src, "edits", ConsumeOptions{Limit: 10, RedeliverAfter: time.Minute, Filter: edits})
+	addMessages(store, 2)
+
+	_, err = s.Consume(src, "edits", ConsumeOptions{Limit: 10, RedeliverAfter: time.Minute})
+	if !errors.Is(err, ErrFilterMismatch) {
+		t.Fatalf("Consume with another filter error = %v, want ErrFilterMismatch", err)
+	}
+	if list := s.List(); list.Consumers[0].Committed != 0 || len(list.Consumers[0].Types) != 2 {
+		t.Fatalf("rejected call changed the consumer: %+v", list.Consumers[0])
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reordered := updatefeed.Filter{Types: []types.UpdateType{types.UpdateTypeNewMessage, types.UpdateTypeEditMessage}}
+	got := consume(t, s, src, "edits", ConsumeOptions{Limit: 10, RedeliverAfter: time.Minute, Filter: reordered})
+	if got.Count != 2 {
+		t.Fatalf("reopened consumer delivered %v, want the same filter to match", ids(got.Updates))
+	}
+	byPeer := ConsumeOptions{Limit: 10, Filter: updatefeed.Filter{Peer: "user:1"}}
+	if _, err := s.Consume(src, "edits", byPeer); !errors.Is(err, ErrFilterMismatch) {
+		t.Fatalf("reopened consumer accepted another filter: %v", err)
+	}
+}
+
+func TestAckRejectsUnknownConsumersAndFutureIDs(t *testing.T) {
+	store := telegram.NewUpdateStore(100)
+	src := storeSource{store}
+	addMessages(store, 2)
+	s := New()
+
+	if _, err := s.Ack(src, "triage", []int64{1}, 0); !errors.Is(err, ErrUnknownConsumer) {
+		t.Fatalf("ack for unknown consumer err = %v", err)
+	}
+	consume(t, s, src, "triage", ConsumeOptions{Limit: 10, RedeliverAfter: time.Minute})
+	if _, err := s.Ack(src, "triage", nil, 9); !errors.Is(err, ErrInvalidAck) {
+		t.Fatalf("ack beyond the newest update err = %v", err)
+	}
+	if !s.Delete("triage") || s.Delete("triage") {
+		t.Fatal("Delete should report whether the consumer existed")
+	}
+}
@@ -0,0 +1,273 @@
+// Package updatefeed delivers stored Telegram updates to long-lived
+// subscribers as they arrive, resuming from update store cursors.
+package updatefeed
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"agent-telegram/telegram"
+	"agent-telegram/telegram/types"
+)
+
+const (
+	// pageSize is the number of stored updates read per catch-up step.
+	pageSize = 100
+	// liveBuffer is the number of live updates buffered per watcher. Live
+	// updates are transient, so a watcher that falls further behind misses
+	// them.
+	liveBuffer = 64
+)
+
+// Feed fans UpdateStore.SetOnUpdate out to any number of watchers. Watchers
+// only receive a wake-up signal and read the store by cursor, so a slow
+// subscriber never blocks the update dispatcher and never misses updates
+// that are still retained. Live updates, which are not stored, are handed to
+// live watchers directly.
+type Feed struct {
+	mu       sync.Mutex
+	watchers map[chan struct{}]struct{}
+	live     map[chan types.StoredUpdate]struct{}
+}
+
+// New creates an empty Feed.
+func New() *Feed {
+	return &Feed{
+		watchers: make(map[chan struct{}]struct{}),
+		live:     make(map[chan types.StoredUpdate]struct{}),
+	}
+}
+
+// Publish wakes every watcher. It matches the UpdateStore.SetOnUpdate
+// callback signature and never blocks.
+func (f *Feed) Publish(types.StoredUpdate) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Watch returns a channel signalled after new updates are stored, and a
+// function that stops watching.
+func (f *Feed) Watch() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	f.mu.Lock()
+	f.watchers[ch] = struct{}{}
+	f.mu.Unlock()
+	return ch, func() {
+		f.mu.Lock()
+		delete(f.watchers, ch)
+		f.mu.Unlock()
+	}
+}
+
+// PublishLive hands a live update to every live watcher. It matches the
+// UpdateStore.SetOnLive callback signature and never blocks; watchers with a
+// full buffer miss the update.
+func (f *Feed) PublishLive(update types.StoredUpdate) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.live {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+// WatchLive returns a channel of live updates and a function that stops
+// watching.
+func (f *Feed) WatchLive() (<-chan types.StoredUpdate, func()) {
+	ch := make(chan types.StoredUpdate, liveBuffer)
+	f.mu.Lock()
+	f.live[ch] = struct{}{}
+	f.mu.Unlock()
+	return ch, func() {
+		f.mu.Lock()
+		delete(f.live, ch)
+		f.mu.Unlock()
+	}
+}
+
+// Source reads cursor pages of stored updates.
+type Source interface {
+	GetUpdatePage(limit int, offset int64, epoch string) telegram.UpdatePage
+}
+
+// Filter selects updates server-side. Zero fields match everything.
+type Filter struct {
+	// Peer matches the message peer by numeric ID, typed peer or a
+	// case-insensitive substring of the peer or sender name.
+	Peer string
+	// Types restricts delivery to the listed update types.
+	Types []types.UpdateType
+	// Direction is "in" for incoming or "out" for outgoing messages.
+	Direction string
+	// Allow, when set, is consulted after the other fields, e.g. to apply
+	// per-peer policy to each delivered update.
+	Allow func(types.StoredUpdate) bool
+}
+
+// Match reports whether update passes the filter.
+func (f Filter) Match(update types.StoredUpdate) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, update.Type) {
+		return false
+	}
+	if f.Peer != "" || f.Direction != "" {
+		msg, ok := peerData(update)
+		if !ok {
+			return false
+		}
+		if f.Peer != "" && !PeerMatches(msg, f.Peer) {
+			return false
+		}
+		if f.Direction != "" {
+			out, _ := msg["out"].(bool)
+			if out != (f.Direction == types.UpdateDirectionOut) {
+				return false
+			}
+		}
+	}
+	return f.Allow == nil || f.Allow(update)
+}
+
+// UpdatePeer returns the typed peer ("user:123") of an update, or "" when the
+// update carries no peer.
+func UpdatePeer(update types.StoredUpdate) string {
+	msg, ok := peerData(update)
+	if !ok {
+		return ""
+	}
+	peer, _ := msg["peer"].(string)
+	return peer
+}
+
+// peerData returns the part of an update that identifies its chat: the
+// message of message updates, or the data itself for updates such as read
+// receipts and typing that carry a top-level peer.
+func peerData(update types.StoredUpdate) (map[string]any, bool) {
+	if msg, ok := update.Data["message"].(map[string]any); ok {
+		return msg, true
+	}
+	if _, ok := update.Data["peer"].(string); ok {
+		return update.Data, true
+	}
+	return nil, false
+}
+
+// PeerMatches checks if a message's peer or sender matches filter. A leading
+// "@" is ignored.
+func PeerMatches(msg map[string]any, filter string) bool {
+	filterValue := strings.ToLower(strings.TrimPrefix(filter, "@"))
+	if filterValue == "" {
+		return true
+	}
+
+	// Peer format: user:123, chat:123, channel:123
+	if peer, ok := msg["peer"].(string); ok {
+		if _, err := strconv.ParseInt(filterValue, 10, 64); err == nil {
+			if _, id, found := strings.Cut(peer, ":"); found && id == filterValue {
+				return true
+			}
+		}
+		if strings.Contains(strings.ToLower(peer), filterValue) {
+			return true
+		}
+	}
+
+	if fromName, ok := msg["from_name"].(string); ok {
+		if strings.Contains(strings.ToLower(fromName), filterValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// Batch is one delivery to a subscriber.
+type Batch struct {
+	Updates    []types.StoredUpdate
+	NextOffset int64
+	Epoch      string
+	// Gap reports that updates between the previous cursor and this batch
+	// are no longer retained or belong to another daemon epoch.
+	Gap bool
+}
+
+// Cursor is a subscriber position in the update store.
+type Cursor struct {
+	Offset int64
+	Epoch  string
+}
+
+// Head returns the cursor of the newest stored update, used to subscribe
+// without replaying history.
+func Head(src Source) Cursor {
+	page := src.GetUpdatePage(1, 0, "")
+	return Cursor{Offset: page.NextOffset, Epoch: page.Epoch}
+}
+
+// Follow delivers matching updates after cursor until ctx is cancelled or
+// emit fails. Register the watch channel before computing cursor so no update
+// stored in between is missed. Matching updates from live, which may be nil,
+// are emitted in batches of one that leave the cursor unchanged.
+func Follow(
+	ctx context.Context,
+	src Source,
+	wake <-chan struct{},
+	live <-chan types.StoredUpdate,
+	filter Filter,
+	cursor Cursor,
+	emit func(Batch) error,
+) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for {
+			page := src.GetUpdatePage(pageSize, cursor.Offset, cursor.Epoch)
+			if len(page.Updates) == 0 && !page.Gap {
+				break
+			}
+			cursor = Cursor{Offset: page.NextOffset, Epoch: page.Epoch}
+
+			batch := Batch{NextOffset: page.NextOffset, Epoch: page.Epoch, Gap: page.Gap}
+			for _, update := range page.Updates {
+				if filter.Match(update) {
+					batch.Updates = append(batch.Updates, update)
+				}
+			}
+			if len(batch.Updates) > 0 || batch.Gap {
+				if batch.Updates == nil {
+					batch.Updates = []types.StoredUpdate{}
+				}
+				if err := emit(batch); err != nil {
+					return err
+				}
+			}
+			if len(page.Updates) < pageSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case update := <-live:
+			if !filter.Match(update) {
+				continue
+			}
+			batch := Batch{Updates: []types.StoredUpdate{update}, NextOffset: cursor.Offset, Epoch: cursor.Epoch}
+			if err := emit(batch); err != nil {
+				return err
+			}
+		}
+	}
+}
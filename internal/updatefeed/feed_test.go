@@ -0,0 +1,168 @@
+package updatefeed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"agent-telegram/telegram"
+	"agent-telegram/telegram/types"
+)
+
+type storeSource struct{ store *telegram.UpdateStore }
+
+func (s storeSource) GetUpdatePage(limit int, offset int64, epoch string) telegram.UpdatePage {
+	return s.store.Page(limit, offset, epoch)
+}
+
+func message(peer string, out bool) map[string]any {
+	return map[string]any{"message": map[string]any{"peer": peer, "out": out, "from_name": "Ada"}}
+}
+
+func TestFilterMatch(t *testing.T) {
+	incoming := types.StoredUpdate{Type: types.UpdateTypeNewMessage, Data: message("user:42", false)}
+	outgoing := types.StoredUpdate{Type: types.UpdateTypeEditMessage, Data: message("channel:7", true)}
+	other := types.StoredUpdate{Type: types.UpdateTypeOther, Data: map[string]any{}}
+	read := types.StoredUpdate{Type: types.UpdateTypeReadOutbox, Data: map[string]any{"peer": "user:42", "maxId": 9}}
+
+	cases := []struct {
+		name   string
+		filter Filter
+		update types.StoredUpdate
+		want   bool
+	}{
+		{"empty matches all", Filter{}, other, true},
+		{"type", Filter{Types: []types.UpdateType{types.UpdateTypeNewMessage}}, outgoing, false},
+		{"numeric peer", Filter{Peer: "42"}, incoming, true},
+		{"typed peer", Filter{Peer: "channel:7"}, outgoing, true},
+		{"sender name", Filter{Peer: "@ada"}, incoming, true},
+		{"peer mismatch", Filter{Peer: "43"}, incoming, false},
+		{"peer needs message", Filter{Peer: "42"}, other, false},
+		{"top-level peer", Filter{Peer: "42"}, read, true},
+		{"direction in", Filter{Direction: types.UpdateDirectionIn}, incoming, true},
+		{"direction out", Filter{Direction: types.UpdateDirectionOut}, incoming, false},
+		{"allow", Filter{Allow: func(u types.StoredUpdate) bool { return UpdatePeer(u) != "user:42" }}, incoming, false},
+		{"allow without peer", Filter{Allow: func(u types.StoredUpdate) bool { return UpdatePeer(u) == "" }}, other, true},
+	}
+	for _, tc := range cases {
+		if got := tc.filter.Match(tc.update); got != tc.want {
+			t.Errorf("%s: Match = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFollowDeliversFromCursorAndLiveUpdates(t *testing.T) {
+	store := telegram.NewUpdateStore(10)
+	feed := New()
+	store.SetOnUpdate(feed.Publish)
+	src := storeSource{store}
+
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeNewMessage, Data: message("user:1", false)})
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeNewMessage, Data: message("user:2", false)})
+
+	wake, stop := feed.Watch()
+	defer stop()
+	cursor := Cursor{Offset: 1, Epoch: Head(src).Epoch}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	batches := make(chan Batch, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, src, wake, nil, Filter{Peer: "user:"}, cursor, func(batch Batch) error {
+			batches <- batch
+			return nil
+		})
+	}()
+
+	first := <-batches
+	if len(first.Updates) != 1 || first.Updates[0].ID != 2 || first.NextOffset != 2 {
+		t.Fatalf("resume batch = %+v", first)
+	}
+
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeOther, Data: map[string]any{}})
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeNewMessage, Data: message("user:3", false)})
+	var live Batch
+	for live.NextOffset < 4 {
+		select {
+		case live = <-batches:
+		case <-ctx.Done():
+			t.Fatal("live update was not delivered")
+		}
+	}
+	if got := live.Updates[len(live.Updates)-1].ID; got != 4 {
+		t.Fatalf("live update id = %d, want 4", got)
+	}
+	for _, update := range live.Updates {
+		if update.Type == types.UpdateTypeOther {
+			t.Fatal("filtered update was delivered")
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Follow error = %v", err)
+	}
+}
+
+func TestFollowDeliversLiveUpdatesWithoutMovingCursor(t *testing.T) {
+	store := telegram.NewUpdateStore(10)
+	feed := New()
+	store.SetOnUpdate(feed.Publish)
+	store.SetOnLive(feed.PublishLive)
+	src := storeSource{store}
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeNewMessage, Data: message("user:1", false)})
+
+	wake, stop := feed.Watch()
+	defer stop()
+	live, stopLive := feed.WatchLive()
+	defer stopLive()
+	cursor := Head(src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	batches := make(chan Batch, 10)
+	go func() {
+		_ = Follow(ctx, src, wake, live, Filter{Peer: "user:2"}, cursor, func(batch Batch) error {
+			batches <- batch
+			return nil
+		})
+	}()
+
+	store.AddLive(types.StoredUpdate{Type: types.UpdateTypeTyping, Data: map[string]any{"peer": "user:3"}})
+	store.AddLive(types.StoredUpdate{Type: types.UpdateTypeTyping, Data: map[string]any{"peer": "user:2"}})
+	select {
+	case batch := <-batches:
+		if len(batch.Updates) != 1 || !batch.Updates[0].Live || batch.Updates[0].Data["peer"] != "user:2" {
+			t.Fatalf("live batch = %+v", batch)
+		}
+		if batch.NextOffset != cursor.Offset || batch.Epoch != cursor.Epoch {
+			t.Fatalf("live batch cursor = %d/%s, want %d/%s", batch.NextOffset, batch.Epoch, cursor.Offset, cursor.Epoch)
+		}
+	case <-ctx.Done():
+		t.Fatal("live update was not delivered")
+	}
+	if page := store.Page(10, cursor.Offset, cursor.Epoch); len(page.Updates) != 0 {
+		t.Fatalf("live updates were stored: %+v", page.Updates)
+	}
+}
+
+func TestFollowReportsEpochGap(t *testing.T) {
+	store := telegram.NewUpdateStore(10)
+	store.Add(types.StoredUpdate{Type: types.UpdateTypeNewMessage, Data: message("user:1", false)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got Batch
+	err := Follow(ctx, storeSource{store}, nil, nil, Filter{}, Cursor{Offset: 5, Epoch: "old"}, func(batch Batch) error {
+		got = batch
+		return errors.New("stop")
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Fatalf("Follow error = %v", err)
+	}
+	if !got.Gap || got.Epoch == "old" {
+		t.Fatalf("batch = %+v, want gap with new epoch", got)
+	}
+}
@@ -0,0 +1,382 @@
+// Package updatejournal provides a segmented append-only journal of Telegram
+// updates that survives daemon restarts.
+package updatejournal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-telegram/telegram/types"
+)
+
+const (
+	defaultSegmentBytes = int64(4 << 20)
+	defaultMaxBytes     = int64(256 << 20)
+	defaultMaxAge       = 7 * 24 * time.Hour
+
+	// MaxBytesEnv overrides the total journal size retained on disk.
+	MaxBytesEnv = "AGENT_TELEGRAM_UPDATES_MAX_BYTES"
+	// MaxAgeEnv overrides how long sealed segments are retained, e.g. "72h".
+	MaxAgeEnv = "AGENT_TELEGRAM_UPDATES_MAX_AGE"
+
+	epochFile     = "epoch"
+	segmentExt    = ".jsonl"
+	maxRecordSize = 16 << 20
+)
+
+// Options controls segment size and retention. Zero values use defaults.
+type Options struct {
+	SegmentBytes int64
+	MaxBytes     int64
+	MaxAge       time.Duration
+}
+
+// OptionsFromEnv returns retention options from the environment.
+func OptionsFromEnv() Options {
+	var opts Options
+	if raw := os.Getenv(MaxBytesEnv); raw != "" {
+		if value, err := strconv.ParseInt(raw, 10, 64); err == nil && value > 0 {
+			opts.MaxBytes = value
+		}
+	}
+	if raw := os.Getenv(MaxAgeEnv); raw != "" {
+		if value, err := time.ParseDuration(raw); err == nil && value > 0 {
+			opts.MaxAge = value
+		}
+	}
+	return opts
+}
+
+func (o Options) withDefaults() Options {
+	if o.SegmentBytes <= 0 {
+		o.SegmentBytes = defaultSegmentBytes
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = defaultMaxBytes
+	}
+	if o.MaxAge <= 0 {
+		o.MaxAge = defaultMaxAge
+	}
+	return o
+}
+
+// segment is one journal file named after the first update ID it holds.
+type segment struct {
+	firstID int64
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Journal is a directory of JSONL segments plus a stable epoch file.
+// Retention only removes sealed segments, so the active segment always
+// carries the last ID forward across restarts.
+type Journal struct {
+	mu       sync.Mutex
+	dir      string
+	opts     Options
+	epoch    string
+	segments []segment
+	active   *os.File
+	lastID   int64
+	now      func() time.Time
+}
+
+// Open opens or creates the journal in dir. A torn trailing record left by a
+// crash is truncated. A new epoch is generated when no history exists.
+func Open(dir string, opts Options) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create update journal directory: %w", err)
+	}
+	j := &Journal{dir: dir, opts: opts.withDefaults(), now: time.Now}
+	if err := j.loadSegments(); err != nil {
+		return nil, err
+	}
+	if err := j.loadEpoch(); err != nil {
+		return nil, err
+	}
+	if len(j.segments) > 0 {
+		if err := j.recoverTail(); err != nil {
+			return nil, err
+		}
+	}
+	j.prune()
+	return j, nil
+}
+
+func (j *Journal) loadSegments() error {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return fmt.Errorf("read update journal directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		firstID, err := strconv.ParseInt(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil || firstID <= 0 {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("stat update journal segment: %w", err)
+		}
+		j.segments = append(j.segments, segment{
+			firstID: firstID,
+			path:    filepath.Join(j.dir, name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.Slice(j.segments, func(a, b int) bool { return j.segments[a].firstID < j.segments[b].firstID })
+	return nil
+}
+
+func (j *Journal) loadEpoch() error {
+	path := filepath.Join(j.dir, epochFile)
+	// #nosec G304 -- path is inside the owner-only journal directory
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read update journal epoch: %w", err)
+	}
+	j.epoch = strings.TrimSpace(string(data))
+	// Without segments there is no history a cursor could refer to, so reusing
+	// the old epoch would let stale cursors silently skip restarted IDs.
+	if j.epoch != "" && len(j.segments) > 0 {
+		return nil
+	}
+	j.epoch = newEpoch()
+	if err := os.WriteFile(path, []byte(j.epoch+"\n"), 0o600); err != nil {
+		return fmt.Errorf("write update journal epoch: %w", err)
+	}
+	return nil
+}
+
+// recoverTail scans the newest segment for the last ID, drops a torn final
+// record, and reopens the segment for appending.
+func (j *Journal) recoverTail() error {
+	last := &j.segments[len(j.segments)-1]
+	// #nosec G304 -- path is inside the owner-only journal directory
+	file, err := os.OpenFile(last.path, os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("open update journal segment: %w", err)
+	}
+	reader := bufio.NewReader(file)
+	var good int64
+	lastID := last.firstID - 1
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil {
+			break
+		}
+		var update types.StoredUpdate
+		if err := json.Unmarshal(line, &update); err != nil {
+			break
+		}
+		good += int64(len(line))
+		lastID = update.ID
+	}
+	if good != last.size {
+		if err := file.Truncate(good); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("truncate torn update journal record: %w", err)
+		}
+		last.size = good
+	}
+	if _, err := file.Seek(good, io.SeekStart); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("seek update journal segment: %w", err)
+	}
+	j.active = file
+	j.lastID = lastID
+	return nil
+}
+
+// Epoch implements telegram.UpdateJournal.
+func (j *Journal) Epoch() string {
+	return j.epoch
+}
+
+// FirstID implements telegram.UpdateJournal.
+func (j *Journal) FirstID() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.segments) == 0 || j.lastID < j.segments[0].firstID {
+		return 0
+	}
+	return j.segments[0].firstID
+}
+
+// LastID implements telegram.UpdateJournal.
+func (j *Journal) LastID() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.lastID
+}
+
+// Append implements telegram.UpdateJournal.
+func (j *Journal) Append(update types.StoredUpdate) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if update.ID <= j.lastID {
+		return fmt.Errorf("update id %d is not after journal id %d", update.ID, j.lastID)
+	}
+	data, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("encode update: %w", err)
+	}
+	data = append(data, '\n')
+
+	if j.active == nil || j.segments[len(j.segments)-1].size >= j.opts.SegmentBytes {
+		if err := j.roll(update.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := j.active.Write(data); err != nil {
+		return fmt.Errorf("write update journal: %w", err)
+	}
+	tail := &j.segments[len(j.segments)-1]
+	tail.size += int64(len(data))
+	tail.modTime = j.now()
+	j.lastID = update.ID
+	j.prune()
+	return nil
+}
+
+// roll seals the active segment and starts a new one at firstID.
+func (j *Journal) roll(firstID int64) error {
+	if j.active != nil {
+		if err := j.active.Close(); err != nil {
+			return fmt.Errorf("close update journal segment: %w", err)
+		}
+		j.active = nil
+	}
+	path := filepath.Join(j.dir, fmt.Sprintf("%020d%s", firstID, segmentExt))
+	// #nosec G304 -- path is inside the owner-only journal directory
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("create update journal segment: %w", err)
+	}
+	j.active = file
+	j.segments = append(j.segments, segment{firstID: firstID, path: path, modTime: j.now()})
+	return nil
+}
+
+// prune removes sealed segments beyond the size or age limits.
+func (j *Journal) prune() {
+	var total int64
+	for _, seg := range j.segments {
+		total += seg.size
+	}
+	cutoff := j.now().Add(-j.opts.MaxAge)
+	for len(j.segments) > 1 {
+		oldest := j.segments[0]
+		if total <= j.opts.MaxBytes && !oldest.modTime.Before(cutoff) {
+			return
+		}
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			return
+		}
+		total -= oldest.size
+		j.segments = j.segments[1:]
+	}
+}
+
+// ReadAfter implements telegram.UpdateJournal.
+func (j *Journal) ReadAfter(afterID int64, limit int) ([]types.StoredUpdate, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if limit <= 0 || afterID >= j.lastID || len(j.segments) == 0 {
+		return []types.StoredUpdate{}, nil
+	}
+	start := sort.Search(len(j.segments), func(i int) bool {
+		return j.segments[i].firstID > afterID+1
+	}) - 1
+	start = max(start, 0)
+
+	out := make([]types.StoredUpdate, 0, min(limit, int(j.lastID-afterID)))
+	for _, seg := range j.segments[start:] {
+		var err error
+		out, err = readSegment(seg, afterID, limit, out)
+		if err != nil {
+			return nil, err
+		}
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func readSegment(seg segment, afterID int64, limit int, out []types.StoredUpdate) ([]types.StoredUpdate, error) {
+	// #nosec G304 -- path is inside the owner-only journal directory
+	file, err := os.Open(seg.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return out, nil
+		}
+		return nil, fmt.Errorf("open update journal segment: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(io.LimitReader(file, seg.size))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var update types.StoredUpdate
+		if err := json.Unmarshal(line, &update); err != nil {
+			return nil, fmt.Errorf("decode update journal record: %w", err)
+		}
+		if update.ID <= afterID {
+			continue
+		}
+		out = append(out, update)
+		if len(out) >= limit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read update journal segment: %w", err)
+	}
+	return out, nil
+}
+
+// Close implements telegram.UpdateJournal.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.active == nil {
+		return nil
+	}
+	syncErr := j.active.Sync()
+	closeErr := j.active.Close()
+	j.active = nil
+	return errors.Join(syncErr, closeErr)
+}
+
+func newEpoch() string {
+	var value [8]byte
+	if _, err := rand.Read(value[:]); err == nil {
+		return hex.EncodeToString(value[:])
+	}
+	return fmt.Sprintf("epoch-%d", time.Now().UnixNano())
+}
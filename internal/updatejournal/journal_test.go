@@ -0,0 +1,154 @@
+package updatejournal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"agent-telegram/telegram/types"
+)
+
+func appendN(t *testing.T, j *Journal, from, to int64) {
+	t.Helper()
+	for id := from; id <= to; id++ {
+		update := types.StoredUpdate{ID: id, Type: types.UpdateTypeNewMessage, Data: map[string]any{"n": id}}
+		if err := j.Append(update); err != nil {
+			t.Fatalf("append %d: %v", id, err)
+		}
+	}
+}
+
+func ids(updates []types.StoredUpdate) []int64 {
+	out := make([]int64, 0, len(updates))
+	for _, u := range updates {
+		out = append(out, u.ID)
+	}
+	return out
+}
+
+func TestJournalReopenKeepsEpochAndSequence(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, Options{SegmentBytes: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	epoch := j.Epoch()
+	appendN(t, j, 1, 10)
+	if err := j.Append(types.StoredUpdate{ID: 10}); err == nil {
+		t.Fatal("non-increasing id should be rejected")
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(dir, Options{SegmentBytes: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if reopened.Epoch() != epoch || reopened.LastID() != 10 || reopened.FirstID() != 1 {
+		t.Fatalf("reopened epoch=%q last=%d first=%d", reopened.Epoch(), reopened.LastID(), reopened.FirstID())
+	}
+	if len(reopened.segments) < 2 {
+		t.Fatalf("expected rolled segments, got %d", len(reopened.segments))
+	}
+	got, err := reopened.ReadAfter(3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{4, 5, 6, 7}; !slices.Equal(ids(got), want) {
+		t.Fatalf("ReadAfter(3,4) = %v, want %v", ids(got), want)
+	}
+	appendN(t, reopened, 11, 11)
+	if got, _ := reopened.ReadAfter(10, 5); !slices.Equal(ids(got), []int64{11}) {
+		t.Fatalf("after reopen append = %v", ids(got))
+	}
+}
+
+func TestJournalTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendN(t, j, 1, 3)
+	path := j.segments[len(j.segments)-1].path
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"id":4,"type":"new_mess`)
+	_ = f.Close()
+
+	reopened, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if reopened.LastID() != 3 {
+		t.Fatalf("last id after torn record = %d", reopened.LastID())
+	}
+	appendN(t, reopened, 4, 4)
+	if got, err := reopened.ReadAfter(0, 10); err != nil || !slices.Equal(ids(got), []int64{1, 2, 3, 4}) {
+		t.Fatalf("records = %v, %v", ids(got), err)
+	}
+}
+
+func TestJournalRetentionBySizeAndAge(t *testing.T) {
+	j, err := Open(t.TempDir(), Options{SegmentBytes: 100, MaxBytes: 400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = j.Close() }()
+	appendN(t, j, 1, 30)
+	var total int64
+	for _, seg := range j.segments {
+		total += seg.size
+	}
+	if total > 400+j.opts.SegmentBytes {
+		t.Fatalf("retained %d bytes", total)
+	}
+	first := j.FirstID()
+	if first <= 1 {
+		t.Fatalf("size retention kept first id %d", first)
+	}
+	if got, _ := j.ReadAfter(0, 1); len(got) != 1 || got[0].ID != first {
+		t.Fatalf("read from start = %v, want first retained %d", ids(got), first)
+	}
+
+	now := time.Now()
+	j.now = func() time.Time { return now.Add(2 * defaultMaxAge) }
+	appendN(t, j, 31, 31)
+	if len(j.segments) != 1 || j.LastID() != 31 {
+		t.Fatalf("age retention kept %d segments, last=%d", len(j.segments), j.LastID())
+	}
+}
+
+func TestJournalNewEpochWithoutHistory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, epochFile), []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	j, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = j.Close() }()
+	if j.Epoch() == "old" || j.Epoch() == "" {
+		t.Fatalf("epoch = %q, want a fresh epoch", j.Epoch())
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv(MaxBytesEnv, "1024")
+	t.Setenv(MaxAgeEnv, "2h")
+	opts := OptionsFromEnv().withDefaults()
+	if opts.MaxBytes != 1024 || opts.MaxAge != 2*time.Hour || opts.SegmentBytes != defaultSegmentBytes {
+		t.Fatalf("options = %+v", opts)
+	}
+}
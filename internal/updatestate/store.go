@@ -0,0 +1,272 @@
+// Package updatestate persists MTProto update state (pts/qts/seq/date,
+// per-channel pts and channel access hashes) for update gap recovery.
+package updatestate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/telegram/updates"
+
+	"agent-telegram/internal/fsutil"
+)
+
+// defaultFlushDelay batches state writes. Persisting a slightly older pts is
+// safe: on restart getDifference replays the tail again (at-least-once).
+const defaultFlushDelay = time.Second
+
+var errStateNotFound = errors.New("update state not found")
+
+type channelState struct {
+	Pts        int   `json:"pts,omitempty"`
+	AccessHash int64 `json:"accessHash,omitempty"`
+}
+
+type userState struct {
+	Pts      int                     `json:"pts"`
+	Qts      int                     `json:"qts"`
+	Date     int                     `json:"date"`
+	Seq      int                     `json:"seq"`
+	Known    bool                    `json:"known"`
+	Channels map[int64]*channelState `json:"channels,omitempty"`
+}
+
+type fileFormat struct {
+	Version int                  `json:"version"`
+	Users   map[int64]*userState `json:"users"`
+}
+
+// Store is a JSON-file backed updates.StateStorage and
+// updates.ChannelAccessHasher. Writes are atomic and owner-only.
+type Store struct {
+	mu         sync.Mutex
+	path       string
+	users      map[int64]*userState
+	dirty      bool
+	flushTimer fsutil.FlushTimer
+	flushDelay time.Duration
+}
+
+var (
+	_ updates.StateStorage        = (*Store)(nil)
+	_ updates.ChannelAccessHasher = (*Store)(nil)
+)
+
+// Open loads the state file at path, starting empty when it does not exist.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, users: make(map[int64]*userState), flushDelay: defaultFlushDelay}
+	// #nosec G304 -- path is under the owner-only config directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read update state: %w", err)
+	}
+	var file fileFormat
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode update state: %w", err)
+	}
+	for id, user := range file.Users {
+		if user == nil {
+			continue
+		}
+		if user.Channels == nil {
+			user.Channels = make(map[int64]*channelState)
+		}
+		s.users[id] = user
+	}
+	return s, nil
+}
+
+// GetState implements updates.StateStorage.
+func (s *Store) GetState(_ context.Context, userID int64) (updates.State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok || !user.Known {
+		return updates.State{}, false, nil
+	}
+	return updates.State{Pts: user.Pts, Qts: user.Qts, Date: user.Date, Seq: user.Seq}, true, nil
+}
+
+// SetState implements updates.StateStorage. Channel pts are kept: an older
+// channel pts only makes getChannelDifference replay more, never less.
+func (s *Store) SetState(_ context.Context, userID int64, state updates.State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := s.user(userID)
+	user.Pts, user.Qts, user.Date, user.Seq = state.Pts, state.Qts, state.Date, state.Seq
+	user.Known = true
+	s.markDirty()
+	return nil
+}
+
+// SetPts implements updates.StateStorage.
+func (s *Store) SetPts(_ context.Context, userID int64, pts int) error {
+	return s.update(userID, func(u *userState) { u.Pts = pts })
+}
+
+// SetQts implements updates.StateStorage.
+func (s *Store) SetQts(_ context.Context, userID int64, qts int) error {
+	return s.update(userID, func(u *userState) { u.Qts = qts })
+}
+
+// SetDate implements updates.StateStorage.
+func (s *Store) SetDate(_ context.Context, userID int64, date int) error {
+	return s.update(userID, func(u *userState) { u.Date = date })
+}
+
+// SetSeq implements updates.StateStorage.
+func (s *Store) SetSeq(_ context.Context, userID int64, seq int) error {
+	return s.update(userID, func(u *userState) { u.Seq = seq })
+}
+
+// SetDateSeq implements updates.StateStorage.
+func (s *Store) SetDateSeq(_ context.Context, userID int64, date, seq int) error {
+	return s.update(userID, func(u *userState) { u.Date, u.Seq = date, seq })
+}
+
+// GetChannelPts implements updates.StateStorage.
+func (s *Store) GetChannelPts(_ context.Context, userID, channelID int64) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return 0, false, nil
+	}
+	channel, ok := user.Channels[channelID]
+	if !ok || channel.Pts == 0 {
+		return 0, false, nil
+	}
+	return channel.Pts, true, nil
+}
+
+// SetChannelPts implements updates.StateStorage.
+func (s *Store) SetChannelPts(_ context.Context, userID, channelID int64, pts int) error {
+	return s.update(userID, func(u *userState) { u.channel(channelID).Pts = pts })
+}
+
+// ForEachChannels implements updates.StateStorage.
+func (s *Store) ForEachChannels(
+	ctx context.Context,
+	userID int64,
+	f func(ctx context.Context, channelID int64, pts int) error,
+) error {
+	s.mu.Lock()
+	user, ok := s.users[userID]
+	channels := make(map[int64]int)
+	if ok {
+		for id, channel := range user.Channels {
+			if channel.Pts > 0 {
+				channels[id] = channel.Pts
+			}
+		}
+	}
+	s.mu.Unlock()
+	for id, pts := range channels {
+		if err := f(ctx, id, pts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetChannelAccessHash implements updates.ChannelAccessHasher.
+func (s *Store) GetChannelAccessHash(_ context.Context, userID, channelID int64) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return 0, false, nil
+	}
+	channel, ok := user.Channels[channelID]
+	if !ok || channel.AccessHash == 0 {
+		return 0, false, nil
+	}
+	return channel.AccessHash, true, nil
+}
+
+// SetChannelAccessHash implements updates.ChannelAccessHasher.
+func (s *Store) SetChannelAccessHash(_ context.Context, userID, channelID, accessHash int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	channel := s.user(userID).channel(channelID)
+	if channel.AccessHash == accessHash {
+		return nil
+	}
+	channel.AccessHash = accessHash
+	s.markDirty()
+	return nil
+}
+
+// Flush writes pending state immediately.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+// Close flushes pending state and stops background writes.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushTimer.Stop()
+	return s.flushLocked()
+}
+
+func (s *Store) update(userID int64, fn func(*userState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok || !user.Known {
+		return errStateNotFound
+	}
+	fn(user)
+	s.markDirty()
+	return nil
+}
+
+func (s *Store) user(userID int64) *userState {
+	user, ok := s.users[userID]
+	if !ok {
+		user = &userState{Channels: make(map[int64]*channelState)}
+		s.users[userID] = user
+	}
+	return user
+}
+
+func (u *userState) channel(channelID int64) *channelState {
+	channel, ok := u.Channels[channelID]
+	if !ok {
+		channel = &channelState{}
+		u.Channels[channelID] = channel
+	}
+	return channel
+}
+
+// markDirty schedules a batched write of the state. Callers hold s.mu.
+func (s *Store) markDirty() {
+	s.dirty = true
+	s.flushTimer.Schedule(s.flushDelay, func() { _ = s.Flush() })
+}
+
+func (s *Store) flushLocked() error {
+	if !s.dirty {
+		return nil
+	}
+	data, err := json.Marshal(fileFormat{Version: 1, Users: s.users})
+	if err != nil {
+		return fmt.Errorf("encode update state: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("write update state: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
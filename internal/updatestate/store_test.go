@@ -0,0 +1,107 @@
+package updatestate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotd/td/telegram/updates"
+)
+
+func TestStoreRoundTripAcrossOpen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "update-state.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := s.GetState(ctx, 1); found {
+		t.Fatal("empty store should not report state")
+	}
+	if err := s.SetPts(ctx, 1, 5); err == nil {
+		t.Fatal("SetPts without state should fail")
+	}
+	if err := s.SetState(ctx, 1, updates.State{Pts: 10, Qts: 2, Date: 100, Seq: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDateSeq(ctx, 1, 200, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetChannelPts(ctx, 1, 77, 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetChannelAccessHash(ctx, 1, 77, 999); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("state file mode = %o, want 600", perm)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, found, err := s.GetState(ctx, 1)
+	if err != nil || !found {
+		t.Fatalf("GetState found=%v err=%v", found, err)
+	}
+	if want := (updates.State{Pts: 10, Qts: 2, Date: 200, Seq: 4}); state != want {
+		t.Fatalf("state = %+v, want %+v", state, want)
+	}
+	if pts, found, _ := s.GetChannelPts(ctx, 1, 77); !found || pts != 50 {
+		t.Fatalf("channel pts = %d found=%v", pts, found)
+	}
+	if hash, found, _ := s.GetChannelAccessHash(ctx, 1, 77); !found || hash != 999 {
+		t.Fatalf("access hash = %d found=%v", hash, found)
+	}
+}
+
+func TestStoreSetStateKeepsChannels(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if err := s.SetState(ctx, 1, updates.State{Pts: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetChannelPts(ctx, 1, 5, 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetState(ctx, 1, updates.State{Pts: 9}); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[int64]int{}
+	err = s.ForEachChannels(ctx, 1, func(_ context.Context, channelID int64, pts int) error {
+		seen[channelID] = pts
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen[5] != 42 || len(seen) != 1 {
+		t.Fatalf("channels = %v, want map[5:42]", seen)
+	}
+}
+
+func TestOpenRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
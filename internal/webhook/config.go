@@ -0,0 +1,132 @@
+// Package webhook pushes stored Telegram updates to HTTP endpoints. Deliveries
+// are HMAC-signed, retried with exponential backoff from a persisted queue,
+// and moved to a dead-letter log once retries are exhausted.
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"agent-telegram/internal/policy"
+	"agent-telegram/telegram/types"
+)
+
+// Webhook is one configured endpoint.
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret is the HMAC-SHA256 signing key. SecretEnv names an environment
+	// variable to read it from instead, keeping the key out of the file.
+	Secret    string `json:"secret,omitempty"`
+	SecretEnv string `json:"secretEnv,omitempty"`
+	// Types restricts deliveries to the listed update types.
+	Types []types.UpdateType `json:"types,omitempty"`
+	// Peers is an allow list of chats (user:123, 123, @username). When set,
+	// updates without a message peer are not delivered.
+	Peers    []string `json:"peers,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// Config is the per-instance webhooks.json file.
+type Config struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// LoadConfig reads a webhook configuration file. A missing file is an empty
+// configuration.
+func LoadConfig(path string) (Config, error) {
+	// #nosec G304 -- path is under the owner-only config directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("read webhooks: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode webhooks: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate checks IDs, URLs and signing secrets.
+func (c Config) Validate() error {
+	seen := make(map[string]struct{}, len(c.Webhooks))
+	for i, hook := range c.Webhooks {
+		if hook.ID == "" {
+			return fmt.Errorf("webhook %d: id is required", i)
+		}
+		if _, ok := seen[hook.ID]; ok {
+			return fmt.Errorf("webhook %q: duplicate id", hook.ID)
+		}
+		seen[hook.ID] = struct{}{}
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %q: url must be an absolute http(s) URL", hook.ID)
+		}
+		if hook.Secret == "" && hook.SecretEnv == "" {
+			return fmt.Errorf("webhook %q: secret or secretEnv is required", hook.ID)
+		}
+	}
+	return nil
+}
+
+// Get returns the webhook with id.
+func (c Config) Get(id string) (Webhook, bool) {
+	for _, hook := range c.Webhooks {
+		if hook.ID == id {
+			return hook, true
+		}
+	}
+	return Webhook{}, false
+}
+
+var errNoSecret = errors.New("signing secret is empty")
+
+// signingKey returns the HMAC key, reading SecretEnv when set.
+func (w Webhook) signingKey() ([]byte, error) {
+	secret := w.Secret
+	if w.SecretEnv != "" {
+		secret = os.Getenv(w.SecretEnv)
+	}
+	if secret == "" {
+		return nil, errNoSecret
+	}
+	return []byte(secret), nil
+}
+
+// matchesType reports whether the webhook accepts the update type.
+func (w Webhook) matchesType(updateType types.UpdateType) bool {
+	return len(w.Types) == 0 || slices.Contains(w.Types, updateType)
+}
+
+// matchesPeer reports whether peer (typed, e.g. "user:123") is in the allow
+// list. resolve maps @usernames to typed peers and may return "".
+func (w Webhook) matchesPeer(peer string, resolve func(string) string) bool {
+	if len(w.Peers) == 0 {
+		return true
+	}
+	if peer == "" {
+		return false
+	}
+	_, id, _ := strings.Cut(peer, ":")
+	for _, entry := range w.Peers {
+		entry = policy.NormalizePeer(entry)
+		if strings.HasPrefix(entry, "@") && resolve != nil {
+			entry = policy.NormalizePeer(resolve(entry))
+		}
+		if entry != "" && (entry == peer || entry == id) {
+			return true
+		}
+	}
+	return false
+}
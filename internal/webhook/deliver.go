@@ -0,0 +1,229 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"agent-telegram/telegram/types"
+)
+
+// TestUpdateType is the event type of synthetic updates sent by Test.
+const TestUpdateType types.UpdateType = "test"
+
+// Attempt is the outcome of one HTTP delivery.
+type Attempt struct {
+	Status     int           `json:"status,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"-"`
+	retryAfter time.Duration
+	retryable  bool
+}
+
+// OK reports whether the receiver answered with a 2xx status.
+func (a Attempt) OK() bool {
+	return a.Error == "" && a.Status >= 200 && a.Status < 300
+}
+
+// deliverDue hands the due deliveries of every idle webhook to a worker of
+// its own, so a slow or failing receiver only delays its own queue. It
+// returns the earliest pending retry time of the idle webhooks, or zero when
+// there is none; a worker wakes Run again when it is done.
+func (d *Dispatcher) deliverDue(ctx context.Context) time.Time {
+	now := time.Now()
+	d.mu.Lock()
+	due := make(map[string][]*Delivery)
+	for _, delivery := range d.queue {
+		if !d.busy[delivery.WebhookID] && !delivery.NextAttempt.After(now) {
+			due[delivery.WebhookID] = append(due[delivery.WebhookID], delivery)
+		}
+	}
+	for id := range due {
+		d.busy[id] = true
+	}
+	d.mu.Unlock()
+
+	for id, deliveries := range due {
+		d.workers.Go(func() { d.deliverWebhook(ctx, id, deliveries) })
+	}
+	return d.nextDue()
+}
+
+// deliverWebhook sends one webhook's due deliveries in queue order.
+func (d *Dispatcher) deliverWebhook(ctx context.Context, id string, due []*Delivery) {
+	defer func() {
+		d.mu.Lock()
+		delete(d.busy, id)
+		d.mu.Unlock()
+		d.signal()
+	}()
+	hook, ok := d.cfg.Get(id)
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if !ok || hook.Disabled {
+			d.finish(delivery, Attempt{Error: "webhook is not configured or disabled"})
+			continue
+		}
+		attempt := d.send(ctx, hook, delivery)
+		if ctx.Err() != nil {
+			// Shutting down: keep the delivery queued for the next run.
+			return
+		}
+		d.finish(delivery, attempt)
+	}
+}
+
+func (d *Dispatcher) nextDue() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var next time.Time
+	for _, delivery := range d.queue {
+		if d.busy[delivery.WebhookID] {
+			continue
+		}
+		if next.IsZero() || delivery.NextAttempt.Before(next) {
+			next = delivery.NextAttempt
+		}
+	}
+	return next
+}
+
+// finish records an attempt: successes leave the queue, retryable failures
+// are rescheduled with backoff, and everything else is dead-lettered.
+func (d *Dispatcher) finish(delivery *Delivery, attempt Attempt) {
+	d.mu.Lock()
+	delivery.Attempts++
+	delivery.LastStatus = attempt.Status
+	delivery.LastError = attempt.Error
+	dead := !attempt.OK() && (!attempt.retryable || delivery.Attempts >= d.opts.MaxAttempts)
+	if attempt.OK() || dead {
+		d.queue = slices.DeleteFunc(d.queue, func(q *Delivery) bool { return q == delivery })
+	} else {
+		delay := d.backoff(delivery.Attempts)
+		delay = max(delay, attempt.retryAfter)
+		delivery.NextAttempt = time.Now().UTC().Add(delay)
+	}
+	snapshot := *delivery
+	d.mu.Unlock()
+
+	if dead {
+		slog.Warn("webhook delivery failed permanently",
+			"webhook", snapshot.WebhookID, "delivery", snapshot.ID,
+			"attempts", snapshot.Attempts, "status", snapshot.LastStatus, "error", snapshot.LastError)
+		if err := d.appendDeadLetter(DeadLetter{Delivery: snapshot, FailedAt: time.Now().UTC()}); err != nil {
+			slog.Error("webhook dead-letter write failed", "delivery", snapshot.ID, "error", err)
+		}
+	}
+	d.flushTimer.Schedule(queueFlushDelay, d.persistQueue)
+}
+
+// backoff returns BaseBackoff·2^(attempts-1), capped at MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.BaseBackoff
+	for i := 1; i < attempts && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.opts.MaxBackoff)
+}
+
+// send POSTs one signed delivery.
+func (d *Dispatcher) send(ctx context.Context, hook Webhook, delivery *Delivery) Attempt {
+	start := time.Now()
+	key, err := hook.signingKey()
+	if err != nil {
+		return Attempt{Error: fmt.Sprintf("%s (secretEnv %s)", err, hook.SecretEnv)}
+	}
+	body, err := json.Marshal(Payload{
+		DeliveryID: delivery.ID,
+		WebhookID:  hook.ID,
+		Attempt:    delivery.Attempts + 1,
+		Update:     delivery.Update,
+	})
+	if err != nil {
+		return Attempt{Error: fmt.Sprintf("encode payload: %v", err)}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return Attempt{Error: err.Error()}
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "agent-telegram-webhook")
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderWebhook, hook.ID)
+	req.Header.Set(HeaderEvent, string(delivery.Update.Type))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(key, timestamp, body))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return Attempt{Error: err.Error(), Duration: time.Since(start), retryable: true}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return classify(resp, time.Since(start))
+}
+
+// classify maps an HTTP response to an attempt. 4xx responses other than
+// timeouts and throttling are the receiver rejecting the payload, so they
+// are not retried.
+func classify(resp *http.Response, duration time.Duration) Attempt {
+	attempt := Attempt{Status: resp.StatusCode, Duration: duration}
+	if attempt.OK() {
+		return attempt
+	}
+	attempt.Error = resp.Status
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout,
+		resp.StatusCode == http.StatusTooEarly,
+		resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= 500:
+		attempt.retryable = true
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		attempt.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return attempt
+}
+
+// ErrUnknownWebhook is returned for webhook IDs missing from the config.
+var ErrUnknownWebhook = errors.New("unknown webhook")
+
+// Test sends a synthetic "test" update to one webhook synchronously. It is
+// not queued, retried or dead-lettered.
+func (d *Dispatcher) Test(ctx context.Context, id string) (Attempt, error) {
+	hook, ok := d.cfg.Get(id)
+	if !ok {
+		return Attempt{}, fmt.Errorf("%w: %s", ErrUnknownWebhook, id)
+	}
+	now := time.Now().UTC()
+	delivery := &Delivery{
+		ID:        newDeliveryID(),
+		WebhookID: id,
+		Update: types.StoredUpdate{
+			Type:      TestUpdateType,
+			Timestamp: now,
+			Data:      map[string]any{"text": "agent-telegram webhook test"},
+		},
+		CreatedAt: now,
+	}
+	return d.send(ctx, hook, delivery), nil
+}
+
+func newDeliveryID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
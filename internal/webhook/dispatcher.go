@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"agent-telegram/internal/fsutil"
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/policy"
+	"agent-telegram/internal/updatefeed"
+	"agent-telegram/telegram/types"
+)
+
+const (
+	defaultMaxAttempts = 8
+	defaultBaseBackoff = 2 * time.Second
+	defaultMaxBackoff  = 10 * time.Minute
+	defaultTimeout     = 10 * time.Second
+
+	// maxInbox bounds updates waiting to be matched against webhooks. The
+	// oldest are dropped when a slow disk or resolver falls behind.
+	maxInbox = 10000
+	// resolveTTL is how long a failed @username resolution is remembered.
+	resolveTTL = 5 * time.Minute
+	// policyMethod is the operation checked by policy before an update
+	// leaves the process.
+	policyMethod = "get_updates"
+	// queueFlushDelay batches queue writes after delivery attempts. A crash
+	// inside the window only repeats those deliveries (at-least-once).
+	queueFlushDelay = time.Second
+)
+
+// Options configures a Dispatcher. Zero values select defaults.
+type Options struct {
+	QueuePath      string
+	DeadLetterPath string
+	// Resolver maps @username allow-list entries to typed peers.
+	Resolver policy.PeerResolver
+	// Policy, when set, is asked whether each update's peer may be read.
+	Policy      ipc.PolicyChecker
+	Client      *http.Client
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Delivery is one update queued for one webhook.
+type Delivery struct {
+	ID          string             `json:"id"`
+	WebhookID   string             `json:"webhookId"`
+	Update      types.StoredUpdate `json:"update"`
+	Attempts    int                `json:"attempts"`
+	NextAttempt time.Time          `json:"nextAttempt"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	LastStatus  int                `json:"lastStatus,omitempty"`
+	LastError   string             `json:"lastError,omitempty"`
+}
+
+// DeadLetter is a delivery that exhausted its retries or was rejected.
+type DeadLetter struct {
+	Delivery
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// Payload is the JSON body POSTed to a webhook.
+type Payload struct {
+	DeliveryID string             `json:"deliveryId"`
+	WebhookID  string             `json:"webhookId"`
+	Attempt    int                `json:"attempt"`
+	Update     types.StoredUpdate `json:"update"`
+}
+
+type resolved struct {
+	peer string
+	at   time.Time
+}
+
+// Dispatcher matches stored updates against configured webhooks and
+// delivers them from a persisted queue. Publish never blocks; Run does the
+// network and disk work on its own goroutine.
+type Dispatcher struct {
+	cfg  Config
+	opts Options
+	wake chan struct{}
+
+	mu      sync.Mutex
+	inbox   []types.StoredUpdate
+	queue   []*Delivery
+	dropped int64
+	// busy marks webhooks whose worker is delivering.
+	busy    map[string]bool
+	workers sync.WaitGroup
+
+	persistMu  sync.Mutex
+	flushTimer fsutil.FlushTimer
+
+	resolveMu sync.Mutex
+	resolved  map[string]resolved
+
+	// deadMu serializes dead-letter appends with replay rewrites.
+	deadMu sync.Mutex
+}
+
+// New creates a dispatcher and loads deliveries left over from a previous run.
+func New(cfg Config, opts Options) (*Dispatcher, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = defaultBaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	queue, err := loadQueue(opts.QueuePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Dispatcher{
+		cfg:      cfg,
+		opts:     opts,
+		wake:     make(chan struct{}, 1),
+		queue:    queue,
+		busy:     make(map[string]bool),
+		resolved: make(map[string]resolved),
+	}, nil
+}
+
+// Config returns the loaded configuration.
+func (d *Dispatcher) Config() Config {
+	return d.cfg
+}
+
+// Publish hands an update to the dispatcher. It is safe to use as an
+// UpdateStore callback: it only appends to memory and never waits on I/O.
+func (d *Dispatcher) Publish(update types.StoredUpdate) {
+	if !d.hasEnabled() {
+		return
+	}
+	d.mu.Lock()
+	if len(d.inbox) >= maxInbox {
+		d.inbox = d.inbox[1:]
+		d.dropped++
+		if d.dropped == 1 || d.dropped%1000 == 0 {
+			slog.Warn("webhook inbox full, dropping oldest updates", "dropped", d.dropped)
+		}
+	}
+	d.inbox = append(d.inbox, update)
+	d.mu.Unlock()
+	d.signal()
+}
+
+func (d *Dispatcher) hasEnabled() bool {
+	for _, hook := range d.cfg.Webhooks {
+		if !hook.Disabled {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run matches and delivers updates until ctx is cancelled. It returns once
+// every worker has stopped and the queue is written.
+func (d *Dispatcher) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	defer func() {
+		d.workers.Wait()
+		d.flushTimer.Stop()
+		d.persistQueue()
+	}()
+	for {
+		d.enqueueInbox(ctx)
+		next := d.deliverDue(ctx)
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = max(time.Until(next), 0)
+		}
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// enqueueInbox turns pending updates into one delivery per matching webhook.
+func (d *Dispatcher) enqueueInbox(ctx context.Context) {
+	d.mu.Lock()
+	inbox := d.inbox
+	d.inbox = nil
+	d.mu.Unlock()
+	if len(inbox) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	var added []*Delivery
+	for _, update := range inbox {
+		for _, hook := range d.matching(ctx, update) {
+			added = append(added, &Delivery{
+				ID:          newDeliveryID(),
+				WebhookID:   hook.ID,
+				Update:      update,
+				NextAttempt: now,
+				CreatedAt:   now,
+			})
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	d.mu.Lock()
+	d.queue = append(d.queue, added...)
+	d.mu.Unlock()
+	d.persistQueue()
+}
+
+// matching returns the enabled webhooks that accept update.
+func (d *Dispatcher) matching(ctx context.Context, update types.StoredUpdate) []Webhook {
+	peer := updatefeed.UpdatePeer(update)
+	if !d.policyAllows(ctx, peer) {
+		return nil
+	}
+	resolve := func(name string) string { return d.resolve(ctx, name) }
+	var hooks []Webhook
+	for _, hook := range d.cfg.Webhooks {
+		if hook.Disabled || !hook.matchesType(update.Type) || !hook.matchesPeer(peer, resolve) {
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks
+}
+
+func (d *Dispatcher) policyAllows(ctx context.Context, peer string) bool {
+	if d.opts.Policy == nil || peer == "" {
+		return true
+	}
+	params, _ := json.Marshal(map[string]string{"peer": peer})
+	return d.opts.Policy.Check(ctx, policyMethod, params) == nil
+}
+
+// resolve maps an @username to a typed peer, caching the answer. Failures
+// are cached for resolveTTL so an unknown name does not hit Telegram for
+// every update.
+func (d *Dispatcher) resolve(ctx context.Context, name string) string {
+	if d.opts.Resolver == nil {
+		return ""
+	}
+	d.resolveMu.Lock()
+	cached, ok := d.resolved[name]
+	d.resolveMu.Unlock()
+	if ok && (cached.peer != "" || time.Since(cached.at) < resolveTTL) {
+		return cached.peer
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	peer, err := d.opts.Resolver.ResolvePeerID(ctx, name)
+	if err != nil {
+		slog.Warn("webhook: failed to resolve allow-list peer", "peer", name, "error", err)
+		peer = ""
+	}
+	d.resolveMu.Lock()
+	d.resolved[name] = resolved{peer: peer, at: time.Now()}
+	d.resolveMu.Unlock()
+	return peer
+}
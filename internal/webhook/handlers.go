@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"agent-telegram/internal/ipc"
+	"agent-telegram/internal/operations"
+	"agent-telegram/internal/strictjson"
+)
+
+// RegisterHandlers registers list_webhooks, test_webhook and replay_webhooks.
+func RegisterHandlers(srv ipc.MethodRegistrar, d *Dispatcher) {
+	srv.Register("list_webhooks", func(context.Context, json.RawMessage) (any, *ipc.ErrorObject) {
+		result, err := d.List()
+		if err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInternalError, ipc.ErrorTypeInternal, err.Error(), nil)
+		}
+		return result, nil
+	})
+
+	srv.Register("test_webhook", func(ctx context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+		var p operations.WebhookTestParams
+		if err := strictjson.Decode(params, &p); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		attempt, err := d.Test(ctx, p.ID)
+		if errors.Is(err, ErrUnknownWebhook) {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		return operations.WebhookTestResult{
+			Webhook:    p.ID,
+			Success:    attempt.OK(),
+			Status:     attempt.Status,
+			Error:      attempt.Error,
+			DurationMs: attempt.Duration.Milliseconds(),
+		}, nil
+	})
+
+	srv.Register("replay_webhooks", func(_ context.Context, params json.RawMessage) (any, *ipc.ErrorObject) {
+		var p operations.WebhookReplayParams
+		if err := strictjson.Decode(params, &p); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInvalidParams, ipc.ErrorTypeValidation, err.Error(), nil)
+		}
+		replayed, err := d.Replay(ReplayFilter{IDs: p.IDs, Webhook: p.Webhook, All: p.All})
+		if err != nil {
+			return nil, ipc.NewTypedError(ipc.ErrCodeInternalError, ipc.ErrorTypeInternal, err.Error(), nil)
+		}
+		result := operations.WebhookReplayResult{Replayed: len(replayed)}
+		for _, delivery := range replayed {
+			result.DeliveryIDs = append(result.DeliveryIDs, delivery.ID)
+		}
+		return result, nil
+	})
+}
+
+// List describes the configured webhooks with their queue and dead-letter
+// counts. Secrets are left out.
+func (d *Dispatcher) List() (operations.ListWebhooksResult, error) {
+	dead, err := d.DeadLetters()
+	if err != nil {
+		return operations.ListWebhooksResult{}, err
+	}
+	deadCounts := make(map[string]int)
+	for _, entry := range dead {
+		deadCounts[entry.WebhookID]++
+	}
+	pending := d.Pending()
+
+	result := operations.ListWebhooksResult{Webhooks: []operations.WebhookInfo{}}
+	for _, hook := range d.cfg.Webhooks {
+		result.Webhooks = append(result.Webhooks, operations.WebhookInfo{
+			ID:          hook.ID,
+			URL:         hook.URL,
+			Types:       hook.Types,
+			Peers:       hook.Peers,
+			Disabled:    hook.Disabled,
+			SecretEnv:   hook.SecretEnv,
+			Pending:     pending[hook.ID],
+			DeadLetters: deadCounts[hook.ID],
+		})
+	}
+	result.Count = len(result.Webhooks)
+	return result, nil
+}
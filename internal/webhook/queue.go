@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"agent-telegram/internal/fsutil"
+)
+
+// queueFile is the on-disk retry queue.
+type queueFile struct {
+	Version    int         `json:"version"`
+	Deliveries []*Delivery `json:"deliveries"`
+}
+
+func loadQueue(path string) ([]*Delivery, error) {
+	if path == "" {
+		return nil, nil
+	}
+	// #nosec G304 -- path is under the owner-only config directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read webhook queue: %w", err)
+	}
+	var file queueFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode webhook queue: %w", err)
+	}
+	return file.Deliveries, nil
+}
+
+// persistQueue writes the current queue. Failures are logged: the queue
+// stays in memory and is written again on the next change.
+func (d *Dispatcher) persistQueue() {
+	if d.opts.QueuePath == "" {
+		return
+	}
+	// persistMu keeps snapshots from concurrent callers landing out of order.
+	d.persistMu.Lock()
+	defer d.persistMu.Unlock()
+	d.mu.Lock()
+	data, err := json.Marshal(queueFile{Version: 1, Deliveries: d.queue})
+	d.mu.Unlock()
+	if err == nil {
+		err = fsutil.WriteFileAtomic(d.opts.QueuePath, data)
+	}
+	if err != nil {
+		slog.Warn("webhook queue write failed", "path", d.opts.QueuePath, "error", err)
+	}
+}
+
+// Pending returns the number of queued deliveries per webhook.
+func (d *Dispatcher) Pending() map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	counts := make(map[string]int)
+	for _, delivery := range d.queue {
+		counts[delivery.WebhookID]++
+	}
+	return counts
+}
+
+func (d *Dispatcher) appendDeadLetter(entry DeadLetter) error {
+	if d.opts.DeadLetterPath == "" {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(d.opts.DeadLetterPath), 0o700); err != nil {
+		return err
+	}
+	// #nosec G304 -- path is under the owner-only config directory
+	f, err := os.OpenFile(d.opts.DeadLetterPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DeadLetters returns the dead-letter log, oldest first.
+func (d *Dispatcher) DeadLetters() ([]DeadLetter, error) {
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	return d.readDeadLetters()
+}
+
+func (d *Dispatcher) readDeadLetters() ([]DeadLetter, error) {
+	if d.opts.DeadLetterPath == "" {
+		return nil, nil
+	}
+	// #nosec G304 -- path is under the owner-only config directory
+	data, err := os.ReadFile(d.opts.DeadLetterPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read webhook dead letters: %w", err)
+	}
+	var entries []DeadLetter
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for scanner.Scan() {
+		var entry DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			slog.Warn("skipping malformed webhook dead letter", "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// ReplayFilter selects dead letters to requeue. IDs and Webhook combine;
+// All selects everything.
+type ReplayFilter struct {
+	IDs     []string
+	Webhook string
+	All     bool
+}
+
+func (f ReplayFilter) matches(entry DeadLetter) bool {
+	if f.Webhook != "" && entry.WebhookID != f.Webhook {
+		return false
+	}
+	if len(f.IDs) > 0 {
+		return slices.Contains(f.IDs, entry.ID)
+	}
+	return f.All || f.Webhook != ""
+}
+
+// Replay moves matching dead letters back into the retry queue with a fresh
+// attempt budget and returns the requeued deliveries.
+func (d *Dispatcher) Replay(filter ReplayFilter) ([]Delivery, error) {
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	entries, err := d.readDeadLetters()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var keep []DeadLetter
+	var replayed []*Delivery
+	for _, entry := range entries {
+		if !filter.matches(entry) {
+			keep = append(keep, entry)
+			continue
+		}
+		delivery := entry.Delivery
+		delivery.Attempts = 0
+		delivery.NextAttempt = now
+		replayed = append(replayed, &delivery)
+	}
+	if len(replayed) == 0 {
+		return nil, nil
+	}
+	if err := d.rewriteDeadLetters(keep); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.queue = append(d.queue, replayed...)
+	d.mu.Unlock()
+	d.persistQueue()
+	d.signal()
+
+	out := make([]Delivery, len(replayed))
+	for i, delivery := range replayed {
+		out[i] = *delivery
+	}
+	return out, nil
+}
+
+func (d *Dispatcher) rewriteDeadLetters(entries []DeadLetter) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := fsutil.WriteFileAtomic(d.opts.DeadLetterPath, buf.Bytes()); err != nil {
+		return fmt.Errorf("write webhook dead letters: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Delivery headers. The signature covers "<timestamp>.<body>" so receivers
+// can reject replayed requests by checking the timestamp.
+const (
+	HeaderDelivery  = "X-Agent-Telegram-Delivery"
+	HeaderWebhook   = "X-Agent-Telegram-Webhook"
+	HeaderEvent     = "X-Agent-Telegram-Event"
+	HeaderTimestamp = "X-Agent-Telegram-Timestamp"
+	HeaderSignature = "X-Agent-Telegram-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the X-Agent-Telegram-Signature value for body sent at
+// timestamp (Unix seconds).
+func Sign(key []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature produced by Sign in constant time.
+func Verify(key []byte, timestamp int64, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(key, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,291 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-telegram/telegram/types"
+)
+
+// receiver is a local webhook endpoint that verifies signatures and records
+// payloads.
+type receiver struct {
+	t      *testing.T
+	key    []byte
+	status atomic.Int32
+
+	mu       sync.Mutex
+	payloads []Payload
+	got      chan Payload
+}
+
+func newReceiver(t *testing.T, key string) (*receiver, *httptest.Server) {
+	t.Helper()
+	r := &receiver{t: t, key: []byte(key), got: make(chan Payload, 16)}
+	r.status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *receiver) serve(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	ts, _ := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	if !Verify(r.key, ts, body, req.Header.Get(HeaderSignature)) {
+		r.t.Errorf("bad signature %q", req.Header.Get(HeaderSignature))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		r.t.Errorf("decode payload: %v", err)
+	}
+	if req.Header.Get(HeaderDelivery) != payload.DeliveryID || req.Header.Get(HeaderEvent) != string(payload.Update.Type) {
+		r.t.Errorf("headers do not match payload: %v", req.Header)
+	}
+	status := int(r.status.Load())
+	if status < 300 {
+		r.mu.Lock()
+		r.payloads = append(r.payloads, payload)
+		r.mu.Unlock()
+	}
+	w.WriteHeader(status)
+	r.got <- payload
+}
+
+func (r *receiver) wait(t *testing.T) Payload {
+	t.Helper()
+	select {
+	case p := <-r.got:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for delivery")
+		return Payload{}
+	}
+}
+
+func messageUpdate(id int64, peer string) types.StoredUpdate {
+	return types.StoredUpdate{
+		ID:   id,
+		Type: types.UpdateTypeNewMessage,
+		Data: map[string]any{"message": map[string]any{"id": id, "peer": peer, "text": "hi"}},
+	}
+}
+
+func startDispatcher(t *testing.T, cfg Config, opts Options) *Dispatcher {
+	t.Helper()
+	dir := t.TempDir()
+	if opts.QueuePath == "" {
+		opts.QueuePath = filepath.Join(dir, "webhook-queue.json")
+	}
+	if opts.DeadLetterPath == "" {
+		opts.DeadLetterPath = filepath.Join(dir, "webhook-dead-letter.jsonl")
+	}
+	d, err := New(cfg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return d
+}
+
+func TestSignVerify(t *testing.T) {
+	sig := Sign([]byte("k"), 100, []byte(`{}`))
+	if !Verify([]byte("k"), 100, []byte(`{}`), sig) {
+		t.Fatal("signature does not verify")
+	}
+	if Verify([]byte("k"), 101, []byte(`{}`), sig) || Verify([]byte("other"), 100, []byte(`{}`), sig) {
+		t.Fatal("signature verified with wrong timestamp or key")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cases := []Config{
+		{Webhooks: []Webhook{{ID: "a", URL: "ftp://x", Secret: "s"}}},
+		{Webhooks: []Webhook{{ID: "a", URL: "http://x"}}},
+		{Webhooks: []Webhook{{ID: "a", URL: "http://x", Secret: "s"}, {ID: "a", URL: "http://y", Secret: "s"}}},
+		{Webhooks: []Webhook{{URL: "http://x", Secret: "s"}}},
+	}
+	for i, cfg := range cases {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("case %d: expected validation error", i)
+		}
+	}
+}
+
+func TestDispatcherFiltersAndSigns(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cret")
+	recv, srv := newReceiver(t, "s3cret")
+	d := startDispatcher(t, Config{Webhooks: []Webhook{{
+		ID:        "alerts",
+		URL:       srv.URL,
+		SecretEnv: "TEST_WEBHOOK_SECRET",
+		Types:     []types.UpdateType{types.UpdateTypeNewMessage},
+		Peers:     []string{"user:42", "100"},
+	}}}, Options{})
+
+	d.Publish(messageUpdate(1, "user:7"))
+	d.Publish(types.StoredUpdate{ID: 2, Type: types.UpdateTypeEditMessage,
+		Data: map[string]any{"message": map[string]any{"peer": "user:42"}}})
+	d.Publish(messageUpdate(3, "user:42"))
+	d.Publish(messageUpdate(4, "chat:100"))
+
+	for _, want := range []int64{3, 4} {
+		p := recv.wait(t)
+		if p.Update.ID != want || p.WebhookID != "alerts" || p.Attempt != 1 {
+			t.Fatalf("payload = %+v, want update %d", p, want)
+		}
+	}
+	select {
+	case p := <-recv.got:
+		t.Fatalf("unexpected delivery %+v", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatcherRetriesDeadLettersAndReplays(t *testing.T) {
+	recv, srv := newReceiver(t, "k")
+	recv.status.Store(http.StatusServiceUnavailable)
+	d := startDispatcher(t, Config{Webhooks: []Webhook{{ID: "h", URL: srv.URL, Secret: "k"}}},
+		Options{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+
+	d.Publish(messageUpdate(1, "user:1"))
+	for attempt := 1; attempt <= 3; attempt++ {
+		if p := recv.wait(t); p.Attempt != attempt {
+			t.Fatalf("attempt = %d, want %d", p.Attempt, attempt)
+		}
+	}
+	dead := waitDeadLetters(t, d, 1)
+	if dead[0].Attempts != 3 || dead[0].LastStatus != http.StatusServiceUnavailable {
+		t.Fatalf("dead letter = %+v", dead[0])
+	}
+	if pending := d.Pending()["h"]; pending != 0 {
+		t.Fatalf("pending = %d after dead-lettering", pending)
+	}
+
+	recv.status.Store(http.StatusNoContent)
+	replayed, err := d.Replay(ReplayFilter{Webhook: "h"})
+	if err != nil || len(replayed) != 1 || replayed[0].ID != dead[0].ID {
+		t.Fatalf("replayed = %+v, err = %v", replayed, err)
+	}
+	if p := recv.wait(t); p.DeliveryID != dead[0].ID || p.Attempt != 1 {
+		t.Fatalf("replayed payload = %+v", p)
+	}
+	waitDeadLetters(t, d, 0)
+}
+
+func TestDispatcherDoesNotRetryRejectedPayloads(t *testing.T) {
+	recv, srv := newReceiver(t, "k")
+	recv.status.Store(http.StatusBadRequest)
+	d := startDispatcher(t, Config{Webhooks: []Webhook{{ID: "h", URL: srv.URL, Secret: "k"}}},
+		Options{BaseBackoff: time.Millisecond})
+
+	d.Publish(messageUpdate(1, "user:1"))
+	recv.wait(t)
+	if dead := waitDeadLetters(t, d, 1); dead[0].Attempts != 1 {
+		t.Fatalf("dead letter = %+v, want a single attempt", dead[0])
+	}
+}
+
+func TestDispatcherSlowWebhookDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	recv, fast := newReceiver(t, "k")
+	d := startDispatcher(t, Config{Webhooks: []Webhook{
+		{ID: "slow", URL: slow.URL, Secret: "k"},
+		{ID: "fast", URL: fast.URL, Secret: "k"},
+	}}, Options{})
+
+	d.Publish(messageUpdate(1, "user:1"))
+	d.Publish(messageUpdate(2, "user:1"))
+	for _, want := range []int64{1, 2} {
+		if p := recv.wait(t); p.Update.ID != want || p.WebhookID != "fast" {
+			t.Fatalf("payload = %+v, want update %d for fast", p, want)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for d.Pending()["fast"] != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if pending := d.Pending(); pending["slow"] != 2 || pending["fast"] != 0 {
+		t.Fatalf("pending = %v", pending)
+	}
+}
+
+func TestDispatcherResumesPersistedQueue(t *testing.T) {
+	recv, srv := newReceiver(t, "k")
+	queuePath := filepath.Join(t.TempDir(), "queue.json")
+	cfg := Config{Webhooks: []Webhook{{ID: "h", URL: srv.URL, Secret: "k"}}}
+
+	// A dispatcher that never runs leaves its deliveries on disk.
+	stopped, err := New(cfg, Options{QueuePath: queuePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped.Publish(messageUpdate(9, "user:1"))
+	stopped.enqueueInbox(context.Background())
+
+	startDispatcher(t, cfg, Options{QueuePath: queuePath})
+	if p := recv.wait(t); p.Update.ID != 9 {
+		t.Fatalf("payload = %+v", p)
+	}
+}
+
+func TestDispatcherTestDelivery(t *testing.T) {
+	recv, srv := newReceiver(t, "k")
+	d, err := New(Config{Webhooks: []Webhook{{ID: "h", URL: srv.URL, Secret: "k"}}}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempt, err := d.Test(context.Background(), "h")
+	if err != nil || !attempt.OK() {
+		t.Fatalf("attempt = %+v, err = %v", attempt, err)
+	}
+	if p := recv.wait(t); p.Update.Type != TestUpdateType {
+		t.Fatalf("payload = %+v", p)
+	}
+	if _, err := d.Test(context.Background(), "missing"); err == nil {
+		t.Fatal("expected unknown webhook error")
+	}
+}
+
+func waitDeadLetters(t *testing.T, d *Dispatcher, want int) []DeadLetter {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		dead, err := d.DeadLetters()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dead) == want {
+			return dead
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dead letters = %d, want %d", len(dead), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
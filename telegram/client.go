@@ -9,6 +9,7 @@ import (
 
 	"github.com/gotd/td/session"
 	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/updates"
 	"github.com/gotd/td/tg"
 	"golang.org/x/sync/singleflight"
 
@@ -31,7 +32,12 @@ type Client struct {
 	sessionPath    string
 	sessionStorage session.Storage // optional: in-memory session (e.g. from env)
 	updateStore    *UpdateStore
+	updateState    UpdateStateStorage // optional: persisted pts/qts/seq for gap recovery
+	recovery       *recoveryTracker
+	echoes         *recoveryTracker // messages returned by our own RPC calls
+	flood          *floodControl
 	peerCache      sync.Map           // username → InputPeerClass cache
+	peerStore      PeerStore          // optional: peers persisted across restarts
 	peerFlight     singleflight.Group // deduplicates concurrent peer resolutions
 	ready          chan struct{}      // closed when client is fully initialized
 	reloadCh       chan struct{}      // signals session reload request
@@ -56,7 +62,10 @@ func NewClient(appID int, appHash string) *Client {
 		appHash:  appHash,
 		ready:    make(chan struct{}),
 		reloadCh: make(chan struct{}, 1),
+		recovery: newRecoveryTracker(),
+		echoes:   newRecoveryTracker(),
 	}
+	c.flood = newFloodControl(FloodWaitMaxFromEnv(), c.currentDC)
 	c.initDomainClients()
 	return c
 }
@@ -80,6 +89,21 @@ func (c *Client) WithUpdateStore(store *UpdateStore) *Client {
 	return c
 }
 
+// WithUpdateStateStorage persists update state so updates missed while the
+// daemon was down are recovered on startup. Without it state is kept in
+// memory and only disconnects within one process are recovered.
+func (c *Client) WithUpdateStateStorage(storage UpdateStateStorage) *Client {
+	c.updateState = storage
+	return c
+}
+
+// WithFloodObserver reports throttling and flood waits to observer instead of
+// the default log line.
+func (c *Client) WithFloodObserver(observer FloodObserver) *Client {
+	c.flood.setObserver(observer)
+	return c
+}
+
 // Start starts the Telegram client
 func (c *Client) Start(ctx context.Context) error {
 	c.mu.Lock()
@@ -93,14 +117,30 @@ func (c *Client) Start(ctx context.Context) error {
 		c.sessionStorage = storage
 	}
 
-	// Create dispatcher
+	// Create dispatcher behind the gap-recovering updates manager.
 	dispatcher := tg.NewUpdateDispatcher()
 	c.RegisterUpdateHandlers(dispatcher)
+	gapsConfig := updates.Config{Handler: c.peerUpdates(dispatcher)}
+	if c.updateState != nil {
+		gapsConfig.Storage = c.updateState
+		gapsConfig.AccessHasher = c.updateState
+	}
+	gaps := updates.New(gapsConfig)
 
 	// Create a new transport while keeping domain service instances stable.
+	// The hook feeds updates returned by our own RPC calls into the manager so
+	// they advance pts instead of being detected as gaps; the messages they
+	// carry are echoes of our own calls and stay out of the update store.
+	// Flood control wraps
+	// it so throttled and retried calls stay invisible to callers.
 	tgClient := telegram.NewClient(c.appID, c.appHash, telegram.Options{
 		SessionStorage: storage,
-		UpdateHandler:  dispatcher,
+		UpdateHandler:  gaps,
+		Middlewares: []telegram.Middleware{
+			c.flood,
+			c.peerMiddleware(),
+			c.echoHook(gaps.Handle),
+		},
 	})
 	c.runtimeMu.Lock()
 	c.client = tgClient
@@ -108,7 +148,7 @@ func (c *Client) Start(ctx context.Context) error {
 
 	// Run client
 	return tgClient.Run(ctx, func(runCtx context.Context) error {
-		return c.runClient(runCtx, tgClient, readyGeneration)
+		return c.runClient(runCtx, tgClient, gaps, readyGeneration)
 	})
 }
 
@@ -130,7 +170,12 @@ func (c *Client) initDomainClients() {
 }
 
 // runClient is the main client run loop.
-func (c *Client) runClient(ctx context.Context, tgClient *telegram.Client, readyGeneration chan struct{}) error {
+func (c *Client) runClient(
+	ctx context.Context,
+	tgClient *telegram.Client,
+	gaps *updates.Manager,
+	readyGeneration chan struct{},
+) error {
 	// Check authorization status.
 	status, err := tgClient.Auth().Status(ctx)
 	if err != nil {
@@ -149,6 +194,9 @@ func (c *Client) runClient(ctx context.Context, tgClient *telegram.Client, ready
 		return err
 	}
 	slog.Info("Logged in", "first_name", userInfo.FirstName, "username", userInfo.Username)
+	if c.peerStore != nil {
+		c.peerStore.BindAccount(userInfo.ID)
+	}
 
 	// Set API for domain clients
 	c.setDomainAPIs(tgClient.API())
@@ -161,6 +209,18 @@ func (c *Client) runClient(ctx context.Context, tgClient *telegram.Client, ready
 	}
 	c.mu.Unlock()
 
+	// Catch up via getDifference/getChannelDifference, then track pts/qts/seq
+	// until the transport stops. A failing manager must not take the API down.
+	err = gaps.Run(ctx, c.recovery.wrap(tgClient.API()), userInfo.ID, updates.AuthOptions{
+		IsBot: userInfo.Bot,
+		OnStart: func(context.Context) {
+			slog.Info("Update state tracking started", "persistent", c.updateState != nil)
+		},
+	})
+	if err != nil && ctx.Err() == nil {
+		slog.Error("Update gap recovery stopped", "error", err)
+	}
+
 	// Keep running
 	<-ctx.Done()
 	return nil
@@ -246,6 +306,24 @@ func (c *Client) GetStatus(ctx context.Context) ClientStatus {
 	return status
 }
 
+// FloodControlStatus reports throttling and flood waits since the client was
+// created.
+func (c *Client) FloodControlStatus() FloodControlStatus {
+	return c.flood.snapshot()
+}
+
+// currentDC returns the DC of the current transport, or 0 before it has
+// fetched its configuration.
+func (c *Client) currentDC() int {
+	c.runtimeMu.RLock()
+	tgClient := c.client
+	c.runtimeMu.RUnlock()
+	if tgClient == nil {
+		return 0
+	}
+	return tgClient.Config().ThisDC
+}
+
 // Logout invalidates the current Telegram authorization and clears volatile storage.
 func (c *Client) Logout(ctx context.Context) error {
 	c.runtimeMu.RLock()
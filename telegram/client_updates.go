@@ -17,21 +17,21 @@ func (c *Client) RegisterUpdateHandlers(dispatcher tg.UpdateDispatcher) {
 		return
 	}
 
-	// New messages (including service messages with gift actions)
+	// New messages (including service messages)
 	dispatcher.OnNewMessage(func(_ context.Context, entities tg.Entities, update *tg.UpdateNewMessage) error {
-		if data := giftActionData(update.Message, entities); data != nil {
-			c.updateStore.Add(NewStoredUpdate(types.UpdateTypeStarGift, data))
+		if !c.storeMessage(update.Message) {
 			return nil
 		}
-		c.updateStore.Add(NewStoredUpdate(types.UpdateTypeNewMessage, map[string]any{
-			"message": MessageData(update.Message, entities),
-		}))
+		c.updateStore.Add(c.newMessageUpdate(update.Message, entities))
 		return nil
 	})
 
 	// Edited messages
 	dispatcher.OnEditMessage(func(_ context.Context, entities tg.Entities, update *tg.UpdateEditMessage) error {
-		c.updateStore.Add(NewStoredUpdate(types.UpdateTypeEditMessage, map[string]any{
+		if !c.storeMessage(update.Message) {
+			return nil
+		}
+		c.updateStore.Add(c.messageUpdate(types.UpdateTypeEditMessage, update.Message, map[string]any{
 			"message": MessageData(update.Message, entities),
 		}))
 		return nil
@@ -40,16 +40,20 @@ func (c *Client) RegisterUpdateHandlers(dispatcher tg.UpdateDispatcher) {
 	// New channel posts
 	dispatcher.OnNewChannelMessage(
 		func(_ context.Context, entities tg.Entities, update *tg.UpdateNewChannelMessage) error {
-			c.updateStore.Add(NewStoredUpdate(types.UpdateTypeNewMessage, map[string]any{
-				"message": MessageData(update.Message, entities),
-			}))
+			if !c.storeMessage(update.Message) {
+				return nil
+			}
+			c.updateStore.Add(c.newMessageUpdate(update.Message, entities))
 			return nil
 		})
 
 	// Edited channel posts
 	dispatcher.OnEditChannelMessage(
 		func(_ context.Context, entities tg.Entities, update *tg.UpdateEditChannelMessage) error {
-			c.updateStore.Add(NewStoredUpdate(types.UpdateTypeEditMessage, map[string]any{
+			if !c.storeMessage(update.Message) {
+				return nil
+			}
+			c.updateStore.Add(c.messageUpdate(types.UpdateTypeEditMessage, update.Message, map[string]any{
 				"message": MessageData(update.Message, entities),
 			}))
 			return nil
@@ -66,6 +70,122 @@ func (c *Client) RegisterUpdateHandlers(dispatcher tg.UpdateDispatcher) {
 			}
 			return nil
 		})
+
+	c.registerEventHandlers(dispatcher)
+}
+
+// registerEventHandlers registers handlers for deletions in private chats and
+// basic groups, read receipts, typing, online status, reactions, membership
+// and pinned messages.
+func (c *Client) registerEventHandlers(dispatcher tg.UpdateDispatcher) {
+	// Deleted private and basic group messages; Telegram does not say which
+	// chat they were in.
+	dispatcher.OnDeleteMessages(func(_ context.Context, _ tg.Entities, update *tg.UpdateDeleteMessages) error {
+		for _, id := range update.Messages {
+			c.updateStore.Add(NewStoredUpdate(types.UpdateTypeDelete, map[string]any{
+				"message": map[string]any{"id": id},
+			}))
+		}
+		return nil
+	})
+
+	// Read receipts for our outgoing messages
+	dispatcher.OnReadHistoryOutbox(func(_ context.Context, _ tg.Entities, update *tg.UpdateReadHistoryOutbox) error {
+		c.updateStore.Add(NewStoredUpdate(types.UpdateTypeReadOutbox, map[string]any{
+			"peer":  helpers.FormatPeer(update.Peer, helpers.PeerFormatTyped),
+			"maxId": update.MaxID,
+		}))
+		return nil
+	})
+	dispatcher.OnReadChannelOutbox(func(_ context.Context, _ tg.Entities, update *tg.UpdateReadChannelOutbox) error {
+		c.updateStore.Add(NewStoredUpdate(types.UpdateTypeReadOutbox, map[string]any{
+			"peer":  fmt.Sprintf("channel:%d", update.ChannelID),
+			"maxId": update.MaxID,
+		}))
+		return nil
+	})
+
+	// Typing indicators and online status are too frequent to keep; they only
+	// reach live subscribers.
+	dispatcher.OnUserTyping(func(_ context.Context, _ tg.Entities, update *tg.UpdateUserTyping) error {
+		c.updateStore.AddLive(NewStoredUpdate(types.UpdateTypeTyping,
+			typingData(userPeer(update.UserID), nil, update.TopMsgID, update.Action)))
+		return nil
+	})
+	dispatcher.OnChatUserTyping(func(_ context.Context, _ tg.Entities, update *tg.UpdateChatUserTyping) error {
+		c.updateStore.AddLive(NewStoredUpdate(types.UpdateTypeTyping,
+			typingData(fmt.Sprintf("chat:%d", update.ChatID), update.FromID, 0, update.Action)))
+		return nil
+	})
+	dispatcher.OnChannelUserTyping(func(_ context.Context, _ tg.Entities, update *tg.UpdateChannelUserTyping) error {
+		c.updateStore.AddLive(NewStoredUpdate(types.UpdateTypeTyping,
+			typingData(fmt.Sprintf("channel:%d", update.ChannelID), update.FromID, update.TopMsgID, update.Action)))
+		return nil
+	})
+
+	dispatcher.OnUserStatus(func(_ context.Context, _ tg.Entities, update *tg.UpdateUserStatus) error {
+		c.updateStore.AddLive(NewStoredUpdate(types.UpdateTypeUserStatus, userStatusData(update.UserID, update.Status)))
+		return nil
+	})
+
+	// Reactions
+	dispatcher.OnMessageReactions(func(_ context.Context, _ tg.Entities, update *tg.UpdateMessageReactions) error {
+		c.updateStore.Add(NewStoredUpdate(types.UpdateTypeReaction, reactionsData(update)))
+		return nil
+	})
+
+	// Membership changes
+	dispatcher.OnChatParticipant(func(_ context.Context, _ tg.Entities, update *tg.UpdateChatParticipant) error {
+		c.updateStore.Add(NewStoredUpdate(types.UpdateTypeParticipant, participantData(
+			fmt.Sprintf("chat:%d", update.ChatID), update.Date, update.ActorID, update.UserID,
+			chatParticipantRole(update.PrevParticipant), chatParticipantRole(update.NewParticipant),
+		)))
+		return nil
+	})
+	dispatcher.OnChannelParticipant(func(_ context.Context, _ tg.Entities, update *tg.UpdateChannelParticipant) error {
+		c.updateStore.Add(NewStoredUpdate(types.UpdateTypeParticipant, participantData(
+			fmt.Sprintf("channel:%d", update.ChannelID), update.Date, update.ActorID, update.UserID,
+			channelParticipantRole(update.PrevParticipant), channelParticipantRole(update.NewParticipant),
+		)))
+		return nil
+	})
+
+	// Pinned messages
+	dispatcher.OnPinnedMessages(func(_ context.Context, _ tg.Entities, update *tg.UpdatePinnedMessages) error {
+		c.updateStore.Add(NewStoredUpdate(types.UpdateTypePin, pinnedData(
+			helpers.FormatPeer(update.Peer, helpers.PeerFormatTyped), update.Pinned, update.Messages,
+		)))
+		return nil
+	})
+	dispatcher.OnPinnedChannelMessages(
+		func(_ context.Context, _ tg.Entities, update *tg.UpdatePinnedChannelMessages) error {
+			c.updateStore.Add(NewStoredUpdate(types.UpdateTypePin, pinnedData(
+				fmt.Sprintf("channel:%d", update.ChannelID), update.Pinned, update.Messages,
+			)))
+			return nil
+		})
+}
+
+// newMessageUpdate builds the stored update for a new message: a star gift,
+// another service action, or an ordinary message.
+func (c *Client) newMessageUpdate(msg tg.MessageClass, entities tg.Entities) types.StoredUpdate {
+	if data := giftActionData(msg, entities); data != nil {
+		return c.messageUpdate(types.UpdateTypeStarGift, msg, data)
+	}
+	if data := serviceActionData(msg, entities); data != nil {
+		return c.messageUpdate(types.UpdateTypeService, msg, data)
+	}
+	return c.messageUpdate(types.UpdateTypeNewMessage, msg, map[string]any{
+		"message": MessageData(msg, entities),
+	})
+}
+
+// messageUpdate builds a stored update for msg, flagging messages replayed
+// from a difference response as recovered.
+func (c *Client) messageUpdate(updateType types.UpdateType, msg tg.MessageClass, data map[string]any) types.StoredUpdate {
+	update := NewStoredUpdate(updateType, data)
+	update.Recovered = c.recovery.take(msg)
+	return update
 }
 
 // giftActionData extracts gift data from a service message, or returns nil.
@@ -81,16 +81,34 @@ type MessageWriteClient interface {
 	UpdateMessage(ctx context.Context, params types.UpdateMessageParams) (*types.UpdateMessageResult, error)
 	DeleteMessage(ctx context.Context, params types.DeleteMessageParams) (*types.DeleteMessageResult, error)
 	ForwardMessage(ctx context.Context, params types.ForwardMessageParams) (*types.ForwardMessageResult, error)
+	CopyMessage(ctx context.Context, params types.CopyMessageParams) (*types.CopyMessageResult, error)
 	PressInlineButton(ctx context.Context, params types.PressInlineButtonParams) (*types.PressInlineButtonResult, error)
 	ReadMessages(ctx context.Context, params types.ReadMessagesParams) (*types.ReadMessagesResult, error)
 	SetTyping(ctx context.Context, params types.SetTypingParams) (*types.SetTypingResult, error)
 	ReplyToComment(ctx context.Context, params types.ReplyToCommentParams) (*types.ReplyToCommentResult, error)
+	SendScheduledMessages(
+		ctx context.Context, params types.ScheduledMessageIDsParams,
+	) (*types.ScheduledMessageIDsResult, error)
+	DeleteScheduledMessages(
+		ctx context.Context, params types.ScheduledMessageIDsParams,
+	) (*types.ScheduledMessageIDsResult, error)
+	EditScheduledMessage(
+		ctx context.Context, params types.EditScheduledMessageParams,
+	) (*types.EditScheduledMessageResult, error)
+}
+
+// MessageExportClient defines chat history export operations.
+type MessageExportClient interface {
+	InitTakeout(ctx context.Context, params types.InitTakeoutParams) (*types.InitTakeoutResult, error)
+	FinishTakeout(ctx context.Context, params types.FinishTakeoutParams) (*types.FinishTakeoutResult, error)
+	ExportMessages(ctx context.Context, params types.ExportMessagesParams) (*types.ExportMessagesResult, error)
 }
 
 // MessageClient defines the full message operation surface.
 type MessageClient interface {
 	MessageReadClient
 	MessageWriteClient
+	MessageExportClient
 }
 
 // MediaClient defines the interface for media operations.
@@ -98,6 +116,7 @@ type MediaClient interface {
 	SendPhoto(ctx context.Context, params types.SendPhotoParams) (*types.SendPhotoResult, error)
 	SendVideo(ctx context.Context, params types.SendVideoParams) (*types.SendVideoResult, error)
 	SendFile(ctx context.Context, params types.SendFileParams) (*types.SendFileResult, error)
+	SendAlbum(ctx context.Context, params types.SendAlbumParams) (*types.SendAlbumResult, error)
 	SendContact(ctx context.Context, params types.SendContactParams) (*types.SendContactResult, error)
 	SendLocation(ctx context.Context, params types.SendLocationParams) (*types.SendLocationResult, error)
 	SendPoll(ctx context.Context, params types.SendPollParams) (*types.SendPollResult, error)
@@ -108,6 +127,7 @@ type MediaClient interface {
 	GetStickerPacks(ctx context.Context, params types.GetStickerPacksParams) (*types.GetStickerPacksResult, error)
 	SendGIF(ctx context.Context, params types.SendGIFParams) (*types.SendGIFResult, error)
 	SendDice(ctx context.Context, params types.SendDiceParams) (*types.SendDiceResult, error)
+	DownloadMedia(ctx context.Context, params types.DownloadMediaParams) (*types.DownloadMediaResult, error)
 }
 
 // UserClient defines the interface for user operations.
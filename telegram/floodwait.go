@@ -0,0 +1,369 @@
+// Package telegram provides flood-wait aware scheduling of Telegram calls.
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+const (
+	// EnvFloodWaitMax is the longest FLOOD_WAIT the client sleeps through
+	// before retrying a call. Longer waits are returned to the caller. It
+	// accepts Go duration strings such as "45s"; "0" disables retries.
+	EnvFloodWaitMax = "AGENT_TELEGRAM_FLOOD_WAIT_MAX"
+
+	defaultFloodWaitMax = 30 * time.Second
+	// maxFloodRetries bounds retries of one call so a method that keeps
+	// tripping flood waits still fails eventually.
+	maxFloodRetries = 3
+)
+
+// Flood control actions reported to a FloodObserver.
+const (
+	FloodActionThrottle = "throttle" // a call was delayed by a token bucket
+	FloodActionRetry    = "retry"    // a call is retried after a FLOOD_WAIT
+	FloodActionReject   = "reject"   // a FLOOD_WAIT was returned to the caller
+)
+
+// rateLimit is a token bucket budget: calls per second and burst size.
+type rateLimit struct {
+	perSecond float64
+	burst     float64
+}
+
+// dcRateLimit keeps calls to one DC below the general request limit. File
+// transfers are exempt (see transferMethod).
+var dcRateLimit = rateLimit{perSecond: 25, burst: 30}
+
+// methodRateLimits are conservative budgets for methods Telegram is known to
+// flood-limit aggressively. Bursts keep interactive use unaffected; sustained
+// paging is spread out instead of tripping FLOOD_WAIT.
+var methodRateLimits = map[string]rateLimit{
+	"channels.getParticipants":  {perSecond: 1, burst: 5},
+	"contacts.resolveUsername":  {perSecond: 0.2, burst: 5},
+	"contacts.search":           {perSecond: 0.5, burst: 3},
+	"messages.getDialogs":       {perSecond: 1, burst: 5},
+	"messages.getHistory":       {perSecond: 3, burst: 10},
+	"messages.search":           {perSecond: 1, burst: 5},
+	"messages.searchGlobal":     {perSecond: 0.5, burst: 3},
+	"messages.sendMessage":      {perSecond: 1, burst: 5},
+	"messages.sendMedia":        {perSecond: 1, burst: 5},
+	"messages.sendMultiMedia":   {perSecond: 0.5, burst: 3},
+	"messages.forwardMessages":  {perSecond: 1, burst: 5},
+	"messages.getMessagesViews": {perSecond: 1, burst: 5},
+}
+
+// FloodEvent describes one wait imposed by flood control.
+type FloodEvent struct {
+	Action  string
+	Method  string
+	DC      int
+	Wait    time.Duration
+	Attempt int
+}
+
+// FloodObserver is notified of throttling and flood waits. The context is the
+// one of the delayed call, so observers can attach request trace IDs.
+type FloodObserver func(ctx context.Context, event FloodEvent)
+
+// FloodWaitRecord is the most recent FLOOD_WAIT returned by Telegram.
+type FloodWaitRecord struct {
+	Method  string    `json:"method"`
+	DC      int       `json:"dc"`
+	Seconds int       `json:"seconds"`
+	Retried bool      `json:"retried"`
+	At      time.Time `json:"at"`
+}
+
+// FloodBlock is a method that is waiting out a FLOOD_WAIT.
+type FloodBlock struct {
+	Method string    `json:"method"`
+	DC     int       `json:"dc"`
+	Until  time.Time `json:"until"`
+}
+
+// FloodControlStatus summarizes throttling and flood waits since start.
+type FloodControlStatus struct {
+	MaxWaitSeconds int              `json:"maxWaitSeconds"`
+	Retries        int64            `json:"retries"`
+	RetryWaitMs    int64            `json:"retryWaitMs"`
+	Rejected       int64            `json:"rejected"`
+	Throttled      int64            `json:"throttled"`
+	ThrottleWaitMs int64            `json:"throttleWaitMs"`
+	LastFloodWait  *FloodWaitRecord `json:"lastFloodWait,omitempty"`
+	Blocked        []FloodBlock     `json:"blocked,omitempty"`
+}
+
+// FloodWaitMaxFromEnv returns the configured retry threshold.
+func FloodWaitMaxFromEnv() time.Duration {
+	if raw := os.Getenv(EnvFloodWaitMax); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return defaultFloodWaitMax
+}
+
+// floodControl is a gotd middleware that schedules calls through per-DC and
+// per-method token buckets and transparently retries FLOOD_WAIT errors that
+// fit both the threshold and the caller's deadline. A FLOOD_WAIT also blocks
+// the method on that DC, so concurrent callers wait instead of extending the
+// penalty.
+type floodControl struct {
+	maxWait time.Duration
+	dc      func() int
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+
+	mu       sync.Mutex
+	observer FloodObserver
+	buckets  map[string]*tokenBucket
+	blocked  map[string]FloodBlock
+	status   FloodControlStatus
+}
+
+func newFloodControl(maxWait time.Duration, dc func() int) *floodControl {
+	return &floodControl{
+		maxWait: maxWait,
+		dc:      dc,
+		now:     time.Now,
+		sleep:   sleepContext,
+		buckets: make(map[string]*tokenBucket),
+		blocked: make(map[string]FloodBlock),
+	}
+}
+
+// Handle implements telegram.Middleware.
+func (f *floodControl) Handle(next tg.Invoker) telegram.InvokeFunc {
+	return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		method := invokedMethod(input)
+		for attempt := 1; ; attempt++ {
+			dc := f.dc()
+			if err := f.schedule(ctx, method, dc); err != nil {
+				return err
+			}
+			err := next.Invoke(ctx, input, output)
+			wait, ok := tgerr.AsFloodWait(err)
+			if !ok {
+				return err
+			}
+			retry := attempt <= maxFloodRetries && f.fits(ctx, wait)
+			f.recordFloodWait(method, dc, wait, retry)
+			if !retry {
+				f.notify(ctx, FloodEvent{Action: FloodActionReject, Method: method, DC: dc, Wait: wait, Attempt: attempt})
+				return err
+			}
+			f.notify(ctx, FloodEvent{Action: FloodActionRetry, Method: method, DC: dc, Wait: wait, Attempt: attempt})
+		}
+	}
+}
+
+// schedule waits until the method is unblocked and both buckets have a token.
+// A block longer than the threshold or the deadline fails fast with the
+// FLOOD_WAIT Telegram would return, without sending the call.
+func (f *floodControl) schedule(ctx context.Context, method string, dc int) error {
+	key := floodKey(method, dc)
+	f.mu.Lock()
+	now := f.now()
+	var blockedFor time.Duration
+	if block, ok := f.blocked[key]; ok {
+		if blockedFor = block.Until.Sub(now); blockedFor <= 0 {
+			delete(f.blocked, key)
+			blockedFor = 0
+		}
+	}
+	if blockedFor > 0 && !f.fits(ctx, blockedFor) {
+		f.status.Rejected++
+		f.mu.Unlock()
+		seconds := int((blockedFor + time.Second - 1) / time.Second)
+		f.notify(ctx, FloodEvent{Action: FloodActionReject, Method: method, DC: dc, Wait: blockedFor})
+		return tgerr.New(420, "FLOOD_WAIT_"+strconv.Itoa(seconds))
+	}
+	var throttle time.Duration
+	if !transferMethod(method) {
+		throttle = f.bucket("dc:"+strconv.Itoa(dc), dcRateLimit).reserve(now)
+	}
+	if limit, ok := methodRateLimits[method]; ok {
+		throttle = max(throttle, f.bucket(key, limit).reserve(now))
+	}
+	if throttle > 0 {
+		f.status.Throttled++
+		f.status.ThrottleWaitMs += throttle.Milliseconds()
+	}
+	f.mu.Unlock()
+
+	if blockedFor > throttle {
+		// Time spent blocked counts as retry wait; the retry was recorded
+		// when the FLOOD_WAIT arrived.
+		return f.sleep(ctx, blockedFor)
+	}
+	if throttle > 0 {
+		f.notify(ctx, FloodEvent{Action: FloodActionThrottle, Method: method, DC: dc, Wait: throttle})
+		return f.sleep(ctx, throttle)
+	}
+	return nil
+}
+
+// fits reports whether a wait is below the threshold and leaves time before
+// the context deadline.
+func (f *floodControl) fits(ctx context.Context, wait time.Duration) bool {
+	if wait > f.maxWait {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && f.now().Add(wait).After(deadline) {
+		return false
+	}
+	return true
+}
+
+func (f *floodControl) recordFloodWait(method string, dc int, wait time.Duration, retried bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	until := now.Add(wait)
+	key := floodKey(method, dc)
+	if block, ok := f.blocked[key]; !ok || block.Until.Before(until) {
+		f.blocked[key] = FloodBlock{Method: method, DC: dc, Until: until}
+	}
+	if retried {
+		f.status.Retries++
+		f.status.RetryWaitMs += wait.Milliseconds()
+	} else {
+		f.status.Rejected++
+	}
+	f.status.LastFloodWait = &FloodWaitRecord{
+		Method:  method,
+		DC:      dc,
+		Seconds: int(wait / time.Second),
+		Retried: retried,
+		At:      now.UTC(),
+	}
+}
+
+func (f *floodControl) bucket(key string, limit rateLimit) *tokenBucket {
+	b, ok := f.buckets[key]
+	if !ok {
+		b = &tokenBucket{limit: limit, tokens: limit.burst}
+		f.buckets[key] = b
+	}
+	return b
+}
+
+func (f *floodControl) setObserver(observer FloodObserver) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.observer = observer
+}
+
+func (f *floodControl) notify(ctx context.Context, event FloodEvent) {
+	f.mu.Lock()
+	observer := f.observer
+	f.mu.Unlock()
+	if observer == nil {
+		observer = logFloodEvent
+	}
+	observer(ctx, event)
+}
+
+// snapshot returns the counters and the methods that are still blocked.
+func (f *floodControl) snapshot() FloodControlStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := f.status
+	status.MaxWaitSeconds = int(f.maxWait / time.Second)
+	if status.LastFloodWait != nil {
+		last := *status.LastFloodWait
+		status.LastFloodWait = &last
+	}
+	now := f.now()
+	for key, block := range f.blocked {
+		if !block.Until.After(now) {
+			delete(f.blocked, key)
+			continue
+		}
+		status.Blocked = append(status.Blocked, block)
+	}
+	sort.Slice(status.Blocked, func(i, j int) bool {
+		return status.Blocked[i].Until.Before(status.Blocked[j].Until)
+	})
+	return status
+}
+
+func logFloodEvent(_ context.Context, event FloodEvent) {
+	slog.Info("Telegram flood control",
+		"action", event.Action,
+		"method", event.Method,
+		"dc", event.DC,
+		"wait_ms", event.Wait.Milliseconds(),
+		"attempt", event.Attempt,
+	)
+}
+
+// tokenBucket hands out tokens at a fixed rate. Reservations may drive the
+// balance negative; the deficit is the delay before the reserved call.
+type tokenBucket struct {
+	limit  rateLimit
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens = min(b.limit.burst, b.tokens+now.Sub(b.last).Seconds()*b.limit.perSecond)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.limit.perSecond * float64(time.Second))
+}
+
+// invokedMethod returns the TL method name of a request, such as
+// "channels.getParticipants". Calls in a takeout session are named
+// "takeout:<method>": Telegram limits them separately and more loosely, so
+// they do not share the buckets of interactive calls.
+func invokedMethod(input bin.Encoder) string {
+	if takeout, ok := input.(*tg.InvokeWithTakeoutRequest); ok {
+		return "takeout:" + invokedMethod(takeout.Query)
+	}
+	if named, ok := input.(interface{ TypeName() string }); ok {
+		return named.TypeName()
+	}
+	return fmt.Sprintf("%T", input)
+}
+
+func floodKey(method string, dc int) string {
+	return method + "@" + strconv.Itoa(dc)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// transferMethod reports whether method moves file parts. Downloads and
+// uploads run many part requests in parallel and Telegram limits them per
+// file rather than by the general request budget, so they skip the DC bucket;
+// a FLOOD_WAIT they do get is still retried or returned as usual.
+func transferMethod(method string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(method, "takeout:"), "upload.")
+}
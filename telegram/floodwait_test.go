@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// fakeClock advances only when flood control sleeps.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) sleep(_ context.Context, d time.Duration) error {
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func newTestFloodControl(maxWait time.Duration) (*floodControl, *fakeClock, *[]FloodEvent) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	f := newFloodControl(maxWait, func() int { return 2 })
+	f.now = func() time.Time { return clock.now }
+	f.sleep = clock.sleep
+	events := &[]FloodEvent{}
+	f.setObserver(func(_ context.Context, event FloodEvent) { *events = append(*events, event) })
+	return f, clock, events
+}
+
+// floodingInvoker fails its first calls with FLOOD_WAIT_<seconds>.
+type floodingInvoker struct {
+	floods  int
+	seconds string
+	calls   int
+}
+
+func (i *floodingInvoker) Invoke(context.Context, bin.Encoder, bin.Decoder) error {
+	i.calls++
+	if i.calls <= i.floods {
+		return tgerr.New(420, "FLOOD_WAIT_"+i.seconds)
+	}
+	return nil
+}
+
+func TestFloodControlRetriesShortFloodWait(t *testing.T) {
+	f, clock, events := newTestFloodControl(10 * time.Second)
+	start := clock.now
+	next := &floodingInvoker{floods: 1, seconds: "3"}
+
+	err := f.Handle(next)(context.Background(), &tg.ChannelsGetParticipantsRequest{}, nil)
+	if err != nil {
+		t.Fatalf("invoke error = %v, want retried success", err)
+	}
+	if next.calls != 2 || clock.now.Sub(start) != 3*time.Second {
+		t.Fatalf("calls = %d, waited %s; want 2 calls after 3s", next.calls, clock.now.Sub(start))
+	}
+	if len(*events) != 1 || (*events)[0].Action != FloodActionRetry || (*events)[0].Method != "channels.getParticipants" {
+		t.Fatalf("events = %+v", *events)
+	}
+	status := f.snapshot()
+	if status.Retries != 1 || status.RetryWaitMs != 3000 || status.LastFloodWait == nil || !status.LastFloodWait.Retried {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestFloodControlReturnsLongFloodWaitAndBlocksMethod(t *testing.T) {
+	f, _, _ := newTestFloodControl(10 * time.Second)
+	next := &floodingInvoker{floods: 1, seconds: "120"}
+	invoke := f.Handle(next)
+
+	err := invoke(context.Background(), &tg.ContactsResolveUsernameRequest{}, nil)
+	if wait, ok := tgerr.AsFloodWait(err); !ok || wait != 120*time.Second {
+		t.Fatalf("error = %v, want FLOOD_WAIT_120", err)
+	}
+	// The blocked method fails fast without reaching Telegram again.
+	err = invoke(context.Background(), &tg.ContactsResolveUsernameRequest{}, nil)
+	if _, ok := tgerr.AsFloodWait(err); !ok || next.calls != 1 {
+		t.Fatalf("second call error = %v after %d calls, want local FLOOD_WAIT", err, next.calls)
+	}
+	// Other methods are unaffected.
+	if err := invoke(context.Background(), &tg.MessagesGetHistoryRequest{}, nil); err != nil {
+		t.Fatalf("unrelated method error = %v", err)
+	}
+	status := f.snapshot()
+	if status.Rejected != 2 || len(status.Blocked) != 1 || status.Blocked[0].Method != "contacts.resolveUsername" {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestFloodControlRespectsDeadline(t *testing.T) {
+	f, clock, _ := newTestFloodControl(time.Minute)
+	next := &floodingInvoker{floods: 1, seconds: "20"}
+	ctx, cancel := context.WithDeadline(context.Background(), clock.now.Add(5*time.Second))
+	defer cancel()
+
+	err := f.Handle(next)(ctx, &tg.MessagesSearchRequest{}, nil)
+	if _, ok := tgerr.AsFloodWait(err); !ok || next.calls != 1 {
+		t.Fatalf("error = %v after %d calls, want FLOOD_WAIT without retry", err, next.calls)
+	}
+}
+
+func TestFloodControlThrottlesParticipantPaging(t *testing.T) {
+	f, clock, events := newTestFloodControl(10 * time.Second)
+	start := clock.now
+	invoke := f.Handle(&floodingInvoker{})
+
+	limit := methodRateLimits["channels.getParticipants"]
+	pages := int(limit.burst) + 3
+	for range pages {
+		if err := invoke(context.Background(), &tg.ChannelsGetParticipantsRequest{}, nil); err != nil {
+			t.Fatalf("invoke error = %v", err)
+		}
+	}
+	want := time.Duration(float64(pages-int(limit.burst)) / limit.perSecond * float64(time.Second))
+	if elapsed := clock.now.Sub(start); elapsed != want {
+		t.Fatalf("paging took %s, want %s", elapsed, want)
+	}
+	if status := f.snapshot(); status.Throttled != 3 || len(*events) != 3 {
+		t.Fatalf("throttled = %d, events = %d; want 3", status.Throttled, len(*events))
+	}
+}
+
+func TestFloodControlKeepsTakeoutPagingOutOfHistoryBucket(t *testing.T) {
+	f, clock, _ := newTestFloodControl(10 * time.Second)
+	start := clock.now
+	invoke := f.Handle(&floodingInvoker{})
+
+	pages := int(methodRateLimits["messages.getHistory"].burst) + 3
+	for range pages {
+		req := &tg.InvokeWithTakeoutRequest{TakeoutID: 1, Query: &tg.MessagesGetHistoryRequest{}}
+		if err := invoke(context.Background(), req, nil); err != nil {
+			t.Fatalf("invoke error = %v", err)
+		}
+	}
+	if elapsed := clock.now.Sub(start); elapsed != 0 {
+		t.Fatalf("takeout paging was throttled for %s", elapsed)
+	}
+	if got := invokedMethod(&tg.InvokeWithTakeoutRequest{Query: &tg.MessagesGetHistoryRequest{}}); got != "takeout:messages.getHistory" {
+		t.Fatalf("method = %q", got)
+	}
+}
+
+func TestFloodControlLeavesFileTransfersOutOfDCBucket(t *testing.T) {
+	f, clock, events := newTestFloodControl(10 * time.Second)
+	start := clock.now
+	invoke := f.Handle(&floodingInvoker{})
+
+	// A parallel download fetches many parts at once; none of them should
+	// wait on the per-DC budget.
+	parts := 4 * int(dcRateLimit.burst)
+	for range parts {
+		if err := invoke(context.Background(), &tg.UploadGetFileRequest{}, nil); err != nil {
+			t.Fatalf("invoke error = %v", err)
+		}
+	}
+	if elapsed := clock.now.Sub(start); elapsed != 0 || len(*events) != 0 {
+		t.Fatalf("transfer was throttled for %s (%d events)", elapsed, len(*events))
+	}
+
+	// Other calls still share the DC budget.
+	calls := int(dcRateLimit.burst) + 1
+	for range calls {
+		if err := invoke(context.Background(), &tg.UsersGetFullUserRequest{}, nil); err != nil {
+			t.Fatalf("invoke error = %v", err)
+		}
+	}
+	if elapsed := clock.now.Sub(start); elapsed == 0 {
+		t.Fatal("calls over the DC burst were not throttled")
+	}
+}
@@ -0,0 +1,489 @@
+package helpers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gotd/td/telegram/message/entity"
+	"github.com/gotd/td/telegram/message/html"
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/types"
+)
+
+// UserResolver returns the input user for a mention by user ID.
+type UserResolver = entity.UserResolver
+
+// customEmojiPrefix matches a <custom:documentId> marker at the start of text.
+var customEmojiPrefix = regexp.MustCompile(`^<custom:(\d+)>`)
+
+// FormatText converts outgoing text into plain text and entities with UTF-16
+// offsets. Explicit entities are used as given and leave the text untouched.
+// Otherwise the text is parsed in format.ParseMode; <custom:ID> markers are
+// expanded in every mode except HTML, which uses <tg-emoji emoji-id="ID">.
+// resolve is used for mentions by user ID and may be nil.
+func FormatText(text string, format types.TextFormat, resolve UserResolver) (string, []tg.MessageEntityClass, error) {
+	if len(format.Entities) > 0 {
+		entities, err := InputEntities(format.Entities, resolve)
+		return text, entities, err
+	}
+	switch format.ParseMode {
+	case "", types.ParseModeNone:
+		parsed, entities := ParseCustomEmojis(text)
+		return parsed, entities, nil
+	case types.ParseModeMarkdown:
+		return ParseMarkdown(text, resolve)
+	case types.ParseModeHTML:
+		return ParseHTML(text, resolve)
+	default:
+		return "", nil, fmt.Errorf("unknown parse mode %q", format.ParseMode)
+	}
+}
+
+// InputEntities converts explicit entities into Telegram entities.
+func InputEntities(entities []types.MessageEntity, resolve UserResolver) ([]tg.MessageEntityClass, error) {
+	result := make([]tg.MessageEntityClass, 0, len(entities))
+	for _, e := range entities {
+		offset, length := e.Offset, e.Length
+		switch e.Type {
+		case types.EntityBold:
+			result = append(result, &tg.MessageEntityBold{Offset: offset, Length: length})
+		case types.EntityItalic:
+			result = append(result, &tg.MessageEntityItalic{Offset: offset, Length: length})
+		case types.EntityUnderline:
+			result = append(result, &tg.MessageEntityUnderline{Offset: offset, Length: length})
+		case types.EntityStrike:
+			result = append(result, &tg.MessageEntityStrike{Offset: offset, Length: length})
+		case types.EntitySpoiler:
+			result = append(result, &tg.MessageEntitySpoiler{Offset: offset, Length: length})
+		case types.EntityCode:
+			result = append(result, &tg.MessageEntityCode{Offset: offset, Length: length})
+		case types.EntityPre:
+			result = append(result, &tg.MessageEntityPre{Offset: offset, Length: length, Language: e.Language})
+		case types.EntityTextURL:
+			result = append(result, &tg.MessageEntityTextURL{Offset: offset, Length: length, URL: e.URL})
+		case types.EntityBlockquote:
+			result = append(result, &tg.MessageEntityBlockquote{Offset: offset, Length: length})
+		case types.EntityCustomEmoji:
+			result = append(result, &tg.MessageEntityCustomEmoji{Offset: offset, Length: length, DocumentID: e.DocumentID})
+		case types.EntityMentionName:
+			user, err := resolveUser(resolve, e.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("resolve mentioned user %d: %w", e.UserID, err)
+			}
+			result = append(result, &tg.InputMessageEntityMentionName{Offset: offset, Length: length, UserID: user})
+		default:
+			return nil, fmt.Errorf("unknown entity type %q", e.Type)
+		}
+	}
+	sortEntities(result)
+	return result, nil
+}
+
+// ParseHTML parses Telegram Bot API style HTML: b, i, u, s, tg-spoiler, code,
+// pre (with a nested <code class="language-x">), a href, blockquote and
+// tg-emoji. Unsupported tags are dropped and their text kept.
+func ParseHTML(text string, resolve UserResolver) (string, []tg.MessageEntityClass, error) {
+	var b entity.Builder
+	if err := html.HTML(strings.NewReader(text), &b, html.Options{UserResolver: resolve}); err != nil {
+		return "", nil, fmt.Errorf("parse html: %w", err)
+	}
+	parsed, entities := b.Complete()
+	return parsed, entities, nil
+}
+
+// ParseMarkdown parses the Markdown agents usually write:
+//
+//   - **bold** or __bold__, *italic* or _italic_, ~~strike~~, ||spoiler||
+//   - `code`, ```pre``` and fenced blocks with a language
+//   - [text](url) links and [name](tg://user?id=123) mentions
+//   - lines starting with "> " as a blockquote
+//
+// A backslash escapes the punctuation that follows it. Unmatched markers are
+// kept as written.
+func ParseMarkdown(text string, resolve UserResolver) (string, []tg.MessageEntityClass, error) {
+	p := &markdownParser{resolve: resolve}
+	lines := strings.Split(text, "\n")
+	blocks := 0
+	block := func(write func()) {
+		if blocks > 0 {
+			p.write("\n")
+		}
+		blocks++
+		write()
+	}
+	var paragraph []string
+	flush := func() {
+		if paragraph != nil {
+			content := strings.Join(paragraph, "\n")
+			paragraph = nil
+			block(func() { p.inline(content) })
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if fence, language, ok := fenceOpen(lines[i]); ok {
+			end := i + 1
+			for end < len(lines) && !fenceClose(lines[end], fence) {
+				end++
+			}
+			if end < len(lines) {
+				flush()
+				code := strings.Join(lines[i+1:end], "\n")
+				block(func() { p.pre(code, language) })
+				i = end + 1
+				continue
+			}
+		}
+		if _, ok := quoteLine(lines[i]); ok {
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				line, ok := quoteLine(lines[i])
+				if !ok {
+					break
+				}
+				quoted = append(quoted, line)
+			}
+			content := strings.Join(quoted, "\n")
+			block(func() {
+				start := p.offset
+				p.inline(content)
+				p.add(&tg.MessageEntityBlockquote{Offset: start, Length: p.offset - start})
+			})
+			continue
+		}
+		paragraph = append(paragraph, lines[i])
+		i++
+	}
+	flush()
+
+	if p.err != nil {
+		return "", nil, p.err
+	}
+	sortEntities(p.entities)
+	return p.out.String(), p.entities, nil
+}
+
+type markdownParser struct {
+	out      strings.Builder
+	offset   int // UTF-16 length of out
+	entities []tg.MessageEntityClass
+	resolve  UserResolver
+	err      error
+}
+
+func (p *markdownParser) write(s string) {
+	p.out.WriteString(s)
+	p.offset += utf16Len(s)
+}
+
+// add records an entity unless it covers no text.
+func (p *markdownParser) add(e tg.MessageEntityClass) {
+	if e.GetLength() > 0 {
+		p.entities = append(p.entities, e)
+	}
+}
+
+func (p *markdownParser) pre(code, language string) {
+	start := p.offset
+	p.write(code)
+	p.add(&tg.MessageEntityPre{Offset: start, Length: p.offset - start, Language: language})
+}
+
+func (p *markdownParser) inline(s string) {
+	for i := 0; i < len(s); {
+		next := 0
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			p.write(s[i+1 : i+2])
+			next = i + 2
+		case c == '`':
+			next = p.code(s, i)
+		case c == '[':
+			next = p.link(s, i)
+		case c == '<':
+			next = p.customEmoji(s, i)
+		case c == '*' || c == '_' || c == '~' || c == '|':
+			next = p.emphasis(s, i)
+		}
+		if next > 0 {
+			i = next
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		p.write(s[i : i+size])
+		i += size
+	}
+}
+
+// code writes a code span opened by the backtick run at i. Three or more
+// backticks make a pre block. An unclosed run is written as is.
+func (p *markdownParser) code(s string, i int) int {
+	n := runLength(s, i)
+	end := codeClose(s, i+n, n)
+	if end < 0 || end == i+n {
+		p.write(s[i : i+n])
+		return i + n
+	}
+	content := s[i+n : end]
+	if n >= 3 {
+		p.pre(strings.TrimSuffix(strings.TrimPrefix(content, "\n"), "\n"), "")
+	} else {
+		start := p.offset
+		p.write(content)
+		p.add(&tg.MessageEntityCode{Offset: start, Length: p.offset - start})
+	}
+	return end + n
+}
+
+// link writes [text](url) at i as a text link, or as a mention for
+// tg://user?id= URLs.
+func (p *markdownParser) link(s string, i int) int {
+	closeText := matchingBracket(s, i, '[', ']')
+	if closeText < 0 || closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return 0
+	}
+	closeURL := matchingBracket(s, closeText+1, '(', ')')
+	if closeURL < 0 {
+		return 0
+	}
+	target := strings.TrimSpace(s[closeText+2 : closeURL])
+	if target == "" {
+		return 0
+	}
+	start := p.offset
+	p.inline(s[i+1 : closeText])
+	length := p.offset - start
+	if userID, ok := mentionUserID(target); ok {
+		user, err := resolveUser(p.resolve, userID)
+		if err != nil {
+			if p.err == nil {
+				p.err = fmt.Errorf("resolve mentioned user %d: %w", userID, err)
+			}
+			return closeURL + 1
+		}
+		p.add(&tg.InputMessageEntityMentionName{Offset: start, Length: length, UserID: user})
+	} else {
+		p.add(&tg.MessageEntityTextURL{Offset: start, Length: length, URL: target})
+	}
+	return closeURL + 1
+}
+
+func (p *markdownParser) customEmoji(s string, i int) int {
+	match := customEmojiPrefix.FindStringSubmatch(s[i:])
+	if match == nil {
+		return 0
+	}
+	docID, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	start := p.offset
+	p.write(customEmojiPlaceholder)
+	p.add(&tg.MessageEntityCustomEmoji{Offset: start, Length: 1, DocumentID: docID})
+	return i + len(match[0])
+}
+
+// emphasis writes a delimited span opened at i. Single-character delimiters
+// do not start inside a longer run, and underscores do not open or close
+// inside a word, so snake_case stays literal.
+func (p *markdownParser) emphasis(s string, i int) int {
+	for _, delim := range []string{"**", "__", "~~", "||", "*", "_"} {
+		if !strings.HasPrefix(s[i:], delim) {
+			continue
+		}
+		open := i + len(delim)
+		if len(delim) == 1 && open < len(s) && s[open] == delim[0] {
+			continue
+		}
+		if open >= len(s) || isSpace(s[open]) {
+			continue
+		}
+		if delim[0] == '_' && i > 0 && isWordByte(s[i-1]) {
+			continue
+		}
+		end := emphasisClose(s, open, delim)
+		if end < 0 {
+			continue
+		}
+		start := p.offset
+		p.inline(s[open:end])
+		length := p.offset - start
+		switch delim {
+		case "**", "__":
+			p.add(&tg.MessageEntityBold{Offset: start, Length: length})
+		case "*", "_":
+			p.add(&tg.MessageEntityItalic{Offset: start, Length: length})
+		case "~~":
+			p.add(&tg.MessageEntityStrike{Offset: start, Length: length})
+		case "||":
+			p.add(&tg.MessageEntitySpoiler{Offset: start, Length: length})
+		}
+		return end + len(delim)
+	}
+	return 0
+}
+
+// emphasisClose finds the delimiter closing a span whose content starts at
+// from, skipping escapes and code spans. Within a longer run of the delimiter
+// character the last len(delim) characters close, so ***x*** nests.
+func emphasisClose(s string, from int, delim string) int {
+	for j := from; j < len(s); {
+		switch {
+		case s[j] == '\\' && j+1 < len(s):
+			j += 2
+			continue
+		case s[j] == '`':
+			n := runLength(s, j)
+			if end := codeClose(s, j+n, n); end >= 0 {
+				j = end + n
+			} else {
+				j += n
+			}
+			continue
+		case s[j] == delim[0]:
+			run := j + runLength(s, j)
+			if run-j < len(delim) || (len(delim) == 1 && run-j > 1) || isSpace(s[j-1]) {
+				j = run
+				continue
+			}
+			if delim[0] == '_' && run < len(s) && isWordByte(s[run]) {
+				j = run
+				continue
+			}
+			if closing := run - len(delim); closing > from {
+				return closing
+			}
+			j = run
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// codeClose finds a backtick run of exactly n characters at or after from.
+func codeClose(s string, from, n int) int {
+	for j := from; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		run := runLength(s, j)
+		if run == n {
+			return j
+		}
+		j += run
+	}
+	return -1
+}
+
+// matchingBracket returns the index of the bracket closing the one at i.
+func matchingBracket(s string, i int, open, closing byte) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		case '\n':
+			if open == '(' {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// fenceOpen reports whether line opens a fenced code block, returning the
+// fence and the block language.
+func fenceOpen(line string) (string, string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	n := runLength(trimmed, 0)
+	if n < 3 || trimmed[0] != '`' {
+		return "", "", false
+	}
+	language := strings.TrimSpace(trimmed[n:])
+	if strings.ContainsAny(language, "` ") {
+		return "", "", false
+	}
+	return trimmed[:n], language, true
+}
+
+func fenceClose(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, "`") == ""
+}
+
+// quoteLine strips the "> " marker of a blockquote line.
+func quoteLine(line string) (string, bool) {
+	if !strings.HasPrefix(line, ">") {
+		return "", false
+	}
+	return strings.TrimPrefix(line[1:], " "), true
+}
+
+// mentionUserID extracts the user ID of a tg://user?id= link.
+func mentionUserID(target string) (int64, bool) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "tg" || u.Host != "user" {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(u.Query().Get("id"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// sortEntities orders entities by offset, enclosing entities first.
+func sortEntities(entities []tg.MessageEntityClass) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		a, b := entities[i], entities[j]
+		if a.GetOffset() != b.GetOffset() {
+			return a.GetOffset() < b.GetOffset()
+		}
+		return a.GetLength() > b.GetLength()
+	})
+}
+
+func resolveUser(resolve UserResolver, id int64) (tg.InputUserClass, error) {
+	if resolve == nil {
+		return &tg.InputUser{UserID: id}, nil
+	}
+	return resolve(id)
+}
+
+func runLength(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// isWordByte treats every non-ASCII byte as part of a word.
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && (unicode.IsPunct(rune(c)) || unicode.IsSymbol(rune(c)))
+}
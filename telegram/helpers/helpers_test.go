@@ -1,9 +1,13 @@
 package helpers
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/types"
 )
 
 func TestFormatPeer(t *testing.T) {
@@ -69,3 +73,108 @@ func TestParseCustomEmojis(t *testing.T) {
 		t.Fatalf("invalid = %q, %#v", invalid, entities)
 	}
 }
+
+func TestConvertEntities(t *testing.T) {
+	entities := ConvertEntities([]tg.MessageEntityClass{
+		&tg.MessageEntityURL{Offset: 0, Length: 1},
+		&tg.MessageEntityEmail{Offset: 0, Length: 1},
+		&tg.MessageEntityHashtag{Offset: 0, Length: 1},
+		&tg.MessageEntityCashtag{Offset: 0, Length: 1},
+		&tg.MessageEntityMention{Offset: 0, Length: 1},
+		&tg.MessageEntityBotCommand{Offset: 0, Length: 1},
+		&tg.MessageEntityItalic{Offset: 0, Length: 1},
+		&tg.MessageEntityUnderline{Offset: 0, Length: 1},
+		&tg.MessageEntityStrike{Offset: 0, Length: 1},
+		&tg.MessageEntityCode{Offset: 0, Length: 1},
+		&tg.MessageEntitySpoiler{Offset: 0, Length: 1},
+		&tg.MessageEntityBlockquote{Offset: 0, Length: 1},
+	})
+	if len(entities) != 12 || entities[0]["type"] != "url" {
+		t.Fatalf("entities = %#v", entities)
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	text, entities, err := ParseMarkdown(strings.Join([]string{
+		"**Done** in 😀 *2s*, see [docs](https://example.com) and ask [Ann](tg://user?id=7)",
+		"snake_case stays, `x*y` is code, ||secret|| and ~~old~~ \\*literal\\*",
+		"> quoted **line**",
+		"```go",
+		"fmt.Println(1)",
+		"```",
+	}, "\n"), func(id int64) (tg.InputUserClass, error) {
+		return &tg.InputUser{UserID: id, AccessHash: 70}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantText := strings.Join([]string{
+		"Done in 😀 2s, see docs and ask Ann",
+		"snake_case stays, x*y is code, secret and old *literal*",
+		"quoted line",
+		"fmt.Println(1)",
+	}, "\n")
+	if text != wantText {
+		t.Fatalf("text = %q\nwant %q", text, wantText)
+	}
+	// The emoji takes two UTF-16 code units, shifting everything after it.
+	want := []tg.MessageEntityClass{
+		&tg.MessageEntityBold{Offset: 0, Length: 4},
+		&tg.MessageEntityItalic{Offset: 11, Length: 2},
+		&tg.MessageEntityTextURL{Offset: 19, Length: 4, URL: "https://example.com"},
+		&tg.InputMessageEntityMentionName{Offset: 32, Length: 3, UserID: &tg.InputUser{UserID: 7, AccessHash: 70}},
+		&tg.MessageEntityCode{Offset: 54, Length: 3},
+		&tg.MessageEntitySpoiler{Offset: 67, Length: 6},
+		&tg.MessageEntityStrike{Offset: 78, Length: 3},
+		&tg.MessageEntityBlockquote{Offset: 92, Length: 11},
+		&tg.MessageEntityBold{Offset: 99, Length: 4},
+		&tg.MessageEntityPre{Offset: 104, Length: 14, Language: "go"},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Fatalf("entities = %v\nwant %v", entities, want)
+	}
+
+	plain, entities, err := ParseMarkdown("2 * 3 = 6 and **unclosed", nil)
+	if err != nil || plain != "2 * 3 = 6 and **unclosed" || len(entities) != 0 {
+		t.Fatalf("unmatched markers = %q, %#v, %v", plain, entities, err)
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	text, entities, err := ParseHTML(`<b>bold</b> <pre><code class="language-go">x</code></pre> <tg-spoiler>s</tg-spoiler>`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "bold x s" {
+		t.Fatalf("text = %q", text)
+	}
+	want := []tg.MessageEntityClass{
+		&tg.MessageEntityBold{Offset: 0, Length: 4},
+		&tg.MessageEntityPre{Offset: 5, Length: 1, Language: "go"},
+		&tg.MessageEntitySpoiler{Offset: 7, Length: 1},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Fatalf("entities = %#v", entities)
+	}
+}
+
+func TestFormatTextExplicitEntities(t *testing.T) {
+	text, entities, err := FormatText("<custom:1> hi", types.TextFormat{Entities: []types.MessageEntity{
+		{Type: types.EntityMentionName, Offset: 11, Length: 2, UserID: 7},
+		{Type: types.EntityPre, Offset: 0, Length: 10, Language: "txt"},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Explicit entities leave the text, including emoji markers, untouched.
+	if text != "<custom:1> hi" {
+		t.Fatalf("text = %q", text)
+	}
+	want := []tg.MessageEntityClass{
+		&tg.MessageEntityPre{Offset: 0, Length: 10, Language: "txt"},
+		&tg.InputMessageEntityMentionName{Offset: 11, Length: 2, UserID: &tg.InputUser{UserID: 7}},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Fatalf("entities = %#v", entities)
+	}
+}
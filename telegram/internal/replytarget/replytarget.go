@@ -7,7 +7,8 @@ import (
 )
 
 // Build converts the shared thread target into Telegram's reply representation.
-func Build(target types.ThreadTarget) *tg.InputReplyToMessage {
+// It returns a nil interface for an empty target so the request omits reply_to.
+func Build(target types.ThreadTarget) tg.InputReplyToClass {
 	if target.ThreadID == 0 && target.ReplyTo == 0 {
 		return nil
 	}
@@ -3,6 +3,8 @@ package replytarget
 import (
 	"testing"
 
+	"github.com/gotd/td/tg"
+
 	"agent-telegram/telegram/types"
 )
 
@@ -28,7 +30,8 @@ func TestBuild(t *testing.T) {
 				}
 				return
 			}
-			if got == nil || got.ReplyToMsgID != tt.wantReply || got.TopMsgID != tt.wantTop {
+			reply, ok := got.(*tg.InputReplyToMessage)
+			if !ok || reply.ReplyToMsgID != tt.wantReply || reply.TopMsgID != tt.wantTop {
 				t.Fatalf("Build() = %#v, want reply=%d top=%d", got, tt.wantReply, tt.wantTop)
 			}
 		})
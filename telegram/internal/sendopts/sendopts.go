@@ -0,0 +1,88 @@
+// Package sendopts applies shared send options to MTProto send requests.
+package sendopts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/types"
+)
+
+// PeerResolver resolves a peer reference to an input peer.
+type PeerResolver func(ctx context.Context, peer string) (tg.InputPeerClass, error)
+
+// Options are send options with sendAs resolved.
+type Options struct {
+	types.SendOptions
+	sendAs tg.InputPeerClass
+}
+
+// Resolve resolves the sendAs peer of opts.
+func Resolve(ctx context.Context, resolve PeerResolver, opts types.SendOptions) (Options, error) {
+	out := Options{SendOptions: opts}
+	if opts.SendAs == "" {
+		return out, nil
+	}
+	sendAs, err := resolve(ctx, opts.SendAs)
+	if err != nil {
+		return Options{}, fmt.Errorf("failed to resolve sendAs %s: %w", opts.SendAs, err)
+	}
+	out.sendAs = sendAs
+	return out, nil
+}
+
+// Message sets the options on a text message request.
+func (o Options) Message(req *tg.MessagesSendMessageRequest) {
+	req.Silent = o.Silent
+	req.NoWebpage = o.NoWebpage
+	req.InvertMedia = o.InvertMedia
+	req.Noforwards = o.NoForwards
+	if o.sendAs != nil {
+		req.SetSendAs(o.sendAs)
+	}
+}
+
+// Media sets the options on a media message request, including the spoiler
+// and self-destruct timer of its media.
+func (o Options) Media(req *tg.MessagesSendMediaRequest) {
+	req.Silent = o.Silent
+	req.InvertMedia = o.InvertMedia
+	req.Noforwards = o.NoForwards
+	if o.sendAs != nil {
+		req.SetSendAs(o.sendAs)
+	}
+	ApplyMedia(req.Media, o.SendOptions)
+}
+
+// MultiMedia sets the options on an album request. Item media get their
+// spoiler flag from ApplyMedia.
+func (o Options) MultiMedia(req *tg.MessagesSendMultiMediaRequest) {
+	req.Silent = o.Silent
+	req.InvertMedia = o.InvertMedia
+	req.Noforwards = o.NoForwards
+	if o.sendAs != nil {
+		req.SetSendAs(o.sendAs)
+	}
+}
+
+// ApplyMedia sets the spoiler flag and self-destruct timer on photo and
+// document media; other media are left unchanged. A spoiler already set on the
+// media is kept.
+func ApplyMedia(media tg.InputMediaClass, opts types.SendOptions) {
+	switch m := media.(type) {
+	case *tg.InputMediaUploadedPhoto:
+		m.Spoiler = m.Spoiler || opts.HasSpoiler
+		m.TTLSeconds = opts.TTLSeconds
+	case *tg.InputMediaUploadedDocument:
+		m.Spoiler = m.Spoiler || opts.HasSpoiler
+		m.TTLSeconds = opts.TTLSeconds
+	case *tg.InputMediaPhoto:
+		m.Spoiler = m.Spoiler || opts.HasSpoiler
+		m.TTLSeconds = opts.TTLSeconds
+	case *tg.InputMediaDocument:
+		m.Spoiler = m.Spoiler || opts.HasSpoiler
+		m.TTLSeconds = opts.TTLSeconds
+	}
+}
@@ -0,0 +1,54 @@
+package sendopts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/types"
+)
+
+func TestResolveSetsRequestFlags(t *testing.T) {
+	channel := &tg.InputPeerChannel{ChannelID: 5, AccessHash: 9}
+	resolve := func(_ context.Context, peer string) (tg.InputPeerClass, error) {
+		if peer != "@channel" {
+			t.Fatalf("resolved %q, want @channel", peer)
+		}
+		return channel, nil
+	}
+
+	opts, err := Resolve(context.Background(), resolve, types.SendOptions{
+		Silent: true, NoForwards: true, HasSpoiler: true, TTLSeconds: 10, SendAs: "@channel",
+	})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	req := &tg.MessagesSendMediaRequest{Media: &tg.InputMediaUploadedPhoto{}}
+	opts.Media(req)
+	if !req.Silent || !req.Noforwards || req.SendAs != channel {
+		t.Fatalf("request = %#v", req)
+	}
+	photo := req.Media.(*tg.InputMediaUploadedPhoto)
+	if !photo.Spoiler || photo.TTLSeconds != 10 {
+		t.Fatalf("media = %#v", photo)
+	}
+}
+
+func TestResolveWithoutSendAs(t *testing.T) {
+	resolve := func(context.Context, string) (tg.InputPeerClass, error) {
+		t.Fatal("resolve should not be called without sendAs")
+		return nil, nil
+	}
+
+	opts, err := Resolve(context.Background(), resolve, types.SendOptions{NoWebpage: true})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	req := &tg.MessagesSendMessageRequest{}
+	opts.Message(req)
+	if !req.NoWebpage || req.SendAs != nil {
+		t.Fatalf("request = %#v", req)
+	}
+}
@@ -0,0 +1,194 @@
+// Package media provides Telegram album operations.
+package media
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gotd/td/tg"
+	"golang.org/x/sync/errgroup"
+
+	"agent-telegram/telegram/helpers"
+	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
+	"agent-telegram/telegram/types"
+)
+
+// albumUploadWorkers bounds how many album files upload at once.
+const albumUploadWorkers = 4
+
+// SendAlbum sends up to ten files as one grouped message.
+func (c *Client) SendAlbum(ctx context.Context, params types.SendAlbumParams) (*types.SendAlbumResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Album items must reference media already stored by Telegram, so each
+	// file is uploaded and registered with messages.uploadMedia first.
+	media := make([]tg.InputMediaClass, len(params.Items))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(albumUploadWorkers)
+	for i, item := range params.Items {
+		g.Go(func() error {
+			m, err := c.uploadAlbumItem(gctx, inputPeer, item)
+			if err != nil {
+				return fmt.Errorf("item %d (%s): %w", i+1, filepath.Base(item.File), err)
+			}
+			media[i] = m
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	single := make([]tg.InputSingleMedia, len(params.Items))
+	randomID := time.Now().UnixNano()
+	for i, item := range params.Items {
+		sendopts.ApplyMedia(media[i], params.SendOptions)
+		parsed, entities := helpers.ParseCustomEmojis(item.Caption)
+		single[i] = tg.InputSingleMedia{
+			Media:    media[i],
+			RandomID: randomID + int64(i),
+			Message:  parsed,
+		}
+		if len(entities) > 0 {
+			single[i].SetEntities(entities)
+		}
+	}
+
+	req := &tg.MessagesSendMultiMediaRequest{
+		Peer:         inputPeer,
+		MultiMedia:   single,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+	}
+	opts.MultiMedia(req)
+	result, err := c.API().MessagesSendMultiMedia(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send album: %w", err)
+	}
+
+	ids, groupedID := extractAlbum(result)
+	return &types.SendAlbumResult{
+		IDs:       ids,
+		GroupedID: groupedID,
+		Date:      types.SentDate(scheduleDate),
+		Peer:      params.Peer,
+		Count:     len(ids),
+	}, nil
+}
+
+// uploadAlbumItem uploads a file and returns it as stored Telegram media.
+func (c *Client) uploadAlbumItem(
+	ctx context.Context, peer tg.InputPeerClass, item types.AlbumItem,
+) (tg.InputMediaClass, error) {
+	uploaded, err := uploadFile(ctx, c.API(), item.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var input tg.InputMediaClass
+	switch item.ItemType() {
+	case types.AlbumPhoto:
+		input = &tg.InputMediaUploadedPhoto{File: uploaded}
+	default:
+		input = &tg.InputMediaUploadedDocument{
+			File:       uploaded,
+			MimeType:   albumMimeType(item),
+			Attributes: albumAttributes(item),
+		}
+	}
+
+	stored, err := c.API().MessagesUploadMedia(ctx, &tg.MessagesUploadMediaRequest{Peer: peer, Media: input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload media: %w", err)
+	}
+	switch m := stored.(type) {
+	case *tg.MessageMediaPhoto:
+		if photo, ok := m.Photo.(*tg.Photo); ok {
+			return &tg.InputMediaPhoto{ID: photo.AsInput()}, nil
+		}
+	case *tg.MessageMediaDocument:
+		if doc, ok := m.Document.(*tg.Document); ok {
+			return &tg.InputMediaDocument{ID: doc.AsInput()}, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected uploaded media %T", stored)
+}
+
+func albumMimeType(item types.AlbumItem) string {
+	if item.ItemType() == types.AlbumVideo {
+		return "video/mp4"
+	}
+	if mimeType := mime.TypeByExtension(filepath.Ext(item.File)); mimeType != "" {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+func albumAttributes(item types.AlbumItem) []tg.DocumentAttributeClass {
+	attributes := []tg.DocumentAttributeClass{
+		&tg.DocumentAttributeFilename{FileName: filepath.Base(item.File)},
+	}
+	switch item.ItemType() {
+	case types.AlbumVideo:
+		attributes = append(attributes, &tg.DocumentAttributeVideo{SupportsStreaming: true})
+	case types.AlbumAudio:
+		attributes = append(attributes, &tg.DocumentAttributeAudio{})
+	}
+	return attributes
+}
+
+// extractAlbum returns the IDs of the sent album messages in order and their
+// shared grouped ID.
+func extractAlbum(result tg.UpdatesClass) ([]int64, int64) {
+	updates, ok := result.(*tg.Updates)
+	if !ok {
+		return nil, 0
+	}
+	byRandomID := map[int64]int64{}
+	var randomIDs []int64
+	var groupedID int64
+	for _, update := range updates.Updates {
+		switch u := update.(type) {
+		case *tg.UpdateMessageID:
+			byRandomID[u.RandomID] = int64(u.ID)
+			randomIDs = append(randomIDs, u.RandomID)
+		case *tg.UpdateNewMessage:
+			groupedID = messageGroupedID(u.Message, groupedID)
+		case *tg.UpdateNewChannelMessage:
+			groupedID = messageGroupedID(u.Message, groupedID)
+		case *tg.UpdateNewScheduledMessage:
+			groupedID = messageGroupedID(u.Message, groupedID)
+		}
+	}
+	// Random IDs were assigned in item order.
+	slices.Sort(randomIDs)
+	ids := make([]int64, 0, len(randomIDs))
+	for _, randomID := range randomIDs {
+		ids = append(ids, byRandomID[randomID])
+	}
+	return ids, groupedID
+}
+
+// messageGroupedID returns the grouped ID of msg, or fallback if it has none.
+func messageGroupedID(msg tg.MessageClass, fallback int64) int64 {
+	if m, ok := msg.(*tg.Message); ok && m.GroupedID != 0 {
+		return m.GroupedID
+	}
+	return fallback
+}
@@ -7,16 +7,25 @@ import (
 	"time"
 
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
 
 // SendContact sends a contact to a peer.
 func (c *Client) SendContact(ctx context.Context, params types.SendContactParams) (*types.SendContactResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	contact := &tg.InputMediaContact{
 		PhoneNumber: params.Phone,
@@ -24,12 +33,15 @@ func (c *Client) SendContact(ctx context.Context, params types.SendContactParams
 		LastName:    params.LastName,
 	}
 
-	result, err := c.API().MessagesSendMedia(ctx, &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    contact,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
-	})
+	req := &tg.MessagesSendMediaRequest{
+		Peer:         inputPeer,
+		Media:        contact,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
+	}
+	opts.Media(req)
+	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send contact: %w", err)
 	}
@@ -37,7 +49,7 @@ func (c *Client) SendContact(ctx context.Context, params types.SendContactParams
 	msgID := extractMessageID(result)
 	return &types.SendContactResult{
 		ID:    msgID,
-		Date:  time.Now().Unix(),
+		Date:  types.SentDate(scheduleDate),
 		Peer:  params.Peer,
 		Phone: params.Phone,
 	}, nil
@@ -9,6 +9,7 @@ import (
 	"github.com/gotd/td/tg"
 
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 )
 
@@ -17,10 +18,18 @@ const defaultDiceEmoticon = "🎲"
 
 // SendDice sends a dice (random value) to a peer.
 func (c *Client) SendDice(ctx context.Context, params types.SendDiceParams) (*types.SendDiceResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	emoticon := params.Emoticon
 	if emoticon == "" {
@@ -32,12 +41,14 @@ func (c *Client) SendDice(ctx context.Context, params types.SendDiceParams) (*ty
 	}
 
 	req := &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    dice,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
+		Peer:         inputPeer,
+		Media:        dice,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
 	}
 
+	opts.Media(req)
 	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send dice: %w", err)
@@ -47,14 +58,14 @@ func (c *Client) SendDice(ctx context.Context, params types.SendDiceParams) (*ty
 	value := extractDiceValue(result)
 
 	// For channels, dice value arrives later via edit update.
-	// Poll the message to get the actual value.
-	if value == 0 && msgID != 0 {
+	// Poll the message to get the actual value. Scheduled dice have no value yet.
+	if value == 0 && msgID != 0 && scheduleDate == 0 {
 		value = c.waitForDiceValue(ctx, inputPeer, int(msgID))
 	}
 
 	return &types.SendDiceResult{
 		ID:       msgID,
-		Date:     time.Now().Unix(),
+		Date:     types.SentDate(scheduleDate),
 		Peer:     params.Peer,
 		Value:    value,
 		Emoticon: emoticon,
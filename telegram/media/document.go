@@ -7,14 +7,20 @@ import (
 	"time"
 
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
 
 // SendDocument sends a document to a peer with custom mime type.
 func (c *Client) SendDocument(
-	ctx context.Context, peer, file, mimeType, caption string, target types.ThreadTarget,
+	ctx context.Context, peer, file, mimeType, caption string,
+	target types.ThreadTarget, schedule types.SendSchedule, options types.SendOptions,
 ) (*types.SendFileResult, error) {
+	scheduleDate, err := schedule.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	if err := c.CheckInitialized(); err != nil {
 		return nil, err
 	}
@@ -23,6 +29,10 @@ func (c *Client) SendDocument(
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, options)
+	if err != nil {
+		return nil, err
+	}
 
 	uploadedFile, err := uploadFile(ctx, c.API(), file)
 	if err != nil {
@@ -34,12 +44,15 @@ func (c *Client) SendDocument(
 		MimeType: mimeType,
 	}
 
-	result, err := c.API().MessagesSendMedia(ctx, &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    media,
-		ReplyTo:  replytarget.Build(target),
-		RandomID: time.Now().UnixNano(),
-	})
+	req := &tg.MessagesSendMediaRequest{
+		Peer:         inputPeer,
+		Media:        media,
+		ReplyTo:      replytarget.Build(target),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
+	}
+	opts.Media(req)
+	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send document: %w", err)
 	}
@@ -47,7 +60,7 @@ func (c *Client) SendDocument(
 	msgID := extractMessageID(result)
 	return &types.SendFileResult{
 		ID:      msgID,
-		Date:    time.Now().Unix(),
+		Date:    types.SentDate(scheduleDate),
 		Peer:    peer,
 		Caption: caption,
 	}, nil
@@ -55,12 +68,18 @@ func (c *Client) SendDocument(
 
 // SendFile sends a file to a peer.
 func (c *Client) SendFile(ctx context.Context, params types.SendFileParams) (*types.SendFileResult, error) {
-	return c.SendDocument(ctx, params.Peer, params.File, "application/octet-stream", params.Caption, params.ThreadTarget)
+	return c.SendDocument(
+		ctx, params.Peer, params.File, "application/octet-stream", params.Caption,
+		params.ThreadTarget, params.SendSchedule, params.SendOptions,
+	)
 }
 
 // SendVideo sends a video to a peer.
 func (c *Client) SendVideo(ctx context.Context, params types.SendVideoParams) (*types.SendVideoResult, error) {
-	fileResult, err := c.SendDocument(ctx, params.Peer, params.File, "video/mp4", params.Caption, params.ThreadTarget)
+	fileResult, err := c.SendDocument(
+		ctx, params.Peer, params.File, "video/mp4", params.Caption,
+		params.ThreadTarget, params.SendSchedule, params.SendOptions,
+	)
 	if err != nil {
 		return nil, err
 	}
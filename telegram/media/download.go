@@ -0,0 +1,562 @@
+// Package media provides Telegram media download operations.
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+
+	"agent-telegram/telegram/types"
+)
+
+const (
+	// downloadChunkSize satisfies upload.getFile constraints: divisible by
+	// 4 KiB and a divisor of 1 MiB, so a chunk never crosses a 1 MiB boundary.
+	downloadChunkSize = 512 * 1024
+	// defaultDownloadThreads is used when params.Threads is zero.
+	defaultDownloadThreads = 4
+	// partSuffix marks an incomplete download. The .part file always holds a
+	// contiguous prefix of the media, so its size is the resume offset.
+	partSuffix = ".part"
+)
+
+// DownloadProgress reports how much of a download has been written to disk.
+type DownloadProgress struct {
+	Path       string
+	Downloaded int64
+	Total      int64
+}
+
+// ProgressFunc receives DownloadProgress after every written chunk.
+type ProgressFunc func(DownloadProgress)
+
+type progressKey struct{}
+
+// WithProgress attaches a progress callback to a DownloadMedia context.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// mediaFile describes a downloadable file attached to a message.
+type mediaFile struct {
+	location  tg.InputFileLocationClass
+	size      int64
+	mediaType string
+	mimeType  string
+	fileName  string
+}
+
+// DownloadMedia downloads the photo or document attached to a message.
+// Interrupted downloads resume from the matching .part file.
+func (c *Client) DownloadMedia(ctx context.Context, params types.DownloadMediaParams) (*types.DownloadMediaResult, error) {
+	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
+	if err != nil {
+		return nil, err
+	}
+	if params.Output == "" {
+		return nil, fmt.Errorf("output path is required")
+	}
+
+	file, err := c.fetchMediaFile(ctx, inputPeer, int(params.MessageID))
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := resolveDownloadPath(params.Output, params.Peer, params.MessageID, file)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.DownloadMediaResult{
+		Path:      dest,
+		Peer:      params.Peer,
+		MessageID: params.MessageID,
+		MediaType: file.mediaType,
+		MimeType:  file.mimeType,
+		FileName:  file.fileName,
+		Size:      file.size,
+	}
+
+	if info, statErr := os.Stat(dest); statErr == nil {
+		if !params.Overwrite && file.size > 0 && info.Size() == file.size {
+			result.Complete = true
+			return result, nil
+		}
+		if !params.Overwrite {
+			return nil, fmt.Errorf("destination %s already exists (use overwrite)", dest)
+		}
+		_ = os.Remove(dest + partSuffix)
+	}
+
+	threads := params.Threads
+	if threads <= 0 {
+		threads = defaultDownloadThreads
+	}
+	d := &chunkDownloader{
+		api:      c.API(),
+		threads:  threads,
+		progress: progressFromContext(ctx),
+	}
+
+	refreshed := false
+	for {
+		err = d.download(ctx, file, dest, result)
+		if err == nil {
+			break
+		}
+		// File references expire; refetch the message once and resume.
+		if refreshed || !tgerr.Is(err, "FILE_REFERENCE_EXPIRED") {
+			return nil, fmt.Errorf("failed to download media: %w", err)
+		}
+		refreshed = true
+		if file, err = c.fetchMediaFile(ctx, inputPeer, int(params.MessageID)); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Complete = true
+	return result, nil
+}
+
+// fetchMediaFile loads a message and extracts its downloadable media.
+func (c *Client) fetchMediaFile(ctx context.Context, inputPeer tg.InputPeerClass, msgID int) (mediaFile, error) {
+	ids := []tg.InputMessageClass{&tg.InputMessageID{ID: msgID}}
+
+	var (
+		res tg.MessagesMessagesClass
+		err error
+	)
+	if ch, ok := inputPeer.(*tg.InputPeerChannel); ok {
+		res, err = c.API().ChannelsGetMessages(ctx, &tg.ChannelsGetMessagesRequest{
+			Channel: &tg.InputChannel{ChannelID: ch.ChannelID, AccessHash: ch.AccessHash},
+			ID:      ids,
+		})
+	} else {
+		res, err = c.API().MessagesGetMessages(ctx, ids)
+	}
+	if err != nil {
+		return mediaFile{}, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	modified, ok := res.AsModified()
+	if !ok {
+		return mediaFile{}, fmt.Errorf("message %d not found", msgID)
+	}
+	for _, m := range modified.GetMessages() {
+		if msg, ok := m.(*tg.Message); ok && msg.ID == msgID {
+			return mediaFileFromMessage(msg)
+		}
+	}
+	return mediaFile{}, fmt.Errorf("message %d not found", msgID)
+}
+
+// mediaFileFromMessage picks the best photo size or the document of a message.
+func mediaFileFromMessage(msg *tg.Message) (mediaFile, error) {
+	switch m := msg.Media.(type) {
+	case *tg.MessageMediaPhoto:
+		photo, ok := m.Photo.(*tg.Photo)
+		if !ok {
+			return mediaFile{}, fmt.Errorf("message %d photo is unavailable", msg.ID)
+		}
+		sizeType, size, ok := bestPhotoSize(photo.Sizes)
+		if !ok {
+			return mediaFile{}, fmt.Errorf("message %d photo has no downloadable size", msg.ID)
+		}
+		return mediaFile{
+			location: &tg.InputPhotoFileLocation{
+				ID:            photo.ID,
+				AccessHash:    photo.AccessHash,
+				FileReference: photo.FileReference,
+				ThumbSize:     sizeType,
+			},
+			size:      size,
+			mediaType: "photo",
+			mimeType:  "image/jpeg",
+		}, nil
+	case *tg.MessageMediaDocument:
+		doc, ok := m.Document.(*tg.Document)
+		if !ok {
+			return mediaFile{}, fmt.Errorf("message %d document is unavailable", msg.ID)
+		}
+		return mediaFile{
+			location: &tg.InputDocumentFileLocation{
+				ID:            doc.ID,
+				AccessHash:    doc.AccessHash,
+				FileReference: doc.FileReference,
+			},
+			size:      doc.Size,
+			mediaType: documentMediaType(doc),
+			mimeType:  doc.MimeType,
+			fileName:  documentFileName(doc),
+		}, nil
+	case nil:
+		return mediaFile{}, fmt.Errorf("message %d has no media", msg.ID)
+	default:
+		return mediaFile{}, fmt.Errorf("message %d media %T is not downloadable", msg.ID, m)
+	}
+}
+
+// bestPhotoSize returns the type and byte size of the largest photo size.
+// Inline sizes (stripped, cached, path) are skipped since they are thumbnails.
+func bestPhotoSize(sizes []tg.PhotoSizeClass) (string, int64, bool) {
+	var (
+		bestType string
+		bestArea int
+		bestSize int64
+		found    bool
+	)
+	consider := func(sizeType string, w, h int, size int64) {
+		area := w * h
+		if !found || area > bestArea || (area == bestArea && size > bestSize) {
+			bestType, bestArea, bestSize, found = sizeType, area, size, true
+		}
+	}
+	for _, s := range sizes {
+		switch v := s.(type) {
+		case *tg.PhotoSize:
+			consider(v.Type, v.W, v.H, int64(v.Size))
+		case *tg.PhotoSizeProgressive:
+			var size int64
+			if len(v.Sizes) > 0 {
+				size = int64(v.Sizes[len(v.Sizes)-1])
+			}
+			consider(v.Type, v.W, v.H, size)
+		}
+	}
+	return bestType, bestSize, found
+}
+
+func documentMediaType(doc *tg.Document) string {
+	mediaType := "document"
+	for _, attr := range doc.Attributes {
+		switch a := attr.(type) {
+		case *tg.DocumentAttributeVideo:
+			if a.RoundMessage {
+				return "video_note"
+			}
+			mediaType = "video"
+		case *tg.DocumentAttributeAudio:
+			if a.Voice {
+				return "voice"
+			}
+			mediaType = "audio"
+		case *tg.DocumentAttributeSticker:
+			return "sticker"
+		case *tg.DocumentAttributeAnimated:
+			return "animation"
+		}
+	}
+	return mediaType
+}
+
+func documentFileName(doc *tg.Document) string {
+	for _, attr := range doc.Attributes {
+		if a, ok := attr.(*tg.DocumentAttributeFilename); ok {
+			name := filepath.Base(filepath.Clean("/" + a.FileName))
+			if name != "/" && name != "." {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// knownExtensions covers common Telegram MIME types whose system mapping is
+// missing or ambiguous.
+var knownExtensions = map[string]string{
+	"image/jpeg":              ".jpg",
+	"image/png":               ".png",
+	"image/webp":              ".webp",
+	"image/gif":               ".gif",
+	"video/mp4":               ".mp4",
+	"video/webm":              ".webm",
+	"audio/ogg":               ".ogg",
+	"audio/mpeg":              ".mp3",
+	"application/pdf":         ".pdf",
+	"application/zip":         ".zip",
+	"application/x-tgsticker": ".tgs",
+}
+
+func extensionForMime(mimeType string) string {
+	if ext, ok := knownExtensions[mimeType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// resolveDownloadPath turns Output into an absolute file path. An existing
+// directory (or a path ending in a separator) receives a generated name.
+func resolveDownloadPath(output, peer string, msgID int64, file mediaFile) (string, error) {
+	dest, err := filepath.Abs(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output path: %w", err)
+	}
+	isDir := strings.HasSuffix(output, string(filepath.Separator))
+	if info, statErr := os.Stat(dest); statErr == nil && info.IsDir() {
+		isDir = true
+	}
+	if !isDir {
+		return dest, nil
+	}
+	name := file.fileName
+	if name == "" {
+		name = fmt.Sprintf("%s_%d%s", sanitizeFileComponent(peer), msgID, extensionForMime(file.mimeType))
+	}
+	return filepath.Join(dest, name), nil
+}
+
+func sanitizeFileComponent(s string) string {
+	s = strings.TrimPrefix(s, "@")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// chunkDownloader fetches upload.getFile chunks in parallel and appends them
+// to the .part file strictly in order.
+type chunkDownloader struct {
+	api      *tg.Client
+	threads  int
+	progress ProgressFunc
+	// fetch overrides upload.getFile in tests.
+	fetch func(ctx context.Context, loc tg.InputFileLocationClass, offset int64) ([]byte, error)
+}
+
+type chunkResult struct {
+	index int64
+	data  []byte
+	err   error
+}
+
+func (d *chunkDownloader) getChunk(ctx context.Context, loc tg.InputFileLocationClass, offset int64) ([]byte, error) {
+	if d.fetch != nil {
+		return d.fetch(ctx, loc, offset)
+	}
+	res, err := d.api.UploadGetFile(ctx, &tg.UploadGetFileRequest{
+		Location: loc,
+		Offset:   offset,
+		Limit:    downloadChunkSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	file, ok := res.(*tg.UploadFile)
+	if !ok {
+		return nil, fmt.Errorf("unexpected upload.getFile response %T", res)
+	}
+	return file.Bytes, nil
+}
+
+// download writes file into dest, resuming from dest.part when present.
+func (d *chunkDownloader) download(ctx context.Context, file mediaFile, dest string, result *types.DownloadMediaResult) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	partPath := dest + partSuffix
+	// #nosec G304 -- dest is validated against file roots by the IPC layer
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open partial file: %w", err)
+	}
+	defer func() { _ = part.Close() }()
+
+	info, err := part.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat partial file: %w", err)
+	}
+	offset := resumeOffset(info.Size(), file.size)
+	if err := part.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate partial file: %w", err)
+	}
+	if _, err := part.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek partial file: %w", err)
+	}
+	if result.ResumedFrom == 0 {
+		result.ResumedFrom = offset
+	}
+
+	w := &progressWriter{w: part, path: dest, written: offset, total: file.size, fn: d.progress}
+	var chunks int
+	if file.size > 0 {
+		chunks, err = d.parallel(ctx, file, offset, w)
+	} else {
+		chunks, err = d.sequential(ctx, file, offset, w)
+	}
+	result.Chunks += chunks
+	result.Downloaded = w.written - result.ResumedFrom
+	if err != nil {
+		return err
+	}
+	if file.size > 0 && w.written != file.size {
+		return fmt.Errorf("downloaded %d bytes, expected %d", w.written, file.size)
+	}
+	if result.Size == 0 {
+		result.Size = w.written
+	}
+	if err := part.Sync(); err != nil {
+		return fmt.Errorf("failed to sync partial file: %w", err)
+	}
+	if err := part.Close(); err != nil {
+		return fmt.Errorf("failed to close partial file: %w", err)
+	}
+	if err := os.Rename(partPath, dest); err != nil {
+		return fmt.Errorf("failed to finalize download: %w", err)
+	}
+	return nil
+}
+
+// resumeOffset rounds an existing partial size down to a chunk boundary so
+// the next request keeps upload.getFile's offset alignment.
+func resumeOffset(partSize, total int64) int64 {
+	if partSize <= 0 || (total > 0 && partSize > total) {
+		return 0
+	}
+	return partSize - partSize%downloadChunkSize
+}
+
+// chunkCount returns the number of chunks needed for size bytes.
+func chunkCount(size int64) int64 {
+	return (size + downloadChunkSize - 1) / downloadChunkSize
+}
+
+// parallel fetches chunks with d.threads workers. At most 2*threads chunks
+// are buffered so memory stays bounded while a slow chunk is outstanding.
+func (d *chunkDownloader) parallel(ctx context.Context, file mediaFile, offset int64, w *progressWriter) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	first := offset / downloadChunkSize
+	last := chunkCount(file.size)
+	jobs := make(chan int64)
+	results := make(chan chunkResult, d.threads)
+	window := make(chan struct{}, d.threads*2)
+
+	go func() {
+		defer close(jobs)
+		for idx := first; idx < last; idx++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- idx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range d.threads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				data, err := d.getChunk(ctx, file.location, idx*downloadChunkSize)
+				select {
+				case results <- chunkResult{index: idx, data: data, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int64][]byte)
+	next := first
+	written := 0
+	for r := range results {
+		if r.err != nil {
+			return written, r.err
+		}
+		pending[r.index] = r.data
+		for {
+			data, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if len(data) == 0 {
+				return written, fmt.Errorf("unexpected empty chunk at offset %d", next*downloadChunkSize)
+			}
+			if _, err := w.Write(data); err != nil {
+				return written, fmt.Errorf("failed to write chunk: %w", err)
+			}
+			written++
+			next++
+			<-window
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// sequential downloads media of unknown size until a short chunk arrives.
+func (d *chunkDownloader) sequential(ctx context.Context, file mediaFile, offset int64, w *progressWriter) (int, error) {
+	written := 0
+	for {
+		data, err := d.getChunk(ctx, file.location, offset)
+		if err != nil {
+			return written, err
+		}
+		if len(data) > 0 {
+			if _, err := w.Write(data); err != nil {
+				return written, fmt.Errorf("failed to write chunk: %w", err)
+			}
+			written++
+		}
+		if len(data) < downloadChunkSize {
+			return written, nil
+		}
+		offset += int64(len(data))
+	}
+}
+
+// progressWriter counts written bytes and forwards progress to fn.
+type progressWriter struct {
+	w       io.Writer
+	path    string
+	written int64
+	total   int64
+	fn      ProgressFunc
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.fn != nil && n > 0 {
+		p.fn(DownloadProgress{Path: p.path, Downloaded: p.written, Total: p.total})
+	}
+	if err == nil && n < len(b) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
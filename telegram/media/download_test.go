@@ -0,0 +1,199 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/types"
+)
+
+func TestBestPhotoSizePicksLargest(t *testing.T) {
+	sizes := []tg.PhotoSizeClass{
+		&tg.PhotoStrippedSize{Type: "i"},
+		&tg.PhotoSize{Type: "m", W: 320, H: 240, Size: 10_000},
+		&tg.PhotoSizeProgressive{Type: "y", W: 1280, H: 960, Sizes: []int{5_000, 40_000, 90_000}},
+		&tg.PhotoSize{Type: "x", W: 800, H: 600, Size: 50_000},
+	}
+	sizeType, size, ok := bestPhotoSize(sizes)
+	if !ok || sizeType != "y" || size != 90_000 {
+		t.Fatalf("bestPhotoSize = %q %d %v", sizeType, size, ok)
+	}
+	if _, _, ok := bestPhotoSize([]tg.PhotoSizeClass{&tg.PhotoStrippedSize{Type: "i"}}); ok {
+		t.Fatal("stripped-only photo should not be downloadable")
+	}
+}
+
+func TestMediaFileFromMessage(t *testing.T) {
+	doc := &tg.Message{ID: 5, Media: &tg.MessageMediaDocument{Document: &tg.Document{
+		ID:       1,
+		Size:     1234,
+		MimeType: "application/pdf",
+		Attributes: []tg.DocumentAttributeClass{
+			&tg.DocumentAttributeFilename{FileName: "../../report.pdf"},
+		},
+	}}}
+	file, err := mediaFileFromMessage(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.mediaType != "document" || file.fileName != "report.pdf" || file.size != 1234 {
+		t.Fatalf("document file = %+v", file)
+	}
+
+	voice := &tg.Message{ID: 6, Media: &tg.MessageMediaDocument{Document: &tg.Document{
+		Attributes: []tg.DocumentAttributeClass{&tg.DocumentAttributeAudio{Voice: true}},
+	}}}
+	if file, err := mediaFileFromMessage(voice); err != nil || file.mediaType != "voice" {
+		t.Fatalf("voice file = %+v, %v", file, err)
+	}
+
+	if _, err := mediaFileFromMessage(&tg.Message{ID: 7}); err == nil {
+		t.Fatal("message without media should fail")
+	}
+	if _, err := mediaFileFromMessage(&tg.Message{ID: 8, Media: &tg.MessageMediaGeo{}}); err == nil {
+		t.Fatal("geo media should not be downloadable")
+	}
+}
+
+func TestResolveDownloadPath(t *testing.T) {
+	dir := t.TempDir()
+	photo := mediaFile{mimeType: "image/jpeg"}
+
+	got, err := resolveDownloadPath(dir, "@news", 42, photo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "news_42.jpg"); got != want {
+		t.Fatalf("directory output = %q, want %q", got, want)
+	}
+
+	named := mediaFile{fileName: "report.pdf", mimeType: "application/pdf"}
+	if got, _ := resolveDownloadPath(dir, "@news", 42, named); got != filepath.Join(dir, "report.pdf") {
+		t.Fatalf("named output = %q", got)
+	}
+
+	file := filepath.Join(dir, "custom.bin")
+	if got, _ := resolveDownloadPath(file, "@news", 42, named); got != file {
+		t.Fatalf("file output = %q", got)
+	}
+}
+
+func TestResumeOffsetAlignsToChunks(t *testing.T) {
+	tests := []struct {
+		part, total, want int64
+	}{
+		{part: 0, total: 10 * downloadChunkSize, want: 0},
+		{part: downloadChunkSize + 10, total: 10 * downloadChunkSize, want: downloadChunkSize},
+		{part: 3 * downloadChunkSize, total: 10 * downloadChunkSize, want: 3 * downloadChunkSize},
+		{part: 11 * downloadChunkSize, total: 10 * downloadChunkSize, want: 0},
+	}
+	for _, tt := range tests {
+		if got := resumeOffset(tt.part, tt.total); got != tt.want {
+			t.Fatalf("resumeOffset(%d, %d) = %d, want %d", tt.part, tt.total, got, tt.want)
+		}
+	}
+}
+
+// fakeSource serves chunks of content and can fail once at a given offset.
+type fakeSource struct {
+	content []byte
+	failAt  int64
+	failed  atomic.Bool
+	calls   atomic.Int32
+}
+
+func (f *fakeSource) fetch(_ context.Context, _ tg.InputFileLocationClass, offset int64) ([]byte, error) {
+	f.calls.Add(1)
+	if f.failAt >= 0 && offset == f.failAt && f.failed.CompareAndSwap(false, true) {
+		return nil, errors.New("network down")
+	}
+	if offset >= int64(len(f.content)) {
+		return nil, nil
+	}
+	end := min(offset+downloadChunkSize, int64(len(f.content)))
+	return f.content[offset:end], nil
+}
+
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestChunkDownloaderResumesAfterFailure(t *testing.T) {
+	content := testContent(5*downloadChunkSize + 123)
+	src := &fakeSource{content: content, failAt: 3 * downloadChunkSize}
+	var lastProgress DownloadProgress
+	d := &chunkDownloader{
+		threads:  1,
+		fetch:    src.fetch,
+		progress: func(p DownloadProgress) { lastProgress = p },
+	}
+	file := mediaFile{size: int64(len(content))}
+	dest := filepath.Join(t.TempDir(), "out.bin")
+
+	first := &types.DownloadMediaResult{}
+	if err := d.download(context.Background(), file, dest, first); err == nil {
+		t.Fatal("first attempt should fail")
+	}
+	part, err := os.Stat(dest + partSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Size() != 3*downloadChunkSize {
+		t.Fatalf("partial size = %d, want contiguous %d", part.Size(), 3*downloadChunkSize)
+	}
+
+	d.threads = 4
+	second := &types.DownloadMediaResult{}
+	if err := d.download(context.Background(), file, dest, second); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatal("downloaded content mismatch")
+	}
+	if second.ResumedFrom != 3*downloadChunkSize || second.Chunks != 3 {
+		t.Fatalf("resume result = %+v", second)
+	}
+	if second.Downloaded != int64(len(content))-3*downloadChunkSize {
+		t.Fatalf("downloaded = %d", second.Downloaded)
+	}
+	if lastProgress.Downloaded != int64(len(content)) || lastProgress.Total != int64(len(content)) {
+		t.Fatalf("last progress = %+v", lastProgress)
+	}
+	if _, err := os.Stat(dest + partSuffix); !os.IsNotExist(err) {
+		t.Fatalf("partial file should be renamed, stat err = %v", err)
+	}
+}
+
+func TestChunkDownloaderUnknownSize(t *testing.T) {
+	content := testContent(2*downloadChunkSize + 7)
+	src := &fakeSource{content: content, failAt: -1}
+	d := &chunkDownloader{threads: 4, fetch: src.fetch}
+	dest := filepath.Join(t.TempDir(), "out.bin")
+
+	result := &types.DownloadMediaResult{}
+	if err := d.download(context.Background(), mediaFile{}, dest, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Size != int64(len(content)) || result.Chunks != 3 {
+		t.Fatalf("result = %+v", result)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("content mismatch: %v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
@@ -102,6 +103,10 @@ func (g *GeoCoder) Geocode(city string) (*GeocodeResult, error) {
 
 // SendLocation sends a location to a peer.
 func (c *Client) SendLocation(ctx context.Context, params types.SendLocationParams) (*types.SendLocationResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	if err := c.CheckInitialized(); err != nil {
 		return nil, err
 	}
@@ -111,6 +116,10 @@ func (c *Client) SendLocation(ctx context.Context, params types.SendLocationPara
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve peer %s: %w", params.Peer, err)
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create geo point media
 	geoPoint := &tg.InputMediaGeoPoint{
@@ -121,12 +130,15 @@ func (c *Client) SendLocation(ctx context.Context, params types.SendLocationPara
 	}
 
 	// Send location using MessagesSendMedia
-	result, err := c.API().MessagesSendMedia(ctx, &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    geoPoint,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
-	})
+	req := &tg.MessagesSendMediaRequest{
+		Peer:         inputPeer,
+		Media:        geoPoint,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
+	}
+	opts.Media(req)
+	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send location: %w", err)
 	}
@@ -136,7 +148,7 @@ func (c *Client) SendLocation(ctx context.Context, params types.SendLocationPara
 
 	return &types.SendLocationResult{
 		ID:        msgID,
-		Date:      time.Now().Unix(),
+		Date:      types.SentDate(scheduleDate),
 		Peer:      params.Peer,
 		Latitude:  params.Latitude,
 		Longitude: params.Longitude,
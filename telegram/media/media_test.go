@@ -3,6 +3,7 @@ package media
 import (
 	"context"
 	"errors"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -40,7 +41,7 @@ func TestClientMethodsRequireInitialization(t *testing.T) {
 	check("SendDice", err)
 	_, err = c.SendFile(ctx, types.SendFileParams{})
 	check("SendFile", err)
-	_, err = c.SendDocument(ctx, "@p", "file.txt", "text/plain", "", types.ThreadTarget{})
+	_, err = c.SendDocument(ctx, "@p", "file.txt", "text/plain", "", types.ThreadTarget{}, types.SendSchedule{}, types.SendOptions{})
 	check("SendDocument", err)
 	_, err = c.SendVideo(ctx, types.SendVideoParams{})
 	check("SendVideo", err)
@@ -60,6 +61,8 @@ func TestClientMethodsRequireInitialization(t *testing.T) {
 	check("SendVoice", err)
 	_, err = c.SendVideoNote(ctx, types.SendVideoNoteParams{})
 	check("SendVideoNote", err)
+	_, err = c.DownloadMedia(ctx, types.DownloadMediaParams{})
+	check("DownloadMedia", err)
 }
 
 func TestMediaExtractors(t *testing.T) {
@@ -183,3 +186,62 @@ func TestSimpleSendMediaMethodsWithFakeAPI(t *testing.T) {
 		t.Fatalf("dice = %+v", dice)
 	}
 }
+
+func TestSendAlbumUploadsThenSendsMultiMedia(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.mp4")}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var sent *tg.MessagesSendMultiMediaRequest
+	c := NewClient(fakeParent{peer: &tg.InputPeerSelf{}})
+	c.SetAPI(tg.NewClient(tgmock.Invoker(func(input bin.Encoder) (bin.Encoder, error) {
+		switch req := input.(type) {
+		case *tg.UploadSaveFilePartRequest:
+			return &tg.BoolTrue{}, nil
+		case *tg.MessagesUploadMediaRequest:
+			switch req.Media.(type) {
+			case *tg.InputMediaUploadedPhoto:
+				return &tg.MessageMediaPhoto{Photo: &tg.Photo{ID: 1, AccessHash: 2}}, nil
+			case *tg.InputMediaUploadedDocument:
+				return &tg.MessageMediaDocument{Document: &tg.Document{ID: 3, AccessHash: 4}}, nil
+			}
+			t.Fatalf("unexpected upload media %T", req.Media)
+			return nil, nil
+		case *tg.MessagesSendMultiMediaRequest:
+			sent = req
+			first, second := req.MultiMedia[0].RandomID, req.MultiMedia[1].RandomID
+			return &tg.Updates{Updates: []tg.UpdateClass{
+				&tg.UpdateMessageID{ID: 11, RandomID: second},
+				&tg.UpdateMessageID{ID: 10, RandomID: first},
+				&tg.UpdateNewMessage{Message: &tg.Message{ID: 10, PeerID: &tg.PeerUser{UserID: 1}, GroupedID: 99}},
+			}}, nil
+		default:
+			t.Fatalf("unexpected request %T", input)
+			return nil, nil
+		}
+	})))
+
+	result, err := c.SendAlbum(context.Background(), types.SendAlbumParams{
+		PeerInfo: types.PeerInfo{Peer: "me"},
+		Items: []types.AlbumItem{
+			{File: files[0], Caption: "before"},
+			{File: files[1]},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.IDs) != 2 || result.IDs[0] != 10 || result.IDs[1] != 11 || result.GroupedID != 99 {
+		t.Fatalf("result = %+v", result)
+	}
+	if _, ok := sent.MultiMedia[0].Media.(*tg.InputMediaPhoto); !ok || sent.MultiMedia[0].Message != "before" {
+		t.Fatalf("first item = %+v", sent.MultiMedia[0])
+	}
+	if _, ok := sent.MultiMedia[1].Media.(*tg.InputMediaDocument); !ok {
+		t.Fatalf("second item = %+v", sent.MultiMedia[1])
+	}
+}
@@ -7,16 +7,25 @@ import (
 	"time"
 
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
 
 // SendPhoto sends a photo to a peer.
 func (c *Client) SendPhoto(ctx context.Context, params types.SendPhotoParams) (*types.SendPhotoResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	uploadedFile, err := uploadFile(ctx, c.API(), params.File)
 	if err != nil {
@@ -25,12 +34,15 @@ func (c *Client) SendPhoto(ctx context.Context, params types.SendPhotoParams) (*
 
 	media := &tg.InputMediaUploadedPhoto{File: uploadedFile}
 
-	result, err := c.API().MessagesSendMedia(ctx, &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    media,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
-	})
+	req := &tg.MessagesSendMediaRequest{
+		Peer:         inputPeer,
+		Media:        media,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
+	}
+	opts.Media(req)
+	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send photo: %w", err)
 	}
@@ -38,7 +50,7 @@ func (c *Client) SendPhoto(ctx context.Context, params types.SendPhotoParams) (*
 	msgID := extractMessageID(result)
 	return &types.SendPhotoResult{
 		ID:      msgID,
-		Date:    time.Now().Unix(),
+		Date:    types.SentDate(scheduleDate),
 		Peer:    params.Peer,
 		Caption: "",
 	}, nil
@@ -8,16 +8,25 @@ import (
 	"time"
 
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
 
 // SendPoll sends a poll to a peer.
 func (c *Client) SendPoll(ctx context.Context, params types.SendPollParams) (*types.SendPollResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build poll answers with random option bytes
 	answers := make([]tg.PollAnswer, len(params.Options))
@@ -50,12 +59,15 @@ func (c *Client) SendPoll(ctx context.Context, params types.SendPollParams) (*ty
 	}
 
 	// Send the poll
-	result, err := c.API().MessagesSendMedia(ctx, &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    poll,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
-	})
+	req := &tg.MessagesSendMediaRequest{
+		Peer:         inputPeer,
+		Media:        poll,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
+	}
+	opts.Media(req)
+	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send poll: %w", err)
 	}
@@ -63,7 +75,7 @@ func (c *Client) SendPoll(ctx context.Context, params types.SendPollParams) (*ty
 	msgID := extractMessageID(result)
 	return &types.SendPollResult{
 		ID:       msgID,
-		Date:     time.Now().Unix(),
+		Date:     types.SentDate(scheduleDate),
 		Peer:     params.Peer,
 		Question: params.Question,
 	}, nil
@@ -8,16 +8,25 @@ import (
 
 	"agent-telegram/telegram/helpers"
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
 
 // SendSticker sends a sticker to a peer.
 func (c *Client) SendSticker(ctx context.Context, params types.SendStickerParams) (*types.SendStickerResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	var media tg.InputMediaClass
 
@@ -43,12 +52,15 @@ func (c *Client) SendSticker(ctx context.Context, params types.SendStickerParams
 		return nil, fmt.Errorf("sticker file is required")
 	}
 
-	result, err := c.API().MessagesSendMedia(ctx, &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    media,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
-	})
+	req := &tg.MessagesSendMediaRequest{
+		Peer:         inputPeer,
+		Media:        media,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
+	}
+	opts.Media(req)
+	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send sticker: %w", err)
 	}
@@ -56,7 +68,7 @@ func (c *Client) SendSticker(ctx context.Context, params types.SendStickerParams
 	msgID := extractMessageID(result)
 	return &types.SendStickerResult{
 		ID:   msgID,
-		Date: time.Now().Unix(),
+		Date: types.SentDate(scheduleDate),
 		Peer: params.Peer,
 	}, nil
 }
@@ -96,10 +108,18 @@ func (c *Client) GetStickerPacks(
 
 // SendGIF sends a GIF/animation to a peer.
 func (c *Client) SendGIF(ctx context.Context, params types.SendGIFParams) (*types.SendGIFResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	uploadedFile, err := uploadFile(ctx, c.API(), params.File)
 	if err != nil {
@@ -119,15 +139,17 @@ func (c *Client) SendGIF(ctx context.Context, params types.SendGIFParams) (*type
 
 	parsed, entities := helpers.ParseCustomEmojis(params.Caption)
 	req := &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    media,
-		Message:  parsed,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
+		Peer:         inputPeer,
+		Media:        media,
+		Message:      parsed,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
 	}
 	if len(entities) > 0 {
 		req.SetEntities(entities)
 	}
+	opts.Media(req)
 	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send GIF: %w", err)
@@ -136,7 +158,7 @@ func (c *Client) SendGIF(ctx context.Context, params types.SendGIFParams) (*type
 	msgID := extractMessageID(result)
 	return &types.SendGIFResult{
 		ID:      msgID,
-		Date:    time.Now().Unix(),
+		Date:    types.SentDate(scheduleDate),
 		Peer:    params.Peer,
 		Caption: params.Caption,
 	}, nil
@@ -8,16 +8,25 @@ import (
 
 	"agent-telegram/telegram/helpers"
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
 
 // SendVoice sends a voice message to a peer.
 func (c *Client) SendVoice(ctx context.Context, params types.SendVoiceParams) (*types.SendVoiceResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	uploadedFile, err := uploadFile(ctx, c.API(), params.File)
 	if err != nil {
@@ -40,15 +49,17 @@ func (c *Client) SendVoice(ctx context.Context, params types.SendVoiceParams) (*
 
 	parsed, entities := helpers.ParseCustomEmojis(params.Caption)
 	req := &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    media,
-		Message:  parsed,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
+		Peer:         inputPeer,
+		Media:        media,
+		Message:      parsed,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
 	}
 	if len(entities) > 0 {
 		req.SetEntities(entities)
 	}
+	opts.Media(req)
 	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send voice: %w", err)
@@ -57,7 +68,7 @@ func (c *Client) SendVoice(ctx context.Context, params types.SendVoiceParams) (*
 	msgID := extractMessageID(result)
 	return &types.SendVoiceResult{
 		ID:       msgID,
-		Date:     time.Now().Unix(),
+		Date:     types.SentDate(scheduleDate),
 		Peer:     params.Peer,
 		Duration: params.Duration,
 	}, nil
@@ -67,10 +78,18 @@ func (c *Client) SendVoice(ctx context.Context, params types.SendVoiceParams) (*
 func (c *Client) SendVideoNote(
 	ctx context.Context, params types.SendVideoNoteParams,
 ) (*types.SendVideoNoteResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	uploadedFile, err := uploadFile(ctx, c.API(), params.File)
 	if err != nil {
@@ -99,12 +118,15 @@ func (c *Client) SendVideoNote(
 		Attributes: attributes,
 	}
 
-	result, err := c.API().MessagesSendMedia(ctx, &tg.MessagesSendMediaRequest{
-		Peer:     inputPeer,
-		Media:    media,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
-	})
+	req := &tg.MessagesSendMediaRequest{
+		Peer:         inputPeer,
+		Media:        media,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
+	}
+	opts.Media(req)
+	result, err := c.API().MessagesSendMedia(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send video note: %w", err)
 	}
@@ -112,7 +134,7 @@ func (c *Client) SendVideoNote(
 	msgID := extractMessageID(result)
 	return &types.SendVideoNoteResult{
 		ID:       msgID,
-		Date:     time.Now().Unix(),
+		Date:     types.SentDate(scheduleDate),
 		Peer:     params.Peer,
 		Duration: params.Duration,
 	}, nil
@@ -2,7 +2,14 @@
 package message
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gotd/td/tg"
+
 	"agent-telegram/telegram/client"
+	"agent-telegram/telegram/helpers"
 )
 
 // Client provides message operations.
@@ -26,3 +33,18 @@ type MessageResult struct { // revive:disable:exported // Used internally
 	FromName string `json:"fromName,omitempty"`
 	Out      bool   `json:"out"`
 }
+
+// mentionResolver resolves users mentioned by ID in formatted text.
+func (c *Client) mentionResolver(ctx context.Context) helpers.UserResolver {
+	return func(id int64) (tg.InputUserClass, error) {
+		peer, err := c.ResolvePeer(ctx, strconv.FormatInt(id, 10))
+		if err != nil {
+			return nil, err
+		}
+		user, ok := peer.(*tg.InputPeerUser)
+		if !ok {
+			return nil, fmt.Errorf("peer %d is not a user", id)
+		}
+		return &tg.InputUser{UserID: user.UserID, AccessHash: user.AccessHash}, nil
+	}
+}
@@ -38,6 +38,31 @@ func extractMessageID(result tg.UpdatesClass) int64 {
 	return 0
 }
 
+// extractSentIDs returns the IDs of sent messages in the order of the random
+// IDs they were sent with.
+func extractSentIDs(result tg.UpdatesClass, randomIDs []int64) []int64 {
+	updates, ok := result.(*tg.Updates)
+	if !ok {
+		if id := extractMessageID(result); id != 0 {
+			return []int64{id}
+		}
+		return nil
+	}
+	byRandomID := make(map[int64]int64, len(randomIDs))
+	for _, update := range updates.Updates {
+		if u, ok := update.(*tg.UpdateMessageID); ok {
+			byRandomID[u.RandomID] = int64(u.ID)
+		}
+	}
+	ids := make([]int64, 0, len(randomIDs))
+	for _, randomID := range randomIDs {
+		if id, ok := byRandomID[randomID]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // extractMessagesData extracts messages and users from the response.
 func extractMessagesData(messagesClass tg.MessagesMessagesClass) ([]tg.MessageClass, []tg.UserClass) {
 	switch m := messagesClass.(type) {
@@ -156,7 +181,7 @@ func extractMessageContent(msg *tg.Message, r *types.MessageResult) {
 		r.Reactions = convertReactions(msg.Reactions)
 	}
 	if len(msg.Entities) > 0 {
-		r.Entities = convertEntities(msg.Entities)
+		r.Entities = helpers.ConvertEntities(msg.Entities)
 	}
 	if msg.ReplyMarkup != nil {
 		r.Buttons = extractButtons(msg.ReplyMarkup)
@@ -26,56 +26,3 @@ func convertReactions(reactions tg.MessageReactions) []map[string]any {
 	}
 	return result
 }
-
-// convertEntities converts message entities to map slice.
-func convertEntities(entities []tg.MessageEntityClass) []map[string]any {
-	result := make([]map[string]any, 0, len(entities))
-	for _, e := range entities {
-		entity := map[string]any{
-			"offset": e.GetOffset(),
-			"length": e.GetLength(),
-		}
-		switch ent := e.(type) {
-		case *tg.MessageEntityTextURL:
-			entity["type"] = "text_url"
-			entity["url"] = ent.URL
-		case *tg.MessageEntityURL:
-			entity["type"] = "url"
-		case *tg.MessageEntityEmail:
-			entity["type"] = "email"
-		case *tg.MessageEntityHashtag:
-			entity["type"] = "hashtag"
-		case *tg.MessageEntityCashtag:
-			entity["type"] = "cashtag"
-		case *tg.MessageEntityMention:
-			entity["type"] = "mention"
-		case *tg.MessageEntityMentionName:
-			entity["type"] = "mention_name"
-			entity["user_id"] = ent.UserID
-		case *tg.MessageEntityBotCommand:
-			entity["type"] = "bot_command"
-		case *tg.MessageEntityBold:
-			entity["type"] = "bold"
-		case *tg.MessageEntityItalic:
-			entity["type"] = "italic"
-		case *tg.MessageEntityUnderline:
-			entity["type"] = "underline"
-		case *tg.MessageEntityStrike:
-			entity["type"] = "strike"
-		case *tg.MessageEntityCode:
-			entity["type"] = "code"
-		case *tg.MessageEntityPre:
-			entity["type"] = "pre"
-			if ent.Language != "" {
-				entity["language"] = ent.Language
-			}
-		case *tg.MessageEntityBlockquote:
-			entity["type"] = "blockquote"
-		case *tg.MessageEntityCustomEmoji:
-			entity["type"] = "custom_emoji"
-			entity["document_id"] = ent.DocumentID
-		}
-		result = append(result, entity)
-	}
-	return result
-}
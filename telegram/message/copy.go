@@ -0,0 +1,173 @@
+// Package message provides Telegram message copy operations.
+package message
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agent-telegram/telegram/helpers"
+	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
+	"agent-telegram/telegram/types"
+	"github.com/gotd/td/tg"
+)
+
+// CopyMessage re-sends a message's text and media as a new message from the
+// current account, without a forward header.
+func (c *Client) CopyMessage(ctx context.Context, params types.CopyMessageParams) (*types.CopyMessageResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := c.CheckInitialized(); err != nil {
+		return nil, err
+	}
+
+	fromPeer, err := c.ResolvePeer(ctx, params.FromPeer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve fromPeer: %w", err)
+	}
+	toPeer, err := c.ResolvePeer(ctx, params.ToPeer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve toPeer: %w", err)
+	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := c.copySource(ctx, fromPeer, params.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	text, entities, err := c.copyText(ctx, source, params)
+	if err != nil {
+		return nil, err
+	}
+	media, err := copyInputMedia(source.Media)
+	if err != nil {
+		return nil, err
+	}
+
+	var result tg.UpdatesClass
+	if media == nil {
+		if text == "" {
+			return nil, fmt.Errorf("message %d has no content to copy", params.MessageID)
+		}
+		req := &tg.MessagesSendMessageRequest{
+			Peer:         toPeer,
+			Message:      text,
+			ReplyTo:      replytarget.Build(params.ThreadTarget),
+			ScheduleDate: scheduleDate,
+			RandomID:     time.Now().UnixNano(),
+		}
+		if len(entities) > 0 {
+			req.SetEntities(entities)
+		}
+		opts.Message(req)
+		req.InvertMedia = req.InvertMedia || source.InvertMedia
+		result, err = c.API().MessagesSendMessage(ctx, req)
+	} else {
+		req := &tg.MessagesSendMediaRequest{
+			Peer:         toPeer,
+			Media:        media,
+			Message:      text,
+			ReplyTo:      replytarget.Build(params.ThreadTarget),
+			ScheduleDate: scheduleDate,
+			RandomID:     time.Now().UnixNano(),
+		}
+		if len(entities) > 0 {
+			req.SetEntities(entities)
+		}
+		opts.Media(req)
+		req.InvertMedia = req.InvertMedia || source.InvertMedia
+		result, err = c.API().MessagesSendMedia(ctx, req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy message: %w", err)
+	}
+
+	return &types.CopyMessageResult{
+		ID:       extractMessageID(result),
+		Date:     types.SentDate(scheduleDate),
+		Peer:     params.ToPeer,
+		SourceID: params.MessageID,
+		HasMedia: media != nil,
+	}, nil
+}
+
+// copySource fetches the message to copy.
+func (c *Client) copySource(ctx context.Context, peer tg.InputPeerClass, id int64) (*tg.Message, error) {
+	messagesClass, err := c.getMessagesByID(ctx, peer, int(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	messages, _ := extractMessagesData(messagesClass)
+	for _, m := range messages {
+		if msg, ok := m.(*tg.Message); ok {
+			return msg, nil
+		}
+	}
+	return nil, fmt.Errorf("message %d not found", id)
+}
+
+// copyText returns the text for the copy: the replacement caption, nothing, or
+// the original text with its entities.
+func (c *Client) copyText(
+	ctx context.Context, source *tg.Message, params types.CopyMessageParams,
+) (string, []tg.MessageEntityClass, error) {
+	switch {
+	case params.DropCaption:
+		return "", nil, nil
+	case params.Caption != "":
+		return helpers.FormatText(params.Caption, params.TextFormat, c.mentionResolver(ctx))
+	default:
+		return source.Message, source.Entities, nil
+	}
+}
+
+// copyInputMedia converts the media of a received message into media that can
+// be sent again. It returns nil for messages without media and for link
+// previews, which are regenerated from the text.
+func copyInputMedia(media tg.MessageMediaClass) (tg.InputMediaClass, error) {
+	switch m := media.(type) {
+	case nil, *tg.MessageMediaEmpty, *tg.MessageMediaWebPage:
+		return nil, nil
+	case *tg.MessageMediaPhoto:
+		if photo, ok := m.Photo.(*tg.Photo); ok {
+			return &tg.InputMediaPhoto{ID: photo.AsInput(), Spoiler: m.Spoiler}, nil
+		}
+	case *tg.MessageMediaDocument:
+		if doc, ok := m.Document.(*tg.Document); ok {
+			return &tg.InputMediaDocument{ID: doc.AsInput(), Spoiler: m.Spoiler}, nil
+		}
+	case *tg.MessageMediaGeo:
+		if geo, ok := m.Geo.(*tg.GeoPoint); ok {
+			return &tg.InputMediaGeoPoint{GeoPoint: &tg.InputGeoPoint{Lat: geo.Lat, Long: geo.Long}}, nil
+		}
+	case *tg.MessageMediaVenue:
+		if geo, ok := m.Geo.(*tg.GeoPoint); ok {
+			return &tg.InputMediaVenue{
+				GeoPoint:  &tg.InputGeoPoint{Lat: geo.Lat, Long: geo.Long},
+				Title:     m.Title,
+				Address:   m.Address,
+				Provider:  m.Provider,
+				VenueID:   m.VenueID,
+				VenueType: m.VenueType,
+			}, nil
+		}
+	case *tg.MessageMediaContact:
+		return &tg.InputMediaContact{
+			PhoneNumber: m.PhoneNumber,
+			FirstName:   m.FirstName,
+			LastName:    m.LastName,
+			Vcard:       m.Vcard,
+		}, nil
+	case *tg.MessageMediaDice:
+		return &tg.InputMediaDice{Emoticon: m.Emoticon}, nil
+	default:
+		return nil, fmt.Errorf("cannot copy %s media; forward it instead", m.TypeName())
+	}
+	return nil, fmt.Errorf("cannot copy expired or unavailable %s media", media.TypeName())
+}
@@ -0,0 +1,147 @@
+// Package message provides Telegram chat history export.
+package message
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/types"
+)
+
+// takeoutInvoker runs every request in a takeout session by wrapping it in
+// invokeWithTakeout. Telegram applies relaxed flood limits to these calls.
+type takeoutInvoker struct {
+	id   int64
+	next tg.Invoker
+}
+
+// Invoke implements tg.Invoker.
+func (t takeoutInvoker) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	query, ok := input.(bin.Object)
+	if !ok {
+		return fmt.Errorf("request %T cannot run in a takeout session", input)
+	}
+	return t.next.Invoke(ctx, &tg.InvokeWithTakeoutRequest{TakeoutID: t.id, Query: query}, output)
+}
+
+// InitTakeout starts a takeout session for exporting messages. The first
+// session of an account may be refused with TAKEOUT_INIT_DELAY until the
+// export is confirmed from another Telegram app.
+func (c *Client) InitTakeout(ctx context.Context, _ types.InitTakeoutParams) (*types.InitTakeoutResult, error) {
+	if err := c.CheckInitialized(); err != nil {
+		return nil, err
+	}
+	req := &tg.AccountInitTakeoutSessionRequest{}
+	req.SetMessageUsers(true)
+	req.SetMessageChats(true)
+	req.SetMessageMegagroups(true)
+	req.SetMessageChannels(true)
+	takeout, err := c.API().AccountInitTakeoutSession(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init takeout session: %w", err)
+	}
+	return &types.InitTakeoutResult{TakeoutID: takeout.ID}, nil
+}
+
+// FinishTakeout ends a takeout session.
+func (c *Client) FinishTakeout(ctx context.Context, params types.FinishTakeoutParams) (*types.FinishTakeoutResult, error) {
+	if err := c.CheckInitialized(); err != nil {
+		return nil, err
+	}
+	req := &tg.AccountFinishTakeoutSessionRequest{}
+	req.SetSuccess(params.Success)
+	api := tg.NewClient(takeoutInvoker{id: params.TakeoutID, next: c.API().Invoker()})
+	if _, err := api.AccountFinishTakeoutSession(ctx, req); err != nil {
+		return nil, fmt.Errorf("failed to finish takeout session: %w", err)
+	}
+	return &types.FinishTakeoutResult{Success: params.Success}, nil
+}
+
+// ExportMessages returns the next page of a chat's history, oldest first.
+func (c *Client) ExportMessages(ctx context.Context, params types.ExportMessagesParams) (*types.ExportMessagesResult, error) {
+	if err := c.CheckInitialized(); err != nil {
+		return nil, err
+	}
+	peer := params.Peer
+	if peer == "" {
+		peer = params.Username
+	}
+	inputPeer, err := c.ResolvePeer(ctx, normalizePeer(peer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve peer %s: %w", peer, err)
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = types.MaxExportLimit
+	}
+	// A negative add_offset of the page size turns getHistory around: it
+	// returns the messages with an ID at or above offset_id, or dated at or
+	// after offset_date.
+	req := &tg.MessagesGetHistoryRequest{
+		Peer:      inputPeer,
+		OffsetID:  int(max(params.OffsetID, params.MinID)),
+		AddOffset: -limit,
+		Limit:     limit,
+	}
+	if req.OffsetID == 0 {
+		if params.Since > 0 {
+			req.OffsetDate = int(params.Since)
+		} else {
+			req.OffsetID = 1
+		}
+	}
+
+	api := c.API()
+	if params.TakeoutID != 0 {
+		api = tg.NewClient(takeoutInvoker{id: params.TakeoutID, next: api.Invoker()})
+	}
+	messagesClass, err := api.MessagesGetHistory(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+	messages, users := extractMessagesData(messagesClass)
+
+	result := &types.ExportMessagesResult{
+		Messages:     []types.MessageResult{},
+		NextOffsetID: int64(req.OffsetID),
+		Done:         len(messages) < limit,
+	}
+	page := make([]tg.MessageClass, 0, len(messages))
+	for _, msg := range messages {
+		if int64(msg.GetID()) < int64(req.OffsetID) {
+			continue
+		}
+		page = append(page, msg)
+		result.NextOffsetID = max(result.NextOffsetID, int64(msg.GetID())+1)
+	}
+
+	userMap := make(map[int64]tg.UserClass)
+	for _, u := range users {
+		if user, ok := u.(*tg.User); ok {
+			userMap[user.ID] = user
+		}
+	}
+	converted := convertMessagesToResult(page, userMap)
+	sort.Slice(converted, func(i, j int) bool { return converted[i].ID < converted[j].ID })
+	for _, msg := range converted {
+		if params.MaxID > 0 && msg.ID > params.MaxID || params.Until > 0 && msg.Date > params.Until {
+			// IDs and dates grow together, so nothing newer is in range.
+			result.Done = true
+			break
+		}
+		if params.Since > 0 && msg.Date < params.Since {
+			continue
+		}
+		result.Messages = append(result.Messages, msg)
+	}
+	if params.MaxID > 0 && result.NextOffsetID > params.MaxID {
+		result.Done = true
+	}
+	result.Count = len(result.Messages)
+	return result, nil
+}
@@ -49,6 +49,8 @@ func TestClientMethodsRequireInitialization(t *testing.T) {
 	check("DeleteMessage", err)
 	_, err = c.ForwardMessage(ctx, types.ForwardMessageParams{})
 	check("ForwardMessage", err)
+	_, err = c.CopyMessage(ctx, types.CopyMessageParams{})
+	check("CopyMessage", err)
 	_, err = c.ReadMessages(ctx, types.ReadMessagesParams{})
 	check("ReadMessages", err)
 	_, err = c.SetTyping(ctx, types.SetTypingParams{})
@@ -63,6 +65,10 @@ func TestClientMethodsRequireInitialization(t *testing.T) {
 	check("SendMessage", err)
 	_, err = c.SendReply(ctx, types.SendReplyParams{})
 	check("SendReply", err)
+	_, err = c.InitTakeout(ctx, types.InitTakeoutParams{})
+	check("InitTakeout", err)
+	_, err = c.ExportMessages(ctx, types.ExportMessagesParams{})
+	check("ExportMessages", err)
 }
 
 func TestMessageConversionHelpers(t *testing.T) {
@@ -211,22 +217,6 @@ func TestMediaButtonKeyboardAndEntityHelpers(t *testing.T) {
 	if len(reactions) != 2 || reactions[0]["emoticon"] != "ok" || reactions[1]["document_id"] != int64(3) {
 		t.Fatalf("reactions = %#v", reactions)
 	}
-	entities := convertEntities([]tg.MessageEntityClass{
-		&tg.MessageEntityURL{Offset: 0, Length: 1},
-		&tg.MessageEntityEmail{Offset: 0, Length: 1},
-		&tg.MessageEntityHashtag{Offset: 0, Length: 1},
-		&tg.MessageEntityCashtag{Offset: 0, Length: 1},
-		&tg.MessageEntityMention{Offset: 0, Length: 1},
-		&tg.MessageEntityBotCommand{Offset: 0, Length: 1},
-		&tg.MessageEntityItalic{Offset: 0, Length: 1},
-		&tg.MessageEntityUnderline{Offset: 0, Length: 1},
-		&tg.MessageEntityStrike{Offset: 0, Length: 1},
-		&tg.MessageEntityCode{Offset: 0, Length: 1},
-		&tg.MessageEntityBlockquote{Offset: 0, Length: 1},
-	})
-	if len(entities) != 11 || entities[0]["type"] != "url" {
-		t.Fatalf("entities = %#v", entities)
-	}
 }
 
 func TestConvertMessageNormalizesTopicMetadata(t *testing.T) {
@@ -360,6 +350,75 @@ func TestReadAndSendMethodsWithFakeAPI(t *testing.T) {
 	}
 }
 
+func TestForwardAndCopyWithFakeAPI(t *testing.T) {
+	c := NewClient(fakeParent{peer: &tg.InputPeerSelf{}})
+	c.SetAPI(tg.NewClient(tgmock.Invoker(func(input bin.Encoder) (bin.Encoder, error) {
+		switch req := input.(type) {
+		case *tg.MessagesForwardMessagesRequest:
+			if len(req.ID) != 3 || req.ID[0] != 5 || !req.DropAuthor || !req.Silent || req.TopMsgID != 7 {
+				t.Fatalf("forward request = %+v", req)
+			}
+			// Report the sent IDs out of order to check they follow the source order.
+			updates := make([]tg.UpdateClass, 0, len(req.RandomID))
+			for i := len(req.RandomID) - 1; i >= 0; i-- {
+				updates = append(updates, &tg.UpdateMessageID{ID: 100 + req.ID[i], RandomID: req.RandomID[i]})
+			}
+			return &tg.Updates{Updates: updates, Users: []tg.UserClass{}, Chats: []tg.ChatClass{}}, nil
+		case *tg.MessagesGetMessagesRequest:
+			return &tg.MessagesMessages{
+				Messages: []tg.MessageClass{&tg.Message{
+					ID:      9,
+					Message: "original",
+					PeerID:  &tg.PeerUser{UserID: 1},
+					Media: &tg.MessageMediaPhoto{
+						Spoiler: true,
+						Photo:   &tg.Photo{ID: 1, AccessHash: 2, FileReference: []byte{3}},
+					},
+				}},
+				Users: []tg.UserClass{},
+			}, nil
+		case *tg.MessagesSendMediaRequest:
+			photo, ok := req.Media.(*tg.InputMediaPhoto)
+			if !ok || !photo.Spoiler || req.Message != "curated" || !req.Silent || req.ReplyTo != nil {
+				t.Fatalf("send media request = %+v", req)
+			}
+			return &tg.UpdateShortSentMessage{ID: 44}, nil
+		default:
+			t.Fatalf("unexpected request %T", input)
+			return nil, nil
+		}
+	})))
+
+	forwarded, err := c.ForwardMessage(context.Background(), types.ForwardMessageParams{
+		FromPeer:     "@src",
+		ToPeer:       "@dst",
+		MessageIDs:   []int64{5, 6, 8},
+		ThreadTarget: types.ThreadTarget{ThreadID: 7},
+		DropAuthor:   true,
+		Silent:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forwarded.Count != 3 || forwarded.MessageID != 105 || forwarded.IDs[2] != 108 {
+		t.Fatalf("forwarded = %+v", forwarded)
+	}
+
+	copied, err := c.CopyMessage(context.Background(), types.CopyMessageParams{
+		FromPeer:    "@src",
+		ToPeer:      "@dst",
+		MessageID:   9,
+		SendOptions: types.SendOptions{Silent: true},
+		Caption:     "curated",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.ID != 44 || !copied.HasMedia || copied.SourceID != 9 {
+		t.Fatalf("copied = %+v", copied)
+	}
+}
+
 func TestPressInlineButtonSelectsByText(t *testing.T) {
 	c := NewClient(fakeParent{peer: &tg.InputPeerSelf{}})
 	c.SetAPI(tg.NewClient(tgmock.Invoker(func(input bin.Encoder) (bin.Encoder, error) {
@@ -395,3 +454,94 @@ func TestPressInlineButtonSelectsByText(t *testing.T) {
 		t.Fatalf("result = %+v", result)
 	}
 }
+
+func TestExportMessagesPagesForwardInTakeout(t *testing.T) {
+	c := NewClient(fakeParent{peer: &tg.InputPeerSelf{}})
+	var requests []*tg.MessagesGetHistoryRequest
+	c.SetAPI(tg.NewClient(tgmock.Invoker(func(input bin.Encoder) (bin.Encoder, error) {
+		wrapped, ok := input.(*tg.InvokeWithTakeoutRequest)
+		if !ok || wrapped.TakeoutID != 9 {
+			t.Fatalf("request %T was not sent in takeout 9", input)
+		}
+		req := wrapped.Query.(*tg.MessagesGetHistoryRequest)
+		requests = append(requests, req)
+		// Telegram returns the page newest first.
+		return &tg.MessagesMessages{
+			Messages: []tg.MessageClass{
+				&tg.Message{ID: 13, Date: 300, Message: "late", PeerID: &tg.PeerUser{UserID: 1}},
+				&tg.MessageService{ID: 12, Date: 200, PeerID: &tg.PeerUser{UserID: 1}, Action: &tg.MessageActionHistoryClear{}},
+				&tg.Message{ID: 11, Date: 150, Message: "kept", PeerID: &tg.PeerUser{UserID: 1}},
+				&tg.Message{ID: 10, Date: 100, Message: "early", PeerID: &tg.PeerUser{UserID: 1}},
+			},
+			Users: []tg.UserClass{},
+		}, nil
+	})))
+
+	got, err := c.ExportMessages(context.Background(), types.ExportMessagesParams{
+		PeerInfo:  types.PeerInfo{Peer: "me"},
+		TakeoutID: 9,
+		OffsetID:  10,
+		Since:     120,
+		Until:     250,
+		Limit:     4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req := requests[0]; req.OffsetID != 10 || req.AddOffset != -4 || req.Limit != 4 {
+		t.Fatalf("getHistory request = %+v", req)
+	}
+	if got.Count != 1 || got.Messages[0].Text != "kept" || !got.Done || got.NextOffsetID != 14 {
+		t.Fatalf("export = %+v", got)
+	}
+
+	got, err = c.ExportMessages(context.Background(), types.ExportMessagesParams{
+		PeerInfo: types.PeerInfo{Peer: "me"}, TakeoutID: 9, Since: 120, Limit: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req := requests[1]; req.OffsetID != 0 || req.OffsetDate != 120 {
+		t.Fatalf("first page by date = %+v", req)
+	}
+	if got.Count != 2 || got.Done || got.Messages[0].ID != 11 || got.Messages[1].ID != 13 {
+		t.Fatalf("export = %+v", got)
+	}
+}
+
+func TestEditScheduledMessageKeepsScheduleDateOnTextEdit(t *testing.T) {
+	c := NewClient(fakeParent{peer: &tg.InputPeerSelf{}})
+	var edit *tg.MessagesEditMessageRequest
+	c.SetAPI(tg.NewClient(tgmock.Invoker(func(input bin.Encoder) (bin.Encoder, error) {
+		switch req := input.(type) {
+		case *tg.MessagesGetScheduledMessagesRequest:
+			if len(req.ID) != 1 || req.ID[0] != 7 {
+				t.Fatalf("getScheduledMessages ids = %v", req.ID)
+			}
+			return &tg.MessagesMessages{
+				Messages: []tg.MessageClass{&tg.Message{ID: 7, Date: 1900000000, Message: "old", PeerID: &tg.PeerUser{UserID: 1}}},
+				Users:    []tg.UserClass{},
+			}, nil
+		case *tg.MessagesEditMessageRequest:
+			edit = req
+			return &tg.Updates{}, nil
+		default:
+			t.Fatalf("unexpected request %T", input)
+			return nil, nil
+		}
+	})))
+
+	params := types.EditScheduledMessageParams{Text: "new"}
+	params.Peer = "me"
+	params.MessageID = 7
+	result, err := c.EditScheduledMessage(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date, ok := edit.GetScheduleDate(); !ok || date != 1900000000 || edit.Message != "new" {
+		t.Fatalf("edit request = %+v, want the queued message's schedule_date", edit)
+	}
+	if result.Date != 1900000000 {
+		t.Fatalf("result = %+v", result)
+	}
+}
@@ -50,17 +50,7 @@ func (c *Client) GetMessage(ctx context.Context, params types.GetMessageParams)
 		return nil, fmt.Errorf("failed to resolve peer %s: %w", peer, err)
 	}
 
-	msgIDs := []tg.InputMessageClass{&tg.InputMessageID{ID: int(params.MessageID)}}
-
-	var messagesClass tg.MessagesMessagesClass
-	if ch, ok := inputPeer.(*tg.InputPeerChannel); ok {
-		messagesClass, err = c.API().ChannelsGetMessages(ctx, &tg.ChannelsGetMessagesRequest{
-			Channel: &tg.InputChannel{ChannelID: ch.ChannelID, AccessHash: ch.AccessHash},
-			ID:      msgIDs,
-		})
-	} else {
-		messagesClass, err = c.API().MessagesGetMessages(ctx, msgIDs)
-	}
+	messagesClass, err := c.getMessagesByID(ctx, inputPeer, int(params.MessageID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
@@ -87,6 +77,24 @@ func (c *Client) GetMessage(ctx context.Context, params types.GetMessageParams)
 	}, nil
 }
 
+// getMessagesByID fetches messages by ID from a chat, using the channel
+// method for channels and supergroups.
+func (c *Client) getMessagesByID(
+	ctx context.Context, inputPeer tg.InputPeerClass, ids ...int,
+) (tg.MessagesMessagesClass, error) {
+	msgIDs := make([]tg.InputMessageClass, 0, len(ids))
+	for _, id := range ids {
+		msgIDs = append(msgIDs, &tg.InputMessageID{ID: id})
+	}
+	if ch, ok := inputPeer.(*tg.InputPeerChannel); ok {
+		return c.API().ChannelsGetMessages(ctx, &tg.ChannelsGetMessagesRequest{
+			Channel: &tg.InputChannel{ChannelID: ch.ChannelID, AccessHash: ch.AccessHash},
+			ID:      msgIDs,
+		})
+	}
+	return c.API().MessagesGetMessages(ctx, msgIDs)
+}
+
 // GetMessages returns messages from a dialog with the given username.
 func (c *Client) GetMessages(ctx context.Context, params types.GetMessagesParams) (*types.GetMessagesResult, error) {
 	if err := c.CheckInitialized(); err != nil {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"agent-telegram/telegram/helpers"
+	"agent-telegram/telegram/internal/replytarget"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
@@ -20,7 +21,10 @@ func (c *Client) UpdateMessage(
 		return nil, err
 	}
 
-	parsed, entities := helpers.ParseCustomEmojis(params.Text)
+	parsed, entities, err := helpers.FormatText(params.Text, params.TextFormat, c.mentionResolver(ctx))
+	if err != nil {
+		return nil, err
+	}
 	req := &tg.MessagesEditMessageRequest{
 		Peer:    inputPeer,
 		ID:      int(params.MessageID),
@@ -62,7 +66,7 @@ func (c *Client) DeleteMessage(
 	}, nil
 }
 
-// ForwardMessage forwards a message to another peer.
+// ForwardMessage forwards one or more messages to another peer.
 func (c *Client) ForwardMessage(
 	ctx context.Context, params types.ForwardMessageParams,
 ) (*types.ForwardMessageResult, error) {
@@ -80,20 +84,43 @@ func (c *Client) ForwardMessage(
 		return nil, fmt.Errorf("failed to resolve toPeer: %w", err)
 	}
 
-	result, err := c.API().MessagesForwardMessages(ctx, &tg.MessagesForwardMessagesRequest{
-		FromPeer: fromPeer,
-		ID:       []int{int(params.MessageID)},
-		ToPeer:   toPeer,
-		RandomID: []int64{time.Now().UnixNano()},
-	})
+	ids := params.IDs()
+	msgIDs := make([]int, len(ids))
+	randomIDs := make([]int64, len(ids))
+	randomID := time.Now().UnixNano()
+	for i, id := range ids {
+		msgIDs[i] = int(id)
+		randomIDs[i] = randomID + int64(i)
+	}
+	req := &tg.MessagesForwardMessagesRequest{
+		Silent:            params.Silent,
+		DropAuthor:        params.DropAuthor,
+		DropMediaCaptions: params.DropMediaCaptions,
+		FromPeer:          fromPeer,
+		ID:                msgIDs,
+		RandomID:          randomIDs,
+		ToPeer:            toPeer,
+	}
+	if params.ReplyTo != 0 {
+		req.SetReplyTo(replytarget.Build(params.ThreadTarget))
+	} else if params.ThreadID != 0 {
+		req.SetTopMsgID(int(params.ThreadID))
+	}
+	result, err := c.API().MessagesForwardMessages(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to forward message: %w", err)
 	}
 
-	newMsgID := extractMessageID(result)
+	forwarded := extractSentIDs(result, randomIDs)
+	var first int64
+	if len(forwarded) > 0 {
+		first = forwarded[0]
+	}
 
 	return &types.ForwardMessageResult{
 		Success:   true,
-		MessageID: newMsgID,
+		MessageID: first,
+		IDs:       forwarded,
+		Count:     len(forwarded),
 	}, nil
 }
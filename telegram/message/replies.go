@@ -163,7 +163,10 @@ func (c *Client) ReplyToComment(ctx context.Context, params types.ReplyToComment
 	}
 
 	// Send reply in the discussion thread
-	parsed, entities := helpers.ParseCustomEmojis(params.Text)
+	parsed, entities, err := helpers.FormatText(params.Text, params.TextFormat, c.mentionResolver(ctx))
+	if err != nil {
+		return nil, err
+	}
 	req := &tg.MessagesSendMessageRequest{
 		Peer:    discussionPeer,
 		Message: parsed,
@@ -4,7 +4,9 @@ package message
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"agent-telegram/telegram/helpers"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
@@ -27,42 +29,24 @@ func (c *Client) GetScheduledMessages(
 		return nil, fmt.Errorf("failed to get scheduled messages: %w", err)
 	}
 
-	messages := make([]types.ScheduledMessage, 0)
-
-	switch r := result.(type) {
-	case *tg.MessagesMessages:
-		for _, msg := range r.Messages {
-			if m, ok := msg.(*tg.Message); ok {
-				messages = append(messages, types.ScheduledMessage{
-					ID:      int64(m.ID),
-					Date:    int64(m.Date),
-					Message: m.Message,
-					Peer:    params.Peer,
-				})
-			}
+	msgs, _ := extractMessagesData(result)
+	messages := make([]types.ScheduledMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		m, ok := msg.(*tg.Message)
+		if !ok {
+			continue
 		}
-	case *tg.MessagesMessagesSlice:
-		for _, msg := range r.Messages {
-			if m, ok := msg.(*tg.Message); ok {
-				messages = append(messages, types.ScheduledMessage{
-					ID:      int64(m.ID),
-					Date:    int64(m.Date),
-					Message: m.Message,
-					Peer:    params.Peer,
-				})
-			}
+		scheduled := types.ScheduledMessage{
+			ID:       int64(m.ID),
+			Date:     int64(m.Date),
+			Message:  m.Message,
+			Peer:     params.Peer,
+			Entities: helpers.ConvertEntities(m.Entities),
 		}
-	case *tg.MessagesChannelMessages:
-		for _, msg := range r.Messages {
-			if m, ok := msg.(*tg.Message); ok {
-				messages = append(messages, types.ScheduledMessage{
-					ID:      int64(m.ID),
-					Date:    int64(m.Date),
-					Message: m.Message,
-					Peer:    params.Peer,
-				})
-			}
+		if m.Media != nil {
+			scheduled.Media = convertMedia(m.Media)
 		}
+		messages = append(messages, scheduled)
 	}
 
 	return &types.GetScheduledMessagesResult{
@@ -70,3 +54,124 @@ func (c *Client) GetScheduledMessages(
 		Count:    len(messages),
 	}, nil
 }
+
+// SendScheduledMessages sends scheduled messages immediately.
+func (c *Client) SendScheduledMessages(
+	ctx context.Context, params types.ScheduledMessageIDsParams,
+) (*types.ScheduledMessageIDsResult, error) {
+	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.API().MessagesSendScheduledMessages(ctx, &tg.MessagesSendScheduledMessagesRequest{
+		Peer: inputPeer,
+		ID:   scheduledIDs(params.MessageIDs),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send scheduled messages: %w", err)
+	}
+
+	return &types.ScheduledMessageIDsResult{
+		Success:    true,
+		Peer:       params.Peer,
+		MessageIDs: params.MessageIDs,
+	}, nil
+}
+
+// DeleteScheduledMessages removes messages from the scheduled queue.
+func (c *Client) DeleteScheduledMessages(
+	ctx context.Context, params types.ScheduledMessageIDsParams,
+) (*types.ScheduledMessageIDsResult, error) {
+	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.API().MessagesDeleteScheduledMessages(ctx, &tg.MessagesDeleteScheduledMessagesRequest{
+		Peer: inputPeer,
+		ID:   scheduledIDs(params.MessageIDs),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete scheduled messages: %w", err)
+	}
+
+	return &types.ScheduledMessageIDsResult{
+		Success:    true,
+		Peer:       params.Peer,
+		MessageIDs: params.MessageIDs,
+	}, nil
+}
+
+// EditScheduledMessage changes the text or send time of a scheduled message.
+// A text-only edit keeps the current send time; without a schedule date
+// Telegram would edit the regular message with the same ID instead.
+func (c *Client) EditScheduledMessage(
+	ctx context.Context, params types.EditScheduledMessageParams,
+) (*types.EditScheduledMessageResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
+	if err != nil {
+		return nil, err
+	}
+	if scheduleDate == 0 {
+		if scheduleDate, err = c.scheduledDate(ctx, inputPeer, int(params.MessageID)); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &tg.MessagesEditMessageRequest{
+		Peer: inputPeer,
+		ID:   int(params.MessageID),
+	}
+	req.SetScheduleDate(scheduleDate)
+	if params.Text != "" {
+		parsed, entities, err := helpers.FormatText(params.Text, params.TextFormat, c.mentionResolver(ctx))
+		if err != nil {
+			return nil, err
+		}
+		req.SetMessage(parsed)
+		if len(entities) > 0 {
+			req.SetEntities(entities)
+		}
+	}
+	if _, err := c.API().MessagesEditMessage(ctx, req); err != nil {
+		return nil, fmt.Errorf("failed to edit scheduled message: %w", err)
+	}
+
+	return &types.EditScheduledMessageResult{
+		Success: true,
+		ID:      params.MessageID,
+		Peer:    params.Peer,
+		Date:    int64(scheduleDate),
+	}, nil
+}
+
+// scheduledDate returns the send time of a queued message.
+func (c *Client) scheduledDate(ctx context.Context, peer tg.InputPeerClass, id int) (int, error) {
+	result, err := c.API().MessagesGetScheduledMessages(ctx, &tg.MessagesGetScheduledMessagesRequest{
+		Peer: peer,
+		ID:   []int{id},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get scheduled message: %w", err)
+	}
+	msgs, _ := extractMessagesData(result)
+	for _, msg := range msgs {
+		if m, ok := msg.(*tg.Message); ok && m.ID == id {
+			return m.Date, nil
+		}
+	}
+	return 0, fmt.Errorf("scheduled message %d not found", id)
+}
+
+func scheduledIDs(ids []int64) []int {
+	out := make([]int, len(ids))
+	for i, id := range ids {
+		out[i] = int(id)
+	}
+	return out
+}
@@ -8,12 +8,17 @@ import (
 
 	"agent-telegram/telegram/helpers"
 	"agent-telegram/telegram/internal/replytarget"
+	"agent-telegram/telegram/internal/sendopts"
 	"agent-telegram/telegram/types"
 	"github.com/gotd/td/tg"
 )
 
 // SendMessage sends a message to a peer.
 func (c *Client) SendMessage(ctx context.Context, params types.SendMessageParams) (*types.SendMessageResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	if err := c.CheckInitialized(); err != nil {
 		return nil, err
 	}
@@ -23,18 +28,27 @@ func (c *Client) SendMessage(ctx context.Context, params types.SendMessageParams
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve peer @%s: %w", params.Peer, err)
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	// Send message
-	parsed, entities := helpers.ParseCustomEmojis(params.Message)
+	parsed, entities, err := helpers.FormatText(params.Message, params.TextFormat, c.mentionResolver(ctx))
+	if err != nil {
+		return nil, err
+	}
 	req := &tg.MessagesSendMessageRequest{
-		Peer:     inputPeer,
-		Message:  parsed,
-		ReplyTo:  replytarget.Build(params.ThreadTarget),
-		RandomID: time.Now().UnixNano(),
+		Peer:         inputPeer,
+		Message:      parsed,
+		ReplyTo:      replytarget.Build(params.ThreadTarget),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
 	}
 	if len(entities) > 0 {
 		req.SetEntities(entities)
 	}
+	opts.Message(req)
 	result, err := c.API().MessagesSendMessage(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send message: %w", err)
@@ -45,7 +59,7 @@ func (c *Client) SendMessage(ctx context.Context, params types.SendMessageParams
 
 	return &types.SendMessageResult{
 		ID:      msgID,
-		Date:    time.Now().Unix(),
+		Date:    types.SentDate(scheduleDate),
 		Message: params.Message,
 		Peer:    params.Peer,
 	}, nil
@@ -53,12 +67,23 @@ func (c *Client) SendMessage(ctx context.Context, params types.SendMessageParams
 
 // SendReply sends a reply to a message.
 func (c *Client) SendReply(ctx context.Context, params types.SendReplyParams) (*types.SendReplyResult, error) {
+	scheduleDate, err := params.ScheduleUnix(time.Now())
+	if err != nil {
+		return nil, err
+	}
 	inputPeer, err := c.InitAndResolve(ctx, params.Peer)
 	if err != nil {
 		return nil, err
 	}
+	opts, err := sendopts.Resolve(ctx, c.ResolvePeer, params.SendOptions)
+	if err != nil {
+		return nil, err
+	}
 
-	parsed, entities := helpers.ParseCustomEmojis(params.Text)
+	parsed, entities, err := helpers.FormatText(params.Text, params.TextFormat, c.mentionResolver(ctx))
+	if err != nil {
+		return nil, err
+	}
 	req := &tg.MessagesSendMessageRequest{
 		Peer:    inputPeer,
 		Message: parsed,
@@ -66,11 +91,13 @@ func (c *Client) SendReply(ctx context.Context, params types.SendReplyParams) (*
 			ThreadID: params.ThreadID,
 			ReplyTo:  params.MessageID,
 		}),
-		RandomID: time.Now().UnixNano(),
+		ScheduleDate: scheduleDate,
+		RandomID:     time.Now().UnixNano(),
 	}
 	if len(entities) > 0 {
 		req.SetEntities(entities)
 	}
+	opts.Message(req)
 	result, err := c.API().MessagesSendMessage(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send reply: %w", err)
@@ -79,7 +106,7 @@ func (c *Client) SendReply(ctx context.Context, params types.SendReplyParams) (*
 	msgID := extractMessageID(result)
 	return &types.SendReplyResult{
 		ID:      msgID,
-		Date:    time.Now().Unix(),
+		Date:    types.SentDate(scheduleDate),
 		Peer:    params.Peer,
 		Text:    params.Text,
 		ReplyTo: params.MessageID,
@@ -9,6 +9,7 @@ import (
 	"github.com/gotd/td/tg"
 
 	"agent-telegram/telegram/helpers"
+	"agent-telegram/telegram/types"
 )
 
 // ResolvePeer resolves a peer string to InputPeerClass with caching.
@@ -99,8 +100,12 @@ func (c *Client) CachePeer(peer string, inputPeer tg.InputPeerClass) {
 	c.peerCache.Store(peer, inputPeer)
 }
 
-// resolveUsername resolves a username via Telegram API.
+// resolveUsername resolves a username from the peer store or via the Telegram
+// API.
 func (c *Client) resolveUsername(ctx context.Context, username string) (tg.InputPeerClass, error) {
+	if inputPeer, ok := c.storedUsername(username); ok {
+		return inputPeer, nil
+	}
 	tgClient := c.currentTelegramClient()
 	if tgClient == nil {
 		return nil, fmt.Errorf("client not initialized")
@@ -130,7 +135,8 @@ func (c *Client) resolveUsername(ctx context.Context, username string) (tg.Input
 	}
 }
 
-// resolveNumericPeer resolves a numeric peer ID by looking up in dialogs.
+// resolveNumericPeer resolves a numeric peer ID from the peer store or by
+// looking it up in dialogs.
 func (c *Client) resolveNumericPeer(ctx context.Context, peer string) (tg.InputPeerClass, error) {
 	id, err := strconv.ParseInt(peer, 10, 64)
 	if err != nil {
@@ -140,6 +146,9 @@ func (c *Client) resolveNumericPeer(ctx context.Context, peer string) (tg.InputP
 	// Determine peer type based on ID format
 	if id > 0 {
 		// Positive = user ID, need to find access hash from dialogs
+		if inputPeer, ok := c.storedPeer(types.PeerKindUser, id); ok {
+			return inputPeer, nil
+		}
 		return c.resolveUserByID(ctx, id)
 	}
 
@@ -151,6 +160,9 @@ func (c *Client) resolveNumericPeer(ctx context.Context, peer string) (tg.InputP
 		// Channel ID format: -100XXXXXXXXX
 		channelID := absID - 1000000000000
 		if channelID > 0 {
+			if inputPeer, ok := c.storedPeer(types.PeerKindChannel, channelID); ok {
+				return inputPeer, nil
+			}
 			return c.resolveChannelByID(ctx, channelID)
 		}
 	}
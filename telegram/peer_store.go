@@ -0,0 +1,272 @@
+// Package telegram provides the persistent peer cache integration.
+package telegram
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+
+	"agent-telegram/telegram/types"
+)
+
+// PeerStore persists peers with their access hashes across restarts. Entries
+// belong to the account passed to BindAccount.
+type PeerStore interface {
+	BindAccount(userID int64)
+	LookupPeer(kind string, id int64) (types.CachedPeer, bool)
+	LookupUsername(username string) (types.CachedPeer, bool)
+	StorePeers(peers []types.CachedPeer)
+	ForgetPeer(kind string, id int64)
+	ForgetUsername(username string)
+	Stats() types.PeerCacheStats
+	Clear() (int, error)
+}
+
+// WithPeerStore persists every user, chat and channel seen in responses and
+// updates, and consults it before resolving peers through Telegram.
+func (c *Client) WithPeerStore(store PeerStore) *Client {
+	c.peerStore = store
+	return c
+}
+
+// PeerCacheStats describes the persistent and in-memory peer caches.
+func (c *Client) PeerCacheStats() types.PeerCacheStats {
+	var stats types.PeerCacheStats
+	if c.peerStore != nil {
+		stats = c.peerStore.Stats()
+	}
+	c.peerCache.Range(func(_, _ any) bool {
+		stats.Memory++
+		return true
+	})
+	return stats
+}
+
+// ClearPeerCache forgets every cached peer of the current account. Peers are
+// resolved through Telegram again on next use.
+func (c *Client) ClearPeerCache() (types.PeerCacheClearResult, error) {
+	result := types.PeerCacheClearResult{}
+	c.peerCache.Range(func(_, _ any) bool {
+		result.Cleared++
+		return true
+	})
+	c.peerCache.Clear()
+	if c.peerStore != nil {
+		cleared, err := c.peerStore.Clear()
+		if err != nil {
+			return result, err
+		}
+		result.Cleared = max(result.Cleared, cleared)
+	}
+	result.Success = true
+	return result, nil
+}
+
+// storedPeer returns a persisted peer as an input peer.
+func (c *Client) storedPeer(kind string, id int64) (tg.InputPeerClass, bool) {
+	if c.peerStore == nil {
+		return nil, false
+	}
+	peer, ok := c.peerStore.LookupPeer(kind, id)
+	if !ok {
+		return nil, false
+	}
+	return inputPeerFromCached(peer)
+}
+
+// storedUsername returns a persisted peer by username as an input peer.
+func (c *Client) storedUsername(username string) (tg.InputPeerClass, bool) {
+	if c.peerStore == nil {
+		return nil, false
+	}
+	peer, ok := c.peerStore.LookupUsername(username)
+	if !ok {
+		return nil, false
+	}
+	return inputPeerFromCached(peer)
+}
+
+func inputPeerFromCached(peer types.CachedPeer) (tg.InputPeerClass, bool) {
+	switch peer.Kind {
+	case types.PeerKindUser:
+		if peer.AccessHash == 0 {
+			return nil, false
+		}
+		return &tg.InputPeerUser{UserID: peer.ID, AccessHash: peer.AccessHash}, true
+	case types.PeerKindChat:
+		return &tg.InputPeerChat{ChatID: peer.ID}, true
+	case types.PeerKindChannel:
+		if peer.AccessHash == 0 {
+			return nil, false
+		}
+		return &tg.InputPeerChannel{ChannelID: peer.ID, AccessHash: peer.AccessHash}, true
+	default:
+		return nil, false
+	}
+}
+
+// peerMiddleware records peers from successful responses and invalidates
+// cached peers Telegram rejects.
+func (c *Client) peerMiddleware() telegram.Middleware {
+	return telegram.MiddlewareFunc(func(next tg.Invoker) telegram.InvokeFunc {
+		return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+			err := next.Invoke(ctx, input, output)
+			if c.peerStore == nil {
+				return err
+			}
+			if err != nil {
+				c.invalidatePeers(input, err)
+				return err
+			}
+			c.peerStore.StorePeers(cachedPeers(responseEntities(output)))
+			return nil
+		}
+	})
+}
+
+// peerUpdates records peers carried by updates, including those replayed by
+// gap recovery, before handing them to next.
+func (c *Client) peerUpdates(next telegram.UpdateHandler) telegram.UpdateHandler {
+	return telegram.UpdateHandlerFunc(func(ctx context.Context, u tg.UpdatesClass) error {
+		if c.peerStore != nil {
+			c.peerStore.StorePeers(cachedPeers(responseEntities(u)))
+		}
+		return next.Handle(ctx, u)
+	})
+}
+
+// invalidatePeers forgets the peer addressed by a request that failed because
+// its username or access hash is no longer valid.
+func (c *Client) invalidatePeers(input bin.Encoder, err error) {
+	switch {
+	case tgerr.Is(err, "USERNAME_NOT_OCCUPIED", "USERNAME_INVALID"):
+		if request, ok := input.(*tg.ContactsResolveUsernameRequest); ok {
+			c.peerStore.ForgetUsername(request.Username)
+			c.peerCache.Delete("@" + strings.ToLower(request.Username))
+		}
+	case tgerr.Is(err, "CHANNEL_INVALID", "CHANNEL_PRIVATE", "PEER_ID_INVALID", "USER_ID_INVALID"):
+		kind, id, ok := requestPeer(input)
+		if !ok {
+			return
+		}
+		c.peerStore.ForgetPeer(kind, id)
+		c.forgetMemoryPeer(kind, id)
+	}
+}
+
+// forgetMemoryPeer removes every in-memory alias of a peer.
+func (c *Client) forgetMemoryPeer(kind string, id int64) {
+	c.peerCache.Range(func(cacheKey, value any) bool {
+		switch peer := value.(type) {
+		case *tg.InputPeerUser:
+			if kind == types.PeerKindUser && peer.UserID == id {
+				c.peerCache.Delete(cacheKey)
+			}
+		case *tg.InputPeerChannel:
+			if kind == types.PeerKindChannel && peer.ChannelID == id {
+				c.peerCache.Delete(cacheKey)
+			}
+		}
+		return true
+	})
+}
+
+// requestPeer returns the user or channel a request addresses.
+func requestPeer(input bin.Encoder) (string, int64, bool) {
+	if request, ok := input.(interface{ GetPeer() tg.InputPeerClass }); ok {
+		switch peer := request.GetPeer().(type) {
+		case *tg.InputPeerUser:
+			return types.PeerKindUser, peer.UserID, true
+		case *tg.InputPeerChannel:
+			return types.PeerKindChannel, peer.ChannelID, true
+		}
+	}
+	if request, ok := input.(interface{ GetChannel() tg.InputChannelClass }); ok {
+		if channel, ok := request.GetChannel().(*tg.InputChannel); ok {
+			return types.PeerKindChannel, channel.ChannelID, true
+		}
+	}
+	return "", 0, false
+}
+
+// responseEntities returns the users and chats attached to a response or
+// update. gotd decodes responses into single-field boxes (for example
+// tg.MessagesMessagesBox), so the boxed value is inspected as well.
+func responseEntities(value any) ([]tg.UserClass, []tg.ChatClass) {
+	if vector, ok := value.(*tg.UserClassVector); ok {
+		return vector.Elems, nil
+	}
+	users, chats := entitiesOf(value)
+	if users != nil || chats != nil {
+		return users, chats
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().NumField() != 1 {
+		return nil, nil
+	}
+	field := v.Elem().Field(0)
+	if field.Kind() != reflect.Interface || field.IsNil() || !field.CanInterface() {
+		return nil, nil
+	}
+	return entitiesOf(field.Interface())
+}
+
+func entitiesOf(value any) (users []tg.UserClass, chats []tg.ChatClass) {
+	if carrier, ok := value.(interface{ GetUsers() []tg.UserClass }); ok {
+		users = carrier.GetUsers()
+	}
+	if carrier, ok := value.(interface{ GetChats() []tg.ChatClass }); ok {
+		chats = carrier.GetChats()
+	}
+	return users, chats
+}
+
+// cachedPeers converts entities into cache entries. Min constructors carry
+// access hashes that are only valid in their original context and are
+// skipped.
+func cachedPeers(users []tg.UserClass, chats []tg.ChatClass) []types.CachedPeer {
+	peers := make([]types.CachedPeer, 0, len(users)+len(chats))
+	for _, item := range users {
+		if user, ok := item.(*tg.User); ok && !user.Min {
+			peers = append(peers, types.CachedPeer{
+				Kind:       types.PeerKindUser,
+				ID:         user.ID,
+				AccessHash: user.AccessHash,
+				Username:   user.Username,
+				Title:      strings.TrimSpace(user.FirstName + " " + user.LastName),
+			})
+		}
+	}
+	for _, item := range chats {
+		switch chat := item.(type) {
+		case *tg.Chat:
+			peers = append(peers, types.CachedPeer{Kind: types.PeerKindChat, ID: chat.ID, Title: chat.Title})
+		case *tg.ChatForbidden:
+			peers = append(peers, types.CachedPeer{Kind: types.PeerKindChat, ID: chat.ID, Title: chat.Title})
+		case *tg.Channel:
+			if chat.Min {
+				continue
+			}
+			peers = append(peers, types.CachedPeer{
+				Kind:       types.PeerKindChannel,
+				ID:         chat.ID,
+				AccessHash: chat.AccessHash,
+				Username:   chat.Username,
+				Title:      chat.Title,
+			})
+		case *tg.ChannelForbidden:
+			peers = append(peers, types.CachedPeer{
+				Kind:       types.PeerKindChannel,
+				ID:         chat.ID,
+				AccessHash: chat.AccessHash,
+				Title:      chat.Title,
+			})
+		}
+	}
+	return peers
+}
@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+
+	"agent-telegram/internal/peercache"
+	"agent-telegram/telegram/types"
+)
+
+func newPeerStoreClient(t *testing.T) (*Client, *peercache.Store) {
+	t.Helper()
+	store, err := peercache.Open(filepath.Join(t.TempDir(), "peers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	store.BindAccount(42)
+	return NewClient(1, "hash").WithPeerStore(store), store
+}
+
+type invokerFunc func(ctx context.Context, input bin.Encoder, output bin.Decoder) error
+
+func (f invokerFunc) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	return f(ctx, input, output)
+}
+
+func TestResolvePeerUsesPeerStoreOffline(t *testing.T) {
+	c, store := newPeerStoreClient(t)
+	store.StorePeers([]types.CachedPeer{
+		{Kind: types.PeerKindUser, ID: 7, AccessHash: 70, Username: "alice"},
+		{Kind: types.PeerKindChannel, ID: 1234, AccessHash: 120, Username: "news"},
+	})
+
+	// No transport exists, so these only resolve from the store.
+	for peer, want := range map[string]string{
+		"@Alice":         "user:7",
+		"7":              "user:7",
+		"@news":          "channel:1234",
+		"-1000000001234": "channel:1234",
+	} {
+		got, err := c.ResolvePeerID(context.Background(), peer)
+		if err != nil || got != want {
+			t.Fatalf("ResolvePeerID(%q) = %q, %v; want %q", peer, got, err, want)
+		}
+	}
+}
+
+func TestPeerMiddlewareRecordsAndInvalidatesPeers(t *testing.T) {
+	c, store := newPeerStoreClient(t)
+	var fail error
+	invoke := c.peerMiddleware().Handle(invokerFunc(func(_ context.Context, _ bin.Encoder, output bin.Decoder) error {
+		if fail != nil {
+			return fail
+		}
+		output.(*tg.MessagesMessagesBox).Messages = &tg.MessagesMessages{
+			Users: []tg.UserClass{
+				&tg.User{ID: 7, AccessHash: 70, Username: "alice", FirstName: "Alice"},
+				&tg.User{ID: 8, AccessHash: 80, Min: true},
+			},
+			Chats: []tg.ChatClass{&tg.Channel{ID: 9, AccessHash: 90, Title: "News"}},
+		}
+		return nil
+	}))
+
+	if err := invoke(context.Background(), &tg.MessagesGetHistoryRequest{}, &tg.MessagesMessagesBox{}); err != nil {
+		t.Fatal(err)
+	}
+	if peer, ok := store.LookupUsername("alice"); !ok || peer.AccessHash != 70 || peer.Title != "Alice" {
+		t.Fatalf("user from response = %+v, %v", peer, ok)
+	}
+	if _, ok := store.LookupPeer(types.PeerKindUser, 8); ok {
+		t.Fatal("min user should not be cached")
+	}
+	if _, ok := store.LookupPeer(types.PeerKindChannel, 9); !ok {
+		t.Fatal("channel from response was not cached")
+	}
+
+	fail = tgerr.New(400, "CHANNEL_INVALID")
+	request := &tg.ChannelsGetFullChannelRequest{Channel: &tg.InputChannel{ChannelID: 9, AccessHash: 90}}
+	_ = invoke(context.Background(), request, &tg.MessagesChatFull{})
+	if _, ok := store.LookupPeer(types.PeerKindChannel, 9); ok {
+		t.Fatal("CHANNEL_INVALID should drop the cached channel")
+	}
+
+	fail = tgerr.New(400, "USERNAME_NOT_OCCUPIED")
+	_ = invoke(context.Background(), &tg.ContactsResolveUsernameRequest{Username: "Alice"}, &tg.ContactsResolvedPeer{})
+	if _, ok := store.LookupUsername("alice"); ok {
+		t.Fatal("USERNAME_NOT_OCCUPIED should drop the cached username")
+	}
+}
@@ -76,6 +76,9 @@ func extractMessages(messages []tg.MessageClass, users []tg.UserClass, _ []tg.Ch
 		if m.Media != nil {
 			result.Media = extractMediaInfo(m.Media)
 		}
+		if len(m.Entities) > 0 {
+			result.Entities = helpers.ConvertEntities(m.Entities)
+		}
 
 		results = append(results, result)
 	}
@@ -2,8 +2,11 @@ package types
 
 import (
 	"encoding/json"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestMessagingSendParamsExposeThreadTarget(t *testing.T) {
@@ -157,6 +160,26 @@ func TestOtherParamValidation(t *testing.T) {
 		RemoveReactionParams{PeerInfo: PeerInfo{Peer: "@p"}, MsgID: MsgID{MessageID: 1}},
 		ListReactionsParams{PeerInfo: PeerInfo{Peer: "@p"}, MsgID: MsgID{MessageID: 1}},
 		GetUserInfoParams{Username: "@p"},
+		TextFormat{ParseMode: ParseModeMarkdown},
+		TextFormat{Entities: []MessageEntity{{Type: EntityBold, Length: 1}}},
+		SendSchedule{ScheduleDate: "+2h"},
+		SendAlbumParams{Items: []AlbumItem{{File: "a.jpg"}, {File: "b.mp4"}}},
+		SendAlbumParams{Items: []AlbumItem{{File: "a.pdf"}, {File: "b.zip"}}},
+		ScheduledMessageIDsParams{PeerInfo: PeerInfo{Peer: "@p"}, MessageIDs: []int64{1, 2}},
+		EditScheduledMessageParams{PeerInfo: PeerInfo{Peer: "@p"}, MsgID: MsgID{MessageID: 1}, SendSchedule: SendSchedule{ScheduleDate: "+1d"}},
+		SendOptions{Silent: true, NoForwards: true, TTLSeconds: 60, SendAs: "@channel"},
+		SendMessageParams{SendOptions: SendOptions{Silent: true, NoWebpage: true}},
+		SendGIFParams{SendOptions: SendOptions{HasSpoiler: true}},
+		SendAlbumParams{SendOptions: SendOptions{HasSpoiler: true}, Items: []AlbumItem{{File: "a.jpg"}}},
+		ForwardMessageParams{MessageID: 1},
+		ForwardMessageParams{MessageIDs: []int64{1, 2}, DropAuthor: true, DropMediaCaptions: true},
+		CopyMessageParams{MessageID: 1, Caption: "new"},
+		ConsumeUpdatesParams{Consumer: "triage", Types: []UpdateType{UpdateTypeNewMessage}, RedeliverAfter: 120},
+		AckUpdatesParams{Consumer: "triage.v2", IDs: []int64{41, 42}},
+		AckUpdatesParams{Consumer: "triage", UpTo: 42},
+		ConsumerParams{Consumer: "triage"},
+		WaitForMessageParams{PeerInfo: PeerInfo{Peer: "@bot"}, AfterID: 120, Text: "(?i)done", Count: 3, Timeout: 20},
+		ExportMessagesParams{PeerInfo: PeerInfo{Peer: "@support"}, MinID: 10, MaxID: 10, Since: 1, Until: 1, Limit: 100},
 	}
 	for _, params := range validators {
 		if err := params.Validate(); err != nil {
@@ -176,6 +199,39 @@ func TestOtherParamValidation(t *testing.T) {
 		GetGiftAttrsParams{},
 		ListReactionsParams{PeerInfo: PeerInfo{Peer: "@p"}},
 		GetUserInfoParams{},
+		TextFormat{ParseMode: "rtf"},
+		TextFormat{ParseMode: ParseModeHTML, Entities: []MessageEntity{{Type: EntityBold, Length: 1}}},
+		TextFormat{Entities: []MessageEntity{{Type: EntityTextURL, Length: 1}}},
+		TextFormat{Entities: []MessageEntity{{Type: "mention", Length: 1}}},
+		SendSchedule{ScheduleDate: "2001-01-01T00:00:00Z"},
+		SendSchedule{ScheduleDate: "tomorrow"},
+		SendAlbumParams{},
+		SendAlbumParams{Items: make([]AlbumItem, MaxAlbumItems+1)},
+		SendAlbumParams{Items: []AlbumItem{{File: "a.jpg"}, {File: "b.pdf"}}},
+		SendAlbumParams{Items: []AlbumItem{{File: "a.jpg", Type: "sticker"}}},
+		ScheduledMessageIDsParams{PeerInfo: PeerInfo{Peer: "@p"}, MessageIDs: []int64{0}},
+		EditScheduledMessageParams{PeerInfo: PeerInfo{Peer: "@p"}, MsgID: MsgID{MessageID: 1}},
+		SendOptions{TTLSeconds: 61},
+		SendMessageParams{SendOptions: SendOptions{HasSpoiler: true}},
+		SendGIFParams{SendOptions: SendOptions{TTLSeconds: 10}},
+		SendAlbumParams{SendOptions: SendOptions{TTLSeconds: 10}, Items: []AlbumItem{{File: "a.jpg"}}},
+		ForwardMessageParams{},
+		ForwardMessageParams{MessageIDs: make([]int64, MaxForwardMessages+1)},
+		ForwardMessageParams{MessageID: 1, DropMediaCaptions: true},
+		CopyMessageParams{},
+		CopyMessageParams{MessageID: 1, Caption: "new", DropCaption: true},
+		ConsumeUpdatesParams{},
+		ConsumeUpdatesParams{Consumer: "has space"},
+		ConsumeUpdatesParams{Consumer: "triage", RedeliverAfter: MaxRedeliverAfter + 1},
+		AckUpdatesParams{Consumer: "triage"},
+		AckUpdatesParams{Consumer: "triage", IDs: []int64{0}},
+		ConsumerParams{Consumer: "-triage"},
+		WaitForMessageParams{},
+		WaitForMessageParams{PeerInfo: PeerInfo{Peer: "@bot"}, Text: "("},
+		WaitForMessageParams{PeerInfo: PeerInfo{Peer: "@bot"}, Count: MaxWaitCount + 1},
+		ExportMessagesParams{PeerInfo: PeerInfo{Peer: "@support"}, MinID: 11, MaxID: 10},
+		ExportMessagesParams{PeerInfo: PeerInfo{Peer: "@support"}, Since: 2, Until: 1},
+		ExportMessagesParams{PeerInfo: PeerInfo{Peer: "@support"}, Limit: MaxExportLimit + 1},
 	}
 	for _, params := range invalid {
 		if err := params.Validate(); err == nil {
@@ -183,3 +239,41 @@ func TestOtherParamValidation(t *testing.T) {
 		}
 	}
 }
+
+func TestParseScheduleDate(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"", time.Time{}},
+		{"+90s", now.Add(90 * time.Second)},
+		{"+2h", now.Add(2 * time.Hour)},
+		{"+1d", now.Add(24 * time.Hour)},
+		{"2026-01-03T00:00:00Z", time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{strconv.FormatInt(now.Unix()+60, 10), now.Add(time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := ParseScheduleDate(tt.value, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Fatalf("ParseScheduleDate(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"+0s", "-1h", "+1y", "+400d", "2026-01-01T00:00:00Z", "soon"} {
+		if _, err := ParseScheduleDate(value, now); err == nil {
+			t.Fatalf("ParseScheduleDate(%q) should fail", value)
+		}
+	}
+}
+
+func TestParseMessageIDs(t *testing.T) {
+	got, err := ParseMessageIDs("101, 105-107,110")
+	if err != nil || !slices.Equal(got, []int64{101, 105, 106, 107, 110}) {
+		t.Fatalf("ParseMessageIDs() = %v, %v", got, err)
+	}
+	for _, spec := range []string{"", "abc", "0", "5-3", "1-101", "1-60,100-160"} {
+		if _, err := ParseMessageIDs(spec); err == nil {
+			t.Fatalf("ParseMessageIDs(%q) should fail", spec)
+		}
+	}
+}
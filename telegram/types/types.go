@@ -70,16 +70,63 @@ const (
 	UpdateTypeDelete UpdateType = "delete"
 	// UpdateTypeStarGift is a star gift received/sent update.
 	UpdateTypeStarGift UpdateType = "star_gift"
+	// UpdateTypeReadOutbox reports that the other side read our messages.
+	UpdateTypeReadOutbox UpdateType = "read_outbox"
+	// UpdateTypeTyping is a typing or upload indicator.
+	UpdateTypeTyping UpdateType = "typing"
+	// UpdateTypeUserStatus is a user's online status change.
+	UpdateTypeUserStatus UpdateType = "user_status"
+	// UpdateTypeReaction is a change of a message's reactions.
+	UpdateTypeReaction UpdateType = "reaction"
+	// UpdateTypeParticipant is a member joining, leaving or changing role.
+	UpdateTypeParticipant UpdateType = "participant"
+	// UpdateTypePin is a change of pinned messages.
+	UpdateTypePin UpdateType = "pin"
+	// UpdateTypeService is a service message such as a join or title change.
+	UpdateTypeService UpdateType = "service"
 	// UpdateTypeOther is an other type update.
 	UpdateTypeOther UpdateType = "other"
 )
 
+// UpdateTypesManifest describes each update type and its data fields for agents.
+func UpdateTypesManifest() []map[string]any {
+	return []map[string]any{
+		{"type": UpdateTypeNewMessage, "description": "New message or channel post",
+			"data": []string{"message"}},
+		{"type": UpdateTypeEditMessage, "description": "Edited message or channel post",
+			"data": []string{"message"}},
+		{"type": UpdateTypeDelete, "description": "Deleted message; private and basic group deletions carry no peer",
+			"data": []string{"message.id", "message.peer"}},
+		{"type": UpdateTypeStarGift, "description": "Star gift received or sent",
+			"data": []string{"msgId", "peer", "from", "action", "giftId", "title", "stars"}},
+		{"type": UpdateTypeReadOutbox, "description": "The peer read our messages up to maxId",
+			"data": []string{"peer", "maxId"}},
+		{"type": UpdateTypeTyping, "description": "Typing or upload indicator; action uses set_typing names. Live streams only",
+			"data": []string{"peer", "from", "action", "threadId"}},
+		{"type": UpdateTypeUserStatus, "description": "User went online or offline. Live streams only",
+			"data": []string{"peer", "status", "expires", "wasOnline"}},
+		{"type": UpdateTypeReaction, "description": "Reactions on a message changed",
+			"data": []string{"peer", "msgId", "threadId", "reactions"}},
+		{"type": UpdateTypeParticipant, "description": "Member joined, left or changed role (action: join, leave, update)",
+			"data": []string{"peer", "user", "actor", "action", "role", "date"}},
+		{"type": UpdateTypePin, "description": "Messages were pinned or unpinned",
+			"data": []string{"peer", "pinned", "messageIds"}},
+		{"type": UpdateTypeService, "description": "Service message (action: join, leave, title_change, pin, ...)",
+			"data": []string{"message", "action", "users", "title", "pinnedMsgId"}},
+	}
+}
+
 // StoredUpdate represents a stored Telegram update.
 type StoredUpdate struct {
 	ID        int64          `json:"id"`
 	Type      UpdateType     `json:"type"`
 	Timestamp time.Time      `json:"timestamp"`
 	Data      map[string]any `json:"data"`
+	// Recovered marks updates replayed by getDifference after a gap or restart.
+	Recovered bool `json:"recovered,omitempty"`
+	// Live marks transient updates, such as typing, that are only streamed to
+	// current subscribers. They have no ID and are never stored.
+	Live bool `json:"live,omitempty"`
 }
 
 // MessageResult represents a single message result.
@@ -52,19 +52,6 @@ type ClearHistoryResult struct {
 	Revoke  bool   `json:"revoke"`
 }
 
-// ForwardMessageParams holds parameters for ForwardMessage.
-type ForwardMessageParams struct {
-	FromPeer  string `json:"fromPeer" validate:"required"`
-	MessageID int64  `json:"messageId" validate:"required"`
-	ToPeer    string `json:"toPeer" validate:"required"`
-}
-
-// ForwardMessageResult is the result of ForwardMessage.
-type ForwardMessageResult struct {
-	Success   bool  `json:"success"`
-	MessageID int64 `json:"id"`
-}
-
 // PinChatParams holds parameters for PinChat (pin chat in dialog list).
 type PinChatParams struct {
 	PeerInfo
@@ -0,0 +1,163 @@
+// Package types provides common types for durable update consumers.
+package types // revive:disable:var-naming
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Limits for consume_updates.
+const (
+	DefaultConsumeLimit = 10
+	MaxConsumeLimit     = 100
+	// DefaultRedeliverAfter is the number of seconds a consumed update stays
+	// reserved for its consumer before it is delivered again unacknowledged.
+	DefaultRedeliverAfter = 60
+	MaxRedeliverAfter     = 24 * 60 * 60
+)
+
+var consumerNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ValidateConsumerName checks a consumer name: 1-64 letters, digits, "_", "."
+// or "-", starting with a letter or digit.
+func ValidateConsumerName(name string) error {
+	if name == "" {
+		return fmt.Errorf("consumer is required")
+	}
+	if !consumerNamePattern.MatchString(name) {
+		return fmt.Errorf("consumer must be 1-64 letters, digits, '_', '.' or '-'")
+	}
+	return nil
+}
+
+// ConsumeUpdatesParams holds parameters for ConsumeUpdates. A consumer keeps
+// the peer, types and direction filter of its first call and rejects calls
+// with another filter, because updates that do not match are acknowledged
+// for it automatically.
+type ConsumeUpdatesParams struct {
+	Consumer string `json:"consumer" validate:"required"`
+	PeerInfo
+	Types     []UpdateType `json:"types,omitempty"`
+	Direction string       `json:"direction,omitempty"`
+	Limit     int          `json:"limit,omitempty"`
+	// RedeliverAfter is the number of seconds before an unacknowledged update
+	// is delivered to the consumer again.
+	RedeliverAfter int `json:"redeliverAfter,omitempty"`
+}
+
+// AllowEmptyPeer marks peer/username as optional update filters.
+func (ConsumeUpdatesParams) AllowEmptyPeer() bool { return true }
+
+// Validate validates ConsumeUpdatesParams.
+func (p ConsumeUpdatesParams) Validate() error {
+	if err := ValidateConsumerName(p.Consumer); err != nil {
+		return err
+	}
+	switch p.Direction {
+	case "", UpdateDirectionIn, UpdateDirectionOut:
+	default:
+		return fmt.Errorf("direction must be %q or %q", UpdateDirectionIn, UpdateDirectionOut)
+	}
+	if p.Limit < 0 || p.Limit > MaxConsumeLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxConsumeLimit)
+	}
+	if p.RedeliverAfter < 0 || p.RedeliverAfter > MaxRedeliverAfter {
+		return fmt.Errorf("redeliverAfter must be between 1 and %d seconds", MaxRedeliverAfter)
+	}
+	return nil
+}
+
+func (ConsumeUpdatesParams) SchemaPropertyHints() map[string]map[string]any {
+	return map[string]map[string]any{
+		"direction":      {"enum": []string{UpdateDirectionIn, UpdateDirectionOut}},
+		"limit":          {"minimum": 1, "maximum": MaxConsumeLimit},
+		"redeliverAfter": {"minimum": 1, "maximum": MaxRedeliverAfter},
+	}
+}
+
+// ConsumeUpdatesResult is the result of ConsumeUpdates.
+type ConsumeUpdatesResult struct {
+	Consumer string         `json:"consumer"`
+	Updates  []StoredUpdate `json:"updates"`
+	Count    int            `json:"count"`
+	// Redelivered counts returned updates whose earlier delivery timed out.
+	Redelivered int `json:"redelivered,omitempty"`
+	// Committed is the update ID up to which everything is acknowledged.
+	Committed int64  `json:"committed"`
+	Epoch     string `json:"epoch"`
+	// Pending counts delivered updates that are not acknowledged yet.
+	Pending int  `json:"pending"`
+	Gap     bool `json:"gap,omitempty"`
+}
+
+// AckUpdatesParams holds parameters for AckUpdates.
+type AckUpdatesParams struct {
+	Consumer string  `json:"consumer" validate:"required"`
+	IDs      []int64 `json:"ids,omitempty"`
+	// UpTo acknowledges every update with an ID up to and including it.
+	UpTo int64 `json:"upTo,omitempty"`
+}
+
+// Validate validates AckUpdatesParams.
+func (p AckUpdatesParams) Validate() error {
+	if err := ValidateConsumerName(p.Consumer); err != nil {
+		return err
+	}
+	if len(p.IDs) == 0 && p.UpTo == 0 {
+		return fmt.Errorf("ids or upTo is required")
+	}
+	if p.UpTo < 0 {
+		return fmt.Errorf("upTo must be positive")
+	}
+	for _, id := range p.IDs {
+		if id <= 0 {
+			return fmt.Errorf("ids must be positive")
+		}
+	}
+	return nil
+}
+
+// AckUpdatesResult is the result of AckUpdates.
+type AckUpdatesResult struct {
+	Consumer  string `json:"consumer"`
+	Acked     int    `json:"acked"`
+	Committed int64  `json:"committed"`
+	Pending   int    `json:"pending"`
+}
+
+// ConsumerParams selects a named consumer.
+type ConsumerParams struct {
+	Consumer string `json:"consumer" validate:"required"`
+}
+
+// Validate validates ConsumerParams.
+func (p ConsumerParams) Validate() error {
+	return ValidateConsumerName(p.Consumer)
+}
+
+// ConsumerInfo describes the progress of a named consumer.
+type ConsumerInfo struct {
+	Name      string `json:"name"`
+	Committed int64  `json:"committed"`
+	Epoch     string `json:"epoch"`
+	// Acked counts updates acknowledged out of order above Committed.
+	Acked   int `json:"acked,omitempty"`
+	Pending int `json:"pending"`
+	// Peer, Types and Direction are the filter the consumer was created with.
+	Peer      string       `json:"peer,omitempty"`
+	Types     []UpdateType `json:"types,omitempty"`
+	Direction string       `json:"direction,omitempty"`
+	UpdatedAt int64        `json:"updatedAt"`
+}
+
+// ListConsumersResult is the result of ListConsumers.
+type ListConsumersResult struct {
+	Consumers []ConsumerInfo `json:"consumers"`
+	Count     int            `json:"count"`
+}
+
+// DeleteConsumerResult is the result of DeleteConsumer.
+type DeleteConsumerResult struct {
+	Success  bool   `json:"success"`
+	Consumer string `json:"consumer"`
+}
@@ -0,0 +1,53 @@
+// Package types provides common types for Telegram media download operations.
+package types // revive:disable:var-naming
+
+import "fmt"
+
+// MaxDownloadThreads bounds parallel upload.getFile requests per download.
+const MaxDownloadThreads = 8
+
+// DownloadMediaParams holds parameters for DownloadMedia.
+type DownloadMediaParams struct {
+	PeerInfo
+	MsgID
+	// Output is a destination file or an existing directory. Directories get
+	// the document file name or a generated <peer>_<messageId> name.
+	Output    string `json:"output,omitempty"`
+	Threads   int    `json:"threads,omitempty"`
+	Overwrite bool   `json:"overwrite,omitempty"`
+}
+
+// Validate validates DownloadMediaParams.
+func (p DownloadMediaParams) Validate() error {
+	if err := p.ValidatePeer(); err != nil {
+		return err
+	}
+	if err := p.ValidateMessageID(); err != nil {
+		return err
+	}
+	if p.Threads < 0 || p.Threads > MaxDownloadThreads {
+		return fmt.Errorf("threads must be between 0 and %d", MaxDownloadThreads)
+	}
+	return nil
+}
+
+func (DownloadMediaParams) SchemaPropertyHints() map[string]map[string]any {
+	return map[string]map[string]any{
+		"threads": {"minimum": 0, "maximum": MaxDownloadThreads},
+	}
+}
+
+// DownloadMediaResult is the result of DownloadMedia.
+type DownloadMediaResult struct {
+	Path        string `json:"path"`
+	Peer        string `json:"peer"`
+	MessageID   int64  `json:"messageId"`
+	MediaType   string `json:"mediaType"`
+	MimeType    string `json:"mimeType,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	Size        int64  `json:"size"`
+	Downloaded  int64  `json:"downloaded"`
+	ResumedFrom int64  `json:"resumedFrom,omitempty"`
+	Chunks      int    `json:"chunks"`
+	Complete    bool   `json:"complete"`
+}
@@ -0,0 +1,81 @@
+// Package types provides common types for chat history export.
+package types // revive:disable:var-naming
+
+import "fmt"
+
+// MaxExportLimit is the largest page export_messages returns.
+const MaxExportLimit = 100
+
+// InitTakeoutParams holds parameters for InitTakeout. A takeout session
+// always covers messages in private chats, groups, supergroups and channels.
+type InitTakeoutParams struct{}
+
+// InitTakeoutResult is the result of InitTakeout.
+type InitTakeoutResult struct {
+	TakeoutID int64 `json:"takeoutId"`
+}
+
+// FinishTakeoutParams holds parameters for FinishTakeout.
+type FinishTakeoutParams struct {
+	TakeoutID int64 `json:"takeoutId" validate:"required"`
+	// Success tells Telegram whether the export completed.
+	Success bool `json:"success,omitempty"`
+}
+
+// FinishTakeoutResult is the result of FinishTakeout.
+type FinishTakeoutResult struct {
+	Success bool `json:"success"`
+}
+
+// ExportMessagesParams holds parameters for ExportMessages. Pages run from
+// the oldest message to the newest; IDs and dates are inclusive bounds.
+type ExportMessagesParams struct {
+	PeerInfo
+	// TakeoutID runs the request in a takeout session from init_takeout_session.
+	TakeoutID int64 `json:"takeoutId,omitempty"`
+	// OffsetID returns messages with an ID at or above it, typically the
+	// nextOffsetId of the previous page.
+	OffsetID int64 `json:"offsetId,omitempty"`
+	MinID    int64 `json:"minId,omitempty"`
+	MaxID    int64 `json:"maxId,omitempty"`
+	// Since and Until are Unix timestamps.
+	Since int64 `json:"since,omitempty"`
+	Until int64 `json:"until,omitempty"`
+	Limit int   `json:"limit,omitempty"`
+}
+
+// Validate validates ExportMessagesParams.
+func (p ExportMessagesParams) Validate() error {
+	if err := p.PeerInfo.Validate(); err != nil {
+		return err
+	}
+	if p.OffsetID < 0 || p.MinID < 0 || p.MaxID < 0 || p.Since < 0 || p.Until < 0 {
+		return fmt.Errorf("offsetId, minId, maxId, since and until must be >= 0")
+	}
+	if p.MaxID > 0 && p.MinID > p.MaxID {
+		return fmt.Errorf("minId must not be greater than maxId")
+	}
+	if p.Until > 0 && p.Since > p.Until {
+		return fmt.Errorf("since must not be after until")
+	}
+	if p.Limit < 0 || p.Limit > MaxExportLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxExportLimit)
+	}
+	return nil
+}
+
+func (ExportMessagesParams) SchemaPropertyHints() map[string]map[string]any {
+	return map[string]map[string]any{
+		"limit": {"minimum": 1, "maximum": MaxExportLimit},
+	}
+}
+
+// ExportMessagesResult is the result of ExportMessages. Messages are sorted
+// by ID; service messages are skipped. Done is set once the newest message
+// or an upper bound is reached; otherwise NextOffsetID continues the export.
+type ExportMessagesResult struct {
+	Messages     []MessageResult `json:"messages"`
+	Count        int             `json:"count"`
+	NextOffsetID int64           `json:"nextOffsetId"`
+	Done         bool            `json:"done"`
+}
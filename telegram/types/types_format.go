@@ -0,0 +1,107 @@
+// Package types provides common types for formatted outgoing text.
+package types // revive:disable:var-naming
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse modes for outgoing text.
+const (
+	ParseModeNone     = "none"
+	ParseModeMarkdown = "markdown"
+	ParseModeHTML     = "html"
+)
+
+// Message entity types accepted in explicit entities. They match the types
+// reported for received messages.
+const (
+	EntityBold        = "bold"
+	EntityItalic      = "italic"
+	EntityUnderline   = "underline"
+	EntityStrike      = "strike"
+	EntitySpoiler     = "spoiler"
+	EntityCode        = "code"
+	EntityPre         = "pre"
+	EntityTextURL     = "text_url"
+	EntityMentionName = "mention_name"
+	EntityBlockquote  = "blockquote"
+	EntityCustomEmoji = "custom_emoji"
+)
+
+var entityTypes = []string{
+	EntityBold, EntityItalic, EntityUnderline, EntityStrike, EntitySpoiler, EntityCode,
+	EntityPre, EntityTextURL, EntityMentionName, EntityBlockquote, EntityCustomEmoji,
+}
+
+// MessageEntity formats a range of the text. Offset and Length count UTF-16
+// code units, as in Telegram.
+type MessageEntity struct {
+	Type       string `json:"type"`
+	Offset     int    `json:"offset"`
+	Length     int    `json:"length"`
+	URL        string `json:"url,omitempty"`
+	UserID     int64  `json:"userId,omitempty"`
+	Language   string `json:"language,omitempty"`
+	DocumentID int64  `json:"documentId,omitempty"`
+}
+
+// Validate validates MessageEntity.
+func (e MessageEntity) Validate() error {
+	if e.Offset < 0 || e.Length <= 0 {
+		return fmt.Errorf("%s entity needs offset >= 0 and length > 0", e.Type)
+	}
+	switch e.Type {
+	case EntityTextURL:
+		if e.URL == "" {
+			return fmt.Errorf("text_url entity needs url")
+		}
+	case EntityMentionName:
+		if e.UserID <= 0 {
+			return fmt.Errorf("mention_name entity needs userId")
+		}
+	case EntityCustomEmoji:
+		if e.DocumentID == 0 {
+			return fmt.Errorf("custom_emoji entity needs documentId")
+		}
+	case EntityBold, EntityItalic, EntityUnderline, EntityStrike, EntitySpoiler,
+		EntityCode, EntityPre, EntityBlockquote:
+	default:
+		return fmt.Errorf("unknown entity type %q (want one of %s)", e.Type, strings.Join(entityTypes, ", "))
+	}
+	return nil
+}
+
+// TextFormat selects how outgoing text is formatted. Text is sent as written
+// with either a parse mode or explicit entities, not both.
+type TextFormat struct {
+	ParseMode string          `json:"parseMode,omitempty"`
+	Entities  []MessageEntity `json:"entities,omitempty"`
+}
+
+// Validate validates TextFormat.
+func (f TextFormat) Validate() error {
+	switch f.ParseMode {
+	case "", ParseModeNone, ParseModeMarkdown, ParseModeHTML:
+	default:
+		return fmt.Errorf("parseMode must be %q, %q or %q", ParseModeMarkdown, ParseModeHTML, ParseModeNone)
+	}
+	if len(f.Entities) == 0 {
+		return nil
+	}
+	if f.ParseMode != "" && f.ParseMode != ParseModeNone {
+		return fmt.Errorf("entities cannot be combined with parseMode %q", f.ParseMode)
+	}
+	for i, entity := range f.Entities {
+		if err := entity.Validate(); err != nil {
+			return fmt.Errorf("entities[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (TextFormat) SchemaPropertyHints() map[string]map[string]any {
+	return map[string]map[string]any{
+		"parseMode": {"enum": []string{ParseModeMarkdown, ParseModeHTML, ParseModeNone}},
+	}
+}
@@ -0,0 +1,123 @@
+// Package types provides common types for forwarding and copying messages.
+package types // revive:disable:var-naming
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxForwardMessages is the most messages Telegram forwards in one request.
+const MaxForwardMessages = 100
+
+// ForwardMessageParams holds parameters for ForwardMessage.
+type ForwardMessageParams struct {
+	FromPeer   string  `json:"fromPeer" validate:"required"`
+	MessageID  int64   `json:"messageId,omitempty"`
+	MessageIDs []int64 `json:"messageIds,omitempty"`
+	ToPeer     string  `json:"toPeer" validate:"required"`
+	ThreadTarget
+	DropAuthor        bool `json:"dropAuthor,omitempty"`        // Forward without the "Forwarded from" header
+	DropMediaCaptions bool `json:"dropMediaCaptions,omitempty"` // Also strip media captions; requires dropAuthor
+	Silent            bool `json:"silent,omitempty"`            // Deliver without a notification
+}
+
+// Validate validates ForwardMessageParams.
+func (p ForwardMessageParams) Validate() error {
+	if err := p.ThreadTarget.Validate(); err != nil {
+		return err
+	}
+	ids := p.IDs()
+	if len(ids) == 0 {
+		return fmt.Errorf("messageId or messageIds is required")
+	}
+	if len(ids) > MaxForwardMessages {
+		return fmt.Errorf("at most %d messages can be forwarded at once", MaxForwardMessages)
+	}
+	for _, id := range ids {
+		if id <= 0 {
+			return fmt.Errorf("message IDs must be positive")
+		}
+	}
+	if p.DropMediaCaptions && !p.DropAuthor {
+		return fmt.Errorf("dropMediaCaptions requires dropAuthor")
+	}
+	return nil
+}
+
+// IDs returns the messages to forward, messageId first.
+func (p ForwardMessageParams) IDs() []int64 {
+	if p.MessageID == 0 {
+		return p.MessageIDs
+	}
+	return append([]int64{p.MessageID}, p.MessageIDs...)
+}
+
+// ForwardMessageResult is the result of ForwardMessage.
+type ForwardMessageResult struct {
+	Success   bool    `json:"success"`
+	MessageID int64   `json:"id"`  // First forwarded message
+	IDs       []int64 `json:"ids"` // All forwarded messages in source order
+	Count     int     `json:"count"`
+}
+
+// ParseMessageIDs parses a comma-separated list of message IDs and ranges,
+// such as "101,105-110".
+func ParseMessageIDs(spec string) ([]int64, error) {
+	var ids []int64
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		lo, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+		if err != nil || lo <= 0 {
+			return nil, fmt.Errorf("invalid message ID %q", part)
+		}
+		hi := lo
+		if isRange {
+			hi, err = strconv.ParseInt(strings.TrimSpace(last), 10, 64)
+			if err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid message ID range %q", part)
+			}
+		}
+		if hi-lo >= MaxForwardMessages || len(ids)+int(hi-lo+1) > MaxForwardMessages {
+			return nil, fmt.Errorf("at most %d message IDs are allowed", MaxForwardMessages)
+		}
+		for id := lo; id <= hi; id++ {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// CopyMessageParams holds parameters for CopyMessage.
+type CopyMessageParams struct {
+	FromPeer  string `json:"fromPeer" validate:"required"`
+	MessageID int64  `json:"messageId" validate:"required"`
+	ToPeer    string `json:"toPeer" validate:"required"`
+	ThreadTarget
+	SendSchedule
+	SendOptions
+	TextFormat
+	Caption     string `json:"caption,omitempty"`     // Replaces the original text or caption
+	DropCaption bool   `json:"dropCaption,omitempty"` // Copy media without its caption
+}
+
+// Validate validates CopyMessageParams.
+func (p CopyMessageParams) Validate() error {
+	if p.MessageID <= 0 {
+		return fmt.Errorf("messageId must be positive")
+	}
+	if p.Caption != "" && p.DropCaption {
+		return fmt.Errorf("caption and dropCaption are mutually exclusive")
+	}
+	return p.ValidateMedia(true, true)
+}
+
+// CopyMessageResult is the result of CopyMessage.
+type CopyMessageResult struct {
+	ID       int64  `json:"id"`
+	Date     int64  `json:"date"`
+	Peer     string `json:"peer"`
+	SourceID int64  `json:"sourceId"`
+	HasMedia bool   `json:"hasMedia"`
+}
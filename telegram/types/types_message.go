@@ -26,7 +26,10 @@ type GetMessageResult struct {
 type SendReplyParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	MsgID
+	TextFormat
 	Text string `json:"text" validate:"required"`
 }
 
@@ -35,6 +38,9 @@ func (p SendReplyParams) Validate() error {
 	if err := p.PeerInfo.Validate(); err != nil {
 		return err
 	}
+	if err := p.ValidateMedia(false, false); err != nil {
+		return err
+	}
 	return p.MsgID.Validate()
 }
 
@@ -51,6 +57,7 @@ type SendReplyResult struct {
 type UpdateMessageParams struct {
 	PeerInfo
 	MsgID
+	TextFormat
 	Text string `json:"text" validate:"required"`
 }
 
@@ -229,6 +236,7 @@ type GetRepliesResult struct {
 type ReplyToCommentParams struct {
 	PeerInfo
 	MsgID
+	TextFormat
 	CommentID int64  `json:"commentId" validate:"required"`
 	Text      string `json:"text" validate:"required"`
 }
@@ -264,10 +272,12 @@ type GetScheduledMessagesParams struct {
 
 // ScheduledMessage represents a scheduled message.
 type ScheduledMessage struct {
-	ID      int64  `json:"id"`
-	Date    int64  `json:"date"` // Scheduled send time
-	Message string `json:"message,omitempty"`
-	Peer    string `json:"peer"`
+	ID       int64            `json:"id"`
+	Date     int64            `json:"date"` // Scheduled send time
+	Message  string           `json:"message,omitempty"`
+	Peer     string           `json:"peer"`
+	Media    map[string]any   `json:"media,omitempty"`
+	Entities []map[string]any `json:"entities,omitempty"`
 }
 
 // GetScheduledMessagesResult is the result of GetScheduledMessages.
@@ -0,0 +1,42 @@
+// Package types provides common types for the persistent peer cache.
+package types // revive:disable:var-naming
+
+import "time"
+
+// Cached peer kinds.
+const (
+	PeerKindUser    = "user"
+	PeerKindChat    = "chat"
+	PeerKindChannel = "channel"
+)
+
+// CachedPeer is a user, basic group or channel remembered with the access
+// hash needed to address it without resolving it again. UpdatedAt is moved
+// forward when the peer changes and, at most hourly, when it is seen again.
+type CachedPeer struct {
+	Kind       string    `json:"kind"`
+	ID         int64     `json:"id"`
+	AccessHash int64     `json:"accessHash,omitempty"`
+	Username   string    `json:"username,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// PeerCacheStats describes the peer cache of the connected account.
+type PeerCacheStats struct {
+	Persistent bool   `json:"persistent"`
+	Path       string `json:"path,omitempty"`
+	Account    int64  `json:"account,omitempty"`
+	Users      int    `json:"users"`
+	Chats      int    `json:"chats"`
+	Channels   int    `json:"channels"`
+	Usernames  int    `json:"usernames"`
+	// Memory counts entries resolved by this process, including aliases.
+	Memory int `json:"memory"`
+}
+
+// PeerCacheClearResult is returned by clear_peer_cache.
+type PeerCacheClearResult struct {
+	Success bool `json:"success"`
+	Cleared int  `json:"cleared"`
+}
@@ -0,0 +1,144 @@
+// Package types provides common types for scheduled messages.
+package types // revive:disable:var-naming
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScheduleAhead is how far ahead Telegram accepts scheduled messages.
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// SendSchedule puts a message into the chat's scheduled queue instead of
+// sending it now.
+type SendSchedule struct {
+	// ScheduleDate is an RFC 3339 time, Unix seconds, or a delay such as
+	// "+90s", "+2h" or "+1d".
+	ScheduleDate string `json:"scheduleDate,omitempty"`
+}
+
+// Validate validates SendSchedule.
+func (s SendSchedule) Validate() error {
+	_, err := s.ScheduleUnix(time.Now())
+	return err
+}
+
+// ScheduleUnix returns the schedule time in Unix seconds, or 0 to send now.
+func (s SendSchedule) ScheduleUnix(now time.Time) (int, error) {
+	at, err := ParseScheduleDate(s.ScheduleDate, now)
+	if err != nil || at.IsZero() {
+		return 0, err
+	}
+	return int(at.Unix()), nil
+}
+
+// ParseScheduleDate parses an absolute or relative schedule time. An empty
+// value returns the zero time.
+func ParseScheduleDate(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	var at time.Time
+	switch {
+	case strings.HasPrefix(value, "+"):
+		delay, err := parseDelay(value[1:])
+		if err != nil || delay <= 0 {
+			return time.Time{}, fmt.Errorf("scheduleDate %q: want a positive delay such as +30m, +2h or +1d", value)
+		}
+		at = now.Add(delay)
+	default:
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			at = time.Unix(seconds, 0)
+			break
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("scheduleDate %q: want RFC 3339, Unix seconds or +delay", value)
+		}
+		at = parsed
+	}
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("scheduleDate %q is not in the future", value)
+	}
+	if at.Sub(now) > maxScheduleAhead {
+		return time.Time{}, fmt.Errorf("scheduleDate %q is more than 365 days ahead", value)
+	}
+	return at, nil
+}
+
+// parseDelay parses a Go duration, also accepting whole days such as "1d".
+func parseDelay(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// ScheduledMessageIDsParams addresses scheduled messages of a chat.
+type ScheduledMessageIDsParams struct {
+	PeerInfo
+	MessageIDs []int64 `json:"messageIds" validate:"required"`
+}
+
+// Validate validates ScheduledMessageIDsParams.
+func (p ScheduledMessageIDsParams) Validate() error {
+	for _, id := range p.MessageIDs {
+		if id <= 0 {
+			return fmt.Errorf("messageIds must be positive")
+		}
+	}
+	return nil
+}
+
+// ScheduledMessageIDsResult is returned by send_scheduled_messages and
+// delete_scheduled_messages.
+type ScheduledMessageIDsResult struct {
+	Success    bool    `json:"success"`
+	Peer       string  `json:"peer"`
+	MessageIDs []int64 `json:"messageIds"`
+}
+
+// EditScheduledMessageParams changes the text or send time of a scheduled
+// message.
+type EditScheduledMessageParams struct {
+	PeerInfo
+	MsgID
+	TextFormat
+	SendSchedule
+	Text string `json:"text,omitempty"`
+}
+
+// Validate validates EditScheduledMessageParams.
+func (p EditScheduledMessageParams) Validate() error {
+	if p.Text == "" && p.ScheduleDate == "" {
+		return fmt.Errorf("text or scheduleDate is required")
+	}
+	if p.Text == "" && (p.ParseMode != "" || len(p.Entities) > 0) {
+		return fmt.Errorf("parseMode and entities need text")
+	}
+	return nil
+}
+
+// EditScheduledMessageResult is the result of EditScheduledMessage.
+type EditScheduledMessageResult struct {
+	Success bool   `json:"success"`
+	ID      int64  `json:"id"`
+	Peer    string `json:"peer"`
+	Date    int64  `json:"date,omitempty"`
+}
+
+// SentDate returns the date reported for a sent message: its schedule time,
+// or now when it was sent immediately.
+func SentDate(scheduleDate int) int64 {
+	if scheduleDate > 0 {
+		return int64(scheduleDate)
+	}
+	return time.Now().Unix()
+}
@@ -1,7 +1,11 @@
 // Package types provides common types for Telegram client send operations.
 package types // revive:disable:var-naming
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
 
 // ThreadTarget identifies a forum topic and an optional message reply target.
 type ThreadTarget struct {
@@ -20,13 +24,57 @@ func (t ThreadTarget) Validate() error {
 	return nil
 }
 
+// maxTTLSeconds is the longest self-destruct timer Telegram accepts for media.
+const maxTTLSeconds = 60
+
+// SendOptions holds the delivery flags shared by send operations.
+type SendOptions struct {
+	Silent      bool   `json:"silent,omitempty"`      // Deliver without a notification
+	NoWebpage   bool   `json:"noWebpage,omitempty"`   // Do not generate a link preview
+	InvertMedia bool   `json:"invertMedia,omitempty"` // Show the link preview or media above the text
+	NoForwards  bool   `json:"noforwards,omitempty"`  // Protect content from forwarding and saving
+	HasSpoiler  bool   `json:"hasSpoiler,omitempty"`  // Blur photos and videos until tapped
+	TTLSeconds  int    `json:"ttlSeconds,omitempty"`  // Self-destruct photos and videos after viewing
+	SendAs      string `json:"sendAs,omitempty"`      // Channel or group to post as
+}
+
+// Validate validates SendOptions.
+func (o SendOptions) Validate() error {
+	if o.TTLSeconds < 0 || o.TTLSeconds > maxTTLSeconds {
+		return fmt.Errorf("ttlSeconds must be between 1 and %d", maxTTLSeconds)
+	}
+	return nil
+}
+
+// ValidateMedia rejects the media options a send does not support.
+func (o SendOptions) ValidateMedia(spoiler, ttl bool) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if o.HasSpoiler && !spoiler {
+		return fmt.Errorf("hasSpoiler is only supported for photos, videos, GIFs and albums")
+	}
+	if o.TTLSeconds > 0 && !ttl {
+		return fmt.Errorf("ttlSeconds is only supported for photos and videos")
+	}
+	return nil
+}
+
 // SendMessageParams holds parameters for SendMessage.
 type SendMessageParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
+	TextFormat
 	Message string `json:"message" validate:"required"`
 }
 
+// Validate validates SendMessageParams.
+func (p SendMessageParams) Validate() error {
+	return p.ValidateMedia(false, false)
+}
+
 // SendMessageResult is the result of SendMessage.
 type SendMessageResult struct {
 	ID      int64  `json:"id"`
@@ -39,12 +87,17 @@ type SendMessageResult struct {
 type SendLocationParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 }
 
 // Validate validates SendLocationParams.
 func (p SendLocationParams) Validate() error {
+	if err := p.ValidateMedia(false, false); err != nil {
+		return err
+	}
 	if err := ValidateLatitude(p.Latitude); err != nil {
 		return err
 	}
@@ -71,6 +124,8 @@ type SendLocationResult struct {
 type SendPhotoParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	File    string `json:"file" validate:"required"`
 	Caption string `json:"caption,omitempty"`
 }
@@ -87,11 +142,18 @@ type SendPhotoResult struct {
 type SendContactParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Phone     string `json:"phone" validate:"required"`
 	FirstName string `json:"firstName" validate:"required"`
 	LastName  string `json:"lastName,omitempty"`
 }
 
+// Validate validates SendContactParams.
+func (p SendContactParams) Validate() error {
+	return p.ValidateMedia(false, false)
+}
+
 // SendContactResult is the result of SendContact.
 type SendContactResult struct {
 	ID    int64  `json:"id"`
@@ -104,10 +166,17 @@ type SendContactResult struct {
 type SendFileParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	File    string `json:"file" validate:"required"`
 	Caption string `json:"caption,omitempty"`
 }
 
+// Validate validates SendFileParams.
+func (p SendFileParams) Validate() error {
+	return p.ValidateMedia(false, false)
+}
+
 // SendFileResult is the result of SendFile.
 type SendFileResult struct {
 	ID      int64  `json:"id"`
@@ -125,6 +194,8 @@ type PollOption struct {
 type SendPollParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Question   string       `json:"question" validate:"required"`
 	Options    []PollOption `json:"options"`
 	Anonymous  bool         `json:"anonymous,omitempty"`
@@ -140,7 +211,7 @@ func (p SendPollParams) Validate() error {
 	if len(p.Options) > 10 {
 		return fmt.Errorf("maximum 10 options allowed")
 	}
-	return nil
+	return p.ValidateMedia(false, false)
 }
 
 func (SendPollParams) SchemaPropertyHints() map[string]map[string]any {
@@ -172,6 +243,8 @@ type SendPollResult struct {
 type SendVideoParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	File    string `json:"file" validate:"required"`
 	Caption string `json:"caption,omitempty"`
 }
@@ -187,12 +260,19 @@ type SendVideoResult struct {
 // SendVoiceParams holds parameters for SendVoice.
 type SendVoiceParams struct {
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Peer     string `json:"peer" validate:"required"`
 	File     string `json:"file" validate:"required"` // Path to voice file (OGG/OPUS)
 	Duration int    `json:"duration,omitempty"`       // Duration in seconds
 	Caption  string `json:"caption,omitempty"`
 }
 
+// Validate validates SendVoiceParams.
+func (p SendVoiceParams) Validate() error {
+	return p.ValidateMedia(false, false)
+}
+
 // SendVoiceResult is the result of SendVoice.
 type SendVoiceResult struct {
 	ID       int64  `json:"id"`
@@ -204,12 +284,19 @@ type SendVoiceResult struct {
 // SendVideoNoteParams holds parameters for SendVideoNote.
 type SendVideoNoteParams struct {
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Peer     string `json:"peer" validate:"required"`
 	File     string `json:"file" validate:"required"` // Path to video file
 	Duration int    `json:"duration,omitempty"`       // Duration in seconds
 	Length   int    `json:"length,omitempty"`         // Video width/height (square)
 }
 
+// Validate validates SendVideoNoteParams.
+func (p SendVideoNoteParams) Validate() error {
+	return p.ValidateMedia(false, false)
+}
+
 // SendVideoNoteResult is the result of SendVideoNote.
 type SendVideoNoteResult struct {
 	ID       int64  `json:"id"`
@@ -221,11 +308,18 @@ type SendVideoNoteResult struct {
 // SendGIFParams holds parameters for SendGIF.
 type SendGIFParams struct {
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Peer    string `json:"peer" validate:"required"`
 	File    string `json:"file" validate:"required"` // Path to GIF file
 	Caption string `json:"caption,omitempty"`
 }
 
+// Validate validates SendGIFParams.
+func (p SendGIFParams) Validate() error {
+	return p.ValidateMedia(true, false)
+}
+
 // SendGIFResult is the result of SendGIF.
 type SendGIFResult struct {
 	ID      int64  `json:"id"`
@@ -240,6 +334,8 @@ var ErrRequiresStickerOrFile = fmt.Errorf("stickerId or file is required")
 // SendStickerParams holds parameters for SendSticker.
 type SendStickerParams struct {
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Peer      string `json:"peer" validate:"required"`
 	StickerID string `json:"stickerId,omitempty"` // Sticker file_id or short_name
 	File      string `json:"file,omitempty"`      // Path to sticker file (WEBP)
@@ -250,7 +346,7 @@ func (p SendStickerParams) Validate() error {
 	if p.StickerID == "" && p.File == "" {
 		return ErrRequiresStickerOrFile
 	}
-	return nil
+	return p.ValidateMedia(false, false)
 }
 
 func (SendStickerParams) SchemaRules() map[string]any {
@@ -268,9 +364,16 @@ type SendStickerResult struct {
 type SendDiceParams struct {
 	PeerInfo
 	ThreadTarget
+	SendSchedule
+	SendOptions
 	Emoticon string `json:"emoticon,omitempty"`
 }
 
+// Validate validates SendDiceParams.
+func (p SendDiceParams) Validate() error {
+	return p.ValidateMedia(false, false)
+}
+
 // SendDiceResult is the result of SendDice.
 type SendDiceResult struct {
 	ID       int64  `json:"id"`
@@ -300,3 +403,89 @@ type GetStickerPacksResult struct {
 	Packs []StickerPack `json:"packs"`
 	Count int           `json:"count"`
 }
+
+// Album item types.
+const (
+	AlbumPhoto    = "photo"
+	AlbumVideo    = "video"
+	AlbumDocument = "document"
+	AlbumAudio    = "audio"
+)
+
+// MaxAlbumItems is the most media Telegram groups into one album.
+const MaxAlbumItems = 10
+
+// AlbumItem is one file of an album.
+type AlbumItem struct {
+	File    string `json:"file" validate:"required"`
+	Type    string `json:"type,omitempty"` // photo, video, document or audio; guessed from the extension when empty
+	Caption string `json:"caption,omitempty"`
+}
+
+// ItemType returns the item's type, guessing it from the file extension
+// when unset.
+func (i AlbumItem) ItemType() string {
+	if i.Type != "" {
+		return i.Type
+	}
+	switch strings.ToLower(filepath.Ext(i.File)) {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return AlbumPhoto
+	case ".mp4", ".mov", ".m4v", ".webm":
+		return AlbumVideo
+	default:
+		return AlbumDocument
+	}
+}
+
+// SchemaPropertyHints returns JSON schema hints for AlbumItem.
+func (AlbumItem) SchemaPropertyHints() map[string]map[string]any {
+	return map[string]map[string]any{
+		"type": {"enum": []string{AlbumPhoto, AlbumVideo, AlbumDocument, AlbumAudio}},
+	}
+}
+
+// SendAlbumParams holds parameters for SendAlbum.
+type SendAlbumParams struct {
+	PeerInfo
+	ThreadTarget
+	SendSchedule
+	SendOptions
+	Items []AlbumItem `json:"items" validate:"required"`
+}
+
+// Validate validates SendAlbumParams. Photos and videos mix freely, while
+// documents and audio only group with their own kind.
+func (p SendAlbumParams) Validate() error {
+	if len(p.Items) == 0 || len(p.Items) > MaxAlbumItems {
+		return fmt.Errorf("items must hold 1 to %d files", MaxAlbumItems)
+	}
+	if err := p.ValidateMedia(true, false); err != nil {
+		return err
+	}
+	kinds := map[string]bool{}
+	for _, item := range p.Items {
+		kind := item.ItemType()
+		switch kind {
+		case AlbumPhoto, AlbumVideo:
+			kind = "visual"
+		case AlbumDocument, AlbumAudio:
+		default:
+			return fmt.Errorf("item type %q: want photo, video, document or audio", item.Type)
+		}
+		kinds[kind] = true
+	}
+	if len(kinds) > 1 {
+		return fmt.Errorf("an album mixes photos and videos, or holds only documents or only audio")
+	}
+	return nil
+}
+
+// SendAlbumResult is the result of SendAlbum.
+type SendAlbumResult struct {
+	IDs       []int64 `json:"ids"`
+	GroupedID int64   `json:"groupedId,omitempty"`
+	Date      int64   `json:"date"`
+	Peer      string  `json:"peer"`
+	Count     int     `json:"count"`
+}
@@ -0,0 +1,65 @@
+// Package types provides common types for Telegram update subscriptions.
+package types // revive:disable:var-naming
+
+import "fmt"
+
+// Update directions for subscription filters.
+const (
+	UpdateDirectionIn  = "in"
+	UpdateDirectionOut = "out"
+)
+
+// UpdatesNotificationMethod is the JSON-RPC notification method used to push
+// subscribed updates.
+const UpdatesNotificationMethod = "updates"
+
+// SubscribeUpdatesParams holds parameters for SubscribeUpdates.
+type SubscribeUpdatesParams struct {
+	PeerInfo
+	Types     []UpdateType `json:"types,omitempty"`
+	Direction string       `json:"direction,omitempty"`
+	// Offset resumes after a stored update ID. Without it only updates stored
+	// after the subscription starts are delivered.
+	Offset int64  `json:"offset,omitempty"`
+	Epoch  string `json:"epoch,omitempty"`
+}
+
+// AllowEmptyPeer marks peer/username as optional update filters.
+func (SubscribeUpdatesParams) AllowEmptyPeer() bool { return true }
+
+// Validate validates SubscribeUpdatesParams.
+func (p SubscribeUpdatesParams) Validate() error {
+	switch p.Direction {
+	case "", UpdateDirectionIn, UpdateDirectionOut:
+	default:
+		return fmt.Errorf("direction must be %q or %q", UpdateDirectionIn, UpdateDirectionOut)
+	}
+	if p.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	return nil
+}
+
+func (SubscribeUpdatesParams) SchemaPropertyHints() map[string]map[string]any {
+	return map[string]map[string]any{
+		"direction": {"enum": []string{UpdateDirectionIn, UpdateDirectionOut}},
+		"offset":    {"minimum": 0},
+	}
+}
+
+// SubscribeUpdatesResult is the result of SubscribeUpdates. Notifications for
+// the subscription follow on the same connection.
+type SubscribeUpdatesResult struct {
+	SubscriptionID string `json:"subscriptionId"`
+	Offset         int64  `json:"offset"`
+	Epoch          string `json:"epoch"`
+}
+
+// UpdatesNotification is the params payload of an "updates" notification.
+type UpdatesNotification struct {
+	SubscriptionID string         `json:"subscriptionId"`
+	Updates        []StoredUpdate `json:"updates"`
+	NextOffset     int64          `json:"nextOffset"`
+	Epoch          string         `json:"epoch"`
+	Gap            bool           `json:"gap,omitempty"`
+}
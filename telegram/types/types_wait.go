@@ -0,0 +1,104 @@
+// Package types provides common types for waiting on messages.
+package types // revive:disable:var-naming
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Limits for wait_for_message.
+const (
+	DefaultWaitCount = 1
+	MaxWaitCount     = 100
+	// DefaultWaitTimeout is the number of seconds wait_for_message waits when
+	// no timeout is given. Waits are also capped by the server's RPC timeout;
+	// callers resume longer waits with the returned cursor.
+	DefaultWaitTimeout = 20
+	MaxWaitTimeout     = 600
+)
+
+// Wait events.
+const (
+	WaitEventNewMessage    = "new_message"
+	WaitEventMessageEdited = "message_edited"
+)
+
+// WaitForMessageParams holds parameters for WaitForMessage. Only incoming
+// messages in the peer match. A message must pass every predicate that is
+// set; editOf additionally accepts edits of that message.
+type WaitForMessageParams struct {
+	PeerInfo
+	// From restricts matches to one sender, e.g. a bot in a group.
+	From     string `json:"from,omitempty"`
+	ThreadID int64  `json:"threadId,omitempty"`
+	// AfterID skips new messages with an ID at or below it, typically the ID
+	// of the message the caller just sent.
+	AfterID int64 `json:"afterId,omitempty"`
+	// Text is a regular expression matched against the message text.
+	Text       string `json:"text,omitempty"`
+	HasButtons *bool  `json:"hasButtons,omitempty"`
+	// EditOf also matches edits of this message ID, e.g. a bot updating the
+	// message whose inline button was pressed.
+	EditOf int64 `json:"editOf,omitempty"`
+	// Count is the number of matching messages to wait for.
+	Count int `json:"count,omitempty"`
+	// Timeout is the number of seconds to wait.
+	Timeout int `json:"timeout,omitempty"`
+	// Offset and Epoch resume from a cursor returned by get_updates or an
+	// earlier wait, so updates stored in between are not missed. An epoch
+	// with offset 0 is the start of that epoch, e.g. a cursor taken while the
+	// update store was empty.
+	Offset int64  `json:"offset,omitempty"`
+	Epoch  string `json:"epoch,omitempty"`
+}
+
+// Validate validates WaitForMessageParams.
+func (p WaitForMessageParams) Validate() error {
+	if err := p.PeerInfo.Validate(); err != nil {
+		return err
+	}
+	if p.ThreadID < 0 || p.AfterID < 0 || p.EditOf < 0 || p.Offset < 0 {
+		return fmt.Errorf("threadId, afterId, editOf and offset must be >= 0")
+	}
+	if p.Text != "" {
+		if _, err := regexp.Compile(p.Text); err != nil {
+			return fmt.Errorf("text: %w", err)
+		}
+	}
+	if p.Count < 0 || p.Count > MaxWaitCount {
+		return fmt.Errorf("count must be between 1 and %d", MaxWaitCount)
+	}
+	if p.Timeout < 0 || p.Timeout > MaxWaitTimeout {
+		return fmt.Errorf("timeout must be between 1 and %d seconds", MaxWaitTimeout)
+	}
+	return nil
+}
+
+func (WaitForMessageParams) SchemaPropertyHints() map[string]map[string]any {
+	return map[string]map[string]any{
+		"count":   {"minimum": 1, "maximum": MaxWaitCount},
+		"timeout": {"minimum": 1, "maximum": MaxWaitTimeout},
+	}
+}
+
+// WaitedMessage is one message matched by WaitForMessage, in the form it has
+// in the update stream.
+type WaitedMessage struct {
+	UpdateID int64          `json:"updateId"`
+	Event    string         `json:"event"`
+	Message  map[string]any `json:"message"`
+}
+
+// WaitForMessageResult is the result of WaitForMessage. Completed is false
+// when the wait ended before count messages matched; NextOffset and Epoch
+// resume it. Gap means updates the wait should have seen were evicted or,
+// for a wait without a cursor, older than its lookback; check message
+// history for them.
+type WaitForMessageResult struct {
+	Messages   []WaitedMessage `json:"messages"`
+	Count      int             `json:"count"`
+	Completed  bool            `json:"completed"`
+	NextOffset int64           `json:"nextOffset"`
+	Epoch      string          `json:"epoch"`
+	Gap        bool            `json:"gap,omitempty"`
+}
@@ -0,0 +1,47 @@
+package telegram
+
+import (
+	"context"
+
+	updhook "github.com/gotd/td/telegram/updates/hook"
+	"github.com/gotd/td/tg"
+)
+
+// echoHook returns the update hook that feeds the results of our own RPC
+// calls to next. Messages carried by those results are remembered first so
+// the dispatcher can keep echoes of what the agent sent or edited out of the
+// update store; the updates manager still sees them and advances pts.
+func (c *Client) echoHook(next updhook.UpdateHook) updhook.UpdateHook {
+	return func(ctx context.Context, u tg.UpdatesClass) error {
+		c.echoes.remember(echoUpdates(u))
+		return next(ctx, u)
+	}
+}
+
+// echoUpdates splits a method result into the messages and updates the
+// echo tracker should remember.
+func echoUpdates(u tg.UpdatesClass) ([]tg.MessageClass, []tg.UpdateClass) {
+	switch u := u.(type) {
+	case *tg.Updates:
+		return nil, u.Updates
+	case *tg.UpdatesCombined:
+		return nil, u.Updates
+	case *tg.UpdateShort:
+		return nil, []tg.UpdateClass{u.Update}
+	case *tg.UpdateShortMessage:
+		return []tg.MessageClass{&tg.Message{ID: u.ID, PeerID: &tg.PeerUser{UserID: u.UserID}}}, nil
+	case *tg.UpdateShortChatMessage:
+		return []tg.MessageClass{&tg.Message{ID: u.ID, PeerID: &tg.PeerChat{ChatID: u.ChatID}}}, nil
+	}
+	return nil, nil
+}
+
+// storeMessage reports whether a message update should reach the update
+// store. Echoes of our own calls are dropped, as is the empty placeholder the
+// updates manager builds from updateShortSentMessage.
+func (c *Client) storeMessage(msg tg.MessageClass) bool {
+	if _, ok := msg.(*tg.MessageEmpty); ok {
+		return false
+	}
+	return !c.echoes.take(msg)
+}
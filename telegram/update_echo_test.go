@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestEchoHookKeepsOwnMessagesOutOfStore(t *testing.T) {
+	store := NewUpdateStore(100)
+	c := &Client{updateStore: store, recovery: newRecoveryTracker(), echoes: newRecoveryTracker()}
+	dispatcher := tg.NewUpdateDispatcher()
+	c.RegisterUpdateHandlers(dispatcher)
+
+	sent := &tg.Message{ID: 20, Out: true, PeerID: &tg.PeerUser{UserID: 7}, Message: "from the agent"}
+	hook := c.echoHook(func(ctx context.Context, u tg.UpdatesClass) error {
+		return dispatcher.Handle(ctx, u)
+	})
+	err := hook(context.Background(), &tg.Updates{
+		Updates: []tg.UpdateClass{&tg.UpdateNewMessage{Message: sent}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = hook(context.Background(), &tg.UpdateShort{
+		Update: &tg.UpdateNewMessage{Message: &tg.MessageEmpty{ID: 21}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Get(100); len(got) != 0 {
+		t.Fatalf("echoes were stored: %+v", got)
+	}
+
+	// The same message sent from another device arrives as a pushed update.
+	other := &tg.Message{ID: 22, Out: true, PeerID: &tg.PeerUser{UserID: 7}, Message: "from the phone"}
+	err = dispatcher.Handle(context.Background(), &tg.Updates{
+		Updates: []tg.UpdateClass{&tg.UpdateNewMessage{Message: other}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := store.Get(100)
+	if len(got) != 1 || got[0].Data["message"].(map[string]any)["id"] != 22 {
+		t.Fatalf("stored = %+v", got)
+	}
+}
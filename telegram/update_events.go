@@ -0,0 +1,260 @@
+// Package telegram provides normalization of non-message Telegram updates.
+package telegram
+
+import (
+	"fmt"
+
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/helpers"
+)
+
+// userPeer formats a user ID as a typed peer.
+func userPeer(id int64) string {
+	return fmt.Sprintf("user:%d", id)
+}
+
+// typingData normalizes a typing indicator. from is nil for private chats,
+// where the peer is the typing user.
+func typingData(peer string, from tg.PeerClass, threadID int, action tg.SendMessageActionClass) map[string]any {
+	data := map[string]any{
+		"peer":   peer,
+		"from":   peer,
+		"action": typingAction(action),
+	}
+	if from != nil {
+		data["from"] = helpers.FormatPeer(from, helpers.PeerFormatTyped)
+	}
+	if threadID != 0 {
+		data["threadId"] = threadID
+	}
+	return data
+}
+
+// typingAction returns the set_typing action name for a typing indicator.
+func typingAction(action tg.SendMessageActionClass) string {
+	switch action.(type) {
+	case *tg.SendMessageCancelAction:
+		return "cancel"
+	case *tg.SendMessageRecordVideoAction:
+		return "record_video"
+	case *tg.SendMessageUploadVideoAction:
+		return "upload_video"
+	case *tg.SendMessageRecordAudioAction:
+		return "record_audio"
+	case *tg.SendMessageUploadAudioAction:
+		return "upload_audio"
+	case *tg.SendMessageUploadPhotoAction:
+		return "upload_photo"
+	case *tg.SendMessageUploadDocumentAction:
+		return "upload_document"
+	case *tg.SendMessageGeoLocationAction:
+		return "geo"
+	case *tg.SendMessageChooseContactAction:
+		return "choose_contact"
+	case *tg.SendMessageGamePlayAction:
+		return "game"
+	case *tg.SendMessageRecordRoundAction:
+		return "record_round"
+	case *tg.SendMessageUploadRoundAction:
+		return "upload_round"
+	case *tg.SendMessageChooseStickerAction:
+		return "choose_sticker"
+	default:
+		return "typing"
+	}
+}
+
+// userStatusData normalizes a user's online status.
+func userStatusData(userID int64, status tg.UserStatusClass) map[string]any {
+	data := map[string]any{"peer": userPeer(userID)}
+	switch s := status.(type) {
+	case *tg.UserStatusOnline:
+		data["status"] = "online"
+		data["expires"] = s.Expires
+	case *tg.UserStatusOffline:
+		data["status"] = "offline"
+		data["wasOnline"] = s.WasOnline
+	case *tg.UserStatusRecently:
+		data["status"] = "recently"
+	case *tg.UserStatusLastWeek:
+		data["status"] = "last_week"
+	case *tg.UserStatusLastMonth:
+		data["status"] = "last_month"
+	default:
+		data["status"] = "unknown"
+	}
+	return data
+}
+
+// reactionsData normalizes the reaction counters of a message.
+func reactionsData(update *tg.UpdateMessageReactions) map[string]any {
+	reactions := make([]map[string]any, 0, len(update.Reactions.Results))
+	for _, r := range update.Reactions.Results {
+		reaction := map[string]any{"count": r.Count}
+		switch react := r.Reaction.(type) {
+		case *tg.ReactionEmoji:
+			reaction["emoticon"] = react.Emoticon
+		case *tg.ReactionCustomEmoji:
+			reaction["document_id"] = react.DocumentID
+		case *tg.ReactionPaid:
+			reaction["paid"] = true
+		}
+		if r.ChosenOrder != 0 {
+			reaction["chosen_order"] = r.ChosenOrder
+		}
+		reactions = append(reactions, reaction)
+	}
+	data := map[string]any{
+		"peer":      helpers.FormatPeer(update.Peer, helpers.PeerFormatTyped),
+		"msgId":     update.MsgID,
+		"reactions": reactions,
+	}
+	if update.TopMsgID != 0 {
+		data["threadId"] = update.TopMsgID
+	}
+	return data
+}
+
+// pinnedData normalizes a change of pinned messages.
+func pinnedData(peer string, pinned bool, messages []int) map[string]any {
+	return map[string]any{
+		"peer":       peer,
+		"pinned":     pinned,
+		"messageIds": messages,
+	}
+}
+
+// participantData normalizes a membership change. prevRole and newRole are
+// the user's roles before and after the change, "" when not a member.
+func participantData(peer string, date int, actorID, userID int64, prevRole, newRole string) map[string]any {
+	action := "update"
+	switch {
+	case prevRole == "" && newRole != "":
+		action = "join"
+	case prevRole != "" && newRole == "":
+		action = "leave"
+	}
+	data := map[string]any{
+		"peer":   peer,
+		"user":   userPeer(userID),
+		"action": action,
+		"date":   date,
+	}
+	if actorID != 0 && actorID != userID {
+		data["actor"] = userPeer(actorID)
+	}
+	if newRole != "" {
+		data["role"] = newRole
+	}
+	return data
+}
+
+// chatParticipantRole returns the role of a basic group member, or "" if p
+// is not a member.
+func chatParticipantRole(p tg.ChatParticipantClass) string {
+	switch p.(type) {
+	case *tg.ChatParticipantCreator:
+		return "creator"
+	case *tg.ChatParticipantAdmin:
+		return "admin"
+	case *tg.ChatParticipant:
+		return "member"
+	default:
+		return ""
+	}
+}
+
+// channelParticipantRole returns the role of a channel or supergroup member,
+// or "" if p is not a member.
+func channelParticipantRole(p tg.ChannelParticipantClass) string {
+	switch p := p.(type) {
+	case *tg.ChannelParticipantCreator:
+		return "creator"
+	case *tg.ChannelParticipantAdmin:
+		return "admin"
+	case *tg.ChannelParticipant, *tg.ChannelParticipantSelf:
+		return "member"
+	case *tg.ChannelParticipantBanned:
+		if p.Left {
+			return ""
+		}
+		return "restricted"
+	default:
+		return ""
+	}
+}
+
+// serviceActionData normalizes a service message such as a join, leave,
+// title change or pin. Star gift actions are handled by giftActionData.
+func serviceActionData(msg tg.MessageClass, entities tg.Entities) map[string]any {
+	svc, ok := msg.(*tg.MessageService)
+	if !ok {
+		return nil
+	}
+
+	message := map[string]any{
+		"id":   svc.ID,
+		"date": svc.Date,
+		"out":  svc.Out,
+	}
+	if svc.FromID != nil {
+		message["from"] = helpers.FormatPeer(svc.FromID, helpers.PeerFormatTyped)
+		if name := getSenderName(entities, svc.FromID); name != "" {
+			message["from_name"] = name
+		}
+	}
+	if svc.PeerID != nil {
+		message["peer"] = helpers.FormatPeer(svc.PeerID, helpers.PeerFormatTyped)
+	}
+	data := map[string]any{"message": message}
+
+	switch action := svc.Action.(type) {
+	case *tg.MessageActionChatAddUser:
+		data["action"] = "join"
+		users := make([]string, 0, len(action.Users))
+		for _, id := range action.Users {
+			users = append(users, userPeer(id))
+		}
+		data["users"] = users
+	case *tg.MessageActionChatJoinedByLink:
+		data["action"] = "join"
+		data["inviter"] = userPeer(action.InviterID)
+		if from, ok := message["from"].(string); ok {
+			data["users"] = []string{from}
+		}
+	case *tg.MessageActionChatJoinedByRequest:
+		data["action"] = "join"
+		if from, ok := message["from"].(string); ok {
+			data["users"] = []string{from}
+		}
+	case *tg.MessageActionChatDeleteUser:
+		data["action"] = "leave"
+		data["users"] = []string{userPeer(action.UserID)}
+	case *tg.MessageActionChatEditTitle:
+		data["action"] = "title_change"
+		data["title"] = action.Title
+	case *tg.MessageActionChatEditPhoto:
+		data["action"] = "photo_change"
+	case *tg.MessageActionChatDeletePhoto:
+		data["action"] = "photo_delete"
+	case *tg.MessageActionPinMessage:
+		data["action"] = "pin"
+		if reply, ok := svc.ReplyTo.(*tg.MessageReplyHeader); ok {
+			data["pinnedMsgId"] = reply.ReplyToMsgID
+		}
+	case *tg.MessageActionChatCreate:
+		data["action"] = "chat_create"
+		data["title"] = action.Title
+	case *tg.MessageActionChannelCreate:
+		data["action"] = "channel_create"
+		data["title"] = action.Title
+	case *tg.MessageActionChatMigrateTo:
+		data["action"] = "migrate"
+		data["channel"] = fmt.Sprintf("channel:%d", action.ChannelID)
+	default:
+		data["action"] = "other"
+		data["actionType"] = svc.Action.TypeName()
+	}
+	return data
+}
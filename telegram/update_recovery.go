@@ -0,0 +1,147 @@
+// Package telegram provides Telegram update gap recovery.
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/helpers"
+)
+
+// UpdateStateStorage persists MTProto update state (pts/qts/seq/date and
+// per-channel pts) together with channel access hashes, so a restarted daemon
+// can catch up with updates.getDifference instead of starting from "now".
+type UpdateStateStorage interface {
+	updates.StateStorage
+	updates.ChannelAccessHasher
+}
+
+// maxPendingRecovered bounds messages remembered from difference responses
+// that never reach a handler (e.g. unsupported message kinds).
+const maxPendingRecovered = 10000
+
+// recoveryTracker remembers messages returned by getDifference and
+// getChannelDifference so the dispatcher can mark them as recovered. The gotd
+// updates manager hands recovered and live updates to the same handler, so
+// the API responses are the only place the distinction is visible.
+type recoveryTracker struct {
+	mu      sync.Mutex
+	pending map[string]int
+}
+
+func newRecoveryTracker() *recoveryTracker {
+	return &recoveryTracker{pending: make(map[string]int)}
+}
+
+// wrap returns an updates.API that records difference results.
+func (r *recoveryTracker) wrap(api updates.API) updates.API {
+	return &recoveryAPI{API: api, tracker: r}
+}
+
+// take reports whether msg came from a difference response and forgets it.
+func (r *recoveryTracker) take(msg tg.MessageClass) bool {
+	if r == nil {
+		return false
+	}
+	key, ok := recoveryKey(msg)
+	if !ok {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.pending[key]
+	if n == 0 {
+		return false
+	}
+	if n == 1 {
+		delete(r.pending, key)
+	} else {
+		r.pending[key] = n - 1
+	}
+	return true
+}
+
+func (r *recoveryTracker) remember(msgs []tg.MessageClass, others []tg.UpdateClass) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) > maxPendingRecovered {
+		clear(r.pending)
+	}
+	add := func(msg tg.MessageClass) {
+		if key, ok := recoveryKey(msg); ok {
+			r.pending[key]++
+		}
+	}
+	for _, msg := range msgs {
+		add(msg)
+	}
+	for _, update := range others {
+		switch u := update.(type) {
+		case *tg.UpdateNewMessage:
+			add(u.Message)
+		case *tg.UpdateNewChannelMessage:
+			add(u.Message)
+		case *tg.UpdateEditMessage:
+			add(u.Message)
+		case *tg.UpdateEditChannelMessage:
+			add(u.Message)
+		}
+	}
+}
+
+func recoveryKey(msg tg.MessageClass) (string, bool) {
+	var peer tg.PeerClass
+	switch m := msg.(type) {
+	case *tg.Message:
+		peer = m.PeerID
+	case *tg.MessageService:
+		peer = m.PeerID
+	default:
+		return "", false
+	}
+	if peer == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d", helpers.FormatPeer(peer, helpers.PeerFormatTyped), msg.GetID()), true
+}
+
+// recoveryAPI intercepts difference calls made by the updates manager.
+type recoveryAPI struct {
+	updates.API
+	tracker *recoveryTracker
+}
+
+func (a *recoveryAPI) UpdatesGetDifference(
+	ctx context.Context,
+	request *tg.UpdatesGetDifferenceRequest,
+) (tg.UpdatesDifferenceClass, error) {
+	diff, err := a.API.UpdatesGetDifference(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	switch d := diff.(type) {
+	case *tg.UpdatesDifference:
+		a.tracker.remember(d.NewMessages, d.OtherUpdates)
+	case *tg.UpdatesDifferenceSlice:
+		a.tracker.remember(d.NewMessages, d.OtherUpdates)
+	}
+	return diff, nil
+}
+
+func (a *recoveryAPI) UpdatesGetChannelDifference(
+	ctx context.Context,
+	request *tg.UpdatesGetChannelDifferenceRequest,
+) (tg.UpdatesChannelDifferenceClass, error) {
+	diff, err := a.API.UpdatesGetChannelDifference(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := diff.(*tg.UpdatesChannelDifference); ok {
+		a.tracker.remember(d.NewMessages, d.OtherUpdates)
+	}
+	return diff, nil
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+
+	"agent-telegram/telegram/types"
+)
+
+type fakeDifferenceAPI struct {
+	updates.API
+	diff        tg.UpdatesDifferenceClass
+	channelDiff tg.UpdatesChannelDifferenceClass
+}
+
+func (f *fakeDifferenceAPI) UpdatesGetDifference(
+	context.Context, *tg.UpdatesGetDifferenceRequest,
+) (tg.UpdatesDifferenceClass, error) {
+	return f.diff, nil
+}
+
+func (f *fakeDifferenceAPI) UpdatesGetChannelDifference(
+	context.Context, *tg.UpdatesGetChannelDifferenceRequest,
+) (tg.UpdatesChannelDifferenceClass, error) {
+	return f.channelDiff, nil
+}
+
+func TestRecoveryTrackerMarksDifferenceMessages(t *testing.T) {
+	ctx := context.Background()
+	userMsg := &tg.Message{ID: 7, PeerID: &tg.PeerUser{UserID: 1}}
+	editMsg := &tg.Message{ID: 8, PeerID: &tg.PeerUser{UserID: 1}}
+	channelMsg := &tg.Message{ID: 7, PeerID: &tg.PeerChannel{ChannelID: 2}}
+
+	tracker := newRecoveryTracker()
+	api := tracker.wrap(&fakeDifferenceAPI{
+		diff: &tg.UpdatesDifference{
+			NewMessages:  []tg.MessageClass{userMsg},
+			OtherUpdates: []tg.UpdateClass{&tg.UpdateEditMessage{Message: editMsg}},
+		},
+		channelDiff: &tg.UpdatesChannelDifference{NewMessages: []tg.MessageClass{channelMsg}},
+	})
+	if _, err := api.UpdatesGetDifference(ctx, &tg.UpdatesGetDifferenceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.UpdatesGetChannelDifference(ctx, &tg.UpdatesGetChannelDifferenceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []tg.MessageClass{userMsg, editMsg, channelMsg} {
+		if !tracker.take(msg) {
+			t.Fatalf("message %d in %v should be recovered", msg.GetID(), msg.(*tg.Message).PeerID)
+		}
+	}
+	if tracker.take(userMsg) {
+		t.Fatal("a recovered message should only be marked once")
+	}
+	if tracker.take(&tg.Message{ID: 9, PeerID: &tg.PeerUser{UserID: 1}}) {
+		t.Fatal("live message should not be marked recovered")
+	}
+}
+
+func TestMessageUpdateRecoveredFlag(t *testing.T) {
+	c := NewClient(1, "hash")
+	msg := &tg.Message{ID: 3, PeerID: &tg.PeerChat{ChatID: 4}}
+	c.recovery.remember([]tg.MessageClass{msg}, nil)
+
+	if update := c.messageUpdate(types.UpdateTypeNewMessage, msg, nil); !update.Recovered {
+		t.Fatal("first delivery should be marked recovered")
+	}
+	if update := c.messageUpdate(types.UpdateTypeNewMessage, msg, nil); update.Recovered {
+		t.Fatal("second delivery should be live")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -13,14 +14,35 @@ import (
 	"github.com/gotd/td/tg"
 )
 
-// UpdateStore stores Telegram updates in memory.
+// UpdateJournal persists stored updates so cursors survive daemon restarts.
+// Updates are appended with strictly increasing IDs.
+type UpdateJournal interface {
+	// Epoch identifies the journal history. It changes only when history is lost.
+	Epoch() string
+	// FirstID returns the oldest retained update ID, or zero when empty.
+	FirstID() int64
+	// LastID returns the newest appended update ID, or zero when empty.
+	LastID() int64
+	// Append persists an update that already carries its ID and timestamp.
+	Append(update types.StoredUpdate) error
+	// ReadAfter returns up to limit updates with ID > afterID, oldest first.
+	ReadAfter(afterID int64, limit int) ([]types.StoredUpdate, error)
+	// Close flushes and releases the journal.
+	Close() error
+}
+
+// UpdateStore stores Telegram updates in memory, optionally backed by an
+// UpdateJournal. With a journal the in-memory ring is a cache of the newest
+// updates and older cursors are served from disk.
 type UpdateStore struct {
 	mu       sync.RWMutex
 	updates  []types.StoredUpdate
 	nextID   int64
 	limit    int
 	onUpdate func(types.StoredUpdate)
+	onLive   func(types.StoredUpdate)
 	epoch    string
+	journal  UpdateJournal
 }
 
 // NewUpdateStore creates a new UpdateStore with the given limit.
@@ -36,6 +58,42 @@ func NewUpdateStore(limit int) *UpdateStore {
 	}
 }
 
+// NewJournaledUpdateStore creates an UpdateStore that persists updates to
+// journal. The epoch and ID sequence continue from the journal, and the newest
+// limit updates are loaded into memory.
+func NewJournaledUpdateStore(limit int, journal UpdateJournal) (*UpdateStore, error) {
+	s := NewUpdateStore(limit)
+	s.journal = journal
+	s.epoch = journal.Epoch()
+	s.nextID = journal.LastID() + 1
+
+	after := max(journal.LastID()-int64(s.limit), 0)
+	for len(s.updates) < s.limit {
+		batch, err := journal.ReadAfter(after, s.limit-len(s.updates))
+		if err != nil {
+			return nil, fmt.Errorf("load update journal: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		s.updates = append(s.updates, batch...)
+		after = batch[len(batch)-1].ID
+	}
+	return s, nil
+}
+
+// Close closes the backing journal, if any.
+func (s *UpdateStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.journal == nil {
+		return nil
+	}
+	err := s.journal.Close()
+	s.journal = nil
+	return err
+}
+
 // SetOnUpdate sets a callback that is called after each update is stored.
 // The callback is called outside the lock to avoid deadlocks.
 func (s *UpdateStore) SetOnUpdate(fn func(types.StoredUpdate)) {
@@ -44,6 +102,28 @@ func (s *UpdateStore) SetOnUpdate(fn func(types.StoredUpdate)) {
 	s.onUpdate = fn
 }
 
+// SetOnLive sets a callback for the transient updates passed to AddLive.
+func (s *UpdateStore) SetOnLive(fn func(types.StoredUpdate)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onLive = fn
+}
+
+// AddLive hands a transient update, such as a typing indicator, to the live
+// callback without storing or journaling it. It gets no ID, so cursors,
+// get_updates and webhooks never see it.
+func (s *UpdateStore) AddLive(update types.StoredUpdate) {
+	s.mu.RLock()
+	onLive := s.onLive
+	s.mu.RUnlock()
+
+	if onLive != nil {
+		update.Timestamp = time.Now()
+		update.Live = true
+		onLive(update)
+	}
+}
+
 // Add adds a new update to the store.
 func (s *UpdateStore) Add(update types.StoredUpdate) {
 	s.mu.Lock()
@@ -53,6 +133,11 @@ func (s *UpdateStore) Add(update types.StoredUpdate) {
 	s.nextID++
 
 	s.updates = append(s.updates, update)
+	if s.journal != nil {
+		if err := s.journal.Append(update); err != nil {
+			slog.Warn("failed to persist update", "id", update.ID, "error", err)
+		}
+	}
 
 	// Trim old updates if we exceed the limit
 	if len(s.updates) > s.limit {
@@ -82,10 +167,12 @@ type UpdatePage struct {
 	Gap        bool
 }
 
-// Page returns updates in oldest-first delivery order. Offset zero starts at
-// the latest page. A non-zero offset resumes without skipping intermediate
-// events. Gap is set when the cursor belongs to another daemon epoch or points
-// before events already evicted from the bounded store.
+// Page returns updates in oldest-first delivery order. Offset zero without an
+// epoch starts at the latest page. A non-zero offset, or offset zero with the
+// current epoch (a cursor taken while the store was empty), resumes without
+// skipping intermediate events. Gap is set when the cursor belongs to another
+// daemon epoch or points before events already evicted from the bounded store
+// (or, with a journal, removed by retention).
 func (s *UpdateStore) Page(limit int, offset int64, epoch string) UpdatePage {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -102,6 +189,7 @@ func (s *UpdateStore) Page(limit int, offset int64, epoch string) UpdatePage {
 		offset = 0
 		page.NextOffset = 0
 	}
+	resume := offset > 0 || epoch == s.epoch
 	if len(s.updates) == 0 {
 		return page
 	}
@@ -110,8 +198,14 @@ func (s *UpdateStore) Page(limit int, offset int64, epoch string) UpdatePage {
 	if start < 0 {
 		start = 0
 	}
-	if offset > 0 {
+	if resume {
 		oldest := s.updates[0].ID
+		if offset < oldest-1 && s.journal != nil {
+			if journaled, ok := s.journalPage(limit, offset); ok {
+				journaled.Epoch = page.Epoch
+				return journaled
+			}
+		}
 		if offset < oldest-1 {
 			page.Gap = true
 		}
@@ -136,6 +230,22 @@ func (s *UpdateStore) Page(limit int, offset int64, epoch string) UpdatePage {
 	return page
 }
 
+// journalPage serves a cursor older than the in-memory cache from the journal.
+func (s *UpdateStore) journalPage(limit int, offset int64) (UpdatePage, bool) {
+	updates, err := s.journal.ReadAfter(offset, limit)
+	if err != nil || len(updates) == 0 {
+		if err != nil {
+			slog.Warn("failed to read update journal", "offset", offset, "error", err)
+		}
+		return UpdatePage{}, false
+	}
+	page := UpdatePage{Updates: updates, NextOffset: updates[len(updates)-1].ID}
+	if first := s.journal.FirstID(); first > 0 && offset < first-1 {
+		page.Gap = true
+	}
+	return page, true
+}
+
 func newUpdateEpoch() string {
 	var value [8]byte
 	if _, err := rand.Read(value[:]); err == nil {
@@ -215,8 +325,22 @@ func MessageData(msg tg.MessageClass, entities tg.Entities) map[string]interface
 	if m, ok := msg.(*tg.Message); ok {
 		data["id"] = m.ID
 		data["text"] = m.Message
+		if len(m.Entities) > 0 {
+			data["entities"] = helpers.ConvertEntities(m.Entities)
+		}
 		data["date"] = m.Date
 		data["out"] = m.Out
+		if m.EditDate != 0 {
+			data["edit_date"] = m.EditDate
+		}
+		if header, ok := m.ReplyTo.(*tg.MessageReplyHeader); ok {
+			// Forum topic, as in MessageResult.ThreadID.
+			if header.ReplyToTopID != 0 {
+				data["thread_id"] = header.ReplyToTopID
+			} else if header.ForumTopic && header.ReplyToMsgID != 0 {
+				data["thread_id"] = header.ReplyToMsgID
+			}
+		}
 
 		// Simplify from_id to string format
 		if m.FromID != nil {
@@ -3,6 +3,7 @@ package telegram
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"strings"
 	"sync"
 	"testing"
@@ -12,6 +13,7 @@ import (
 	"github.com/gotd/td/session"
 	"github.com/gotd/td/tg"
 
+	"agent-telegram/internal/updatejournal"
 	"agent-telegram/telegram/types"
 )
 
@@ -83,15 +85,67 @@ func TestUpdateStorePageCursorOrderEpochAndGap(t *testing.T) {
 	}
 }
 
+func TestJournaledUpdateStoreResumesAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	open := func() (*UpdateStore, *updatejournal.Journal) {
+		t.Helper()
+		journal, err := updatejournal.Open(dir, updatejournal.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		store, err := NewJournaledUpdateStore(2, journal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store, journal
+	}
+
+	store, _ := open()
+	for i := range 3 {
+		store.Add(NewStoredUpdate(types.UpdateTypeNewMessage, map[string]any{"n": i + 1}))
+	}
+	cursor := store.Page(1, 0, "")
+	if cursor.NextOffset != 3 {
+		t.Fatalf("cursor = %+v", cursor)
+	}
+	for i := range 3 {
+		store.Add(NewStoredUpdate(types.UpdateTypeNewMessage, map[string]any{"n": i + 4}))
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, journal := open()
+	defer func() { _ = restarted.Close() }()
+	if journal.Epoch() != cursor.Epoch {
+		t.Fatalf("epoch changed across restart: %q != %q", journal.Epoch(), cursor.Epoch)
+	}
+	// Only 5..6 are cached in memory, so the cursor at 3 is served from disk.
+	page := restarted.Page(10, cursor.NextOffset, cursor.Epoch)
+	if page.Gap || len(page.Updates) != 3 || page.Updates[0].ID != 4 || page.NextOffset != 6 {
+		t.Fatalf("journal page = %+v", page)
+	}
+	page = restarted.Page(10, page.NextOffset, page.Epoch)
+	if page.Gap || len(page.Updates) != 0 || page.NextOffset != 6 {
+		t.Fatalf("caught-up page = %+v", page)
+	}
+	restarted.Add(NewStoredUpdate(types.UpdateTypeEditMessage, nil))
+	if latest := restarted.Get(1); len(latest) != 1 || latest[0].ID != 7 {
+		t.Fatalf("restarted ids should continue, got %+v", latest)
+	}
+}
+
 func TestMessageDataAndHelpers(t *testing.T) {
 	msg := &tg.Message{
-		ID:      7,
-		Message: "hello",
-		Date:    123,
-		Out:     true,
-		FromID:  &tg.PeerUser{UserID: 42},
-		PeerID:  &tg.PeerChannel{ChannelID: 99},
-		Media:   &tg.MessageMediaDice{Value: 6, Emoticon: "dice"},
+		ID:       7,
+		Message:  "hello",
+		Date:     123,
+		EditDate: 130,
+		Out:      true,
+		FromID:   &tg.PeerUser{UserID: 42},
+		ReplyTo:  &tg.MessageReplyHeader{ForumTopic: true, ReplyToMsgID: 5},
+		PeerID:   &tg.PeerChannel{ChannelID: 99},
+		Media:    &tg.MessageMediaDice{Value: 6, Emoticon: "dice"},
 		ReplyMarkup: &tg.ReplyInlineMarkup{Rows: []tg.KeyboardButtonRow{{
 			Buttons: []tg.KeyboardButtonClass{
 				&tg.KeyboardButtonURL{Text: "Site", URL: "https://example.com"},
@@ -117,6 +171,9 @@ func TestMessageDataAndHelpers(t *testing.T) {
 	if data["id"] != 7 || data["text"] != "hello" || data["from_name"] != "Bot (bot)" {
 		t.Fatalf("message data = %#v", data)
 	}
+	if data["edit_date"] != 130 || data["thread_id"] != 5 {
+		t.Fatalf("edit_date/thread_id = %v/%v", data["edit_date"], data["thread_id"])
+	}
 	if media := data["media"].(map[string]any); media["type"] != "dice" || media["value"] != 6 {
 		t.Fatalf("media = %#v", media)
 	}
@@ -260,3 +317,89 @@ func TestClientAccessorsAndPeerCache(t *testing.T) {
 		t.Fatal("reload signal was not sent")
 	}
 }
+
+func TestRegisterUpdateHandlersNormalizesEvents(t *testing.T) {
+	store := NewUpdateStore(100)
+	var live []types.StoredUpdate
+	store.SetOnLive(func(update types.StoredUpdate) { live = append(live, update) })
+	c := &Client{updateStore: store, recovery: newRecoveryTracker()}
+	dispatcher := tg.NewUpdateDispatcher()
+	c.RegisterUpdateHandlers(dispatcher)
+
+	err := dispatcher.Handle(context.Background(), &tg.Updates{
+		Updates: []tg.UpdateClass{
+			&tg.UpdateDeleteMessages{Messages: []int{5}},
+			&tg.UpdateReadHistoryOutbox{Peer: &tg.PeerUser{UserID: 7}, MaxID: 40},
+			&tg.UpdateChatUserTyping{ChatID: 3, FromID: &tg.PeerUser{UserID: 7}, Action: &tg.SendMessageUploadPhotoAction{}},
+			&tg.UpdateUserStatus{UserID: 7, Status: &tg.UserStatusOffline{WasOnline: 100}},
+			&tg.UpdateMessageReactions{
+				Peer:  &tg.PeerChannel{ChannelID: 9},
+				MsgID: 11,
+				Reactions: tg.MessageReactions{Results: []tg.ReactionCount{
+					{Reaction: &tg.ReactionEmoji{Emoticon: "👍"}, Count: 2},
+				}},
+			},
+			&tg.UpdateChannelParticipant{
+				ChannelID:       9,
+				UserID:          7,
+				PrevParticipant: &tg.ChannelParticipant{UserID: 7},
+				NewParticipant:  &tg.ChannelParticipantBanned{Left: true, Peer: &tg.PeerUser{UserID: 7}},
+			},
+			&tg.UpdatePinnedMessages{Pinned: true, Peer: &tg.PeerChat{ChatID: 3}, Messages: []int{12}},
+			&tg.UpdateNewMessage{Message: &tg.MessageService{
+				ID:     13,
+				PeerID: &tg.PeerChat{ChatID: 3},
+				FromID: &tg.PeerUser{UserID: 7},
+				Action: &tg.MessageActionChatEditTitle{Title: "New"},
+			}},
+		},
+		Users: []tg.UserClass{},
+		Chats: []tg.ChatClass{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byType := map[types.UpdateType]map[string]any{}
+	for _, update := range store.Get(100) {
+		if update.Type == types.UpdateTypeTyping || update.Type == types.UpdateTypeUserStatus {
+			t.Fatalf("%s update was stored: %+v", update.Type, update)
+		}
+		byType[update.Type] = update.Data
+	}
+	for _, update := range live {
+		if !update.Live || update.ID != 0 {
+			t.Fatalf("live update = %+v", update)
+		}
+		byType[update.Type] = update.Data
+	}
+	checks := []struct {
+		typ   types.UpdateType
+		key   string
+		value any
+	}{
+		{types.UpdateTypeDelete, "message", map[string]any{"id": 5}},
+		{types.UpdateTypeReadOutbox, "maxId", 40},
+		{types.UpdateTypeTyping, "action", "upload_photo"},
+		{types.UpdateTypeUserStatus, "status", "offline"},
+		{types.UpdateTypeReaction, "peer", "channel:9"},
+		{types.UpdateTypeParticipant, "action", "leave"},
+		{types.UpdateTypePin, "peer", "chat:3"},
+		{types.UpdateTypeService, "title", "New"},
+	}
+	for _, check := range checks {
+		data, ok := byType[check.typ]
+		if !ok {
+			t.Fatalf("missing %s update; got %v", check.typ, byType)
+		}
+		if fmt.Sprint(data[check.key]) != fmt.Sprint(check.value) {
+			t.Fatalf("%s %s = %v, want %v", check.typ, check.key, data[check.key], check.value)
+		}
+	}
+	if from := byType[types.UpdateTypeTyping]["from"]; from != "user:7" {
+		t.Fatalf("typing from = %v", from)
+	}
+	if msg := byType[types.UpdateTypeService]["message"].(map[string]any); msg["peer"] != "chat:3" {
+		t.Fatalf("service message = %v", msg)
+	}
+}